@@ -60,6 +60,23 @@ func shapeOf(av types.AttributeValue) shapeKey {
 	return shapeAny
 }
 
+// maxExactInt is the largest integer magnitude that float64 can represent exactly.
+const maxExactInt = 1 << 53
+
+// number2iface converts a number into interface{}.
+// Numbers become float64, except for integers too large to be represented exactly by float64,
+// which become int64 or uint64 (if above math.MaxInt64) so that large numeric keys round-trip unchanged.
+func number2iface(n string) (interface{}, error) {
+	if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+		if i > maxExactInt || i < -maxExactInt {
+			return i, nil
+		}
+	} else if u, err := strconv.ParseUint(n, 10, 64); err == nil {
+		return u, nil
+	}
+	return strconv.ParseFloat(n, 64)
+}
+
 // av2iface converts an av into interface{}.
 func av2iface(av types.AttributeValue) (interface{}, error) {
 	switch v := av.(type) {
@@ -70,7 +87,7 @@ func av2iface(av types.AttributeValue) (interface{}, error) {
 	case *types.AttributeValueMemberBOOL:
 		return v.Value, nil
 	case *types.AttributeValueMemberN:
-		return strconv.ParseFloat(v.Value, 64)
+		return number2iface(v.Value)
 	case *types.AttributeValueMemberS:
 		return v.Value, nil
 	case *types.AttributeValueMemberL:
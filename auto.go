@@ -0,0 +1,192 @@
+package dynamo
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// IDGenerator generates a unique ID for fields with the auto option.
+// See [RegisterIDGenerator].
+type IDGenerator func() (string, error)
+
+var idGenerators = struct {
+	mu   sync.RWMutex
+	gens map[string]IDGenerator
+}{
+	gens: map[string]IDGenerator{
+		"ulid":  NewULID,
+		"ksuid": NewKSUID,
+	},
+}
+
+// RegisterIDGenerator registers gen under the given name, so that string fields tagged with
+// the `dynamo:",auto=name"` option will use it to generate their value when empty.
+// The generators "ulid" ([NewULID]) and "ksuid" ([NewKSUID]) are registered by default,
+// but may be replaced.
+//
+// Fields with the auto option are generated when creating a [Put] (including via [BatchWrite.Put]).
+// If the item was given as a pointer, the generated value is also set in the field.
+//
+//	type Event struct {
+//		UserID string `dynamo:",hash"`
+//		ID     string `dynamo:",range,auto=ulid"`
+//	}
+func RegisterIDGenerator(name string, gen IDGenerator) {
+	idGenerators.mu.Lock()
+	defer idGenerators.mu.Unlock()
+	idGenerators.gens[name] = gen
+}
+
+func idGenerator(name string) (IDGenerator, bool) {
+	idGenerators.mu.RLock()
+	defer idGenerators.mu.RUnlock()
+	gen, ok := idGenerators.gens[name]
+	return gen, ok
+}
+
+type autoField struct {
+	name  string
+	index []int
+	gen   string
+}
+
+var autoFieldCache sync.Map // typeKey → []autoField
+
+// autoFieldsOf returns the fields of struct type rt with the auto option.
+func autoFieldsOf(rt reflect.Type, names *NameMapper) []autoField {
+	key := typeKey{rt: rt, names: names}
+	if cached, ok := autoFieldCache.Load(key); ok {
+		return cached.([]autoField)
+	}
+	var fields []autoField
+	visitTypeFields(rt, names, nil, nil, func(name string, index []int, _ encodeFlags, _ reflect.Type) error {
+		tag := rt.FieldByIndex(index).Tag.Get("dynamo")
+		for _, part := range strings.Split(tag, ",")[1:] {
+			if gen, ok := strings.CutPrefix(part, "auto="); ok {
+				fields = append(fields, autoField{name: name, index: index, gen: gen})
+			}
+		}
+		return nil
+	})
+	autoFieldCache.Store(key, fields)
+	return fields
+}
+
+// fillAuto generates values for the empty auto fields of in, setting them in the encoded item.
+// names is the name mapper item was encoded with.
+func fillAuto(in interface{}, item Item, names *NameMapper) error {
+	rv := reflect.ValueOf(in)
+	if !rv.IsValid() {
+		return nil
+	}
+	rt := rv.Type()
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	for _, field := range autoFieldsOf(rt, names) {
+		fv := indirectNoAlloc(dig(rv, field.index))
+		if fv.IsValid() && fv.Kind() != reflect.String {
+			return fmt.Errorf("dynamo: auto option is only supported for string fields (field %q is %s)", field.name, fv.Type())
+		}
+		if fv.IsValid() && fv.String() != "" {
+			continue
+		}
+		gen, ok := idGenerator(field.gen)
+		if !ok {
+			return fmt.Errorf("dynamo: unknown ID generator %q for field %q", field.gen, field.name)
+		}
+		id, err := gen()
+		if err != nil {
+			return err
+		}
+		item[field.name] = &types.AttributeValueMemberS{Value: id}
+		if fv.CanSet() {
+			fv.SetString(id)
+		}
+	}
+	return nil
+}
+
+// keepAuto copies the values generated by fillAuto for in's auto fields from prev into item,
+// for items that are marshaled again after being built, such as those with values implementing MarshalerCtx.
+// Otherwise, the IDs generated for items passed by value would be lost.
+func keepAuto(in interface{}, prev, item Item, names *NameMapper) {
+	rt := reflect.TypeOf(in)
+	if rt == nil {
+		return
+	}
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return
+	}
+	for _, field := range autoFieldsOf(rt, names) {
+		if av, ok := prev[field.name]; ok {
+			item[field.name] = av
+		}
+	}
+}
+
+// Crockford's base32 alphabet, used by ULIDs
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a new ULID, a 26 character lexicographically sortable unique ID
+// made of a millisecond timestamp followed by 80 random bits.
+// See: https://github.com/ulid/spec
+func NewULID() (string, error) {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+
+	// 128 bits as 26 characters of 5 bits each, with 2 bits of leading padding
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = ulidAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+const (
+	ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEpoch    = 1400000000
+	ksuidLength   = 27
+)
+
+// NewKSUID returns a new KSUID, a 27 character lexicographically sortable unique ID
+// made of a second-precision timestamp followed by 128 random bits.
+// See: https://github.com/segmentio/ksuid
+func NewKSUID() (string, error) {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	if _, err := rand.Read(b[4:]); err != nil {
+		return "", err
+	}
+
+	n := new(big.Int).SetBytes(b[:])
+	base := big.NewInt(int64(len(ksuidAlphabet)))
+	mod := new(big.Int)
+	out := []byte(strings.Repeat("0", ksuidLength))
+	for i := ksuidLength - 1; i >= 0 && n.Sign() > 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = ksuidAlphabet[mod.Int64()]
+	}
+	return string(out), nil
+}
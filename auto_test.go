@@ -0,0 +1,129 @@
+package dynamo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestNewULID(t *testing.T) {
+	a, err := NewULID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 26 || strings.Trim(a, ulidAlphabet) != "" {
+		t.Error("bad ULID:", a)
+	}
+	time.Sleep(2 * time.Millisecond)
+	b, err := NewULID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a >= b {
+		t.Error("ULIDs not sortable by time:", a, b)
+	}
+
+	id, err := NewKSUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 27 || strings.Trim(id, ksuidAlphabet) != "" {
+		t.Error("bad KSUID:", id)
+	}
+}
+
+func TestPutAuto(t *testing.T) {
+	type event struct {
+		UserID string `dynamo:",hash"`
+		ID     string `dynamo:",range,auto=ulid"`
+		Ref    string `dynamo:",auto=counter"`
+	}
+	var n int
+	RegisterIDGenerator("counter", func() (string, error) {
+		n++
+		return strings.Repeat("x", n), nil
+	})
+
+	table := NewFromIface(nil).Table("Auto")
+
+	t.Run("pointer", func(t *testing.T) {
+		ev := &event{UserID: "a"}
+		put := table.Put(ev)
+		if put.err != nil {
+			t.Fatal(put.err)
+		}
+		if len(ev.ID) != 26 {
+			t.Error("ULID not set in struct:", ev.ID)
+		}
+		if got := put.item["ID"].(*types.AttributeValueMemberS).Value; got != ev.ID {
+			t.Error("bad encoded ID. want:", ev.ID, "got:", got)
+		}
+		if ev.Ref != "x" {
+			t.Error("custom generator not used:", ev.Ref)
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		bw := table.Batch().Write().Put(event{UserID: "a", Ref: "keep"})
+		if bw.err != nil {
+			t.Fatal(bw.err)
+		}
+		item := bw.ops[0].op.PutRequest.Item
+		if id := item["ID"].(*types.AttributeValueMemberS).Value; len(id) != 26 {
+			t.Error("bad encoded ID:", id)
+		}
+		if ref := item["Ref"].(*types.AttributeValueMemberS).Value; ref != "keep" {
+			t.Error("existing value overwritten:", ref)
+		}
+	})
+
+	t.Run("marshaled again", func(t *testing.T) {
+		// items with MarshalerCtx values are marshaled again with the request's context
+		type tenantEvent struct {
+			UserID string `dynamo:",hash"`
+			ID     string `dynamo:",range,auto=ulid"`
+			Name   tenantString
+		}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "t1")
+
+		put := table.Put(tenantEvent{UserID: "a", Name: "n"})
+		item, err := put.marshal(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, ok := item["ID"].(*types.AttributeValueMemberS); !ok || id.Value != put.item["ID"].(*types.AttributeValueMemberS).Value {
+			t.Error("put: auto ID lost:", item["ID"], "want:", put.item["ID"])
+		}
+		if name := item["Name"].(*types.AttributeValueMemberS).Value; name != "t1:n" {
+			t.Error("put: not marshaled with context:", name)
+		}
+
+		client := new(recordingClient)
+		_, err = NewFromIface(client).Table("Auto").Batch().Write().Put(tenantEvent{UserID: "a", Name: "n"}).Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent := client.batches[0].RequestItems["Auto"][0].PutRequest.Item
+		if id, ok := sent["ID"].(*types.AttributeValueMemberS); !ok || len(id.Value) != 26 {
+			t.Error("batch: auto ID lost:", sent["ID"])
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		type unknown struct {
+			ID string `dynamo:",auto=nope"`
+		}
+		if err := table.Put(unknown{}).err; err == nil {
+			t.Error("expected error for unknown generator")
+		}
+		type notString struct {
+			ID int `dynamo:",auto=ulid"`
+		}
+		if err := table.Put(notString{}).err; err == nil {
+			t.Error("expected error for non-string field")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	smithytime "github.com/aws/smithy-go/time"
+)
+
+// DefaultBackfillPollInterval is the default time to wait between checks of an index's backfill progress.
+const DefaultBackfillPollInterval = 20 * time.Second
+
+// IndexBackfill is a request to create a global secondary index and wait for it to become usable.
+// See [Table.BackfillIndex].
+type IndexBackfill struct {
+	table    Table
+	index    Index
+	interval time.Duration
+	progress func(idx Index, elapsed time.Duration)
+	samples  int
+	err      error
+}
+
+// BackfillIndex creates a new global secondary index on this table (via UpdateTable)
+// and waits for DynamoDB to finish backfilling it with existing items.
+// If an index with the same name already exists, it is not created again and only its progress is monitored,
+// so an interrupted backfill can be resumed by running it again.
+// The index must be specified as in [UpdateTable.CreateIndex].
+//
+//	desc, err := table.BackfillIndex(dynamo.Index{
+//		Name:           "Email-index",
+//		HashKey:        "Email",
+//		HashKeyType:    dynamo.StringType,
+//		ProjectionType: dynamo.KeysOnlyProjection,
+//	}).OnProgress(func(idx dynamo.Index, elapsed time.Duration) {
+//		log.Printf("%s: %s (backfilling: %v) after %v", idx.Name, idx.Status, idx.Backfilling, elapsed)
+//	}).Verify(10).Run(ctx)
+func (table Table) BackfillIndex(index Index) *IndexBackfill {
+	ib := &IndexBackfill{
+		table:    table,
+		index:    index,
+		interval: DefaultBackfillPollInterval,
+	}
+	if index.Local {
+		ib.err = fmt.Errorf("dynamo: backfill index %s: local secondary indexes can only be created with the table", index.Name)
+	}
+	return ib
+}
+
+// PollInterval sets the time to wait between checks of the index's status.
+// The default is [DefaultBackfillPollInterval].
+func (ib *IndexBackfill) PollInterval(interval time.Duration) *IndexBackfill {
+	ib.interval = interval
+	return ib
+}
+
+// OnProgress sets a function that is called with the latest description of the index every time its status is checked,
+// along with the time elapsed since Run began.
+func (ib *IndexBackfill) OnProgress(fn func(idx Index, elapsed time.Duration)) *IndexBackfill {
+	ib.progress = fn
+	return ib
+}
+
+// Verify makes Run check that up to n items from the table can be found by querying the new index
+// once backfilling is done. Sample items are chosen by scanning the table for items with the index's keys.
+// As global secondary indexes are eventually consistent, missing items are retried once after the poll interval
+// before Run gives up and returns an error.
+func (ib *IndexBackfill) Verify(n int) *IndexBackfill {
+	ib.samples = n
+	return ib
+}
+
+// Run creates the index if necessary and blocks until it is active and done backfilling,
+// returning the table's latest description.
+func (ib *IndexBackfill) Run(ctx context.Context) (Description, error) {
+	if ib.err != nil {
+		return Description{}, ib.err
+	}
+	start := time.Now()
+
+	desc, err := ib.table.Describe().Run(ctx)
+	if err != nil {
+		return Description{}, err
+	}
+	if _, ok := findGSI(desc, ib.index.Name); !ok {
+		desc, err = ib.table.UpdateTable().CreateIndex(ib.index).Run(ctx)
+		if err != nil {
+			return Description{}, err
+		}
+	}
+
+	for {
+		idx, ok := findGSI(desc, ib.index.Name)
+		if !ok {
+			return desc, fmt.Errorf("dynamo: backfill index %s: index not found on table %s", ib.index.Name, ib.table.Name())
+		}
+		if ib.progress != nil {
+			ib.progress(idx, time.Since(start))
+		}
+		if idx.Status == ActiveStatus && !idx.Backfilling {
+			break
+		}
+		if err := smithytime.SleepWithContext(ctx, ib.interval); err != nil {
+			return desc, err
+		}
+		desc, err = ib.table.Describe().Run(ctx)
+		if err != nil {
+			return desc, err
+		}
+	}
+
+	if ib.samples > 0 {
+		idx, _ := findGSI(desc, ib.index.Name)
+		if err := ib.verify(ctx, desc, idx); err != nil {
+			return desc, err
+		}
+	}
+	return desc, nil
+}
+
+// verify checks that sample items from the table can be found via the index.
+func (ib *IndexBackfill) verify(ctx context.Context, desc Description, idx Index) error {
+	exists := []string{"attribute_exists($)"}
+	args := []interface{}{idx.HashKey}
+	if idx.RangeKey != "" {
+		exists = append(exists, "attribute_exists($)")
+		args = append(args, idx.RangeKey)
+	}
+	var samples []Item
+	err := ib.table.Scan().
+		Filter(strings.Join(exists, " AND "), args...).
+		Limit(ib.samples).
+		All(ctx, &samples)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range samples {
+		found, err := ib.findViaIndex(ctx, desc, idx, item)
+		if err == nil && !found {
+			if err := smithytime.SleepWithContext(ctx, ib.interval); err != nil {
+				return err
+			}
+			found, err = ib.findViaIndex(ctx, desc, idx, item)
+		}
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("dynamo: backfill index %s: sample item #%d of %d not found via index", idx.Name, i+1, len(samples))
+		}
+	}
+	return nil
+}
+
+func (ib *IndexBackfill) findViaIndex(ctx context.Context, desc Description, idx Index, item Item) (bool, error) {
+	q := ib.table.Get(idx.HashKey, item[idx.HashKey]).Index(idx.Name)
+	if idx.RangeKey != "" {
+		q.Range(idx.RangeKey, Equal, item[idx.RangeKey])
+	}
+	// narrow it down to this item, but filters can't use the index's own keys
+	for _, key := range []string{desc.HashKey, desc.RangeKey} {
+		if key == "" || key == idx.HashKey || key == idx.RangeKey {
+			continue
+		}
+		q.Filter("$ = ?", key, item[key])
+	}
+	n, err := q.Count(ctx)
+	return n > 0, err
+}
+
+func findGSI(desc Description, name string) (Index, bool) {
+	for _, idx := range desc.GSI {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return Index{}, false
+}
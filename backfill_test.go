@@ -0,0 +1,125 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// backfillClient is a table whose Email-index finishes backfilling after a few checks.
+type backfillClient struct {
+	dynamodbiface.DynamoDBAPI
+	created   bool
+	describes int
+	queries   []*dynamodb.QueryInput
+	missing   bool
+}
+
+func (c *backfillClient) table() *types.TableDescription {
+	table := &types.TableDescription{
+		TableName: aws.String("Users"),
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash}},
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("ID"), AttributeType: types.ScalarAttributeTypeN},
+			{AttributeName: aws.String("Email"), AttributeType: types.ScalarAttributeTypeS},
+		},
+	}
+	if c.created {
+		status, backfilling := types.IndexStatusCreating, true
+		if c.describes >= 3 {
+			status, backfilling = types.IndexStatusActive, false
+		}
+		table.GlobalSecondaryIndexes = []types.GlobalSecondaryIndexDescription{{
+			IndexName:   aws.String("Email-index"),
+			IndexArn:    aws.String("arn"),
+			IndexStatus: status,
+			Backfilling: aws.Bool(backfilling),
+			KeySchema:   []types.KeySchemaElement{{AttributeName: aws.String("Email"), KeyType: types.KeyTypeHash}},
+		}}
+	}
+	return table
+}
+
+func (c *backfillClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.describes++
+	return &dynamodb.DescribeTableOutput{Table: c.table()}, nil
+}
+
+func (c *backfillClient) UpdateTable(_ context.Context, in *dynamodb.UpdateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	c.created = true
+	return &dynamodb.UpdateTableOutput{TableDescription: c.table()}, nil
+}
+
+func (c *backfillClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return &dynamodb.ScanOutput{Items: []Item{
+		{"ID": &types.AttributeValueMemberN{Value: "1"}, "Email": &types.AttributeValueMemberS{Value: "a@example.com"}},
+		{"ID": &types.AttributeValueMemberN{Value: "2"}, "Email": &types.AttributeValueMemberS{Value: "b@example.com"}},
+	}}, nil
+}
+
+func (c *backfillClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, in)
+	if c.missing {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	return &dynamodb.QueryOutput{Count: 1}, nil
+}
+
+func TestBackfillIndex(t *testing.T) {
+	ctx := context.Background()
+	index := Index{
+		Name:           "Email-index",
+		HashKey:        "Email",
+		HashKeyType:    StringType,
+		ProjectionType: KeysOnlyProjection,
+	}
+
+	client := new(backfillClient)
+	var checks []Status
+	desc, err := NewFromIface(client).Table("Users").BackfillIndex(index).
+		PollInterval(time.Millisecond).
+		OnProgress(func(idx Index, _ time.Duration) {
+			checks = append(checks, idx.Status)
+		}).
+		Verify(2).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.created {
+		t.Error("index not created")
+	}
+	if len(checks) != 3 || checks[len(checks)-1] != ActiveStatus {
+		t.Error("bad progress:", checks)
+	}
+	if desc.GSI[0].Backfilling {
+		t.Error("index still backfilling")
+	}
+	if len(client.queries) != 2 {
+		t.Fatal("want 2 verification queries, got", len(client.queries))
+	}
+	if *client.queries[0].IndexName != "Email-index" || client.queries[0].FilterExpression == nil {
+		t.Error("bad verification query:", client.queries[0])
+	}
+
+	t.Run("resume and verify failure", func(t *testing.T) {
+		client.missing = true
+		client.queries = nil
+		_, err := NewFromIface(client).Table("Users").BackfillIndex(index).
+			PollInterval(time.Millisecond).
+			Verify(1).
+			Run(ctx)
+		if err == nil {
+			t.Error("want verification error, got nil")
+		}
+		if len(client.queries) != 2 {
+			t.Error("want verification to be retried once, got", len(client.queries), "queries")
+		}
+	})
+}
@@ -2,8 +2,17 @@ package dynamo
 
 import (
 	"context"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 const batchSize = 101
@@ -199,3 +208,519 @@ func TestBatchGetInputSize(t *testing.T) {
 		t.Error("input amalgamation size mismatch. want:", len(keys), "got:", size)
 	}
 }
+
+func TestBatchWritePutIf(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingClient{}
+	table := NewFromIface(client).Table("Widgets")
+
+	type widget struct {
+		UserID int `dynamo:",hash"`
+		Time   int `dynamo:",range"`
+	}
+
+	var cc ConsumedCapacity
+	batch := table.Batch("UserID", "Time").Write().ConsumedCapacity(&cc)
+	for i := 0; i < 30; i++ {
+		batch.Put(widget{UserID: i})
+	}
+	for i := 0; i < 101; i++ {
+		batch.PutIf(widget{UserID: i, Time: 1}, "attribute_not_exists('UserID')")
+	}
+	wrote, err := batch.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrote != 131 {
+		t.Error("bad wrote count. want:", 131, "got:", wrote)
+	}
+	if len(client.batches) != 2 {
+		t.Error("wrong number of batch requests. want:", 2, "got:", len(client.batches))
+	}
+	if len(client.txs) != 2 {
+		t.Fatal("wrong number of transactions. want:", 2, "got:", len(client.txs))
+	}
+	if got := len(client.txs[0].TransactItems) + len(client.txs[1].TransactItems); got != 101 {
+		t.Error("wrong number of transaction items. want:", 101, "got:", got)
+	}
+	put := client.txs[0].TransactItems[0].Put
+	if put == nil || put.ConditionExpression == nil {
+		t.Fatal("missing condition:", put)
+	}
+	if cc.Requests != 4 {
+		t.Error("wrong request count. want:", 4, "got:", cc.Requests)
+	}
+	if want := 30.0 + 2*101; cc.Total != want {
+		t.Error("wrong consumed capacity. want:", want, "got:", cc.Total)
+	}
+
+	t.Run("size and duplicates", func(t *testing.T) {
+		type blob struct {
+			UserID int `dynamo:",hash"`
+			Time   int `dynamo:",range"`
+			Data   string
+		}
+		client.txs = nil
+		data := strings.Repeat("x", 300*1024)
+		batch := table.Batch("UserID", "Time").Write()
+		for i := 0; i < 15; i++ {
+			batch.PutIf(blob{UserID: i, Data: data}, "attribute_not_exists('UserID')")
+		}
+		batch.PutIf(blob{UserID: 100}, "attribute_not_exists('UserID')")
+		batch.PutIf(blob{UserID: 100}, "attribute_exists('UserID')")
+		if _, err := batch.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var sizes []int
+		for _, tx := range client.txs {
+			sizes = append(sizes, len(tx.TransactItems))
+		}
+		// 13 items of 300 KB fit in 4 MB, and the same item can't be written twice in one transaction
+		if want := []int{13, 3, 1}; !reflect.DeepEqual(sizes, want) {
+			t.Error("bad transactions. want:", want, "got:", sizes)
+		}
+	})
+}
+
+func TestBatchGetConsistentTable(t *testing.T) {
+	db := NewFromIface(&recordingClient{})
+	widgets := db.Table("Widgets")
+	sprockets := db.Table("Sprockets")
+	gadgets := db.Table("Gadgets")
+
+	batch := widgets.Batch("UserID", "Time").Get(Keys{1, "abc"}).
+		FromRange(sprockets, "UserID", "Time", Keys{2, "def"}).
+		From(gadgets, "ID", Keys{3}).
+		Consistent(true).
+		ConsistentTable(sprockets, false)
+	input := batch.input(0)
+
+	isConsistent := func(table string) bool {
+		cr := input.RequestItems[table].ConsistentRead
+		return cr != nil && *cr
+	}
+	if !isConsistent("Widgets") {
+		t.Error("expected consistent read for Widgets")
+	}
+	if isConsistent("Sprockets") {
+		t.Error("expected eventually consistent read for Sprockets")
+	}
+	if !isConsistent("Gadgets") {
+		t.Error("expected consistent read for Gadgets")
+	}
+
+	merged := gadgets.Batch("ID").Get(Keys{4}).Merge(widgets.Batch("UserID").Get(Keys{5}).ConsistentTable(widgets, true))
+	input = merged.input(0)
+	if !isConsistent("Widgets") || isConsistent("Gadgets") {
+		t.Error("bad merged consistency:", input.RequestItems)
+	}
+}
+
+func TestBatchGetProjectionInterning(t *testing.T) {
+	db := NewFromIface(&recordingClient{})
+	widgets := db.Table("Widgets")
+
+	var keys []Keyed
+	for i := 0; i < 250; i++ {
+		keys = append(keys, Keys{i, "abc"})
+	}
+	batch := widgets.Batch("UserID", "Time").Get(keys...).Project("UserID", "Count", "Meta.Name")
+	first := batch.input(0).RequestItems["Widgets"]
+	second := batch.input(maxGetOps).RequestItems["Widgets"]
+	other := widgets.Batch("UserID", "Time").Get(Keys{1, "abc"}).Project("UserID", "Count", "Meta.Name").input(0).RequestItems["Widgets"]
+
+	want := "UserID, #s" + encodeName("Count") + ", Meta.Name"
+	if got := *first.ProjectionExpression; got != want {
+		t.Error("bad projection. want:", want, "got:", got)
+	}
+	if len(first.ExpressionAttributeNames) != 1 {
+		t.Error("bad names:", first.ExpressionAttributeNames)
+	}
+	for _, kas := range []types.KeysAndAttributes{second, other} {
+		if *kas.ProjectionExpression != want || !reflect.DeepEqual(kas.ExpressionAttributeNames, first.ExpressionAttributeNames) {
+			t.Error("bad projection:", *kas.ProjectionExpression, kas.ExpressionAttributeNames)
+		}
+	}
+
+	// requests share the interned projection
+	if first.ProjectionExpression != second.ProjectionExpression ||
+		reflect.ValueOf(first.ExpressionAttributeNames).Pointer() != reflect.ValueOf(other.ExpressionAttributeNames).Pointer() {
+		t.Error("projection not shared between requests")
+	}
+	paths := []string{"UserID", "Count", "Meta.Name"}
+	if allocs := testing.AllocsPerRun(100, func() { internProjection(paths) }); allocs > 1 {
+		t.Error("interned projection lookup allocates too much:", allocs)
+	}
+
+	// modifying a request must not affect the interned projection
+	modified := batch.ModifyInput(func(in *dynamodb.BatchGetItemInput) {
+		kas := in.RequestItems["Widgets"]
+		*kas.ProjectionExpression = "Meta"
+		clear(kas.ExpressionAttributeNames)
+	}).input(0).RequestItems["Widgets"]
+	if *modified.ProjectionExpression != "Meta" {
+		t.Error("request not modified:", *modified.ProjectionExpression)
+	}
+	again := widgets.Batch("UserID", "Time").Get(keys...).Project("UserID", "Count", "Meta.Name").input(0).RequestItems["Widgets"]
+	if *again.ProjectionExpression != want || len(again.ExpressionAttributeNames) != 1 {
+		t.Error("interned projection was modified:", *again.ProjectionExpression, again.ExpressionAttributeNames)
+	}
+}
+
+func BenchmarkBatchGetInput(b *testing.B) {
+	widgets := NewFromIface(&recordingClient{}).Table("Widgets")
+	var keys []Keyed
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, Keys{i, "abc"})
+	}
+	batch := widgets.Batch("UserID", "Time").Get(keys...).Project("UserID", "Count", "Meta.Name")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for start := 0; start < len(keys); start += maxGetOps {
+			batch.input(start)
+		}
+	}
+}
+
+func TestBatchGetMixedProjections(t *testing.T) {
+	ctx := context.Background()
+	client := new(evenClient)
+	table := NewFromIface(client).Table("Widgets")
+
+	summary := table.Batch("UserID").Get(Keys{1}, Keys{2}).Project("UserID", "Msg")
+	detail := table.Batch("UserID").Get(Keys{3}).Project("UserID", "Msg", "Meta")
+	batch := summary.Merge(detail).
+		AndProject([]string{"UserID"}, Keys{4}).
+		And(Keys{6})
+
+	var items []Item
+	if err := batch.All(ctx, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Error("want 3 items, got", len(items))
+	}
+	if client.requests != 3 {
+		t.Error("want 3 requests (one per projection), got", client.requests)
+	}
+
+	first := batch.input(0).RequestItems["Widgets"]
+	if len(first.Keys) != 3 {
+		t.Error("requests with the same projection should be grouped, got keys:", first.Keys)
+	}
+	if want := "UserID, Msg"; *first.ProjectionExpression != want {
+		t.Error("bad projection. want:", want, "got:", *first.ProjectionExpression)
+	}
+}
+
+// evenClient has items for every even UserID.
+type evenClient struct {
+	keysClient
+	requests int
+}
+
+func (c *evenClient) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.requests++
+	out := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]Item)}
+	for table, kas := range in.RequestItems {
+		seen := make(map[string]bool)
+		for _, key := range kas.Keys {
+			id := key["UserID"].(*types.AttributeValueMemberN).Value
+			if seen[id] {
+				panic("duplicate key: " + id)
+			}
+			seen[id] = true
+			if n, _ := strconv.Atoi(id); n%2 == 0 {
+				out.Responses[table] = append(out.Responses[table], key)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestGetMany(t *testing.T) {
+	ctx := context.Background()
+	client := new(evenClient)
+	table := NewFromIface(client).Table("Many")
+
+	type row struct {
+		UserID int
+		Time   string
+	}
+	var keys []row
+	for i := 0; i < 150; i++ {
+		keys = append(keys, row{UserID: i, Time: "t"})
+	}
+	keys = append(keys, row{UserID: 3, Time: "t"}) // duplicate
+
+	var out []row
+	missing, err := GetMany(ctx, table, keys, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.requests != 2 {
+		t.Error("expected 2 requests, got:", client.requests)
+	}
+	if len(out) != 75 {
+		t.Error("expected 75 results, got:", len(out))
+	}
+	if len(missing) != 76 || missing[0].UserID != 1 || missing[75].UserID != 3 {
+		t.Error("bad missing keys:", missing)
+	}
+
+	t.Run("keyed", func(t *testing.T) {
+		var out []row
+		missing, err := GetMany(ctx, table, []Keyed{Keys{1, "t"}, Keys{2, "t"}}, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []row{{2, "t"}}; !reflect.DeepEqual(out, want) {
+			t.Error("bad results. want:", want, "got:", out)
+		}
+		if want := []Keyed{Keys{1, "t"}}; !reflect.DeepEqual(missing, want) {
+			t.Error("bad missing. want:", want, "got:", missing)
+		}
+	})
+
+	t.Run("none found", func(t *testing.T) {
+		var out []row
+		missing, err := GetMany(ctx, table, []Keys{{1, "t"}}, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missing) != 1 || out != nil {
+			t.Error("bad results:", missing, out)
+		}
+	})
+
+	t.Run("missing key attribute", func(t *testing.T) {
+		var out []row
+		if _, err := GetMany(ctx, table, []map[string]int{{"UserID": 1}}, &out); err == nil {
+			t.Error("expected error for key without range key")
+		}
+	})
+	t.Run("name mapper", func(t *testing.T) {
+		type mapped struct {
+			ID   int
+			Time string
+		}
+		db := NewFromIface(client).NameMapper(func(name string) string {
+			if name == "ID" {
+				return "UserID"
+			}
+			return name
+		})
+		var out []mapped
+		missing, err := GetMany(ctx, db.Table("Many"), []mapped{{1, "t"}, {2, "t"}}, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 1 || out[0].ID != 2 || len(missing) != 1 || missing[0].ID != 1 {
+			t.Error("bad results:", out, missing)
+		}
+	})
+}
+
+func TestKeyID(t *testing.T) {
+	s := func(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+	if keyID(s("a\x00Sb"), nil) == keyID(s("a"), s("b\x00")) {
+		t.Error("keys with NUL bytes collide")
+	}
+	if keyID(s("ab"), s("c")) == keyID(s("a"), s("bc")) {
+		t.Error("keys collide")
+	}
+	if keyID(&types.AttributeValueMemberN{Value: "1.0"}, nil) != keyID(&types.AttributeValueMemberN{Value: "1"}, nil) {
+		t.Error("equal numbers have different IDs")
+	}
+}
+
+// ttlClient records batch writes to a table with time to live enabled on the Expires attribute.
+type ttlClient struct {
+	dynamodbiface.DynamoDBAPI
+	describes int
+	writes    []*dynamodb.BatchWriteItemInput
+}
+
+func (c *ttlClient) DescribeTimeToLive(_ context.Context, in *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.describes++
+	desc := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if *in.TableName == "Ephemeral" {
+		desc = &types.TimeToLiveDescription{AttributeName: aws.String("Expires"), TimeToLiveStatus: types.TimeToLiveStatusEnabled}
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: desc}, nil
+}
+
+func (c *ttlClient) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.writes = append(c.writes, in)
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestBatchWritePutWithTTL(t *testing.T) {
+	ctx := context.Background()
+	client := new(ttlClient)
+	db := NewFromIface(client)
+
+	type event struct {
+		ID      int
+		Expires int64 `dynamo:",omitempty"`
+	}
+	now := time.Now()
+	wrote, err := db.Table("Ephemeral").Batch("ID").Write().
+		PutWithTTL(time.Hour, event{ID: 1}, event{ID: 2, Expires: 123}).
+		Put(event{ID: 3}).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrote != 3 || client.describes != 1 {
+		t.Error("bad run:", wrote, client.describes)
+	}
+	puts := client.writes[0].RequestItems["Ephemeral"]
+	for i, put := range puts[:2] {
+		var got event
+		if err := UnmarshalItem(put.PutRequest.Item, &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := now.Add(time.Hour).Unix(); got.Expires < want || got.Expires > want+60 {
+			t.Errorf("put %d: bad expiry: %d (want ~%d)", i, got.Expires, want)
+		}
+	}
+	if _, ok := puts[2].PutRequest.Item["Expires"]; ok {
+		t.Error("unexpected expiry on plain put")
+	}
+
+	t.Run("configured", func(t *testing.T) {
+		client.writes = nil
+		client.describes = 0
+		_, err := db.Table("Other").TTLAttribute("TTL").Batch("ID").Write().PutWithTTL(time.Minute, event{ID: 1}).Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.describes != 0 {
+			t.Error("unexpected DescribeTimeToLive")
+		}
+		if _, ok := client.writes[0].RequestItems["Other"][0].PutRequest.Item["TTL"]; !ok {
+			t.Error("missing TTL attribute")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := db.Table("Other").Batch("ID").Write().PutWithTTL(time.Minute, event{ID: 1}).Run(ctx)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestBatchWriteOrderByPartition(t *testing.T) {
+	ctx := context.Background()
+	client := new(ttlClient)
+	table := NewFromIface(client).Table("Ordered")
+
+	type row struct {
+		ID  int
+		Seq int
+	}
+	var rows []interface{}
+	for i := 0; i < 30; i++ {
+		rows = append(rows, row{ID: i, Seq: 0})
+	}
+	rows = append(rows, row{ID: 1, Seq: 1}, row{ID: 1, Seq: 2})
+	wrote, err := table.Batch("ID", "Seq").Write().
+		Put(rows...).
+		Delete(Keys{1, 2}).
+		OrderByPartition(true).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrote != 33 {
+		t.Error("bad write count:", wrote)
+	}
+	var sizes []int
+	for _, req := range client.writes {
+		sizes = append(sizes, len(req.RequestItems["Ordered"]))
+	}
+	// round 1: 32 items in 2 requests, then the second write to ID 1, Seq 2
+	if want := []int{25, 7, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Error("bad requests. want:", want, "got:", sizes)
+	}
+	if client.writes[2].RequestItems["Ordered"][0].DeleteRequest == nil {
+		t.Error("delete should be last")
+	}
+}
+
+// skipClient is a uniqueClient that also accepts BatchWriteItem requests.
+type skipClient struct {
+	uniqueClient
+	writes []*dynamodb.BatchWriteItemInput
+}
+
+func (c *skipClient) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.writes = append(c.writes, in)
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestBatchWriteSkipExisting(t *testing.T) {
+	ctx := context.Background()
+	client := new(skipClient)
+	table := NewFromIface(client).Table("Imports")
+
+	type row struct {
+		PK string
+		SK string
+	}
+	client.items = make(map[string]Item)
+	for i := 0; i < 10; i++ {
+		item, err := MarshalItem(row{PK: strconv.Itoa(i), SK: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.items[keyID(item["PK"], item["SK"])] = item
+	}
+
+	var rows []interface{}
+	for i := 0; i < 150; i++ {
+		rows = append(rows, row{PK: strconv.Itoa(i), SK: "x"})
+	}
+	rows = append(rows, row{PK: "42", SK: "x"})
+	var existed int
+	wrote, err := table.Batch("PK", "SK").Write().
+		Put(rows...).
+		Delete(Keys{"old", "x"}).
+		SkipExisting(&existed).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrote != 141 {
+		t.Error("bad write count. want: 141 got:", wrote)
+	}
+	if existed != 11 {
+		t.Error("bad existed count. want: 11 got:", existed)
+	}
+	if len(client.writes) != 1 || len(client.writes[0].RequestItems["Imports"]) != 1 {
+		t.Error("delete should be sent with BatchWriteItem:", client.writes)
+	}
+	// first transaction is canceled and retried without the existing items
+	var sizes []int
+	for _, tx := range client.txs {
+		sizes = append(sizes, len(tx.TransactItems))
+	}
+	if want := []int{100, 90, 50}; !reflect.DeepEqual(sizes, want) {
+		t.Error("bad transactions. want:", want, "got:", sizes)
+	}
+	if len(client.items) != 150 {
+		t.Error("bad item count. want: 150 got:", len(client.items))
+	}
+
+	// running it again writes nothing
+	wrote, err = table.Batch("PK", "SK").Write().Put(rows...).SkipExisting(&existed).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrote != 0 || existed != 151 {
+		t.Error("bad counts for re-run. wrote:", wrote, "existed:", existed)
+	}
+}
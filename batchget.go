@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/smithy-go/time"
@@ -49,6 +55,10 @@ type BatchGet struct {
 	projections map[string][]string // table → paths
 	projection  []string            // default paths
 	consistent  bool
+	consistents map[string]bool // table → consistent read
+	modify      func(*dynamodb.BatchGetItemInput)
+	optFns      []func(*dynamodb.Options)
+	ordered     bool
 
 	err error
 	cc  *ConsumedCapacity
@@ -73,6 +83,21 @@ func (bg *BatchGet) And(keys ...Keyed) *BatchGet {
 	return bg.add(bg.batch.table, bg.batch.hashKey, bg.batch.rangeKey, keys...)
 }
 
+// AndProject adds more keys to be gotten from the default table, limiting their results to the given paths.
+// This overrides [BatchGet.Project] and [BatchGet.ProjectTable] for these keys only,
+// allowing for items from the same table to be fetched with different projections, such as summaries and full details.
+// Because DynamoDB only allows one projection per table in each request,
+// keys with different projections are sent in separate BatchGetItem requests.
+func (bg *BatchGet) AndProject(paths []string, keys ...Keyed) *BatchGet {
+	start := len(bg.reqs)
+	bg.And(keys...)
+	for _, get := range bg.reqs[start:] {
+		get.Project(paths...)
+		bg.setError(get.err)
+	}
+	return bg
+}
+
 // From adds more keys to be gotten from the given table.
 // The given table's primary key must be a hash key (partition key) only.
 // For tables with a range key (sort key) primary key, use [BatchGet.FromRange].
@@ -92,6 +117,7 @@ func (bg *BatchGet) add(table Table, hashKey string, rangeKey string, keys ...Ke
 			bg.setError(errors.New("dynamo: batch: the Keyed interface must not be nil"))
 			break
 		}
+		bg.setError(checkKeyNames(table.Name(), hashKey, rangeKey, key))
 		get := table.Get(hashKey, key.HashKey())
 		if rk := key.RangeKey(); rangeKey != "" && rk != nil {
 			get.Range(rangeKey, Equal, rk)
@@ -132,12 +158,73 @@ func (bg *BatchGet) projectionFor(table string) []string {
 	return nil
 }
 
+// maxInternedProjections is the maximum number of distinct projections to cache.
+const maxInternedProjections = 1024
+
+var (
+	// projection paths (joined by NUL) → *internedProjection
+	internedProjections    sync.Map
+	internedProjectionsLen atomic.Int32
+)
+
+// internedProjection is a projection expression and its names, shared between requests.
+// It must not be modified, so requests that can be changed by [BatchGet.ModifyInput] get copies of it.
+type internedProjection struct {
+	expr  string
+	names map[string]string
+}
+
+// internProjection returns the projection expression for paths,
+// reusing a previously computed one if possible.
+// This avoids reparsing the same projection for every batch request.
+func internProjection(paths []string) (*internedProjection, error) {
+	key := strings.Join(paths, "\x00")
+	if proj, ok := internedProjections.Load(key); ok {
+		return proj.(*internedProjection), nil
+	}
+
+	var sub subber
+	exprs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		expr, err := sub.escape(path)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	proj := &internedProjection{
+		expr:  strings.Join(exprs, ", "),
+		names: sub.nameExpr,
+	}
+	if internedProjectionsLen.Load() < maxInternedProjections {
+		if actual, loaded := internedProjections.LoadOrStore(key, proj); loaded {
+			return actual.(*internedProjection), nil
+		}
+		internedProjectionsLen.Add(1)
+	}
+	return proj, nil
+}
+
 // Merge copies operations and settings from src to this batch get.
+// Projections for tables other than this batch's default table are combined.
+// If src projects its default table differently than this batch does,
+// its requests for that table keep their projection (see [BatchGet.AndProject]).
 func (bg *BatchGet) Merge(srcs ...*BatchGet) *BatchGet {
 	for _, src := range srcs {
+		this := bg.batch.table.Name()
+		if proj := src.projectionFor(this); proj != nil && !slices.Equal(proj, bg.projectionFor(this)) {
+			for _, get := range src.reqs {
+				if get.table.Name() == this && get.projection == "" {
+					get.Project(proj...)
+					bg.setError(get.err)
+				}
+			}
+		}
 		bg.reqs = append(bg.reqs, src.reqs...)
 		bg.consistent = bg.consistent || src.consistent
-		this := bg.batch.table.Name()
+		for table, on := range src.consistents {
+			bg.consistentTable(table, on)
+		}
 		for table, proj := range src.projections {
 			if this == table {
 				continue
@@ -172,6 +259,43 @@ func (bg *BatchGet) Consistent(on bool) *BatchGet {
 	return bg
 }
 
+// ConsistentTable will, if on is true, make this batch use a strongly consistent read for the given table.
+// This overrides [BatchGet.Consistent] for that table, allowing for strongly consistent reads
+// of some tables and eventually consistent reads of others in the same batch.
+func (bg *BatchGet) ConsistentTable(table Table, on bool) *BatchGet {
+	return bg.consistentTable(table.Name(), on)
+}
+
+func (bg *BatchGet) consistentTable(table string, on bool) *BatchGet {
+	if bg.consistents == nil {
+		bg.consistents = make(map[string]bool)
+	}
+	bg.consistents[table] = on
+	return bg
+}
+
+func (bg *BatchGet) consistentFor(table string) bool {
+	if on, ok := bg.consistents[table]; ok {
+		return on
+	}
+	return bg.consistent
+}
+
+// ModifyInput sets a function that is called with the input of each BatchGetItem request just before it is first sent,
+// allowing for API parameters that this library does not support yet.
+// Retries of unprocessed keys reuse the modified input.
+func (bg *BatchGet) ModifyInput(fn func(*dynamodb.BatchGetItemInput)) *BatchGet {
+	bg.modify = fn
+	return bg
+}
+
+// RequestOptions sets options that are applied to each request made by this batch, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (bg *BatchGet) RequestOptions(optFns ...func(*dynamodb.Options)) *BatchGet {
+	bg.optFns = optFns
+	return bg
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (bg *BatchGet) ConsumedCapacity(cc *ConsumedCapacity) *BatchGet {
 	bg.cc = cc
@@ -180,6 +304,9 @@ func (bg *BatchGet) ConsumedCapacity(cc *ConsumedCapacity) *BatchGet {
 
 // All executes this request and unmarshals all results to out, which must be a pointer to a slice.
 func (bg *BatchGet) All(ctx context.Context, out interface{}) error {
+	if bg.ordered {
+		return bg.AllParallel(ctx, 1, out)
+	}
 	iter := newBGIter(bg, unmarshalAppendTo(out), nil, bg.err)
 	for iter.Next(ctx, out) {
 	}
@@ -242,12 +369,6 @@ func (bg *BatchGet) input(start int) *dynamodb.BatchGetItemInput {
 		RequestItems: make(map[string]types.KeysAndAttributes),
 	}
 
-	for _, get := range bg.reqs[start:end] {
-		if proj := bg.projectionFor(get.table.Name()); proj != nil {
-			get.Project(proj...)
-			bg.setError(get.err)
-		}
-	}
 	if bg.cc != nil {
 		in.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
 	}
@@ -257,18 +378,76 @@ func (bg *BatchGet) input(start int) *dynamodb.BatchGetItemInput {
 		kas, ok := in.RequestItems[table]
 		if !ok {
 			kas = get.keysAndAttribs()
-			if bg.consistent {
-				kas.ConsistentRead = &bg.consistent
+			if paths := bg.projectionFor(table); paths != nil && get.projection == "" {
+				proj, err := internProjection(paths)
+				bg.setError(err)
+				if proj != nil {
+					kas.ProjectionExpression = &proj.expr
+					kas.ExpressionAttributeNames = proj.names
+					if bg.modify != nil {
+						// copied, as ModifyInput can change the input
+						expr := proj.expr
+						kas.ProjectionExpression = &expr
+						kas.ExpressionAttributeNames = maps.Clone(proj.names)
+					}
+				}
+			}
+			if bg.consistentFor(table) {
+				kas.ConsistentRead = aws.Bool(true)
 			}
 			in.RequestItems[table] = kas
 			continue
 		}
+		if aws.ToString(kas.ProjectionExpression) != aws.ToString(bg.projectionOf(get)) {
+			// only one projection per table is allowed, so the rest will be sent in the next request
+			break
+		}
 		kas.Keys = append(kas.Keys, get.keys())
 		in.RequestItems[table] = kas
 	}
+	if bg.modify != nil {
+		bg.modify(in)
+	}
 	return in
 }
 
+// projectionOf returns the projection expression for get.
+func (bg *BatchGet) projectionOf(get *Query) *string {
+	if get.projection != "" {
+		return &get.projection
+	}
+	if paths := bg.projectionFor(get.table.Name()); paths != nil {
+		if proj, err := internProjection(paths); err == nil {
+			return &proj.expr
+		}
+	}
+	return nil
+}
+
+// groupProjections reorders requests so that those for the same table and projection are next to each other,
+// minimizing the number of requests needed when some requests have their own projection.
+func (bg *BatchGet) groupProjections() {
+	if !slices.ContainsFunc(bg.reqs, func(get *Query) bool { return get.projection != "" }) {
+		return
+	}
+	groups := make(map[string]int)
+	group := func(get *Query) int {
+		key := get.table.Name() + "\x00" + aws.ToString(bg.projectionOf(get))
+		n, ok := groups[key]
+		if !ok {
+			n = len(groups)
+			groups[key] = n
+		}
+		return n
+	}
+	for _, get := range bg.reqs {
+		group(get)
+	}
+	slices.SortStableFunc(bg.reqs, func(a, b *Query) int {
+		return group(a) - group(b)
+	})
+}
+
 func (bg *BatchGet) setError(err error) {
 	if bg.err == nil {
 		bg.err = err
@@ -286,8 +465,10 @@ type bgIter struct {
 	idx       int
 	total     int
 	processed int
-	backoff   *backoff.ExponentialBackOff
+	backoff   backoff.BackOff
 	unmarshal unmarshalFunc
+	// resolved table names → original names, see TableFunc
+	renamed map[string]string
 }
 
 type batchGot struct {
@@ -295,19 +476,31 @@ type batchGot struct {
 	item  Item
 }
 
+// tableOf returns the table of this batch's requests with the given name.
+func (bg *BatchGet) tableOf(name string) Table {
+	for _, req := range bg.reqs {
+		if req.table.name == name {
+			return req.table
+		}
+	}
+	return bg.batch.table
+}
+
 func newBGIter(bg *BatchGet, fn unmarshalFunc, track *string, err error) *bgIter {
 	if err == nil && len(bg.reqs) == 0 {
 		err = ErrNoInput
 	}
+	if err == nil {
+		bg.groupProjections()
+	}
 
 	iter := &bgIter{
 		bg:        bg,
 		track:     track,
 		err:       err,
-		backoff:   backoff.NewExponentialBackOff(),
-		unmarshal: fn,
+		backoff:   newBatchBackOff(),
+		unmarshal: bg.batch.table.db.unmarshaler(fn),
 	}
-	iter.backoff.MaxElapsedTime = 0
 	return iter
 }
 
@@ -326,7 +519,10 @@ redo:
 	// can we use results we already have?
 	if itr.output != nil && itr.idx < len(itr.got) {
 		got := itr.got[itr.idx]
-		itr.err = itr.unmarshal(got.item, out)
+		itr.err = itr.bg.tableOf(got.table).interceptRead(got.item)
+		if itr.err == nil {
+			itr.err = itr.unmarshal(ctx, got.item, out)
+		}
 		itr.idx++
 		itr.total++
 		itr.trackTable(got.table)
@@ -349,6 +545,7 @@ redo:
 		}
 		// have we exhausted all results?
 		if len(itr.output.UnprocessedKeys) == 0 {
+			itr.backoff.Reset()
 			// yes, try to get next inner batch of 100 items
 			if itr.input = itr.bg.input(itr.processed); itr.input == nil {
 				// we're done, no more input
@@ -370,9 +567,17 @@ redo:
 		itr.idx = 0
 	}
 
+	var renamed map[string]string
+	itr.input.RequestItems, renamed, itr.err = resolveNames(ctx, itr.bg.batch.table.db, itr.input.RequestItems)
+	if itr.err != nil {
+		return false
+	}
+	if renamed != nil {
+		itr.renamed = renamed
+	}
 	itr.err = itr.bg.batch.table.db.retry(ctx, func() error {
 		var err error
-		itr.output, err = itr.bg.batch.table.db.client.BatchGetItem(ctx, itr.input)
+		itr.output, err = itr.bg.batch.table.db.client.BatchGetItem(ctx, itr.input, itr.bg.batch.table.db.requestOptions(itr.bg.optFns)...)
 		itr.bg.cc.incRequests()
 		return err
 	})
@@ -387,6 +592,9 @@ redo:
 
 	itr.got = itr.got[:0]
 	for table, resp := range itr.output.Responses {
+		if name, ok := itr.renamed[table]; ok {
+			table = name
+		}
 		for _, item := range resp {
 			itr.got = append(itr.got, batchGot{
 				table: table,
@@ -411,3 +619,113 @@ func (itr *bgIter) trackTable(next string) {
 func (itr *bgIter) Err() error {
 	return itr.err
 }
+
+// GetMany gets the items in table with the given keys, unmarshaling them to out.
+// It uses BatchGetItem, splitting the keys into batches as needed.
+// Keys can be [Keyed] values (such as [Keys]) or structs (or maps) that contain the table's primary key attributes,
+// such as the items themselves.
+// Duplicate keys are only requested once.
+//
+// The keys that were not found are returned as missing, in their original order.
+// If no items are found, out is left untouched and missing contains every key; it is not an error.
+// GetMany calls DescribeTable to determine the table's primary key, caching the result.
+//
+// GetMany is a function instead of a method because Go does not allow methods to have type parameters.
+func GetMany[K any, T any](ctx context.Context, table Table, keys []K, out *[]T) (missing []K, err error) {
+	if len(keys) == 0 {
+		return nil, ErrNoInput
+	}
+	desc, err := table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	batch := table.Batch(desc.HashKey, desc.RangeKey).Get()
+	for i, key := range keys {
+		hash, rng, err := desc.keyValues(table.db.codecContext(ctx), key)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = keyID(hash, rng)
+		if _, dupe := seen[ids[i]]; dupe {
+			continue
+		}
+		seen[ids[i]] = struct{}{}
+		batch.And(Keys{hash, rng})
+	}
+	if batch.err != nil {
+		return nil, batch.err
+	}
+
+	found := make(map[string]struct{}, len(seen))
+	push := unmarshalAppendTo(out)
+	iter := newBGIter(batch, func(ctx context.Context, item Item, out any) error {
+		found[keyID(item[desc.HashKey], item[desc.RangeKey])] = struct{}{}
+		return push(ctx, item, out)
+	}, nil, nil)
+	for iter.Next(ctx, out) {
+	}
+	if err := iter.Err(); err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		if _, ok := found[ids[i]]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
+
+// keyValues returns the primary key values of key, which is either Keyed or an item-like value.
+// ctx carries the DB's marshaling options, see [DB.codecContext].
+func (desc Description) keyValues(ctx context.Context, key any) (hash, rng types.AttributeValue, err error) {
+	if keyed, ok := key.(Keyed); ok {
+		if hash, err = marshalContext(ctx, keyed.HashKey(), flagNone); err != nil {
+			return nil, nil, err
+		}
+		if desc.RangeKey != "" {
+			if rng, err = marshalContext(ctx, keyed.RangeKey(), flagNone); err != nil {
+				return nil, nil, err
+			}
+		}
+	} else {
+		item, err := marshalItemContext(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		hash = item[desc.HashKey]
+		if desc.RangeKey != "" {
+			rng = item[desc.RangeKey]
+		}
+	}
+	if hash == nil {
+		return nil, nil, fmt.Errorf("dynamo: key for table %q is missing hash key %q: %v", desc.Name, desc.HashKey, key)
+	}
+	if desc.RangeKey != "" && rng == nil {
+		return nil, nil, fmt.Errorf("dynamo: key for table %q is missing range key %q: %v", desc.Name, desc.RangeKey, key)
+	}
+	return hash, rng, nil
+}
+
+// keyID returns a string uniquely identifying the given primary key values.
+func keyID(hash, rng types.AttributeValue) string {
+	return keyValueID(hash) + keyValueID(rng)
+}
+
+// keyValueID returns the key value's type and length-prefixed value,
+// so that the IDs of different keys can't collide when concatenated.
+func keyValueID(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S" + strconv.Itoa(len(v.Value)) + ":" + v.Value
+	case *types.AttributeValueMemberN:
+		n := normalizeNumber(v.Value)
+		return "N" + strconv.Itoa(len(n)) + ":" + n
+	case *types.AttributeValueMemberB:
+		return "B" + strconv.Itoa(len(v.Value)) + ":" + string(v.Value)
+	}
+	return "-"
+}
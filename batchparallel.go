@@ -0,0 +1,206 @@
+package dynamo
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cenkalti/backoff/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+// Ordered makes [BatchGet.All] and [BatchGet.AllParallel] return results in the same order as their keys were added,
+// instead of the order DynamoDB returns them in. Keys that weren't found are skipped.
+// Results are buffered until every key has been fetched. It has no effect on [BatchGet.Iter].
+func (bg *BatchGet) Ordered(enabled bool) *BatchGet {
+	bg.ordered = enabled
+	return bg
+}
+
+// AllParallel is like [BatchGet.All], but splits the keys into batches of 100 (the most BatchGetItem allows)
+// and fetches them using up to the given number of concurrent workers.
+// This is meant for fetching many thousands of keys.
+// Unprocessed keys are retried by the worker that requested them, but all workers share the same backoff,
+// so that when the table is throttled every worker slows down instead of only the ones that noticed.
+// The backoff is reset once no worker has unprocessed keys left to retry.
+//
+// Results are unmarshaled to out, which must be a pointer to a slice, once every key has been fetched.
+// They are in no particular order, unless [BatchGet.Ordered] is used.
+// If an error occurs, the other workers are canceled and the error is returned; out is left untouched.
+func (bg *BatchGet) AllParallel(ctx context.Context, workers int, out interface{}) error {
+	if bg.err != nil {
+		return bg.err
+	}
+	if len(bg.reqs) == 0 {
+		return ErrNoInput
+	}
+
+	var order map[string]int
+	if bg.ordered {
+		order = bg.keyOrder()
+	}
+	all := *bg
+	all.reqs = slices.Clone(bg.reqs)
+	all.groupProjections()
+	var chunks [][]*Query
+	for reqs := all.reqs; len(reqs) > 0; {
+		n := min(len(reqs), maxGetOps)
+		chunks = append(chunks, reqs[:n:n])
+		reqs = reqs[n:]
+	}
+
+	var (
+		mu   sync.Mutex
+		got  []batchGot
+		boff = &lockedBackOff{b: newBatchBackOff()}
+		jobs = make(chan []*Query)
+	)
+	grp, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < min(max(workers, 1), len(chunks)); i++ {
+		grp.Go(func() error {
+			for reqs := range jobs {
+				sub := *bg
+				sub.reqs = reqs
+				sub.cc = nil
+				if bg.cc != nil {
+					sub.cc = new(ConsumedCapacity)
+				}
+				var table string
+				var item Item
+				iter := newBGIter(&sub, func(_ context.Context, it Item, _ any) error {
+					item = it
+					return nil
+				}, &table, nil)
+				iter.backoff = boff.worker()
+				var local []batchGot
+				for iter.Next(gctx, nil) {
+					local = append(local, batchGot{table: table, item: item})
+				}
+
+				mu.Lock()
+				got = append(got, local...)
+				mergeConsumedCapacity(bg.cc, sub.cc)
+				mu.Unlock()
+
+				if err := iter.Err(); err != nil && err != ErrNotFound {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+feed:
+	for _, reqs := range chunks {
+		select {
+		case jobs <- reqs:
+		case <-gctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	if err := grp.Wait(); err != nil {
+		return err
+	}
+	if len(got) == 0 {
+		return ErrNotFound
+	}
+
+	if order != nil {
+		keys := bg.keyNames()
+		pos := func(g batchGot) int {
+			names := keys[g.table]
+			if n, ok := order[g.table+"\x00"+keyID(g.item[names[0]], g.item[names[1]])]; ok {
+				return n
+			}
+			return math.MaxInt
+		}
+		slices.SortStableFunc(got, func(a, b batchGot) int {
+			return pos(a) - pos(b)
+		})
+	}
+	unmarshal := bg.batch.table.db.unmarshaler(unmarshalAppendTo(out))
+	for _, g := range got {
+		if err := unmarshal(ctx, g.item, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyOrder returns the position of each requested key, by table and key ID.
+func (bg *BatchGet) keyOrder() map[string]int {
+	order := make(map[string]int, len(bg.reqs))
+	for i, get := range bg.reqs {
+		var rng types.AttributeValue
+		if len(get.rangeValues) > 0 {
+			rng = get.rangeValues[0]
+		}
+		id := get.table.Name() + "\x00" + keyID(get.hashValue, rng)
+		if _, ok := order[id]; !ok {
+			order[id] = i
+		}
+	}
+	return order
+}
+
+// keyNames returns the hash and range key names of each table in this batch.
+func (bg *BatchGet) keyNames() map[string][2]string {
+	names := make(map[string][2]string)
+	for _, get := range bg.reqs {
+		names[get.table.Name()] = [2]string{get.hashKey, get.rangeKey}
+	}
+	return names
+}
+
+// newBatchBackOff returns the backoff used between retries of unprocessed batch items.
+func newBatchBackOff() backoff.BackOff {
+	boff := backoff.NewExponentialBackOff()
+	boff.MaxElapsedTime = 0
+	return boff
+}
+
+// lockedBackOff is a backoff that can be shared by concurrent workers.
+// Each worker uses its own handle (see worker), so that the backoff is only reset
+// when every worker that was retrying has finished.
+type lockedBackOff struct {
+	mu       sync.Mutex
+	b        backoff.BackOff
+	retrying int
+}
+
+func (lb *lockedBackOff) worker() *workerBackOff {
+	return &workerBackOff{shared: lb}
+}
+
+// workerBackOff is a worker's handle to a lockedBackOff.
+type workerBackOff struct {
+	shared   *lockedBackOff
+	retrying bool
+}
+
+func (wb *workerBackOff) NextBackOff() time.Duration {
+	lb := wb.shared
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if !wb.retrying {
+		wb.retrying = true
+		lb.retrying++
+	}
+	return lb.b.NextBackOff()
+}
+
+func (wb *workerBackOff) Reset() {
+	lb := wb.shared
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if wb.retrying {
+		wb.retrying = false
+		lb.retrying--
+	}
+	if lb.retrying == 0 {
+		lb.b.Reset()
+	}
+}
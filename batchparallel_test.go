@@ -0,0 +1,163 @@
+package dynamo
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// parallelGetClient has items for every even UserID, returned in reverse order.
+// The first request leaves its last key unprocessed.
+type parallelGetClient struct {
+	keysClient
+	mu       sync.Mutex
+	requests int
+	active   int
+	peak     int
+}
+
+func (c *parallelGetClient) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	c.requests++
+	first := c.requests == 1
+	c.active++
+	c.peak = max(c.peak, c.active)
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]Item),
+		UnprocessedKeys: make(map[string]types.KeysAndAttributes),
+	}
+	for table, kas := range in.RequestItems {
+		keys := kas.Keys
+		if first && len(keys) > 1 {
+			out.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: keys[len(keys)-1:]}
+			keys = keys[:len(keys)-1]
+		}
+		for _, key := range keys {
+			id := key["UserID"].(*types.AttributeValueMemberN).Value
+			if n, _ := strconv.Atoi(id); n%2 == 0 {
+				out.Responses[table] = append(out.Responses[table], key)
+			}
+		}
+		slices.Reverse(out.Responses[table])
+		out.ConsumedCapacity = append(out.ConsumedCapacity, types.ConsumedCapacity{
+			TableName:     aws.String(table),
+			CapacityUnits: aws.Float64(float64(len(keys))),
+		})
+	}
+	return out, nil
+}
+
+func TestBatchGetAllParallel(t *testing.T) {
+	ctx := context.Background()
+	type row struct {
+		UserID int
+		Time   string
+	}
+	const n = 400
+	batch := func(client *parallelGetClient) *BatchGet {
+		bg := NewFromIface(client).Table("Many").Batch("UserID", "Time").Get()
+		for i := 0; i < n; i++ {
+			bg.And(Keys{i, "t"})
+		}
+		return bg
+	}
+
+	t.Run("unordered", func(t *testing.T) {
+		client := new(parallelGetClient)
+		var cc ConsumedCapacity
+		var out []row
+		if err := batch(client).ConsumedCapacity(&cc).AllParallel(ctx, 4, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != n/2 {
+			t.Error("bad result count:", len(out))
+		}
+		seen := make(map[int]bool)
+		for _, r := range out {
+			if r.UserID%2 != 0 || seen[r.UserID] {
+				t.Error("unexpected result:", r)
+			}
+			seen[r.UserID] = true
+		}
+		if cc.Total != n || cc.Requests != client.requests {
+			t.Errorf("bad consumed capacity: %v (%d requests)", cc, client.requests)
+		}
+		if client.peak < 2 || client.peak > 4 {
+			t.Error("bad concurrency:", client.peak)
+		}
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		var out []row
+		if err := batch(new(parallelGetClient)).Ordered(true).AllParallel(ctx, 4, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != n/2 {
+			t.Fatal("bad result count:", len(out))
+		}
+		for i, r := range out {
+			if r.UserID != i*2 {
+				t.Fatalf("result %d out of order: %v", i, r)
+			}
+		}
+
+		out = nil
+		if err := batch(new(parallelGetClient)).Ordered(true).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != n/2 || out[0].UserID != 0 || out[1].UserID != 2 {
+			t.Error("bad ordered All results:", len(out))
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var out []row
+		bg := NewFromIface(new(parallelGetClient)).Table("Many").Batch("UserID", "Time").Get(Keys{1, "t"}, Keys{3, "t"})
+		if err := bg.AllParallel(ctx, 2, &out); err != ErrNotFound {
+			t.Error("want ErrNotFound, got:", err)
+		}
+	})
+}
+
+// countingBackOff returns the number of backoffs since it was last reset.
+type countingBackOff struct {
+	n int
+}
+
+func (b *countingBackOff) NextBackOff() time.Duration {
+	b.n++
+	return time.Duration(b.n)
+}
+
+func (b *countingBackOff) Reset() { b.n = 0 }
+
+func TestSharedBackOff(t *testing.T) {
+	shared := &lockedBackOff{b: new(countingBackOff)}
+	a, b := shared.worker(), shared.worker()
+	a.NextBackOff()
+	b.NextBackOff()
+	// b finished, but a is still retrying
+	b.Reset()
+	if got := a.NextBackOff(); got != 3 {
+		t.Error("backoff was reset while a worker was retrying:", got)
+	}
+	a.Reset()
+	if got := b.NextBackOff(); got != 1 {
+		t.Error("backoff not reset:", got)
+	}
+}
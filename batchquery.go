@@ -0,0 +1,197 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// DynamoDB API limit, 25 statements per BatchExecuteStatement request
+const maxBatchStatements = 25
+
+// Statement is a PartiQL statement, such as:
+//
+//	dynamo.Statement{Query: `SELECT * FROM "Widgets" WHERE "UserID" = ? AND "Time" = ?`, Args: []any{613, t}}
+//
+// Args are marshaled and bound to the statement's ? placeholders in order.
+type Statement struct {
+	Query string
+	Args  []interface{}
+	// Consistent enables strongly consistent reads for SELECT statements.
+	Consistent bool
+}
+
+func (stmt Statement) request(ctx context.Context) (types.BatchStatementRequest, error) {
+	req := types.BatchStatementRequest{
+		Statement: &stmt.Query,
+	}
+	if stmt.Consistent {
+		req.ConsistentRead = &stmt.Consistent
+	}
+	if len(stmt.Args) > 0 {
+		params, err := marshalSliceNoOmit(ctx, stmt.Args)
+		if err != nil {
+			return req, err
+		}
+		req.Parameters = params
+	}
+	return req, nil
+}
+
+// StatementError is the error of a single statement in a [BatchQuery].
+type StatementError struct {
+	// Index of the statement, in the order given to [DB.BatchQuery].
+	Index int
+	// Code is the error code given by DynamoDB, such as "ConditionalCheckFailed".
+	Code    types.BatchStatementErrorCodeEnum
+	Message string
+	// Item is the item that caused the error, if DynamoDB included it.
+	Item Item
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("dynamo: batch statement %d: %s: %s", e.Index, e.Code, e.Message)
+}
+
+// BatchQuery is a request to run multiple PartiQL statements (BatchExecuteStatement).
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchExecuteStatement.html
+type BatchQuery struct {
+	db     *DB
+	stmts  []Statement
+	cc     *ConsumedCapacity
+	optFns []func(*dynamodb.Options)
+}
+
+// BatchQuery creates a new request to run the given PartiQL statements with BatchExecuteStatement.
+// Each statement can read or write at most one item, specified by its full primary key.
+// Statements are sent in batches of up to 25.
+// The client must implement [dynamodbiface.BatchExecuteStatementAPI].
+func (db *DB) BatchQuery(statements ...Statement) *BatchQuery {
+	return &BatchQuery{
+		db:    db,
+		stmts: statements,
+	}
+}
+
+// ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
+func (bq *BatchQuery) ConsumedCapacity(cc *ConsumedCapacity) *BatchQuery {
+	bq.cc = cc
+	return bq
+}
+
+// RequestOptions sets options that are applied to each request made by this batch, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (bq *BatchQuery) RequestOptions(optFns ...func(*dynamodb.Options)) *BatchQuery {
+	bq.optFns = optFns
+	return bq
+}
+
+// All runs the statements and unmarshals each statement's result by appending it to the corresponding output,
+// so the first statement's item goes into outs[0], the second's into outs[1], and so on.
+// Outputs must be pointers to slices, and different statements can share an output.
+// A nil output discards that statement's result, which is useful for writes.
+// If any statements fail, all other results are still unmarshaled and the returned error
+// contains a [StatementError] for each failure.
+// If no statements fail and none of the non-nil outputs receive any items, All returns ErrNotFound.
+// Batches whose outputs are all nil, such as writes, never return ErrNotFound.
+func (bq *BatchQuery) All(ctx context.Context, outs ...interface{}) error {
+	if len(bq.stmts) == 0 {
+		return ErrNoInput
+	}
+	if err := bq.db.checkPartiQL(); err != nil {
+		return err
+	}
+	if len(outs) != len(bq.stmts) {
+		return fmt.Errorf("dynamo: BatchQuery.All: got %d outputs for %d statements", len(outs), len(bq.stmts))
+	}
+	client, err := clientAs[dynamodbiface.BatchExecuteStatementAPI](bq.db.client, "BatchExecuteStatement")
+	if err != nil {
+		return err
+	}
+	unmarshalers := make([]unmarshalFunc, len(outs))
+	reading := false
+	for i, out := range outs {
+		if out != nil {
+			unmarshalers[i] = bq.db.unmarshaler(unmarshalAppendTo(out))
+			reading = true
+		}
+	}
+
+	var errs []error
+	var found bool
+	for start := 0; start < len(bq.stmts); start += maxBatchStatements {
+		end := min(start+maxBatchStatements, len(bq.stmts))
+		input, err := bq.input(ctx, bq.stmts[start:end])
+		if err != nil {
+			return err
+		}
+
+		var resp *dynamodb.BatchExecuteStatementOutput
+		err = bq.db.retry(ctx, func() error {
+			var err error
+			resp, err = client.BatchExecuteStatement(ctx, input, bq.db.requestOptions(bq.optFns)...)
+			bq.cc.incRequests()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for i := range resp.ConsumedCapacity {
+			bq.cc.add(&resp.ConsumedCapacity[i])
+		}
+
+		for i, res := range resp.Responses {
+			idx := start + i
+			if res.Error != nil {
+				serr := &StatementError{
+					Index: idx,
+					Code:  res.Error.Code,
+					Item:  res.Error.Item,
+				}
+				if res.Error.Message != nil {
+					serr.Message = *res.Error.Message
+				}
+				errs = append(errs, serr)
+				continue
+			}
+			unmarshal := unmarshalers[idx]
+			if res.Item == nil || unmarshal == nil {
+				continue
+			}
+			found = true
+			if err := unmarshal(ctx, res.Item, outs[idx]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if reading && !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (bq *BatchQuery) input(ctx context.Context, stmts []Statement) (*dynamodb.BatchExecuteStatementInput, error) {
+	input := &dynamodb.BatchExecuteStatementInput{
+		Statements: make([]types.BatchStatementRequest, 0, len(stmts)),
+	}
+	for _, stmt := range stmts {
+		req, err := stmt.request(bq.db.codecContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		input.Statements = append(input.Statements, req)
+	}
+	if bq.cc != nil {
+		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	}
+	return input, nil
+}
@@ -0,0 +1,126 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// statementClient responds to each statement with an item whose ID is its first parameter,
+// or an error if the statement is "FAIL".
+type statementClient struct {
+	dynamodbiface.DynamoDBAPI
+	requests []*dynamodb.BatchExecuteStatementInput
+}
+
+func (c *statementClient) BatchExecuteStatement(_ context.Context, in *dynamodb.BatchExecuteStatementInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	c.requests = append(c.requests, in)
+	out := &dynamodb.BatchExecuteStatementOutput{}
+	for _, stmt := range in.Statements {
+		if *stmt.Statement == "FAIL" {
+			out.Responses = append(out.Responses, types.BatchStatementResponse{Error: &types.BatchStatementError{
+				Code:    types.BatchStatementErrorCodeEnumValidationError,
+				Message: aws.String("bad statement"),
+			}})
+			continue
+		}
+		if len(stmt.Parameters) == 0 {
+			out.Responses = append(out.Responses, types.BatchStatementResponse{})
+			continue
+		}
+		out.Responses = append(out.Responses, types.BatchStatementResponse{
+			Item: Item{"ID": stmt.Parameters[0]},
+		})
+	}
+	return out, nil
+}
+
+func TestBatchQuery(t *testing.T) {
+	type widget struct {
+		ID int
+	}
+	type gadget struct {
+		ID string
+	}
+	ctx := context.Background()
+	client := new(statementClient)
+	db := NewFromIface(client)
+
+	var stmts []Statement
+	for i := 0; i < 30; i++ {
+		stmts = append(stmts, Statement{Query: `SELECT * FROM "Widgets" WHERE "ID" = ?`, Args: []any{i}})
+	}
+	stmts = append(stmts,
+		Statement{Query: `SELECT * FROM "Gadgets" WHERE "ID" = ?`, Args: []any{"abc"}, Consistent: true},
+		Statement{Query: `SELECT * FROM "Gadgets" WHERE "ID" = 'missing'`},
+		Statement{Query: "FAIL"},
+	)
+
+	var widgets []widget
+	var gadgets []gadget
+	outs := make([]any, len(stmts))
+	for i := 0; i < 30; i++ {
+		outs[i] = &widgets
+	}
+	outs[30] = &gadgets
+	outs[31] = &gadgets
+	err := db.BatchQuery(stmts...).All(ctx, outs...)
+
+	var serr *StatementError
+	if !errors.As(err, &serr) {
+		t.Fatal("want StatementError, got:", err)
+	}
+	if serr.Index != 32 || serr.Code != types.BatchStatementErrorCodeEnumValidationError || serr.Message != "bad statement" {
+		t.Error("bad statement error:", serr)
+	}
+	if len(client.requests) != 2 || len(client.requests[0].Statements) != 25 || len(client.requests[1].Statements) != 8 {
+		t.Error("bad batching:", len(client.requests))
+	}
+	if !*client.requests[1].Statements[5].ConsistentRead {
+		t.Error("statement not consistent")
+	}
+	if len(widgets) != 30 || widgets[29].ID != 29 {
+		t.Error("bad widgets:", widgets)
+	}
+	if len(gadgets) != 1 || gadgets[0].ID != "abc" {
+		t.Error("bad gadgets:", gadgets)
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		err := db.BatchQuery(Statement{Query: `SELECT * FROM "Gadgets" WHERE "ID" = 'missing'`}).All(ctx, &gadgets)
+		if err != ErrNotFound {
+			t.Error("want ErrNotFound, got:", err)
+		}
+		// items for nil outputs don't count
+		err = db.BatchQuery(
+			Statement{Query: `SELECT * FROM "Gadgets" WHERE "ID" = ?`, Args: []any{"abc"}},
+			Statement{Query: `SELECT * FROM "Gadgets" WHERE "ID" = 'missing'`},
+		).All(ctx, nil, &gadgets)
+		if err != ErrNotFound {
+			t.Error("want ErrNotFound for discarded item, got:", err)
+		}
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		err := db.BatchQuery(
+			Statement{Query: `DELETE FROM "Gadgets" WHERE "ID" = 'a'`},
+			Statement{Query: `DELETE FROM "Gadgets" WHERE "ID" = 'b'`},
+		).All(ctx, nil, nil)
+		if err != nil {
+			t.Error("unexpected error:", err)
+		}
+	})
+
+	t.Run("mismatched outputs", func(t *testing.T) {
+		err := db.BatchQuery(stmts[:2]...).All(ctx, &widgets)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
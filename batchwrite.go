@@ -2,6 +2,7 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"math"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -13,17 +14,42 @@ import (
 // DynamoDB API limit, 25 operations per request
 const maxWriteOps = 25
 
+// DynamoDB API limit, 100 operations per transaction
+const maxWriteTxOps = 100
+
+// DynamoDB API limit, 4 MB of items per transaction
+const maxWriteTxSize = 4 * 1024 * 1024
+
 // BatchWrite is a BatchWriteItem operation.
 type BatchWrite struct {
-	batch Batch
-	ops   []batchWrite
-	err   error
-	cc    *ConsumedCapacity
+	batch  Batch
+	ops    []batchWrite
+	conds  []*Put
+	modify func(*dynamodb.BatchWriteItemInput)
+	optFns []func(*dynamodb.Options)
+	err    error
+	cc     *ConsumedCapacity
+	// tables of puts using PutWithTTL
+	ttlTables map[string]Table
+	// preserve order of operations on the same partition
+	ordered bool
+	// write puts as conditional creates, see SkipExisting
+	skipExisting bool
+	existed      *int
 }
 
 type batchWrite struct {
 	table string
 	op    types.WriteRequest
+	// Unix time to set as the table's time to live attribute, if non-zero
+	expires int64
+	// item to put, if it has values implementing MarshalerCtx (see marshalPuts)
+	deferred *deferredPut
+}
+
+type deferredPut struct {
+	table Table
+	value interface{}
 }
 
 // Write creates a new batch write request, to which
@@ -45,18 +71,65 @@ func (bw *BatchWrite) Put(items ...interface{}) *BatchWrite {
 func (bw *BatchWrite) PutIn(table Table, items ...interface{}) *BatchWrite {
 	name := table.Name()
 	for _, item := range items {
-		encoded, err := marshalItem(item)
+		encoded, deferred, err := marshalItemDeferred(table.db.codecContext(context.Background()), item)
+		if err == nil {
+			err = fillAuto(item, encoded, table.db.nameMapper())
+		}
+		if err == nil {
+			err = table.interceptWrite(encoded)
+		}
 		bw.setError(err)
-		bw.ops = append(bw.ops, batchWrite{
+		op := batchWrite{
 			table: name,
 			op: types.WriteRequest{PutRequest: &types.PutRequest{
 				Item: encoded,
 			}},
-		})
+		}
+		if deferred {
+			op.deferred = &deferredPut{table: table, value: item}
+		}
+		bw.ops = append(bw.ops, op)
 	}
 	return bw
 }
 
+// marshalPuts marshals items with values implementing MarshalerCtx again with the request's context.
+func (bw *BatchWrite) marshalPuts(ctx context.Context) error {
+	for i, op := range bw.ops {
+		if op.deferred == nil {
+			continue
+		}
+		item, err := marshalItemContext(op.deferred.table.db.codecContext(ctx), op.deferred.value)
+		if err == nil {
+			keepAuto(op.deferred.value, op.op.PutRequest.Item, item, op.deferred.table.db.nameMapper())
+			err = op.deferred.table.interceptWrite(item)
+		}
+		if err != nil {
+			return err
+		}
+		bw.ops[i].op = types.WriteRequest{PutRequest: &types.PutRequest{Item: item}}
+	}
+	return nil
+}
+
+// PutIf adds a conditional put operation for item to this batch using the default table.
+// The condition expression is specified as in [Put.If].
+// Because BatchWriteItem does not support conditions, conditional puts are
+// written separately using transactions (TransactWriteItems) of up to 100 items or 4 MB each,
+// while unconditional operations are written with BatchWriteItem as usual.
+// Puts of the same item are written in separate transactions, in the order they were added.
+//
+// Each transaction is all or nothing: if any condition in a transaction fails, none of that transaction's puts
+// are written, including those whose conditions passed, and Run returns the error
+// (see [UnmarshalItemsFromTxCondCheckFailed]) without writing the remaining transactions.
+// Use [Put.If] to write items independently of each other.
+func (bw *BatchWrite) PutIf(item interface{}, expr string, args ...interface{}) *BatchWrite {
+	put := bw.batch.table.Put(item).If(expr, args...)
+	bw.setError(put.err)
+	bw.conds = append(bw.conds, put)
+	return bw
+}
+
 // Delete adds delete operations for the given keys to this batch, using the default table.
 func (bw *BatchWrite) Delete(keys ...Keyed) *BatchWrite {
 	return bw.deleteIn(bw.batch.table, bw.batch.hashKey, bw.batch.rangeKey, keys...)
@@ -81,6 +154,7 @@ func (bw *BatchWrite) DeleteInRange(table Table, hashKey, rangeKey string, keys
 func (bw *BatchWrite) deleteIn(table Table, hashKey, rangeKey string, keys ...Keyed) *BatchWrite {
 	name := table.Name()
 	for _, key := range keys {
+		bw.setError(checkKeyNames(name, hashKey, rangeKey, key))
 		del := table.Delete(hashKey, key.HashKey())
 		if rk := key.RangeKey(); rangeKey != "" && rk != nil {
 			del.Range(rangeKey, rk)
@@ -100,10 +174,58 @@ func (bw *BatchWrite) deleteIn(table Table, hashKey, rangeKey string, keys ...Ke
 func (bw *BatchWrite) Merge(srcs ...*BatchWrite) *BatchWrite {
 	for _, src := range srcs {
 		bw.ops = append(bw.ops, src.ops...)
+		bw.conds = append(bw.conds, src.conds...)
+		for name, table := range src.ttlTables {
+			bw.addTTLTable(name, table)
+		}
 	}
 	return bw
 }
 
+// ModifyInput sets a function that is called with the input of each BatchWriteItem request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+// It is not called for conditional puts (see [BatchWrite.PutIf]), which are written with transactions.
+func (bw *BatchWrite) ModifyInput(fn func(*dynamodb.BatchWriteItemInput)) *BatchWrite {
+	bw.modify = fn
+	return bw
+}
+
+// RequestOptions sets options that are applied to each request made by this batch, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (bw *BatchWrite) RequestOptions(optFns ...func(*dynamodb.Options)) *BatchWrite {
+	bw.optFns = optFns
+	return bw
+}
+
+// OrderByPartition makes Run preserve the order of operations on the same item (with the same primary key) when enabled,
+// so that the last write to an item wins, as if the operations were run one by one.
+// BatchWriteItem makes no guarantees about the order of operations within a request,
+// and doesn't allow more than one operation on the same item in a single request.
+//
+// Operations are sent in rounds, each containing at most one operation per item,
+// and each round waits for the previous round to finish (including retries of unprocessed items).
+// Operations on different items, including items in the same partition, are still batched together.
+// Keys are taken from the [Batch], or for other tables, from their descriptions (see [Table.Describe]).
+func (bw *BatchWrite) OrderByPartition(enabled bool) *BatchWrite {
+	bw.ordered = enabled
+	return bw
+}
+
+// SkipExisting makes Run only write puts (see [BatchWrite.Put]) whose items don't already exist,
+// leaving existing items untouched, so that imports can be safely re-run after a failure.
+// The number of puts skipped because their items already existed is stored in existed, which may be nil.
+// Puts of the same item more than once are skipped after the first as well.
+//
+// Because BatchWriteItem does not support conditions, puts are written with transactions (TransactWriteItems)
+// of up to 100 items or 4 MB each, after all other operations. When a transaction is canceled because some of its items exist,
+// it is retried without them. Skipped puts are not counted in the number of items written returned by Run.
+// Transactions consume twice the write capacity of regular writes.
+func (bw *BatchWrite) SkipExisting(existed *int) *BatchWrite {
+	bw.skipExisting = true
+	bw.existed = existed
+	return bw
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (bw *BatchWrite) ConsumedCapacity(cc *ConsumedCapacity) *BatchWrite {
 	bw.cc = cc
@@ -114,31 +236,153 @@ func (bw *BatchWrite) ConsumedCapacity(cc *ConsumedCapacity) *BatchWrite {
 // For batches with more than 25 operations, an error could indicate that
 // some records have been written and some have not. Consult the wrote
 // return amount to figure out which operations have succeeded.
+// Conditional puts (see [BatchWrite.PutIf]) are written after all other operations.
 func (bw *BatchWrite) Run(ctx context.Context) (wrote int, err error) {
 	if bw.err != nil {
 		return 0, bw.err
 	}
-	if len(bw.ops) == 0 {
+	if len(bw.ops) == 0 && len(bw.conds) == 0 {
 		return 0, ErrNoInput
 	}
+	if err := bw.marshalPuts(ctx); err != nil {
+		return 0, err
+	}
+	if err := bw.stampTTL(ctx); err != nil {
+		return 0, err
+	}
+
+	ops := bw.ops
+	var creates []batchWrite
+	if bw.skipExisting {
+		if bw.existed != nil {
+			*bw.existed = 0
+		}
+		ops = nil
+		for _, op := range bw.ops {
+			if op.op.PutRequest != nil {
+				creates = append(creates, op)
+			} else {
+				ops = append(ops, op)
+			}
+		}
+	}
+
+	if bw.ordered {
+		rounds, err := bw.partitionRounds(ctx, ops)
+		if err != nil {
+			return 0, err
+		}
+		for _, ops := range rounds {
+			n, err := bw.runBatches(ctx, ops)
+			wrote += n
+			if err != nil {
+				return wrote, err
+			}
+		}
+	} else {
+		wrote, err = bw.runBatches(ctx, ops)
+		if err != nil {
+			return wrote, err
+		}
+	}
+	if len(creates) > 0 {
+		n, err := bw.runCreates(ctx, creates)
+		wrote += n
+		if err != nil {
+			return wrote, err
+		}
+	}
+	if len(bw.conds) > 0 {
+		n, err := bw.runConds(ctx)
+		wrote += n
+		if err != nil {
+			return wrote, err
+		}
+	}
+	return wrote, nil
+}
+
+// runConds writes conditional puts with transactions. See PutIf.
+func (bw *BatchWrite) runConds(ctx context.Context) (wrote int, err error) {
+	names, err := bw.keysOf(ctx, bw.batch.table.Name())
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0, len(bw.conds))
+	for _, put := range bw.conds {
+		// marshal now so that the transactions can be sized
+		item, err := put.marshal(ctx)
+		if err != nil {
+			return 0, err
+		}
+		put.item, put.value = item, nil
+		ids = append(ids, keyID(item[names[0]], item[names[1]]))
+	}
+	txs, err := splitTx(bw.conds, ids)
+	if err != nil {
+		return 0, err
+	}
+	for _, puts := range txs {
+		tx := bw.batch.table.db.WriteTx().ConsumedCapacity(bw.cc).RequestOptions(bw.optFns...)
+		for _, put := range puts {
+			tx.Put(put)
+		}
+		if err := tx.Run(ctx); err != nil {
+			return wrote, err
+		}
+		wrote += len(puts)
+	}
+	return wrote, nil
+}
 
+// splitTx splits puts into groups that fit in a transaction: at most 100 puts of at most 4 MB in total,
+// and no more than one put of the same item, as identified by ids.
+func splitTx(puts []*Put, ids []string) ([][]*Put, error) {
+	var txs [][]*Put
+	seen := make(map[string]struct{})
+	start, size := 0, 0
+	for i, put := range puts {
+		itemSize, err := ItemSize(put.item)
+		if err != nil {
+			return nil, err
+		}
+		_, dup := seen[ids[i]]
+		if i > start && (dup || i-start == maxWriteTxOps || size+itemSize > maxWriteTxSize) {
+			txs = append(txs, puts[start:i])
+			start, size = i, 0
+			clear(seen)
+		}
+		seen[ids[i]] = struct{}{}
+		size += itemSize
+	}
+	if start < len(puts) {
+		txs = append(txs, puts[start:])
+	}
+	return txs, nil
+}
+
+func (bw *BatchWrite) runBatches(ctx context.Context, all []batchWrite) (wrote int, err error) {
 	// TODO: this could be made to be more efficient,
 	// by combining unprocessed items with the next request.
 
 	boff := backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
-	batches := int(math.Ceil(float64(len(bw.ops)) / maxWriteOps))
+	batches := int(math.Ceil(float64(len(all)) / maxWriteOps))
 	for i := 0; i < batches; i++ {
 		start, end := i*maxWriteOps, (i+1)*maxWriteOps
-		if end > len(bw.ops) {
-			end = len(bw.ops)
+		if end > len(all) {
+			end = len(all)
 		}
-		ops := bw.ops[start:end]
+		ops := all[start:end]
 		for {
 			var res *dynamodb.BatchWriteItemOutput
 			req := bw.input(ops)
-			err := bw.batch.table.db.retry(ctx, func() error {
+			var err error
+			if req.RequestItems, _, err = resolveNames(ctx, bw.batch.table.db, req.RequestItems); err != nil {
+				return wrote, err
+			}
+			err = bw.batch.table.db.retry(ctx, func() error {
 				var err error
-				res, err = bw.batch.table.db.client.BatchWriteItem(ctx, req)
+				res, err = bw.batch.table.db.client.BatchWriteItem(ctx, req, bw.batch.table.db.requestOptions(bw.optFns)...)
 				bw.cc.incRequests()
 				return err
 			})
@@ -178,6 +422,120 @@ func (bw *BatchWrite) Run(ctx context.Context) (wrote int, err error) {
 	return wrote, nil
 }
 
+// partitionRounds splits this batch's operations into rounds, where the nth round
+// contains the nth operation on each item.
+func (bw *BatchWrite) partitionRounds(ctx context.Context, ops []batchWrite) ([][]batchWrite, error) {
+	keys := make(map[string][2]string)
+	seen := make(map[string]int)
+	var rounds [][]batchWrite
+	for _, op := range ops {
+		names, ok := keys[op.table]
+		if !ok {
+			var err error
+			if names, err = bw.keysOf(ctx, op.table); err != nil {
+				return nil, err
+			}
+			keys[op.table] = names
+		}
+		var item Item
+		switch {
+		case op.op.PutRequest != nil:
+			item = op.op.PutRequest.Item
+		case op.op.DeleteRequest != nil:
+			item = op.op.DeleteRequest.Key
+		}
+		var rangeKey types.AttributeValue
+		if names[1] != "" {
+			rangeKey = item[names[1]]
+		}
+		id := op.table + "\x00" + keyID(item[names[0]], rangeKey)
+		n := seen[id]
+		seen[id] = n + 1
+		if n == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[n] = append(rounds[n], op)
+	}
+	return rounds, nil
+}
+
+// runCreates writes puts only if their items don't exist, in transactions of up to 100 items or 4 MB.
+// See SkipExisting.
+func (bw *BatchWrite) runCreates(ctx context.Context, creates []batchWrite) (wrote int, err error) {
+	skip := func() {
+		if bw.existed != nil {
+			*bw.existed++
+		}
+	}
+	keys := make(map[string][2]string)
+	seen := make(map[string]struct{}, len(creates))
+	all := make([]*Put, 0, len(creates))
+	ids := make([]string, 0, len(creates))
+	for _, op := range creates {
+		names, ok := keys[op.table]
+		if !ok {
+			if names, err = bw.keysOf(ctx, op.table); err != nil {
+				return wrote, err
+			}
+			keys[op.table] = names
+		}
+		item := op.op.PutRequest.Item
+		id := op.table + "\x00" + keyID(item[names[0]], item[names[1]])
+		if _, dup := seen[id]; dup {
+			skip()
+			continue
+		}
+		seen[id] = struct{}{}
+		put := &Put{table: bw.batch.table.db.Table(op.table), subber: subber{db: bw.batch.table.db}, item: item}
+		all = append(all, put.If("attribute_not_exists($)", names[0]))
+		ids = append(ids, id)
+	}
+
+	txs, err := splitTx(all, ids)
+	if err != nil {
+		return wrote, err
+	}
+	for _, puts := range txs {
+		for len(puts) > 0 {
+			tx := bw.batch.table.db.WriteTx().ConsumedCapacity(bw.cc).RequestOptions(bw.optFns...)
+			for _, put := range puts {
+				tx.Put(put)
+			}
+			err := tx.Run(ctx)
+			if err == nil {
+				wrote += len(puts)
+				break
+			}
+			var txe *types.TransactionCanceledException
+			if !errors.As(err, &txe) || len(txe.CancellationReasons) != len(puts) || !IsCondCheckFailed(err) {
+				return wrote, err
+			}
+			var remaining []*Put
+			for i, reason := range txe.CancellationReasons {
+				if reason.Code != nil && *reason.Code == "ConditionalCheckFailed" {
+					skip()
+					continue
+				}
+				remaining = append(remaining, puts[i])
+			}
+			puts = remaining
+		}
+	}
+	return wrote, nil
+}
+
+// keysOf returns the hash and range key names of the given table.
+func (bw *BatchWrite) keysOf(ctx context.Context, table string) ([2]string, error) {
+	if table == bw.batch.table.Name() && bw.batch.hashKey != "" {
+		return [2]string{bw.batch.hashKey, bw.batch.rangeKey}, nil
+	}
+	desc, err := bw.batch.table.db.Table(table).description(ctx)
+	if err != nil {
+		return [2]string{}, err
+	}
+	return [2]string{desc.HashKey, desc.RangeKey}, nil
+}
+
 func (bw *BatchWrite) input(ops []batchWrite) *dynamodb.BatchWriteItemInput {
 	items := make(map[string][]types.WriteRequest)
 	for _, op := range ops {
@@ -189,6 +547,9 @@ func (bw *BatchWrite) input(ops []batchWrite) *dynamodb.BatchWriteItemInput {
 	if bw.cc != nil {
 		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
 	}
+	if bw.modify != nil {
+		bw.modify(input)
+	}
 	return input
 }
 
@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"context"
 	"reflect"
 	"strconv"
 	"testing"
@@ -65,7 +66,7 @@ func BenchmarkDecodeSimple(b *testing.B) {
 
 	var out simpleObject
 	for n := 0; n < b.N; n++ {
-		unmarshalItem(av, &out)
+		unmarshalItem(context.Background(), av, &out)
 	}
 }
 
@@ -78,7 +79,7 @@ func BenchmarkDecodeSimpleMap(b *testing.B) {
 
 	var out map[string]interface{}
 	for n := 0; n < b.N; n++ {
-		unmarshalItem(av, &out)
+		unmarshalItem(context.Background(), av, &out)
 	}
 }
 
@@ -109,7 +110,7 @@ func BenchmarkDecodeVeryComplex(b *testing.B) {
 
 	var out fancyObject
 	for n := 0; n < b.N; n++ {
-		unmarshalItem(av, &out)
+		unmarshalItem(context.Background(), av, &out)
 	}
 }
 
@@ -118,17 +119,17 @@ func BenchmarkDecodeVeryComplexMap(b *testing.B) {
 
 	var out map[string]interface{}
 	for n := 0; n < b.N; n++ {
-		unmarshalItem(av, &out)
+		unmarshalItem(context.Background(), av, &out)
 	}
 }
 
 func BenchmarkUnmarshal3(b *testing.B) {
 	var got widget
 	rv := reflect.ValueOf(&got)
-	r, _ := typedefOf(rv.Type())
+	r, _ := typedefOf(rv.Type(), nil)
 	// x := newRecipe(rv)
 	for i := 0; i < b.N; i++ {
-		if err := r.decodeItem(exampleItem, rv); err != nil {
+		if err := r.decodeItem(context.Background(), exampleItem, rv); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -144,8 +145,8 @@ func BenchmarkUnmarshalText(b *testing.B) {
 		rv := reflect.ValueOf(&got)
 		// x := newRecipe(rv)
 		for i := 0; i < b.N; i++ {
-			r, _ := typedefOf(rv.Type())
-			if err := r.decodeItem(map[string]types.AttributeValue{
+			r, _ := typedefOf(rv.Type(), nil)
+			if err := r.decodeItem(context.Background(), map[string]types.AttributeValue{
 				"Foo": &types.AttributeValueMemberS{Value: "true"},
 			}, rv); err != nil {
 				b.Fatal(err)
@@ -171,7 +172,7 @@ func BenchmarkUnmarshalAppend(b *testing.B) {
 	}, 0, len(items))
 	for i := 0; i < b.N; i++ {
 		for j := range items {
-			if err := unmarshalAppend(items[j], &dst); err != nil {
+			if err := unmarshalAppend(context.Background(), items[j], &dst); err != nil {
 				b.Fatal(err)
 			}
 		}
@@ -197,7 +198,7 @@ func BenchmarkUnmarshalAppend2(b *testing.B) {
 	do := unmarshalAppendTo(&dst)
 	for i := 0; i < b.N; i++ {
 		for j := range items {
-			if err := do(items[j], &dst); err != nil {
+			if err := do(context.Background(), items[j], &dst); err != nil {
 				b.Fatal(err)
 			}
 		}
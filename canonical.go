@@ -0,0 +1,217 @@
+package dynamo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"math/big"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// HashItem returns a stable content hash of item, as a hex-encoded SHA-256 digest.
+// The hash does not depend on the order of attributes or set members,
+// and equivalent numbers (such as 1, 1.0, and 1e0) hash the same.
+// Attributes named in ignore are excluded, such as the attribute that stores the hash itself.
+//
+// Content hashes can be used as ETags for optimistic concurrency, see [Put.ContentHash] and [ContentHashMatches].
+func HashItem(item Item, ignore ...string) string {
+	if len(ignore) > 0 {
+		filtered := make(Item, len(item))
+		for k, v := range item {
+			filtered[k] = v
+		}
+		for _, name := range ignore {
+			delete(filtered, name)
+		}
+		item = filtered
+	}
+	h := sha256.New()
+	canonicalWriter{h: h}.item(item)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalWriter writes a deterministic serialization of DynamoDB data to a hash.
+// Map keys and set members are sorted, numbers are normalized, and every value is length-prefixed,
+// so logically identical inputs always produce identical output.
+type canonicalWriter struct {
+	h hash.Hash
+}
+
+func (w canonicalWriter) tag(t byte) {
+	w.h.Write([]byte{t})
+}
+
+func (w canonicalWriter) len(n int) {
+	var buf [binary.MaxVarintLen64]byte
+	w.h.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+}
+
+func (w canonicalWriter) bytes(b []byte) {
+	w.len(len(b))
+	w.h.Write(b)
+}
+
+func (w canonicalWriter) string(s string) {
+	w.len(len(s))
+	w.h.Write([]byte(s))
+}
+
+func (w canonicalWriter) optString(s *string) {
+	if s == nil {
+		w.tag(0)
+		return
+	}
+	w.tag(1)
+	w.string(*s)
+}
+
+func (w canonicalWriter) strings(ss []string) {
+	sorted := append([]string(nil), ss...)
+	sort.Strings(sorted)
+	w.len(len(sorted))
+	for _, s := range sorted {
+		w.string(s)
+	}
+}
+
+func (w canonicalWriter) names(names map[string]string) {
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.len(len(keys))
+	for _, k := range keys {
+		w.string(k)
+		w.string(names[k])
+	}
+}
+
+func (w canonicalWriter) item(item Item) {
+	keys := make([]string, 0, len(item))
+	for k := range item {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.len(len(keys))
+	for _, k := range keys {
+		w.string(k)
+		w.av(item[k])
+	}
+}
+
+func (w canonicalWriter) av(av types.AttributeValue) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		w.tag('S')
+		w.string(v.Value)
+	case *types.AttributeValueMemberN:
+		w.tag('N')
+		w.string(normalizeNumber(v.Value))
+	case *types.AttributeValueMemberB:
+		w.tag('B')
+		w.bytes(v.Value)
+	case *types.AttributeValueMemberBOOL:
+		w.tag('T')
+		if v.Value {
+			w.tag(1)
+		} else {
+			w.tag(0)
+		}
+	case *types.AttributeValueMemberNULL:
+		w.tag('0')
+	case *types.AttributeValueMemberSS:
+		w.tag('s')
+		w.strings(v.Value)
+	case *types.AttributeValueMemberNS:
+		w.tag('n')
+		ns := make([]string, len(v.Value))
+		for i, n := range v.Value {
+			ns[i] = normalizeNumber(n)
+		}
+		w.strings(ns)
+	case *types.AttributeValueMemberBS:
+		w.tag('b')
+		sorted := append([][]byte(nil), v.Value...)
+		sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+		w.len(len(sorted))
+		for _, b := range sorted {
+			w.bytes(b)
+		}
+	case *types.AttributeValueMemberL:
+		w.tag('L')
+		w.len(len(v.Value))
+		for _, elem := range v.Value {
+			w.av(elem)
+		}
+	case *types.AttributeValueMemberM:
+		w.tag('M')
+		w.item(v.Value)
+	default:
+		w.tag('?')
+	}
+}
+
+func (w canonicalWriter) writeTxItem(wti types.TransactWriteItem) {
+	switch {
+	case wti.ConditionCheck != nil:
+		w.tag('C')
+		w.optString(wti.ConditionCheck.TableName)
+		w.item(wti.ConditionCheck.Key)
+		w.optString(wti.ConditionCheck.ConditionExpression)
+		w.names(wti.ConditionCheck.ExpressionAttributeNames)
+		w.item(wti.ConditionCheck.ExpressionAttributeValues)
+	case wti.Delete != nil:
+		w.tag('D')
+		w.optString(wti.Delete.TableName)
+		w.item(wti.Delete.Key)
+		w.optString(wti.Delete.ConditionExpression)
+		w.names(wti.Delete.ExpressionAttributeNames)
+		w.item(wti.Delete.ExpressionAttributeValues)
+	case wti.Put != nil:
+		w.tag('P')
+		w.optString(wti.Put.TableName)
+		w.item(wti.Put.Item)
+		w.optString(wti.Put.ConditionExpression)
+		w.names(wti.Put.ExpressionAttributeNames)
+		w.item(wti.Put.ExpressionAttributeValues)
+	case wti.Update != nil:
+		w.tag('U')
+		w.optString(wti.Update.TableName)
+		w.item(wti.Update.Key)
+		w.optString(wti.Update.UpdateExpression)
+		w.optString(wti.Update.ConditionExpression)
+		w.names(wti.Update.ExpressionAttributeNames)
+		w.item(wti.Update.ExpressionAttributeValues)
+	default:
+		w.tag('?')
+	}
+}
+
+func (w canonicalWriter) getItem(in *dynamodb.GetItemInput) {
+	w.optString(in.TableName)
+	w.item(in.Key)
+	if in.ConsistentRead != nil && *in.ConsistentRead {
+		w.tag(1)
+	} else {
+		w.tag(0)
+	}
+	w.optString(in.ProjectionExpression)
+	w.names(in.ExpressionAttributeNames)
+	w.string(string(in.ReturnConsumedCapacity))
+}
+
+// normalizeNumber returns the canonical form of the number n, so that equivalent numbers are equal.
+// Invalid numbers are returned as-is.
+func normalizeNumber(n string) string {
+	r, ok := new(big.Rat).SetString(n)
+	if !ok {
+		return n
+	}
+	return r.RatString()
+}
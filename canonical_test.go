@@ -0,0 +1,76 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestHashItem(t *testing.T) {
+	a := Item{
+		"ID":   &types.AttributeValueMemberN{Value: "1"},
+		"Tags": &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"Nums": &types.AttributeValueMemberNS{Value: []string{"1.50", "2"}},
+		"Meta": &types.AttributeValueMemberM{Value: Item{
+			"X": &types.AttributeValueMemberBOOL{Value: true},
+			"Y": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberNULL{Value: true}}},
+		}},
+	}
+	b := Item{
+		"Meta": &types.AttributeValueMemberM{Value: Item{
+			"Y": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberNULL{Value: true}}},
+			"X": &types.AttributeValueMemberBOOL{Value: true},
+		}},
+		"Nums": &types.AttributeValueMemberNS{Value: []string{"2e0", "1.5"}},
+		"Tags": &types.AttributeValueMemberSS{Value: []string{"b", "a"}},
+		"ID":   &types.AttributeValueMemberN{Value: "1.0"},
+		"ETag": &types.AttributeValueMemberS{Value: "ignored"},
+	}
+	hash := HashItem(a)
+	if len(hash) != 64 {
+		t.Error("bad hash:", hash)
+	}
+	if got := HashItem(b, "ETag"); got != hash {
+		t.Error("equivalent items have different hashes:", hash, got)
+	}
+	if got := HashItem(b); got == hash {
+		t.Error("ignored attribute not ignored")
+	}
+	if _, ok := b["ETag"]; !ok {
+		t.Error("HashItem modified its input")
+	}
+
+	b["ID"] = &types.AttributeValueMemberS{Value: "1"}
+	if got := HashItem(b, "ETag"); got == hash {
+		t.Error("different items have the same hash")
+	}
+}
+
+func TestPutContentHash(t *testing.T) {
+	type doc struct {
+		ID   int
+		Body string
+	}
+	table := NewFromIface(nil).Table("ETags")
+	item, err := MarshalItem(doc{ID: 1, Body: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := HashItem(item)
+
+	put := table.Put(doc{ID: 1, Body: "hello"}).ContentHash("ETag").If("$", ContentHashMatches("ETag", "old"))
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	input := put.input()
+	if got := input.Item["ETag"].(*types.AttributeValueMemberS).Value; got != etag {
+		t.Error("bad stored hash. want:", etag, "got:", got)
+	}
+	if got := input.ExpressionAttributeValues[":x_v0"].(*types.AttributeValueMemberS).Value; got != "old" {
+		t.Error("bad condition value:", got)
+	}
+
+	if cond := ContentHashMatches("ETag", ""); cond.Expression != "attribute_not_exists(#s"+encodeName("ETag")+")" {
+		t.Error("bad condition for blank etag:", cond.Expression)
+	}
+}
@@ -0,0 +1,114 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// Capabilities are the API features supported by the endpoint a [DB] is connected to.
+// DynamoDB supports everything, but alternatives such as DynamoDB Local, DAX, and ScyllaDB Alternator
+// can lack some features. See [DB.Capabilities].
+type Capabilities struct {
+	// Transactions is true if TransactGetItems and TransactWriteItems are supported,
+	// as used by [GetTx] and [WriteTx].
+	Transactions bool
+	// PartiQL is true if PartiQL statements are supported,
+	// as used by [BatchQuery].
+	PartiQL bool
+}
+
+// Capabilities probes which API features the connected endpoint supports,
+// by sending requests for an item in a table that doesn't exist, and checking how they are rejected.
+// The result is cached for this DB and every DB derived from it.
+//
+// Once capabilities are known, operations that need an unsupported feature fail before sending any requests,
+// with an error that wraps [errors.ErrUnsupported].
+// Operations are not checked before Capabilities is called.
+func (db *DB) Capabilities(ctx context.Context) (Capabilities, error) {
+	return db.caps.resolve(ctx, db.probeCapabilities)
+}
+
+// probeTable is the table that capability probes ask for. It shouldn't exist, but it's harmless if it does.
+const probeTable = "dynamo-capabilities-probe"
+
+func (db *DB) probeCapabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+	var err error
+	caps.Transactions, err = db.probe(ctx, func() error {
+		_, err := db.client.TransactGetItems(ctx, &dynamodb.TransactGetItemsInput{
+			TransactItems: []types.TransactGetItem{{
+				Get: &types.Get{
+					TableName: aws.String(probeTable),
+					Key:       Item{"ID": &types.AttributeValueMemberS{Value: "probe"}},
+				},
+			}},
+		}, db.optFns...)
+		return err
+	})
+	if err != nil {
+		return caps, err
+	}
+	client, err := clientAs[dynamodbiface.BatchExecuteStatementAPI](db.client, "BatchExecuteStatement")
+	if err != nil {
+		// PartiQL can't be used with this client
+		return caps, nil
+	}
+	caps.PartiQL, err = db.probe(ctx, func() error {
+		_, err := client.BatchExecuteStatement(ctx, &dynamodb.BatchExecuteStatementInput{
+			Statements: []types.BatchStatementRequest{{
+				Statement: aws.String(`SELECT * FROM "` + probeTable + `" WHERE ID = 'probe'`),
+			}},
+		}, db.optFns...)
+		return err
+	})
+	return caps, err
+}
+
+// probe sends a request that is expected to fail, returning false if the operation is unsupported.
+func (db *DB) probe(ctx context.Context, send func() error) (bool, error) {
+	err := db.retry(ctx, send)
+	var ae smithy.APIError
+	if !errors.As(err, &ae) {
+		// either success, which is unexpected but fine, or a network problem
+		return true, err
+	}
+	return !isUnsupported(ae), nil
+}
+
+// isUnsupported returns true if err means the endpoint doesn't support the operation.
+func isUnsupported(err smithy.APIError) bool {
+	switch err.ErrorCode() {
+	case "UnknownOperationException", "UnsupportedOperationException", "NotImplemented", "NotImplementedException":
+		return true
+	}
+	msg := strings.ToLower(err.ErrorMessage())
+	return strings.Contains(msg, "not supported") ||
+		strings.Contains(msg, "unsupported operation") ||
+		strings.Contains(msg, "not implemented")
+}
+
+// checkSupported returns an error if capabilities are known and feature isn't supported.
+func (db *DB) checkSupported(feature string, supported func(Capabilities) bool) error {
+	caps, ok := db.caps.load()
+	if !ok || supported(caps) {
+		return nil
+	}
+	return fmt.Errorf("dynamo: %s are not supported by this endpoint: %w", feature, errors.ErrUnsupported)
+}
+
+func (db *DB) checkTransactions() error {
+	return db.checkSupported("transactions", func(caps Capabilities) bool { return caps.Transactions })
+}
+
+func (db *DB) checkPartiQL() error {
+	return db.checkSupported("PartiQL statements", func(caps Capabilities) bool { return caps.PartiQL })
+}
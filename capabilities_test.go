@@ -0,0 +1,136 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// noTxClient is an endpoint that supports PartiQL but not transactions.
+type noTxClient struct {
+	dynamodbiface.DynamoDBAPI
+	probes int
+}
+
+func (c *noTxClient) TransactGetItems(_ context.Context, _ *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	c.probes++
+	return nil, &smithy.GenericAPIError{Code: "UnknownOperationException", Message: "TransactGetItems"}
+}
+
+func (c *noTxClient) BatchExecuteStatement(_ context.Context, _ *dynamodb.BatchExecuteStatementInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	c.probes++
+	return nil, &smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "Requested resource not found"}
+}
+
+// noPartiQLClient is an endpoint that supports transactions but whose client can't send PartiQL statements.
+type noPartiQLClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (noPartiQLClient) TransactGetItems(_ context.Context, _ *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	return nil, &smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "Requested resource not found"}
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+	client := new(noTxClient)
+	db := NewFromIface(client)
+	table := db.Table("Caps")
+
+	// not checked until probed
+	if err := db.checkTransactions(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	caps, err := db.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Capabilities{Transactions: false, PartiQL: true}); caps != want {
+		t.Errorf("bad capabilities. want: %+v, got: %+v", want, caps)
+	}
+	if _, err := db.ErrorValueLimit(10).Capabilities(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if client.probes != 2 {
+		t.Error("capabilities not cached, probes:", client.probes)
+	}
+
+	err = db.WriteTx().Delete(table.Delete("ID", 1)).Run(ctx)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Error("want ErrUnsupported, got:", err)
+	}
+	err = db.GetTx().GetOne(table.Get("ID", 1), new(Item)).Run(ctx)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Error("want ErrUnsupported, got:", err)
+	}
+	if client.probes != 2 {
+		t.Error("unexpected requests:", client.probes)
+	}
+
+	t.Run("client without PartiQL", func(t *testing.T) {
+		db := NewFromIface(noPartiQLClient{})
+		caps, err := db.Capabilities(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (Capabilities{Transactions: true, PartiQL: false}); caps != want {
+			t.Errorf("bad capabilities. want: %+v, got: %+v", want, caps)
+		}
+		stmt := Statement{Query: `SELECT * FROM "Caps" WHERE ID = ?`, Args: []any{1}}
+		if err := NewFromIface(noPartiQLClient{}).BatchQuery(stmt).All(ctx, new([]Item)); !errors.Is(err, errors.ErrUnsupported) {
+			t.Error("want ErrUnsupported, got:", err)
+		}
+	})
+}
+
+var _ dynamodbiface.BatchExecuteStatementAPI = (*dynamodb.Client)(nil)
+
+func TestCapabilitiesHTTP(t *testing.T) {
+	// an endpoint like DynamoDB Local without PartiQL support
+	var targets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		targets = append(targets, target)
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), probeTable) {
+			t.Error("probe is missing table name:", string(body))
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		switch target {
+		case "DynamoDB_20120810.TransactGetItems":
+			io.WriteString(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"Requested resource not found"}`)
+		default:
+			io.WriteString(w, `{"__type":"com.amazonaws.dynamodb.v20120810#UnknownOperationException","message":"Unknown operation"}`)
+		}
+	}))
+	defer srv.Close()
+
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		BaseEndpoint:     aws.String(srv.URL),
+		RetryMaxAttempts: 1,
+	})
+	caps, err := NewFromIface(client).Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Capabilities{Transactions: true, PartiQL: false}); caps != want {
+		t.Errorf("bad capabilities. want: %+v, got: %+v", want, caps)
+	}
+	if len(targets) != 2 {
+		t.Error("expected 2 probes, got:", targets)
+	}
+}
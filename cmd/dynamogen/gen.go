@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// pkg is a parsed package.
+type pkg struct {
+	name    string
+	structs map[string]structDecl
+}
+
+type structDecl struct {
+	st   *ast.StructType
+	file *ast.File
+}
+
+// model is a struct type to generate code for.
+type model struct {
+	Name   string
+	Gets   []getter
+	Ranges []keyField
+}
+
+// keyField is a key attribute of a table or index.
+type keyField struct {
+	Field string // Go field name
+	Attr  string // attribute name
+	Type  string // Go type expression
+	// String is true for string keys, which support BeginsWith.
+	String bool
+}
+
+// getter is a method that begins a query by hash key.
+type getter struct {
+	Method string
+	Index  string // empty for the table itself
+	Hash   keyField
+}
+
+// index is a table's secondary index.
+type index struct {
+	hash, rng *keyField
+	local     bool
+}
+
+// generate returns the source code for the given types in dir. args are the command line arguments to note in the header.
+func generate(dir string, typeNames []string, args string) ([]byte, error) {
+	p, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]string{"dynamo": "github.com/guregu/dynamo/v2"}
+	var models []model
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		decl, ok := p.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found in %s", name, dir)
+		}
+		m, err := p.model(name, decl, imports)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+
+	var std, other []string
+	for _, path := range imports {
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var buf bytes.Buffer
+	err = fileTmpl.Execute(&buf, struct {
+		Args    string
+		Package string
+		Std     []string
+		Imports []string
+		Models  []model
+	}{
+		Args:    args,
+		Package: p.name,
+		Std:     std,
+		Imports: other,
+		Models:  models,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+func parsePackage(dir string) (*pkg, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	p := &pkg{structs: make(map[string]structDecl)}
+	fset := token.NewFileSet()
+	for _, filename := range files {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(file) {
+			continue
+		}
+		p.name = file.Name.Name
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+					p.structs[ts.Name.Name] = structDecl{st: st, file: file}
+				}
+			}
+		}
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+	return p, nil
+}
+
+func isGenerated(file *ast.File) bool {
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, "// Code generated ") && strings.HasSuffix(c.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *pkg) model(name string, decl structDecl, imports map[string]string) (model, error) {
+	m := model{Name: name}
+	var hash, rng *keyField
+	indexes := make(map[string]*index)
+	err := p.visitFields(decl, make(map[string]bool), func(field *ast.Field, fieldName string, file *ast.File) error {
+		fm := dynamo.DescribeTag(fieldName, fieldTag(field))
+		if fm.Name == "-" {
+			return nil
+		}
+		key := func() *keyField {
+			kf := &keyField{
+				Field:  fieldName,
+				Attr:   fm.Name,
+				Type:   types.ExprString(field.Type),
+				String: types.ExprString(field.Type) == "string",
+			}
+			addImports(field.Type, file, imports)
+			return kf
+		}
+
+		for _, role := range fm.Keys {
+			if role.Index == "" {
+				switch role.KeyType {
+				case dynamodbtypes.KeyTypeHash:
+					hash = key()
+				case dynamodbtypes.KeyTypeRange:
+					rng = key()
+				}
+				continue
+			}
+			idx := indexes[role.Index]
+			if idx == nil {
+				idx = &index{local: role.Local}
+				indexes[role.Index] = idx
+			}
+			switch role.KeyType {
+			case dynamodbtypes.KeyTypeHash:
+				idx.hash = key()
+			case dynamodbtypes.KeyTypeRange:
+				idx.rng = key()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	if hash == nil {
+		return m, fmt.Errorf("struct type %s has no hash key (use the dynamo:\",hash\" struct tag option)", name)
+	}
+
+	used := make(map[string]bool)
+	addGetter := func(hash keyField, indexName string) {
+		method := "GetBy" + hash.Field
+		if used[method] {
+			method += "From" + ident(indexName)
+		}
+		used[method] = true
+		m.Gets = append(m.Gets, getter{Method: method, Index: indexName, Hash: hash})
+	}
+	addRange := func(rng *keyField) {
+		if rng == nil {
+			return
+		}
+		for _, existing := range m.Ranges {
+			if existing.Attr == rng.Attr {
+				return
+			}
+		}
+		m.Ranges = append(m.Ranges, *rng)
+	}
+
+	addGetter(*hash, "")
+	addRange(rng)
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, indexName := range names {
+		idx := indexes[indexName]
+		switch {
+		case idx.local:
+			addGetter(*hash, indexName)
+		case idx.hash != nil:
+			addGetter(*idx.hash, indexName)
+		default:
+			return m, fmt.Errorf("struct type %s: global secondary index %s has no hash key", name, indexName)
+		}
+		addRange(idx.rng)
+	}
+	return m, nil
+}
+
+// visitFields calls fn for every exported field of decl, including those of embedded structs declared in the same package.
+func (p *pkg) visitFields(decl structDecl, seen map[string]bool, fn func(field *ast.Field, name string, file *ast.File) error) error {
+	for _, field := range decl.st.Fields.List {
+		if len(field.Names) == 0 {
+			// embedded
+			typ := field.Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if id, ok := typ.(*ast.Ident); ok && fieldTag(field).Get("dynamo") != "-" {
+				if embedded, ok := p.structs[id.Name]; ok {
+					if err := p.visitFields(embedded, seen, fn); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() || seen[name.Name] {
+				continue
+			}
+			seen[name.Name] = true
+			if err := fn(field, name.Name, decl.file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// addImports adds the imports used by the package selectors in expr.
+func addImports(expr ast.Expr, file *ast.File, imports map[string]string) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		id, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name == id.Name {
+				imports[name] = path
+			}
+		}
+		return false
+	})
+}
+
+// ident converts an index name such as "Msg-Time-index" into an identifier such as "MsgTimeIndex".
+func ident(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+var fileTmpl = template.Must(template.New("file").Parse(`// Code generated by "dynamogen {{.Args}}"; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Std}}
+	"{{.}}"
+{{- end}}
+{{if .Std}}
+{{end}}
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range $m := .Models}}
+// {{.Name}}Table is a table of {{.Name}} items.
+type {{.Name}}Table struct {
+	dynamo.Table
+}
+
+// New{{.Name}}Table wraps table, which holds {{.Name}} items.
+func New{{.Name}}Table(table dynamo.Table) {{.Name}}Table {
+	return {{.Name}}Table{Table: table}
+}
+{{range .Gets}}
+// {{.Method}} begins a query for {{$m.Name}} items with the given {{.Hash.Attr}}{{if .Index}} using the {{.Index}} index{{end}}.
+func (t {{$m.Name}}Table) {{.Method}}(value {{.Hash.Type}}) {{$m.Name}}Query {
+	q := dynamo.TypedGetField[{{$m.Name}}](t.Table, {{printf "%q" .Hash.Field}}, value)
+{{- if .Index}}
+	q.Query().Index({{printf "%q" .Index}})
+{{- end}}
+	return {{$m.Name}}Query{q}
+}
+{{end}}
+// {{.Name}}Query is a query for {{.Name}} items.
+type {{.Name}}Query struct {
+	dynamo.TypedQuery[{{.Name}}]
+}
+{{range .Ranges}}
+// Range{{.Field}}Equal limits results to items whose {{.Attr}} is equal to value.
+func (q {{$m.Name}}Query) Range{{.Field}}Equal(value {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.Equal, value)
+	return q
+}
+
+// Range{{.Field}}Less limits results to items whose {{.Attr}} is less than value.
+func (q {{$m.Name}}Query) Range{{.Field}}Less(value {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.Less, value)
+	return q
+}
+
+// Range{{.Field}}LessOrEqual limits results to items whose {{.Attr}} is less than or equal to value.
+func (q {{$m.Name}}Query) Range{{.Field}}LessOrEqual(value {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.LessOrEqual, value)
+	return q
+}
+
+// Range{{.Field}}Greater limits results to items whose {{.Attr}} is greater than value.
+func (q {{$m.Name}}Query) Range{{.Field}}Greater(value {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.Greater, value)
+	return q
+}
+
+// Range{{.Field}}GreaterOrEqual limits results to items whose {{.Attr}} is greater than or equal to value.
+func (q {{$m.Name}}Query) Range{{.Field}}GreaterOrEqual(value {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.GreaterOrEqual, value)
+	return q
+}
+
+// Range{{.Field}}Between limits results to items whose {{.Attr}} is between lo and hi, inclusive.
+func (q {{$m.Name}}Query) Range{{.Field}}Between(lo, hi {{.Type}}) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.Between, lo, hi)
+	return q
+}
+{{if .String}}
+// Range{{.Field}}BeginsWith limits results to items whose {{.Attr}} begins with prefix.
+func (q {{$m.Name}}Query) Range{{.Field}}BeginsWith(prefix string) {{$m.Name}}Query {
+	q.TypedQuery.RangeField({{printf "%q" .Field}}, dynamo.BeginsWith, prefix)
+	return q
+}
+{{end}}{{end}}
+// Filter takes an expression that all results will be evaluated against. See [dynamo.Query.Filter].
+func (q {{.Name}}Query) Filter(expr string, args ...interface{}) {{.Name}}Query {
+	q.Query().Filter(expr, args...)
+	return q
+}
+
+// Limit specifies the maximum amount of results to return. See [dynamo.Query.Limit].
+func (q {{.Name}}Query) Limit(limit int) {{.Name}}Query {
+	q.Query().Limit(limit)
+	return q
+}
+
+// Order specifies the desired result order. See [dynamo.Query.Order].
+func (q {{.Name}}Query) Order(order dynamo.Order) {{.Name}}Query {
+	q.Query().Order(order)
+	return q
+}
+
+// Consistent will, if on is true, make this query a strongly consistent read. See [dynamo.Query.Consistent].
+func (q {{.Name}}Query) Consistent(on bool) {{.Name}}Query {
+	q.Query().Consistent(on)
+	return q
+}
+
+// StartFrom makes this query continue from a previous one. See [dynamo.Query.StartFrom].
+func (q {{.Name}}Query) StartFrom(key dynamo.PagingKey) {{.Name}}Query {
+	q.Query().StartFrom(key)
+	return q
+}
+{{end}}`))
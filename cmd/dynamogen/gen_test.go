@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	const golden = "testdata/widget/widget_dynamo.go"
+	got, err := generate("testdata/widget", []string{"Widget"}, "-type Widget testdata/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated code doesn't match %s (run: go run . -type Widget testdata/widget). got:\n%s", golden, got)
+	}
+
+	if testing.Short() {
+		return
+	}
+	out, err := exec.Command("go", "vet", "./testdata/widget").CombinedOutput()
+	if err != nil {
+		t.Errorf("generated code doesn't compile: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := generate("testdata/widget", []string{"Missing"}, ""); err == nil {
+		t.Error("expected error for missing type")
+	}
+	if _, err := generate("testdata/widget", []string{"Meta"}, ""); err == nil {
+		t.Error("expected error for type without a hash key")
+	}
+}
+
+func TestIdent(t *testing.T) {
+	for in, want := range map[string]string{
+		"Msg-Time-index": "MsgTimeIndex",
+		"by_user.v2":     "ByUserV2",
+	} {
+		if got := ident(in); got != want {
+			t.Errorf("ident(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Command dynamogen generates typed query builders for structs, based on their dynamo struct tags.
+// It is intended to be used with go generate:
+//
+//	//go:generate go run github.com/guregu/dynamo/v2/cmd/dynamogen -type Widget
+//
+//	type Widget struct {
+//		UserID int       `dynamo:",hash" index:"Msg-Time-index,range"`
+//		Time   time.Time `dynamo:",range"`
+//		Msg    string    `index:"Msg-Time-index,hash"`
+//	}
+//
+// For each type, dynamogen writes a table wrapper with a constructor and a Get method for each
+// hash key of the table and its indexes, and a query type with methods for each range key
+// and common query options. Results are unmarshaled into the struct type:
+//
+//	widgets := NewWidgetTable(db.Table("Widgets"))
+//	results, err := widgets.GetByUserID(613).RangeTimeBetween(start, end).All(ctx)
+//
+// Key attributes are found the same way as [github.com/guregu/dynamo/v2.DB.CreateTable]:
+// the hash and range options of the dynamo tag mark the table's primary key,
+// and the index and localIndex tags mark the keys of secondary indexes.
+// Embedded structs declared in the same package are included.
+// Key values given to the generated methods are encoded according to their fields' options, such as unixtime and keyfmt.
+// Key attribute names are looked up by field name at run time, so generated code follows the
+// name mapper of the table's DB (see [github.com/guregu/dynamo/v2.DB.NameMapper]).
+//
+// Usage:
+//
+//	dynamogen -type Widget[,Sprocket...] [-output file.go] [dir]
+//
+// By default, the output is written to <type>_dynamo.go (using the first type's name, in lowercase)
+// in the package's directory, which is the current directory unless given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dynamogen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; required")
+	output := flag.String("output", "", "output file name; default <type>_dynamo.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: dynamogen -type T[,T...] [-output file.go] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+
+	src, err := generate(dir, names, strings.Join(os.Args[1:], " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out := *output
+	if out == "" {
+		out = strings.ToLower(names[0]) + "_dynamo.go"
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
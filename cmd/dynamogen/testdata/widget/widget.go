@@ -0,0 +1,20 @@
+package widget
+
+import (
+	"time"
+)
+
+//go:generate go run github.com/guregu/dynamo/v2/cmd/dynamogen -type Widget
+
+type Widget struct {
+	UserID int       `dynamo:",hash" index:"Msg-Time-index,range"`
+	Time   time.Time `dynamo:",range,unixtime" localIndex:"ID-Seq-index,range"`
+	Msg    string    `index:"Msg-Time-index,hash"`
+	Meta
+}
+
+type Meta struct {
+	Seq     int64  `dynamo:",keyfmt=%012d" localIndex:"ID-Seq-index,range"`
+	Version string `dynamo:"v" index:"Version-index,hash"`
+	secret  string
+}
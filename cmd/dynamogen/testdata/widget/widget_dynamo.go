@@ -0,0 +1,189 @@
+// Code generated by "dynamogen -type Widget testdata/widget"; DO NOT EDIT.
+
+package widget
+
+import (
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// WidgetTable is a table of Widget items.
+type WidgetTable struct {
+	dynamo.Table
+}
+
+// NewWidgetTable wraps table, which holds Widget items.
+func NewWidgetTable(table dynamo.Table) WidgetTable {
+	return WidgetTable{Table: table}
+}
+
+// GetByUserID begins a query for Widget items with the given UserID.
+func (t WidgetTable) GetByUserID(value int) WidgetQuery {
+	q := dynamo.TypedGetField[Widget](t.Table, "UserID", value)
+	return WidgetQuery{q}
+}
+
+// GetByUserIDFromIDSeqIndex begins a query for Widget items with the given UserID using the ID-Seq-index index.
+func (t WidgetTable) GetByUserIDFromIDSeqIndex(value int) WidgetQuery {
+	q := dynamo.TypedGetField[Widget](t.Table, "UserID", value)
+	q.Query().Index("ID-Seq-index")
+	return WidgetQuery{q}
+}
+
+// GetByMsg begins a query for Widget items with the given Msg using the Msg-Time-index index.
+func (t WidgetTable) GetByMsg(value string) WidgetQuery {
+	q := dynamo.TypedGetField[Widget](t.Table, "Msg", value)
+	q.Query().Index("Msg-Time-index")
+	return WidgetQuery{q}
+}
+
+// GetByVersion begins a query for Widget items with the given v using the Version-index index.
+func (t WidgetTable) GetByVersion(value string) WidgetQuery {
+	q := dynamo.TypedGetField[Widget](t.Table, "Version", value)
+	q.Query().Index("Version-index")
+	return WidgetQuery{q}
+}
+
+// WidgetQuery is a query for Widget items.
+type WidgetQuery struct {
+	dynamo.TypedQuery[Widget]
+}
+
+// RangeTimeEqual limits results to items whose Time is equal to value.
+func (q WidgetQuery) RangeTimeEqual(value time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.Equal, value)
+	return q
+}
+
+// RangeTimeLess limits results to items whose Time is less than value.
+func (q WidgetQuery) RangeTimeLess(value time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.Less, value)
+	return q
+}
+
+// RangeTimeLessOrEqual limits results to items whose Time is less than or equal to value.
+func (q WidgetQuery) RangeTimeLessOrEqual(value time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.LessOrEqual, value)
+	return q
+}
+
+// RangeTimeGreater limits results to items whose Time is greater than value.
+func (q WidgetQuery) RangeTimeGreater(value time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.Greater, value)
+	return q
+}
+
+// RangeTimeGreaterOrEqual limits results to items whose Time is greater than or equal to value.
+func (q WidgetQuery) RangeTimeGreaterOrEqual(value time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.GreaterOrEqual, value)
+	return q
+}
+
+// RangeTimeBetween limits results to items whose Time is between lo and hi, inclusive.
+func (q WidgetQuery) RangeTimeBetween(lo, hi time.Time) WidgetQuery {
+	q.TypedQuery.RangeField("Time", dynamo.Between, lo, hi)
+	return q
+}
+
+// RangeSeqEqual limits results to items whose Seq is equal to value.
+func (q WidgetQuery) RangeSeqEqual(value int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.Equal, value)
+	return q
+}
+
+// RangeSeqLess limits results to items whose Seq is less than value.
+func (q WidgetQuery) RangeSeqLess(value int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.Less, value)
+	return q
+}
+
+// RangeSeqLessOrEqual limits results to items whose Seq is less than or equal to value.
+func (q WidgetQuery) RangeSeqLessOrEqual(value int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.LessOrEqual, value)
+	return q
+}
+
+// RangeSeqGreater limits results to items whose Seq is greater than value.
+func (q WidgetQuery) RangeSeqGreater(value int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.Greater, value)
+	return q
+}
+
+// RangeSeqGreaterOrEqual limits results to items whose Seq is greater than or equal to value.
+func (q WidgetQuery) RangeSeqGreaterOrEqual(value int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.GreaterOrEqual, value)
+	return q
+}
+
+// RangeSeqBetween limits results to items whose Seq is between lo and hi, inclusive.
+func (q WidgetQuery) RangeSeqBetween(lo, hi int64) WidgetQuery {
+	q.TypedQuery.RangeField("Seq", dynamo.Between, lo, hi)
+	return q
+}
+
+// RangeUserIDEqual limits results to items whose UserID is equal to value.
+func (q WidgetQuery) RangeUserIDEqual(value int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.Equal, value)
+	return q
+}
+
+// RangeUserIDLess limits results to items whose UserID is less than value.
+func (q WidgetQuery) RangeUserIDLess(value int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.Less, value)
+	return q
+}
+
+// RangeUserIDLessOrEqual limits results to items whose UserID is less than or equal to value.
+func (q WidgetQuery) RangeUserIDLessOrEqual(value int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.LessOrEqual, value)
+	return q
+}
+
+// RangeUserIDGreater limits results to items whose UserID is greater than value.
+func (q WidgetQuery) RangeUserIDGreater(value int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.Greater, value)
+	return q
+}
+
+// RangeUserIDGreaterOrEqual limits results to items whose UserID is greater than or equal to value.
+func (q WidgetQuery) RangeUserIDGreaterOrEqual(value int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.GreaterOrEqual, value)
+	return q
+}
+
+// RangeUserIDBetween limits results to items whose UserID is between lo and hi, inclusive.
+func (q WidgetQuery) RangeUserIDBetween(lo, hi int) WidgetQuery {
+	q.TypedQuery.RangeField("UserID", dynamo.Between, lo, hi)
+	return q
+}
+
+// Filter takes an expression that all results will be evaluated against. See [dynamo.Query.Filter].
+func (q WidgetQuery) Filter(expr string, args ...interface{}) WidgetQuery {
+	q.Query().Filter(expr, args...)
+	return q
+}
+
+// Limit specifies the maximum amount of results to return. See [dynamo.Query.Limit].
+func (q WidgetQuery) Limit(limit int) WidgetQuery {
+	q.Query().Limit(limit)
+	return q
+}
+
+// Order specifies the desired result order. See [dynamo.Query.Order].
+func (q WidgetQuery) Order(order dynamo.Order) WidgetQuery {
+	q.Query().Order(order)
+	return q
+}
+
+// Consistent will, if on is true, make this query a strongly consistent read. See [dynamo.Query.Consistent].
+func (q WidgetQuery) Consistent(on bool) WidgetQuery {
+	q.Query().Consistent(on)
+	return q
+}
+
+// StartFrom makes this query continue from a previous one. See [dynamo.Query.StartFrom].
+func (q WidgetQuery) StartFrom(key dynamo.PagingKey) WidgetQuery {
+	q.Query().StartFrom(key)
+	return q
+}
@@ -0,0 +1,159 @@
+package dynamo
+
+import (
+	"context"
+	"crypto/sha256"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CoalesceGets returns a copy of this table where identical point reads share a single GetItem request,
+// reducing the read capacity consumed by hot keys in fan-out services.
+// A read joins an identical read that is in progress, or that started less than window ago,
+// so results can be up to window older than the read itself. A window of zero only joins reads in progress.
+//
+// Only [Query.One] (and [Query.OneOrOlder]) requests that use GetItem are coalesced.
+// Strongly consistent reads are never coalesced.
+// Reads are identical if they have the same key, projection, and table (as resolved for [DB.TableFunc]).
+// The shared request is made with the context of the read that started it, without its cancellation,
+// so canceling one read doesn't fail the others.
+// Consumed capacity is only added to the [ConsumedCapacity] of the read that started the request.
+// Each read gets its own copy of the item, so interceptors and callers may modify it freely.
+//
+// Tables derived from the returned table share its reads, but separate calls to CoalesceGets do not.
+func (table Table) CoalesceGets(window time.Duration) Table {
+	table.coalesce = &getCoalescer{
+		window: window,
+		calls:  make(map[[sha256.Size]byte]*getCall),
+	}
+	return table
+}
+
+// getCoalescer shares identical GetItem requests.
+type getCoalescer struct {
+	window time.Duration
+	mu     sync.Mutex
+	calls  map[[sha256.Size]byte]*getCall
+}
+
+type getCall struct {
+	start time.Time
+	done  chan struct{}
+	res   *dynamodb.GetItemOutput
+	err   error
+}
+
+// do calls send for req, or waits for the result of an identical call.
+func (gc *getCoalescer) do(ctx context.Context, req *dynamodb.GetItemInput, send func(context.Context) (*dynamodb.GetItemOutput, error)) (*dynamodb.GetItemOutput, error) {
+	if gc == nil || (req.ConsistentRead != nil && *req.ConsistentRead) {
+		return send(ctx)
+	}
+
+	h := sha256.New()
+	canonicalWriter{h: h}.getItem(req)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+
+	gc.mu.Lock()
+	call, ok := gc.calls[key]
+	if !ok || !call.joinable(gc.window) {
+		call = &getCall{
+			start: time.Now(),
+			done:  make(chan struct{}),
+		}
+		gc.calls[key] = call
+		go gc.run(context.WithoutCancel(ctx), key, call, send)
+	}
+	gc.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		// each read gets its own copy, so interceptors and callers can't modify the others' results
+		res := *call.res
+		res.Item = cloneItem(call.res.Item)
+		return &res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// joinable returns true if new reads can share this call's result.
+func (call *getCall) joinable(window time.Duration) bool {
+	select {
+	case <-call.done:
+		return time.Since(call.start) < window
+	default:
+		return true
+	}
+}
+
+func (gc *getCoalescer) run(ctx context.Context, key [sha256.Size]byte, call *getCall, send func(context.Context) (*dynamodb.GetItemOutput, error)) {
+	call.res, call.err = send(ctx)
+	close(call.done)
+
+	forget := func() {
+		gc.mu.Lock()
+		defer gc.mu.Unlock()
+		if gc.calls[key] == call {
+			delete(gc.calls, key)
+		}
+	}
+	if remaining := gc.window - time.Since(call.start); remaining > 0 {
+		time.AfterFunc(remaining, forget)
+		return
+	}
+	forget()
+}
+
+// cloneItem returns a deep copy of item.
+func cloneItem(item Item) Item {
+	if item == nil {
+		return nil
+	}
+	clone := make(Item, len(item))
+	for k, av := range item {
+		clone[k] = cloneAV(av)
+	}
+	return clone
+}
+
+func cloneAV(av types.AttributeValue) types.AttributeValue {
+	switch av := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: av.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: av.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: slices.Clone(av.Value)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: av.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: av.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: slices.Clone(av.Value)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: slices.Clone(av.Value)}
+	case *types.AttributeValueMemberBS:
+		bs := make([][]byte, len(av.Value))
+		for i, b := range av.Value {
+			bs[i] = slices.Clone(b)
+		}
+		return &types.AttributeValueMemberBS{Value: bs}
+	case *types.AttributeValueMemberL:
+		list := make([]types.AttributeValue, len(av.Value))
+		for i, v := range av.Value {
+			list[i] = cloneAV(v)
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: cloneItem(av.Value)}
+	}
+	return av
+}
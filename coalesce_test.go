@@ -0,0 +1,127 @@
+package dynamo
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// slowGetter echoes the requested key back after a delay, counting requests.
+type slowGetter struct {
+	dynamodbiface.DynamoDBAPI
+	delay time.Duration
+	reqs  atomic.Int32
+}
+
+func (c *slowGetter) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.reqs.Add(1)
+	time.Sleep(c.delay)
+	return &dynamodb.GetItemOutput{Item: in.Key}, nil
+}
+
+func TestCoalesceGets(t *testing.T) {
+	ctx := context.Background()
+	client := &slowGetter{delay: 50 * time.Millisecond}
+	table := NewFromIface(client).Table("Hot").CoalesceGets(250 * time.Millisecond)
+
+	type item struct {
+		ID int
+	}
+	get := func(id int, consistent bool) {
+		var got item
+		if err := table.Get("ID", id).Consistent(consistent).One(ctx, &got); err != nil {
+			t.Error(err)
+		}
+		if got.ID != id {
+			t.Error("bad item:", got)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			get(1, false)
+		}()
+		go func() {
+			defer wg.Done()
+			get(2, false)
+		}()
+		go func() {
+			defer wg.Done()
+			get(1, true)
+		}()
+	}
+	wg.Wait()
+	// one for each key, plus every consistent read
+	if got := client.reqs.Load(); got != 12 {
+		t.Errorf("want 12 requests, got %d", got)
+	}
+
+	// within the window
+	get(1, false)
+	if got := client.reqs.Load(); got != 12 {
+		t.Errorf("want 12 requests, got %d", got)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	get(1, false)
+	if got := client.reqs.Load(); got != 13 {
+		t.Errorf("want 13 requests, got %d", got)
+	}
+
+	t.Run("isolated", func(t *testing.T) {
+		// each read gets its own item, even though they share a request
+		var reads atomic.Int32
+		table := NewFromIface(&slowGetter{delay: 50 * time.Millisecond}).Table("Hot").
+			CoalesceGets(250 * time.Millisecond).
+			Intercept(Interceptor{Read: func(item Item) error {
+				n := reads.Add(1)
+				item["Read"] = &types.AttributeValueMemberN{Value: strconv.Itoa(int(n))}
+				return nil
+			}})
+		items := make([]Item, 10)
+		var wg sync.WaitGroup
+		for i := range items {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := table.Get("ID", 4).One(ctx, &items[i]); err != nil {
+					t.Error(err)
+				}
+				items[i]["Mine"] = &types.AttributeValueMemberN{Value: strconv.Itoa(i)}
+			}()
+		}
+		wg.Wait()
+		seen := make(map[string]bool)
+		for i, item := range items {
+			if got := item["Mine"].(*types.AttributeValueMemberN).Value; got != strconv.Itoa(i) {
+				t.Errorf("item %d: modified by another read: %s", i, got)
+			}
+			read := item["Read"].(*types.AttributeValueMemberN).Value
+			if seen[read] {
+				t.Errorf("item %d: shares intercepted item %s", i, read)
+			}
+			seen[read] = true
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		var got item
+		if err := table.Get("ID", 3).One(ctx, &got); err != context.Canceled {
+			t.Error("want canceled, got:", err)
+		}
+	})
+}
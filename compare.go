@@ -0,0 +1,284 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Comparison is a request to compare the items of two tables, and optionally repair the differences.
+// See [Table.Compare].
+type Comparison struct {
+	source     Table
+	target     Table
+	ignore     []string
+	consistent bool
+	extra      bool
+	repair     bool
+	rate       float64
+	onMismatch func(ctx context.Context, m Mismatch)
+	onProgress func(ComparisonResult)
+	cc         *ConsumedCapacity
+}
+
+// Mismatch is an item that differs between the two tables of a [Comparison].
+type Mismatch struct {
+	// Key is the item's primary key.
+	Key Item
+	// Source is the item in the source table, or nil if it only exists in the target table.
+	Source Item
+	// Target is the item in the target table, or nil if it is missing from the target table.
+	Target Item
+	// Repaired is true if the target table was repaired to match the source table.
+	Repaired bool
+}
+
+// ComparisonResult counts the items checked by a [Comparison].
+type ComparisonResult struct {
+	// Compared is the number of source items compared with the target table.
+	Compared int
+	// Missing is the number of source items that were missing from the target table.
+	Missing int
+	// Different is the number of source items whose target items had different attributes.
+	Different int
+	// Extra is the number of target items that were missing from the source table (see [Comparison.Extra]).
+	Extra int
+	// Repaired is the number of target items that were written or deleted (see [Comparison.Repair]).
+	Repaired int
+}
+
+// Mismatched returns the total number of mismatched items.
+func (r ComparisonResult) Mismatched() int {
+	return r.Missing + r.Different + r.Extra
+}
+
+// Compare creates a request to compare every item of this table (the source) with the item with the same key
+// in target, such as when verifying a migration from one table to another.
+// Source items are scanned and their counterparts are fetched from target with BatchGetItem, 100 at a time.
+// Items are equal if they have the same attributes holding the same data (see [HashItem]).
+// The tables must have the same primary key.
+//
+//	result, err := oldTable.Compare(newTable).
+//		Ignore("MigratedAt").
+//		OnMismatch(func(ctx context.Context, m dynamo.Mismatch) {
+//			log.Println("mismatch:", m.Key)
+//		}).
+//		RateLimit(500).
+//		Run(ctx)
+func (table Table) Compare(target Table) *Comparison {
+	return &Comparison{
+		source: table,
+		target: target,
+	}
+}
+
+// Ignore excludes the given top-level attributes from comparisons, such as timestamps set by a migration.
+func (c *Comparison) Ignore(attrs ...string) *Comparison {
+	c.ignore = append(c.ignore, attrs...)
+	return c
+}
+
+// Consistent enables strongly consistent reads of both tables when on is true.
+// This avoids reporting recent writes as mismatches, at twice the read capacity.
+func (c *Comparison) Consistent(on bool) *Comparison {
+	c.consistent = on
+	return c
+}
+
+// Extra makes Run also scan the target table for items that don't exist in the source table when enabled.
+func (c *Comparison) Extra(enabled bool) *Comparison {
+	c.extra = enabled
+	return c
+}
+
+// Repair makes Run fix mismatches when enabled, treating the source table as canonical:
+// missing and different items are copied from the source table to the target table with PutItem,
+// and extra items (see [Comparison.Extra]) are deleted from the target table.
+// Items are copied as they were when they were read, so items changed in the source table
+// while Run is in progress may need another run to converge.
+func (c *Comparison) Repair(enabled bool) *Comparison {
+	c.repair = enabled
+	return c
+}
+
+// RateLimit limits Run to comparing at most perSecond items each second, with each repair counting as an item,
+// to avoid exhausting the tables' capacity. Zero (the default) means no limit.
+func (c *Comparison) RateLimit(perSecond float64) *Comparison {
+	c.rate = perSecond
+	return c
+}
+
+// OnMismatch sets a function that is called with each mismatched item, after it is repaired if [Comparison.Repair] is enabled.
+func (c *Comparison) OnMismatch(fn func(ctx context.Context, m Mismatch)) *Comparison {
+	c.onMismatch = fn
+	return c
+}
+
+// OnProgress sets a function that is called with the results so far after each batch of items is compared.
+func (c *Comparison) OnProgress(fn func(ComparisonResult)) *Comparison {
+	c.onProgress = fn
+	return c
+}
+
+// ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
+func (c *Comparison) ConsumedCapacity(cc *ConsumedCapacity) *Comparison {
+	c.cc = cc
+	return c
+}
+
+// Run compares the tables, returning the number of items compared, mismatched, and repaired.
+// If an error occurs, Run stops and returns it along with the results so far.
+func (c *Comparison) Run(ctx context.Context) (ComparisonResult, error) {
+	var result ComparisonResult
+	desc, err := c.source.description(ctx)
+	if err != nil {
+		return result, err
+	}
+	targetDesc, err := c.target.description(ctx)
+	if err != nil {
+		return result, err
+	}
+	if desc.HashKey != targetDesc.HashKey || desc.RangeKey != targetDesc.RangeKey {
+		return result, fmt.Errorf("dynamo: compare: tables %s and %s have different primary keys", c.source.Name(), c.target.Name())
+	}
+
+	pace := newPacer(c.rate)
+	err = c.pass(ctx, desc, c.source, c.target, pace, func(ctx context.Context, key, item, other Item) error {
+		result.Compared++
+		switch {
+		case other == nil:
+			result.Missing++
+		case !sameItem(item, other, c.ignore):
+			result.Different++
+		default:
+			return nil
+		}
+		m := Mismatch{Key: key, Source: item, Target: other}
+		if c.repair {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+			if err := c.target.Put(item).ConsumedCapacity(c.cc).Run(ctx); err != nil {
+				return err
+			}
+			m.Repaired = true
+			result.Repaired++
+		}
+		c.mismatch(ctx, m)
+		return nil
+	}, &result)
+	if err != nil || !c.extra {
+		return result, err
+	}
+
+	err = c.pass(ctx, desc, c.target, c.source, pace, func(ctx context.Context, key, item, other Item) error {
+		if other != nil {
+			return nil
+		}
+		result.Extra++
+		m := Mismatch{Key: key, Target: item}
+		if c.repair {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+			del := c.target.Delete(desc.HashKey, key[desc.HashKey])
+			if desc.RangeKey != "" {
+				del.Range(desc.RangeKey, key[desc.RangeKey])
+			}
+			if err := del.ConsumedCapacity(c.cc).Run(ctx); err != nil {
+				return err
+			}
+			m.Repaired = true
+			result.Repaired++
+		}
+		c.mismatch(ctx, m)
+		return nil
+	}, &result)
+	return result, err
+}
+
+// pass scans from, fetching the counterpart of each item from to and calling fn with the item's key,
+// the item, and its counterpart (nil if it doesn't exist).
+func (c *Comparison) pass(ctx context.Context, desc Description, from, to Table, pace *pacer, fn func(ctx context.Context, key, item, other Item) error, result *ComparisonResult) error {
+	names := []string{desc.HashKey}
+	if desc.RangeKey != "" {
+		names = append(names, desc.RangeKey)
+	}
+
+	var batch []Item
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		keys := make([]Keyed, 0, len(batch))
+		for _, item := range batch {
+			keys = append(keys, Keys{item[desc.HashKey], item[desc.RangeKey]})
+		}
+		var got []Item
+		err := to.Batch(names...).Get(keys...).Consistent(c.consistent).ConsumedCapacity(c.cc).All(ctx, &got)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		found := make(map[string]Item, len(got))
+		for _, item := range got {
+			found[keyID(item[desc.HashKey], item[desc.RangeKey])] = item
+		}
+		for _, item := range batch {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+			key := make(Item, len(names))
+			for _, name := range names {
+				key[name] = item[name]
+			}
+			if err := fn(ctx, key, item, found[keyID(item[desc.HashKey], item[desc.RangeKey])]); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		if c.onProgress != nil {
+			c.onProgress(*result)
+		}
+		return nil
+	}
+
+	iter := from.Scan().Consistent(c.consistent).ConsumedCapacity(c.cc).Iter()
+	var item Item
+	for iter.Next(ctx, &item) {
+		batch = append(batch, item)
+		if len(batch) == maxGetOps {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func (c *Comparison) mismatch(ctx context.Context, m Mismatch) {
+	if c.onMismatch != nil {
+		c.onMismatch(ctx, m)
+	}
+}
+
+// sameItem reports whether a and b have the same attributes holding the same data,
+// not counting the attributes named in ignore.
+func sameItem(a, b Item, ignore []string) bool {
+	for name, av := range a {
+		if slices.Contains(ignore, name) {
+			continue
+		}
+		if !sameValue(av, b[name]) {
+			return false
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok && !slices.Contains(ignore, name) {
+			return false
+		}
+	}
+	return true
+}
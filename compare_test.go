@@ -0,0 +1,150 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// compareClient is a fake set of tables with a numeric hash key of ID, stored by table name and ID.
+type compareClient struct {
+	dynamodbiface.DynamoDBAPI
+	tables map[string]map[string]Item
+	puts   int
+	dels   int
+}
+
+func (c *compareClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName: in.TableName,
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash},
+			},
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("ID"), AttributeType: types.ScalarAttributeTypeN},
+			},
+		},
+	}, nil
+}
+
+func (c *compareClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	var items []Item
+	for _, item := range c.tables[*in.TableName] {
+		items = append(items, item)
+	}
+	return &dynamodb.ScanOutput{Items: items, Count: int32(len(items))}, nil
+}
+
+func (c *compareClient) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]map[string]types.AttributeValue)}
+	for table, ka := range in.RequestItems {
+		for _, key := range ka.Keys {
+			if item, ok := c.tables[table][keyValueID(key["ID"])]; ok {
+				out.Responses[table] = append(out.Responses[table], item)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (c *compareClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.puts++
+	c.tables[*in.TableName][keyValueID(in.Item["ID"])] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *compareClient) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.dels++
+	delete(c.tables[*in.TableName], keyValueID(in.Key["ID"]))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestCompare(t *testing.T) {
+	type row struct {
+		ID       int
+		Name     string
+		Migrated bool `dynamo:",omitempty"`
+	}
+	ctx := context.Background()
+	client := &compareClient{tables: map[string]map[string]Item{"Old": {}, "New": {}}}
+	add := func(table string, r row) {
+		item, err := MarshalItem(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.tables[table][keyValueID(item["ID"])] = item
+	}
+	for i := 0; i < 250; i++ {
+		add("Old", row{ID: i, Name: strconv.Itoa(i)})
+		switch i {
+		case 10: // missing
+		case 20: // different
+			add("New", row{ID: i, Name: "changed", Migrated: true})
+		default:
+			add("New", row{ID: i, Name: strconv.Itoa(i), Migrated: true})
+		}
+	}
+	add("New", row{ID: 1000, Name: "extra"})
+
+	db := NewFromIface(client)
+	compare := func(repair bool) (ComparisonResult, []int) {
+		var mismatched []int
+		var progress int
+		result, err := db.Table("Old").Compare(db.Table("New")).
+			Ignore("Migrated").
+			Extra(true).
+			Repair(repair).
+			OnMismatch(func(_ context.Context, m Mismatch) {
+				id, _ := strconv.Atoi(m.Key["ID"].(*types.AttributeValueMemberN).Value)
+				mismatched = append(mismatched, id)
+				if m.Repaired != repair {
+					t.Error("bad repaired flag:", m)
+				}
+			}).
+			OnProgress(func(ComparisonResult) { progress++ }).
+			Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress != 6 {
+			t.Error("bad progress count. want: 6 got:", progress)
+		}
+		sort.Ints(mismatched)
+		return result, mismatched
+	}
+
+	result, mismatched := compare(false)
+	want := ComparisonResult{Compared: 250, Missing: 1, Different: 1, Extra: 1}
+	if result != want {
+		t.Error("bad result. want:", want, "got:", result)
+	}
+	if !reflect.DeepEqual(mismatched, []int{10, 20, 1000}) {
+		t.Error("bad mismatches:", mismatched)
+	}
+	if client.puts != 0 || client.dels != 0 {
+		t.Error("wrote without repair:", client.puts, client.dels)
+	}
+
+	result, _ = compare(true)
+	want.Repaired = 3
+	if result != want {
+		t.Error("bad result. want:", want, "got:", result)
+	}
+	if client.puts != 2 || client.dels != 1 {
+		t.Error("bad repairs. puts:", client.puts, "deletes:", client.dels)
+	}
+
+	result, mismatched = compare(true)
+	if result.Mismatched() != 0 || len(mismatched) != 0 {
+		t.Error("tables should match after repair:", result, mismatched)
+	}
+}
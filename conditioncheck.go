@@ -1,7 +1,7 @@
 package dynamo
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -30,11 +30,9 @@ func (table Table) Check(hashKey string, value interface{}) *ConditionCheck {
 	check := &ConditionCheck{
 		table:   table,
 		hashKey: hashKey,
+		subber:  subber{db: table.db},
 	}
-	check.hashValue, check.err = marshal(value, flagNone)
-	if check.hashValue == nil {
-		check.setError(fmt.Errorf("dynamo: check hash key value is nil or omitted for attribute %q", check.hashKey))
-	}
+	check.hashValue, check.err = table.marshalKey("check hash", hashKey, value)
 	return check
 }
 
@@ -42,11 +40,8 @@ func (table Table) Check(hashKey string, value interface{}) *ConditionCheck {
 func (check *ConditionCheck) Range(rangeKey string, value interface{}) *ConditionCheck {
 	check.rangeKey = rangeKey
 	var err error
-	check.rangeValue, err = marshal(value, flagNone)
+	check.rangeValue, err = check.table.marshalKey("check range", rangeKey, value)
 	check.setError(err)
-	if check.rangeValue == nil {
-		check.setError(fmt.Errorf("dynamo: check range key value is nil or omitted for attribute %q", check.rangeKey))
-	}
 	return check
 }
 
@@ -84,15 +79,19 @@ func (check *ConditionCheck) IncludeItemInCondCheckFail(enabled bool) *Condition
 	return check
 }
 
-func (check *ConditionCheck) writeTxItem() (*types.TransactWriteItem, error) {
+func (check *ConditionCheck) writeTxItem(ctx context.Context) (*types.TransactWriteItem, error) {
 	if check.err != nil {
 		return nil, check.err
 	}
+	values, err := check.values(ctx)
+	if err != nil {
+		return nil, err
+	}
 	item := &types.ConditionCheck{
 		TableName:                 aws.String(check.table.name),
 		Key:                       check.keys(),
 		ExpressionAttributeNames:  check.nameExpr,
-		ExpressionAttributeValues: check.valueExpr,
+		ExpressionAttributeValues: values,
 	}
 	if check.condition != "" {
 		item.ConditionExpression = aws.String(check.condition)
@@ -0,0 +1,162 @@
+package dynamo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotExists returns a condition that is true when none of the given attributes exist.
+// Passing the table's primary key attributes guards against overwriting an existing item.
+//
+// Like the other condition templates in this package ([Exists], [VersionEquals], [TTLNotExpired], and [OwnedBy]),
+// it returns an [ExpressionLiteral] which can be used anywhere an expression is accepted as $ or ?.
+// Conditions can be combined with [And], [Or], and [Not].
+//
+//	err := table.Put(item).If("$", dynamo.NotExists("UserID", "Time")).Run(ctx)
+//
+//	err = table.Update("UserID", 42).
+//		Set("Name", "Bob").
+//		Add("Version", 1).
+//		If("$", dynamo.And(dynamo.VersionEquals("Version", 3), dynamo.OwnedBy("Owner", "bob"))).
+//		Run(ctx)
+func NotExists(names ...string) ExpressionLiteral {
+	return attrFuncCond("attribute_not_exists", names)
+}
+
+// Exists returns a condition that is true when all of the given attributes exist.
+// Passing the table's primary key attributes guards against writing to a nonexistent item.
+func Exists(names ...string) ExpressionLiteral {
+	return attrFuncCond("attribute_exists", names)
+}
+
+func attrFuncCond(fn string, names []string) ExpressionLiteral {
+	var s subber
+	exprs := make([]string, 0, len(names))
+	for _, name := range names {
+		exprs = append(exprs, fn+"("+s.subName(name)+")")
+	}
+	return s.literal(strings.Join(exprs, " AND "))
+}
+
+// VersionEquals returns a condition that is true when the number attribute name equals version,
+// for optimistic locking. Versions start from zero, so a missing attribute is treated as version 0.
+func VersionEquals(name string, version int64) ExpressionLiteral {
+	var s subber
+	attr := s.subName(name)
+	expr := attr + " = " + s.literal64(version)
+	if version == 0 {
+		expr = "attribute_not_exists(" + attr + ") OR " + expr
+	}
+	return s.literal(expr)
+}
+
+// TTLNotExpired returns a condition that is true when the item has not expired,
+// according to the time to live attribute name (in Unix epoch seconds).
+// Items without the attribute never expire.
+// The current time is determined when TTLNotExpired is called.
+func TTLNotExpired(name string) ExpressionLiteral {
+	var s subber
+	attr := s.subName(name)
+	return s.literal("attribute_not_exists(" + attr + ") OR " + attr + " > " + s.literal64(time.Now().Unix()))
+}
+
+// OwnedBy returns a condition that is true when the string attribute name equals owner.
+func OwnedBy(name string, owner string) ExpressionLiteral {
+	var s subber
+	sub, _ := s.subValue(owner, flagAllowEmpty) // strings always encode
+	return s.literal(s.subName(name) + " = " + sub)
+}
+
+// ContentHashMatches returns a condition that is true when the content hash stored in the attribute name equals etag,
+// or when etag is blank and the item doesn't exist.
+// Use it with [Put.ContentHash] to implement If-Match semantics, where writes only succeed if the item
+// is unchanged since it was read.
+//
+//	// read
+//	var item Item
+//	err := table.Get("ID", id).One(ctx, &item)
+//	etag := dynamo.HashItem(item, "ETag")
+//	// ... later, write only if unchanged
+//	err = table.Put(updated).ContentHash("ETag").If("$", dynamo.ContentHashMatches("ETag", etag)).Run(ctx)
+func ContentHashMatches(name string, etag string) ExpressionLiteral {
+	if etag == "" {
+		return NotExists(name)
+	}
+	var s subber
+	sub, _ := s.subValue(etag, flagNone) // non-empty strings always encode
+	return s.literal(s.subName(name) + " = " + sub)
+}
+
+// And returns a condition that is true when all of the given conditions are true.
+func And(conds ...ExpressionLiteral) ExpressionLiteral {
+	return joinConds(" AND ", conds)
+}
+
+// Or returns a condition that is true when any of the given conditions are true.
+func Or(conds ...ExpressionLiteral) ExpressionLiteral {
+	return joinConds(" OR ", conds)
+}
+
+// Not returns a condition that is true when cond is false.
+func Not(cond ExpressionLiteral) ExpressionLiteral {
+	cond.Expression = "NOT " + wrapExpr(cond.Expression)
+	return cond
+}
+
+// joinConds combines conds with sep, renaming placeholders so that they don't collide.
+func joinConds(sep string, conds []ExpressionLiteral) ExpressionLiteral {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	joined := ExpressionLiteral{
+		AttributeNames:  make(map[string]*string),
+		AttributeValues: make(Item),
+	}
+	exprs := make([]string, 0, len(conds))
+	for i, cond := range conds {
+		prefix := "c" + strconv.Itoa(i) + "_"
+		expr := placeholderRegexp.ReplaceAllStringFunc(cond.Expression, func(ph string) string {
+			_, isName := cond.AttributeNames[ph]
+			_, isValue := cond.AttributeValues[ph]
+			if !isName && !isValue {
+				return ph
+			}
+			return ph[:1] + prefix + ph[1:]
+		})
+		for k, v := range cond.AttributeNames {
+			joined.AttributeNames[k[:1]+prefix+k[1:]] = v
+		}
+		for k, v := range cond.AttributeValues {
+			joined.AttributeValues[k[:1]+prefix+k[1:]] = v
+		}
+		exprs = append(exprs, wrapExpr(expr))
+	}
+	joined.Expression = strings.Join(exprs, sep)
+	return joined
+}
+
+func (s *subber) literal64(n int64) string {
+	sub, _ := s.subValue(n, flagNone) // numbers always encode
+	return sub
+}
+
+// literal returns expr with this subber's placeholders as an ExpressionLiteral.
+// Compound expressions are wrapped in parentheses, so they can be safely embedded.
+func (s *subber) literal(expr string) ExpressionLiteral {
+	lit := ExpressionLiteral{
+		Expression:      expr,
+		AttributeValues: s.valueExpr,
+	}
+	if strings.Contains(expr, " AND ") || strings.Contains(expr, " OR ") {
+		lit.Expression = wrapExpr(expr)
+	}
+	if len(s.nameExpr) > 0 {
+		lit.AttributeNames = make(map[string]*string, len(s.nameExpr))
+		for k, v := range s.nameExpr {
+			v := v
+			lit.AttributeNames[k] = &v
+		}
+	}
+	return lit
+}
@@ -0,0 +1,85 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestConditionTemplates(t *testing.T) {
+	table := NewFromIface(nil).Table("Conds")
+	cond := And(
+		NotExists("ID", "Time"),
+		Or(VersionEquals("Version", 3), Not(OwnedBy("Owner", "bob"))),
+		OwnedBy("Owner", "alice"),
+	)
+	put := table.Put(map[string]any{"ID": 1}).If("$", cond)
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	input := put.input()
+
+	wantExpr := "((attribute_not_exists(#x_c0_sJFCA) AND attribute_not_exists(#x_c0_sKRUW2ZI)) AND " +
+		"((#x_c1_c0_sKZSXE43JN5XA = :x_c1_c0_v0) OR (NOT (#x_c1_c1_sJ53W4ZLS = :x_c1_c1_v0))) AND " +
+		"(#x_c2_sJ53W4ZLS = :x_c2_v0))"
+	if got := *input.ConditionExpression; got != wantExpr {
+		t.Errorf("bad expression.\nwant: %s\ngot:  %s", wantExpr, got)
+	}
+	wantNames := map[string]string{
+		"#x_c0_sJFCA":            "ID",
+		"#x_c0_sKRUW2ZI":         "Time",
+		"#x_c1_c0_sKZSXE43JN5XA": "Version",
+		"#x_c1_c1_sJ53W4ZLS":     "Owner",
+		"#x_c2_sJ53W4ZLS":        "Owner",
+	}
+	if !reflect.DeepEqual(input.ExpressionAttributeNames, wantNames) {
+		t.Error("bad names. want:", wantNames, "got:", input.ExpressionAttributeNames)
+	}
+	wantValues := Item{
+		":x_c1_c0_v0": &types.AttributeValueMemberN{Value: "3"},
+		":x_c1_c1_v0": &types.AttributeValueMemberS{Value: "bob"},
+		":x_c2_v0":    &types.AttributeValueMemberS{Value: "alice"},
+	}
+	if !reflect.DeepEqual(input.ExpressionAttributeValues, wantValues) {
+		t.Error("bad values. want:", wantValues, "got:", input.ExpressionAttributeValues)
+	}
+}
+
+func TestConditionTemplatesEmbedded(t *testing.T) {
+	table := NewFromIface(nil).Table("Conds")
+	// compound templates are parenthesized so they can be embedded safely
+	put := table.Put(map[string]any{"ID": 1}).If("'Count' > ? AND $", 0, TTLNotExpired("Expires"))
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	want := "(#sINXXK3TU > :v0 AND (attribute_not_exists(#x_sIV4HA2LSMVZQ) OR #x_sIV4HA2LSMVZQ > :x_v0))"
+	if got := *put.input().ConditionExpression; got != want {
+		t.Errorf("bad expression.\nwant: %s\ngot:  %s", want, got)
+	}
+
+	v0 := VersionEquals("Version", 0)
+	if want := "(attribute_not_exists(#sKZSXE43JN5XA) OR #sKZSXE43JN5XA = :v0)"; v0.Expression != want {
+		t.Error("bad version 0 expression. want:", want, "got:", v0.Expression)
+	}
+}
+
+func TestConditionTemplatesCombined(t *testing.T) {
+	table := NewFromIface(nil).Table("Conds")
+	update := table.Update("ID", 1).Set("Msg", "hi").If("$", OwnedBy("Owner", "bob")).If("$", VersionEquals("Version", 3))
+	if update.err != nil {
+		t.Fatal(update.err)
+	}
+	input := update.updateInput()
+
+	wantExpr := "(#x_sJ53W4ZLS = :x_v0) AND (#x1_sKZSXE43JN5XA = :x1_v0)"
+	if got := *input.ConditionExpression; got != wantExpr {
+		t.Errorf("bad expression.\nwant: %s\ngot:  %s", wantExpr, got)
+	}
+	if got := input.ExpressionAttributeValues[":x_v0"]; !reflect.DeepEqual(got, &types.AttributeValueMemberS{Value: "bob"}) {
+		t.Error("bad owner value:", got)
+	}
+	if got := input.ExpressionAttributeValues[":x1_v0"]; !reflect.DeepEqual(got, &types.AttributeValueMemberN{Value: "3"}) {
+		t.Error("bad version value:", got)
+	}
+}
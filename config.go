@@ -0,0 +1,139 @@
+package dynamo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// TableConfig is the metadata of a table that is discovered from DynamoDB and cached by DB.
+// Its description is used to infer LastEvaluatedKeys, validate keys, and choose indexes,
+// and its time to live configuration is used by [BatchWrite.PutWithTTL].
+//
+// Each part is requested at most once per table, even when many goroutines need it at the same time,
+// and is never modified afterwards, so a TableConfig is safe to share between goroutines but must not be modified.
+// Use [Table.RefreshConfig] to discard the cached metadata after a table changes.
+type TableConfig struct {
+	Name string
+	// Description is the table's description, see [Table.Describe].
+	Description Description
+	// TTL is the table's time to live configuration, see [Table.DescribeTTL].
+	TTL TTLDescription
+}
+
+// Config returns this table's metadata, requesting any parts that aren't cached yet.
+func (table Table) Config(ctx context.Context) (TableConfig, error) {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return TableConfig{}, err
+	}
+	ttl, err := table.ttlDescription(ctx)
+	if err != nil {
+		return TableConfig{}, err
+	}
+	return TableConfig{
+		Name:        table.name,
+		Description: desc,
+		TTL:         ttl,
+	}, nil
+}
+
+// RefreshConfig discards this table's cached metadata, shared by every Table handle of the same DB,
+// and requests it again.
+func (table Table) RefreshConfig(ctx context.Context) (TableConfig, error) {
+	table.db.configs.Store(table.name, new(tableState))
+	return table.Config(ctx)
+}
+
+// tableState holds the cached metadata of a table.
+type tableState struct {
+	desc lazy[Description]
+	ttl  lazy[TTLDescription]
+}
+
+func (db *DB) tableState(name string) *tableState {
+	if state, ok := db.configs.Load(name); ok {
+		return state.(*tableState)
+	}
+	state, _ := db.configs.LoadOrStore(name, new(tableState))
+	return state.(*tableState)
+}
+
+func (table Table) ttlDescription(ctx context.Context) (TTLDescription, error) {
+	return table.db.tableState(table.name).ttl.resolve(ctx, table.DescribeTTL().Run)
+}
+
+// lazy is a value that is resolved once, without concurrent duplicate requests.
+// Failed resolutions are not cached.
+type lazy[T any] struct {
+	mu    sync.Mutex
+	call  *lazyCall[T]
+	value atomic.Pointer[T]
+}
+
+// lazyCall is an in-progress resolution of a lazy value.
+type lazyCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func (l *lazy[T]) load() (T, bool) {
+	if v := l.value.Load(); v != nil {
+		return *v, true
+	}
+	var zero T
+	return zero, false
+}
+
+func (l *lazy[T]) store(v T) {
+	l.value.Store(&v)
+}
+
+// resolve returns the value, calling fn to get it if it isn't set yet.
+// Callers that arrive while fn is running wait for its result or for their own context to be done.
+// If fn fails, waiting callers try again with their own contexts.
+func (l *lazy[T]) resolve(ctx context.Context, fn func(context.Context) (T, error)) (T, error) {
+	for {
+		if v, ok := l.load(); ok {
+			return v, nil
+		}
+		if err := ctx.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+
+		l.mu.Lock()
+		if v, ok := l.load(); ok {
+			l.mu.Unlock()
+			return v, nil
+		}
+		if call := l.call; call != nil {
+			l.mu.Unlock()
+			select {
+			case <-call.done:
+				if call.err == nil {
+					return call.value, nil
+				}
+				// try again, in case the caller that failed was canceled
+				continue
+			case <-ctx.Done():
+				var zero T
+				return zero, ctx.Err()
+			}
+		}
+		call := &lazyCall[T]{done: make(chan struct{})}
+		l.call = call
+		l.mu.Unlock()
+
+		call.value, call.err = fn(ctx)
+		l.mu.Lock()
+		if call.err == nil {
+			l.store(call.value)
+		}
+		l.call = nil
+		l.mu.Unlock()
+		close(call.done)
+		return call.value, call.err
+	}
+}
@@ -0,0 +1,114 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// slowDescribeClient counts slow DescribeTable and DescribeTimeToLive requests, failing the first ones if fail is set.
+type slowDescribeClient struct {
+	dynamodbiface.DynamoDBAPI
+	describes atomic.Int32
+	ttls      atomic.Int32
+	fail      atomic.Bool
+}
+
+func (c *slowDescribeClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.describes.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	if c.fail.Swap(false) {
+		return nil, errors.New("oops")
+	}
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName: aws.String("Config"),
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash}},
+	}}, nil
+}
+
+func (c *slowDescribeClient) DescribeTimeToLive(_ context.Context, _ *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.ttls.Add(1)
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: &types.TimeToLiveDescription{
+		AttributeName:    aws.String("Expires"),
+		TimeToLiveStatus: types.TimeToLiveStatusEnabled,
+	}}, nil
+}
+
+func TestTableConfig(t *testing.T) {
+	ctx := context.Background()
+	client := new(slowDescribeClient)
+	db := NewFromIface(client)
+
+	client.fail.Store(true)
+	if _, err := db.Table("Config").Config(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := db.Table("Config").Config(ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if cfg.Description.HashKey != "ID" || cfg.TTL.Attribute != "Expires" {
+				t.Error("bad config:", cfg)
+			}
+		}()
+	}
+	wg.Wait()
+	// one failure, then one success
+	if n := client.describes.Load(); n != 2 {
+		t.Error("want 2 DescribeTable requests, got:", n)
+	}
+	if n := client.ttls.Load(); n != 1 {
+		t.Error("want 1 DescribeTimeToLive request, got:", n)
+	}
+
+	if _, err := db.ErrorValueLimit(10).Table("Config").RefreshConfig(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Table("Config").Config(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d, ttl := client.describes.Load(), client.ttls.Load(); d != 3 || ttl != 2 {
+		t.Error("refresh: bad request counts:", d, ttl)
+	}
+}
+
+func TestLazyCanceled(t *testing.T) {
+	var l lazy[int]
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go l.resolve(context.Background(), func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 42, nil
+	})
+	<-started
+
+	// waiters give up when their context is done, instead of waiting for the slow request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.resolve(ctx, func(context.Context) (int, error) { return 0, errors.New("unexpected call") }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("want DeadlineExceeded, got:", err)
+	}
+
+	close(release)
+	v, err := l.resolve(context.Background(), func(context.Context) (int, error) { return 0, errors.New("unexpected call") })
+	if err != nil || v != 42 {
+		t.Error("bad value:", v, err)
+	}
+}
@@ -53,8 +53,11 @@ type CreateTable struct {
 	writeUnits              int64
 	streamView              StreamView
 	ondemand                bool
+	ondemandMax             *OnDemandThroughput
+	warm                    *WarmThroughput
 	tags                    []types.Tag
 	encryptionSpecification *types.SSESpecification
+	optFns                  []func(*dynamodb.Options)
 	err                     error
 }
 
@@ -97,6 +100,38 @@ func (ct *CreateTable) OnDemand(enabled bool) *CreateTable {
 	return ct
 }
 
+// MaxOnDemand specifies the maximum read and write request units per second for this table in on-demand mode.
+// Zero means no maximum. See [CreateTable.OnDemand].
+func (ct *CreateTable) MaxOnDemand(maxRead, maxWrite int64) *CreateTable {
+	ct.ondemandMax = &OnDemandThroughput{MaxRead: maxRead, MaxWrite: maxWrite}
+	return ct
+}
+
+// MaxOnDemandIndex specifies the maximum read and write request units per second for the given
+// global secondary index in on-demand mode. Zero means no maximum.
+func (ct *CreateTable) MaxOnDemandIndex(index string, maxRead, maxWrite int64) *CreateTable {
+	idx := ct.globalIndices[index]
+	idx.OnDemandThroughput = OnDemandThroughput{MaxRead: maxRead, MaxWrite: maxWrite}.input()
+	ct.globalIndices[index] = idx
+	return ct
+}
+
+// WarmThroughput specifies the reads and writes per second that this table can instantly support
+// once created, such as for a high-traffic launch or a large import. It applies to both billing modes.
+func (ct *CreateTable) WarmThroughput(read, write int64) *CreateTable {
+	ct.warm = &WarmThroughput{Read: read, Write: write}
+	return ct
+}
+
+// WarmThroughputIndex specifies the reads and writes per second that the given
+// global secondary index can instantly support once created.
+func (ct *CreateTable) WarmThroughputIndex(index string, read, write int64) *CreateTable {
+	idx := ct.globalIndices[index]
+	idx.WarmThroughput = WarmThroughput{Read: read, Write: write}.input()
+	ct.globalIndices[index] = idx
+	return ct
+}
+
 // Provision specifies the provisioned read and write capacity for this table.
 // If Provision isn't called and on-demand mode is disabled, the table will be created with 1 unit each.
 func (ct *CreateTable) Provision(readUnits, writeUnits int64) *CreateTable {
@@ -199,6 +234,12 @@ func (ct *CreateTable) Index(index Index) *CreateTable {
 			WriteCapacityUnits: &index.Throughput.Write,
 		}
 	}
+	if index.OnDemandThroughput != (OnDemandThroughput{}) {
+		idx.OnDemandThroughput = index.OnDemandThroughput.input()
+	}
+	if index.WarmThroughput.Read != 0 || index.WarmThroughput.Write != 0 {
+		idx.WarmThroughput = index.WarmThroughput.input()
+	}
 	if proj != nil {
 		idx.Projection = proj
 	}
@@ -234,6 +275,14 @@ func (ct *CreateTable) SSEEncryption(enabled bool, keyID string, sseType SSEType
 	return ct
 }
 
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// They are not applied to the requests [CreateTable.Wait] makes while waiting for the table.
+func (ct *CreateTable) RequestOptions(optFns ...func(*dynamodb.Options)) *CreateTable {
+	ct.optFns = optFns
+	return ct
+}
+
 // Run creates this table or returns an error.
 func (ct *CreateTable) Run(ctx context.Context) error {
 	if ct.err != nil {
@@ -242,7 +291,7 @@ func (ct *CreateTable) Run(ctx context.Context) error {
 
 	input := ct.input()
 	return ct.db.retry(ctx, func() error {
-		_, err := ct.db.client.CreateTable(ctx, input)
+		_, err := ct.db.client.CreateTable(ctx, input, ct.db.requestOptions(ct.optFns)...)
 		return err
 	})
 }
@@ -268,11 +317,14 @@ func (ct *CreateTable) from(rv reflect.Value) error {
 		field := rv.Type().Field(i)
 		fv := rv.Field(i)
 
-		name, _ := fieldInfo(field)
+		name, _ := fieldInfo(field, ct.db.nameMapper())
 		if name == "-" {
 			// skip
 			continue
 		}
+		if err := checkFieldTag(field); err != nil {
+			return err
+		}
 
 		// inspect anonymous structs
 		if fv.Type().Kind() == reflect.Struct && field.Anonymous {
@@ -334,12 +386,18 @@ func (ct *CreateTable) input() *dynamodb.CreateTableInput {
 	}
 	if ct.ondemand {
 		input.BillingMode = types.BillingModePayPerRequest
+		if ct.ondemandMax != nil {
+			input.OnDemandThroughput = ct.ondemandMax.input()
+		}
 	} else {
 		input.ProvisionedThroughput = &types.ProvisionedThroughput{
 			ReadCapacityUnits:  &ct.readUnits,
 			WriteCapacityUnits: &ct.writeUnits,
 		}
 	}
+	if ct.warm != nil {
+		input.WarmThroughput = ct.warm.input()
+	}
 	if ct.streamView != "" {
 		enabled := true
 		view := string(ct.streamView)
@@ -378,7 +436,11 @@ func (ct *CreateTable) input() *dynamodb.CreateTableInput {
 		}
 		if ct.ondemand {
 			idx.ProvisionedThroughput = nil
-		} else if idx.ProvisionedThroughput == nil {
+		} else {
+			// maximum on-demand throughput is only valid for on-demand tables
+			idx.OnDemandThroughput = nil
+		}
+		if !ct.ondemand && idx.ProvisionedThroughput == nil {
 			units := int64(1)
 			idx.ProvisionedThroughput = &types.ProvisionedThroughput{
 				ReadCapacityUnits:  &units,
@@ -422,8 +484,14 @@ func typeOf(rv reflect.Value, tag string) string {
 	split := strings.Split(tag, ",")
 	if len(split) > 1 {
 		for _, v := range split[1:] {
-			if v == "unixtime" {
+			switch v {
+			case "unixtime", "number":
 				return "N"
+			case "string":
+				return "S"
+			}
+			if strings.HasPrefix(v, "keyfmt=") {
+				return "S"
 			}
 		}
 	}
@@ -444,11 +512,16 @@ func typeOf(rv reflect.Value, tag string) string {
 			}
 		case encoding.TextMarshaler:
 			return "S"
+		case encoding.BinaryMarshaler:
+			return "B"
 		}
 	}
 
 	typ := rv.Type()
 check:
+	if typ == rtypeNumber {
+		return "N"
+	}
 	switch typ.Kind() {
 	case reflect.Ptr:
 		typ = typ.Elem()
@@ -163,3 +163,129 @@ func TestCreateTableUintUnixTime(t *testing.T) {
 		t.Error("unexpected input (unixtime tag)", input2)
 	}
 }
+
+func TestMaxOnDemand(t *testing.T) {
+	db := NewFromIface(nil)
+	odt := func(read, write int64) *types.OnDemandThroughput {
+		return &types.OnDemandThroughput{MaxReadRequestUnits: aws.Int64(read), MaxWriteRequestUnits: aws.Int64(write)}
+	}
+
+	input := db.CreateTable("UserAction", UserAction{}).
+		OnDemand(true).
+		MaxOnDemand(100, 0).
+		MaxOnDemandIndex("Embedded-index", 50, 10).
+		input()
+	if !reflect.DeepEqual(input.OnDemandThroughput, odt(100, -1)) {
+		t.Error("bad table on-demand throughput:", input.OnDemandThroughput)
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		if *gsi.IndexName != "Embedded-index" {
+			continue
+		}
+		if !reflect.DeepEqual(gsi.OnDemandThroughput, odt(50, 10)) {
+			t.Error("bad index on-demand throughput:", gsi.OnDemandThroughput)
+		}
+	}
+
+	provisioned := db.CreateTable("UserAction", UserAction{}).
+		MaxOnDemand(100, 0).
+		MaxOnDemandIndex("Embedded-index", 50, 10).
+		input()
+	if provisioned.OnDemandThroughput != nil {
+		t.Error("provisioned table should not have on-demand throughput:", provisioned.OnDemandThroughput)
+	}
+	for _, gsi := range provisioned.GlobalSecondaryIndexes {
+		if gsi.OnDemandThroughput != nil {
+			t.Error("provisioned index should not have on-demand throughput:", gsi.OnDemandThroughput)
+		}
+	}
+
+	update := db.Table("UserAction").UpdateTable().
+		MaxOnDemand(0, 200).
+		MaxOnDemandIndex("Embedded-index", 5, 5).
+		input()
+	if !reflect.DeepEqual(update.OnDemandThroughput, odt(-1, 200)) {
+		t.Error("bad updated table on-demand throughput:", update.OnDemandThroughput)
+	}
+	if len(update.GlobalSecondaryIndexUpdates) != 1 || !reflect.DeepEqual(update.GlobalSecondaryIndexUpdates[0].Update.OnDemandThroughput, odt(5, 5)) {
+		t.Error("bad updated index on-demand throughput:", update.GlobalSecondaryIndexUpdates)
+	}
+
+	desc := newDescription(&types.TableDescription{
+		TableName:          aws.String("UserAction"),
+		OnDemandThroughput: odt(100, -1),
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName:          aws.String("Embedded-index"),
+			IndexArn:           aws.String("arn"),
+			OnDemandThroughput: odt(50, 10),
+		}},
+	})
+	if want := (OnDemandThroughput{MaxRead: 100}); desc.OnDemandThroughput != want {
+		t.Error("bad description. want:", want, "got:", desc.OnDemandThroughput)
+	}
+	if want := (OnDemandThroughput{MaxRead: 50, MaxWrite: 10}); desc.GSI[0].OnDemandThroughput != want {
+		t.Error("bad index description. want:", want, "got:", desc.GSI[0].OnDemandThroughput)
+	}
+}
+
+func TestWarmThroughput(t *testing.T) {
+	db := NewFromIface(nil)
+	wt := func(read, write int64) *types.WarmThroughput {
+		return &types.WarmThroughput{ReadUnitsPerSecond: aws.Int64(read), WriteUnitsPerSecond: aws.Int64(write)}
+	}
+
+	input := db.CreateTable("UserAction", UserAction{}).
+		WarmThroughput(12000, 4000).
+		WarmThroughputIndex("Embedded-index", 13000, 5000).
+		input()
+	if !reflect.DeepEqual(input.WarmThroughput, wt(12000, 4000)) {
+		t.Error("bad table warm throughput:", input.WarmThroughput)
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		if *gsi.IndexName != "Embedded-index" {
+			continue
+		}
+		if !reflect.DeepEqual(gsi.WarmThroughput, wt(13000, 5000)) {
+			t.Error("bad index warm throughput:", gsi.WarmThroughput)
+		}
+	}
+
+	update := db.Table("UserAction").UpdateTable().
+		WarmThroughput(20000, 0).
+		MaxOnDemandIndex("Embedded-index", 5, 5).
+		WarmThroughputIndex("Embedded-index", 15000, 6000).
+		input()
+	if want := (&types.WarmThroughput{ReadUnitsPerSecond: aws.Int64(20000)}); !reflect.DeepEqual(update.WarmThroughput, want) {
+		t.Error("bad updated table warm throughput:", update.WarmThroughput)
+	}
+	if len(update.GlobalSecondaryIndexUpdates) != 1 {
+		t.Fatal("want 1 index update, got:", update.GlobalSecondaryIndexUpdates)
+	}
+	if up := update.GlobalSecondaryIndexUpdates[0].Update; !reflect.DeepEqual(up.WarmThroughput, wt(15000, 6000)) || up.OnDemandThroughput == nil {
+		t.Error("bad updated index warm throughput:", up)
+	}
+
+	desc := newDescription(&types.TableDescription{
+		TableName: aws.String("UserAction"),
+		WarmThroughput: &types.TableWarmThroughputDescription{
+			ReadUnitsPerSecond:  aws.Int64(12000),
+			WriteUnitsPerSecond: aws.Int64(4000),
+			Status:              types.TableStatusUpdating,
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName: aws.String("Embedded-index"),
+			IndexArn:  aws.String("arn"),
+			WarmThroughput: &types.GlobalSecondaryIndexWarmThroughputDescription{
+				ReadUnitsPerSecond:  aws.Int64(13000),
+				WriteUnitsPerSecond: aws.Int64(5000),
+				Status:              types.IndexStatusActive,
+			},
+		}},
+	})
+	if want := (WarmThroughput{Read: 12000, Write: 4000, Status: UpdatingStatus}); desc.WarmThroughput != want {
+		t.Error("bad description. want:", want, "got:", desc.WarmThroughput)
+	}
+	if want := (WarmThroughput{Read: 13000, Write: 5000, Status: ActiveStatus}); desc.GSI[0].WarmThroughput != want {
+		t.Error("bad index description. want:", want, "got:", desc.GSI[0].WarmThroughput)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -18,8 +19,22 @@ import (
 // DB is a DynamoDB client.
 type DB struct {
 	client dynamodbiface.DynamoDBAPI
-	// table description cache for LEK inference
-	descs *sync.Map // table name → Description
+	// table metadata cache, see TableConfig
+	configs *sync.Map // table name → *tableState
+	// see TableFunc
+	tableFuncs *sync.Map // placeholder table name → func(context.Context) string
+	// see Capabilities
+	caps *lazy[Capabilities]
+	// maximum size of values in error messages
+	errLimit int
+	// send empty key values instead of returning ErrEmptyKey
+	allowEmptyKey bool
+	// names of untagged struct fields, see NameMapper
+	names *NameMapper
+	// see NonFiniteFloats
+	floats NonFiniteFloatFunc
+	// options for every request, see RequestOptions
+	optFns []func(*dynamodb.Options)
 }
 
 // New creates a new client with the given configuration.
@@ -34,8 +49,11 @@ func New(cfg aws.Config, options ...func(*dynamodb.Options)) *DB {
 // NewFromIface creates a new client with the given interface.
 func NewFromIface(client dynamodbiface.DynamoDBAPI) *DB {
 	db := &DB{
-		client: client,
-		descs:  new(sync.Map),
+		client:     client,
+		configs:    new(sync.Map),
+		tableFuncs: new(sync.Map),
+		caps:       new(lazy[Capabilities]),
+		errLimit:   DefaultErrorValueLimit,
 	}
 	return db
 }
@@ -45,21 +63,44 @@ func (db *DB) Client() dynamodbiface.DynamoDBAPI {
 	return db.client
 }
 
-func (db *DB) loadDesc(name string) (desc Description, ok bool) {
-	if descv, exists := db.descs.Load(name); exists {
-		desc, ok = descv.(Description)
+// clientWrapper is a client that wraps another client, such as [HotKeySampler.WrapClient].
+type clientWrapper interface {
+	Unwrap() dynamodbiface.DynamoDBAPI
+}
+
+// clientAs returns client as T, which is one of the optional client interfaces from the dynamodbiface package.
+// If client doesn't implement T, clients it wraps (see Unwrap) are tried instead.
+func clientAs[T any](client dynamodbiface.DynamoDBAPI, op string) (T, error) {
+	for c := client; c != nil; {
+		if impl, ok := c.(T); ok {
+			return impl, nil
+		}
+		wrapper, ok := c.(clientWrapper)
+		if !ok {
+			break
+		}
+		c = wrapper.Unwrap()
 	}
-	return
+	var zero T
+	return zero, fmt.Errorf("dynamo: client %T does not support %s: %w", client, op, errors.ErrUnsupported)
+}
+
+func (db *DB) loadDesc(name string) (desc Description, ok bool) {
+	return db.tableState(name).desc.load()
 }
 
 func (db *DB) storeDesc(desc Description) {
-	db.descs.Store(desc.Name, desc)
+	db.tableState(desc.Name).desc.store(desc)
 }
 
 // ListTables is a request to list tables.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ListTables.html
 type ListTables struct {
-	db *DB
+	db     *DB
+	limit  int
+	prefix string
+	start  string
+	optFns []func(*dynamodb.Options)
 }
 
 // ListTables begins a new request to list all tables.
@@ -67,6 +108,34 @@ func (db *DB) ListTables() *ListTables {
 	return &ListTables{db: db}
 }
 
+// Limit specifies the maximum amount of table names to return.
+// A limit of zero or less means unlimited.
+func (lt *ListTables) Limit(limit int) *ListTables {
+	lt.limit = limit
+	return lt
+}
+
+// Prefix limits the results to tables whose names start with prefix.
+// This filter is applied client-side, so tables that don't match still count towards
+// the underlying requests' limits (but not towards [ListTables.Limit]).
+func (lt *ListTables) Prefix(prefix string) *ListTables {
+	lt.prefix = prefix
+	return lt
+}
+
+// StartFrom makes this request continue listing tables after the given table name.
+func (lt *ListTables) StartFrom(tableName string) *ListTables {
+	lt.start = tableName
+	return lt
+}
+
+// RequestOptions sets options that are applied to each request made while listing tables, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (lt *ListTables) RequestOptions(optFns ...func(*dynamodb.Options)) *ListTables {
+	lt.optFns = optFns
+	return lt
+}
+
 // All returns every table or an error.
 func (lt *ListTables) All(ctx context.Context) ([]string, error) {
 	var tables []string
@@ -78,10 +147,65 @@ func (lt *ListTables) All(ctx context.Context) ([]string, error) {
 	return tables, itr.Err()
 }
 
+// Pages calls fn with each page of table names, in the style of the AWS SDK's pagination helpers.
+// Pages may be empty when using [ListTables.Prefix].
+// lastPage is true for the last page of results.
+// Return false from fn to stop paging early.
+func (lt *ListTables) Pages(ctx context.Context, fn func(tables []string, lastPage bool) bool) error {
+	var n int
+	input := lt.input(nil)
+	for {
+		var res *dynamodb.ListTablesOutput
+		err := lt.db.retry(ctx, func() error {
+			var err error
+			res, err = lt.db.client.ListTables(ctx, input, lt.db.requestOptions(lt.optFns)...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		page := make([]string, 0, len(res.TableNames))
+		for _, name := range res.TableNames {
+			if lt.limit > 0 && n >= lt.limit {
+				break
+			}
+			if !strings.HasPrefix(name, lt.prefix) {
+				continue
+			}
+			page = append(page, name)
+			n++
+		}
+
+		last := res.LastEvaluatedTableName == nil || (lt.limit > 0 && n >= lt.limit)
+		if !fn(page, last) || last {
+			return nil
+		}
+		input = lt.input(res)
+	}
+}
+
+func (lt *ListTables) input(prev *dynamodb.ListTablesOutput) *dynamodb.ListTablesInput {
+	input := &dynamodb.ListTablesInput{}
+	if lt.start != "" {
+		input.ExclusiveStartTableName = aws.String(lt.start)
+	}
+	if prev != nil {
+		input.ExclusiveStartTableName = prev.LastEvaluatedTableName
+	}
+	if lt.limit > 0 && lt.prefix == "" {
+		// API limit: 100
+		limit := int32(min(lt.limit, 100))
+		input.Limit = &limit
+	}
+	return input
+}
+
 type ltIter struct {
 	lt     *ListTables
 	result *dynamodb.ListTablesOutput
 	idx    int
+	n      int
 	err    error
 }
 
@@ -104,52 +228,52 @@ func (itr *ltIter) Next(ctx context.Context, out interface{}) bool {
 		return false
 	}
 
-	if itr.result != nil {
-		if itr.idx < len(itr.result.TableNames) {
-			*out.(*string) = itr.result.TableNames[itr.idx]
-			itr.idx++
-			return true
+	for {
+		if itr.lt.limit > 0 && itr.n >= itr.lt.limit {
+			return false
 		}
 
-		// no more tables
-		if itr.result.LastEvaluatedTableName == nil {
-			return false
+		if itr.result != nil {
+			if itr.idx < len(itr.result.TableNames) {
+				name := itr.result.TableNames[itr.idx]
+				itr.idx++
+				if !strings.HasPrefix(name, itr.lt.prefix) {
+					continue
+				}
+				*out.(*string) = name
+				itr.n++
+				return true
+			}
+
+			// no more tables
+			if itr.result.LastEvaluatedTableName == nil {
+				return false
+			}
 		}
-	}
 
-	itr.err = itr.lt.db.retry(ctx, func() error {
-		res, err := itr.lt.db.client.ListTables(ctx, itr.input())
-		if err != nil {
-			return err
+		itr.err = itr.lt.db.retry(ctx, func() error {
+			res, err := itr.lt.db.client.ListTables(ctx, itr.lt.input(itr.result), itr.lt.db.requestOptions(itr.lt.optFns)...)
+			if err != nil {
+				return err
+			}
+			itr.result = res
+			return nil
+		})
+		if itr.err != nil {
+			return false
 		}
-		itr.result = res
-		return nil
-	})
-	if itr.err != nil {
-		return false
-	}
+		itr.idx = 0
 
-	if len(itr.result.TableNames) == 0 {
-		return false
+		if len(itr.result.TableNames) == 0 {
+			return false
+		}
 	}
-
-	*out.(*string) = itr.result.TableNames[0]
-	itr.idx = 1
-	return true
 }
 
 func (itr *ltIter) Err() error {
 	return itr.err
 }
 
-func (itr *ltIter) input() *dynamodb.ListTablesInput {
-	input := &dynamodb.ListTablesInput{}
-	if itr.result != nil {
-		input.ExclusiveStartTableName = itr.result.LastEvaluatedTableName
-	}
-	return input
-}
-
 // Iter is an iterator for request results.
 type Iter interface {
 	// Next tries to unmarshal the next result into out.
@@ -175,12 +299,54 @@ type ParallelIter interface {
 	// LastEvaluatedKeys returns each parallel segment's last evaluated key in order of segment number.
 	// The slice will be the same size as the number of segments, and the keys can be nil.
 	LastEvaluatedKeys(context.Context) ([]PagingKey, error)
+	// Stop cancels all in-flight segments and waits for them to finish,
+	// returning each segment's last evaluated key for the results already returned by Next.
+	// Segments that were stopped before returning anything have an empty (but non-nil) key.
+	// Use this to time-box scans and checkpoint their progress, resuming later with IterParallelStartFrom.
+	// After calling Stop, Next will return false.
+	Stop() ([]PagingKey, error)
+	// SegmentErrors returns each parallel segment's error in order of segment number, or nil for segments without errors.
+	// Each error is a [*SegmentError]. A failing segment does not stop the others, and Err will return all segment errors joined together.
+	// Failed segments can be retried by passing LastEvaluatedKeys to IterParallelStartFrom, as completed segments have nil keys.
+	SegmentErrors() []error
 }
 
 // PagingKey is a key used for splitting up partial results.
 // Get a PagingKey from a PagingIter and pass it to StartFrom in Query or Scan.
 type PagingKey Item
 
+// ErrBadPagingKey is returned when DynamoDB rejects a paging key passed to StartFrom,
+// such as a key saved before the table or index's key schema changed.
+// The returned error wraps both ErrBadPagingKey and the original ValidationException.
+// See [Query.RestartOnBadKey] and [Scan.RestartOnBadKey] to start over instead.
+var ErrBadPagingKey = errors.New("dynamo: invalid paging key")
+
+// isBadPagingKey returns true if err is a ValidationException complaining about the ExclusiveStartKey.
+func isBadPagingKey(err error) bool {
+	var ae smithy.APIError
+	if !errors.As(err, &ae) || ae.ErrorCode() != "ValidationException" {
+		return false
+	}
+	msg := strings.ToLower(ae.ErrorMessage())
+	return strings.Contains(msg, "starting key") ||
+		strings.Contains(msg, "start key") ||
+		strings.Contains(msg, "exclusivestartkey")
+}
+
+// pagingKeyErr wraps err with ErrBadPagingKey if it was caused by esk.
+func pagingKeyErr(err error, esk Item) error {
+	if esk == nil || !isBadPagingKey(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrBadPagingKey, err)
+}
+
+// shouldRestart returns true if a request starting from esk that failed with err should be retried from the beginning.
+// Only the first request can be restarted, as later requests use keys given by DynamoDB.
+func shouldRestart(restart bool, reqs int, esk Item, err error) bool {
+	return restart && reqs == 0 && esk != nil && isBadPagingKey(err)
+}
+
 // IsCondCheckFailed returns true if the given error is a "conditional check failed" error.
 // This corresponds with a ConditionalCheckFailedException in most APIs,
 // or a TransactionCanceledException with a ConditionalCheckFailed cancellation reason in transactions.
@@ -207,6 +373,10 @@ func IsCondCheckFailed(err error) bool {
 // The return value boolean `match` will be true if condCheckErr is a ConditionalCheckFailedException,
 // otherwise false if it is nil or a different error.
 func UnmarshalItemFromCondCheckFailed(condCheckErr error, out any) (match bool, err error) {
+	return unmarshalItemFromCondCheckFailed(context.Background(), condCheckErr, out, unmarshalItem)
+}
+
+func unmarshalItemFromCondCheckFailed(ctx context.Context, condCheckErr error, out any, unmarshal unmarshalFunc) (match bool, err error) {
 	if condCheckErr == nil {
 		return false, nil
 	}
@@ -215,7 +385,7 @@ func UnmarshalItemFromCondCheckFailed(condCheckErr error, out any) (match bool,
 		if cfe.Item == nil {
 			return true, fmt.Errorf("dynamo: ConditionalCheckFailedException does not contain item (is IncludeItemInCondCheckFail disabled?): %w", condCheckErr)
 		}
-		return true, UnmarshalItem(cfe.Item, out)
+		return true, unmarshal(ctx, cfe.Item, out)
 	}
 	return false, condCheckErr
 }
@@ -224,6 +394,10 @@ func UnmarshalItemFromCondCheckFailed(condCheckErr error, out any) (match bool,
 // The return value boolean `match` will be true if txCancelErr is a TransactionCanceledException with at least one ConditionalCheckFailed cancellation reason,
 // otherwise false if it is nil or a different error.
 func UnmarshalItemsFromTxCondCheckFailed(txCancelErr error, out any) (match bool, err error) {
+	return unmarshalItemsFromTxCondCheckFailed(context.Background(), txCancelErr, out)
+}
+
+func unmarshalItemsFromTxCondCheckFailed(ctx context.Context, txCancelErr error, out any) (match bool, err error) {
 	if txCancelErr == nil {
 		return false, nil
 	}
@@ -235,7 +409,7 @@ func UnmarshalItemsFromTxCondCheckFailed(txCancelErr error, out any) (match bool
 				if cr.Item == nil {
 					return true, fmt.Errorf("dynamo: TransactionCanceledException.CancellationReasons does not contain item (is IncludeItemInCondCheckFail disabled?): %w", txCancelErr)
 				}
-				if err = unmarshal(cr.Item, out); err != nil {
+				if err = unmarshal(ctx, cr.Item, out); err != nil {
 					return true, err
 				}
 				match = true
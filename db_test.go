@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -15,7 +16,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 var (
@@ -166,3 +170,89 @@ func TestListTables(t *testing.T) {
 		t.Error("couldn't find testTable", testTableWidgets, "in:", tables)
 	}
 }
+
+// tablesClient lists a fixed set of tables, two at a time.
+type tablesClient struct {
+	dynamodbiface.DynamoDBAPI
+	tables []string
+	reqs   int
+}
+
+func (c *tablesClient) ListTables(_ context.Context, in *dynamodb.ListTablesInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	c.reqs++
+	start := 0
+	if in.ExclusiveStartTableName != nil {
+		for i, name := range c.tables {
+			if name == *in.ExclusiveStartTableName {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+2, len(c.tables))
+	if in.Limit != nil {
+		end = min(start+int(*in.Limit), end)
+	}
+	out := &dynamodb.ListTablesOutput{TableNames: c.tables[start:end]}
+	if end < len(c.tables) {
+		out.LastEvaluatedTableName = aws.String(c.tables[end-1])
+	}
+	return out, nil
+}
+
+func TestListTablesPaging(t *testing.T) {
+	ctx := context.Background()
+	client := &tablesClient{tables: []string{"app-a", "app-b", "other-a", "app-c", "other-b"}}
+	db := NewFromIface(client)
+
+	tables, err := db.ListTables().Prefix("app-").All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app-a", "app-b", "app-c"}; !reflect.DeepEqual(tables, want) {
+		t.Error("bad tables. want:", want, "got:", tables)
+	}
+
+	tables, err = db.ListTables().Prefix("app-").Limit(2).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app-a", "app-b"}; !reflect.DeepEqual(tables, want) {
+		t.Error("bad tables. want:", want, "got:", tables)
+	}
+
+	tables, err = db.ListTables().StartFrom("app-b").Limit(1).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"other-a"}; !reflect.DeepEqual(tables, want) {
+		t.Error("bad tables. want:", want, "got:", tables)
+	}
+
+	var pages [][]string
+	var lasts []bool
+	err = db.ListTables().Prefix("other-").Pages(ctx, func(tables []string, lastPage bool) bool {
+		pages = append(pages, tables)
+		lasts = append(lasts, lastPage)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{}, {"other-a"}, {"other-b"}}; !reflect.DeepEqual(pages, want) {
+		t.Error("bad pages. want:", want, "got:", pages)
+	}
+	if want := []bool{false, false, true}; !reflect.DeepEqual(lasts, want) {
+		t.Error("bad lastPage. want:", want, "got:", lasts)
+	}
+
+	client.reqs = 0
+	err = db.ListTables().Pages(ctx, func(tables []string, lastPage bool) bool {
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.reqs != 1 {
+		t.Error("expected paging to stop after 1 request, got:", client.reqs)
+	}
+}
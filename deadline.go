@@ -0,0 +1,62 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AllUntil executes this request and unmarshals results to out, which must be a pointer to a slice,
+// until all results are read or deadline is near.
+// Before each request after the first, it checks whether the slowest request so far could finish before deadline,
+// and if not, stops early instead of letting an in-flight request be canceled.
+// It returns a key you can use with StartFrom to continue this query, which is nil if all results were read.
+// This is useful for HTTP handlers that prefer to return partial results and a cursor instead of timing out.
+// The context's own deadline, if any, is not taken into account, so deadline should be set before it.
+func (q *Query) AllUntil(ctx context.Context, deadline time.Time, out interface{}) (PagingKey, error) {
+	iter := q.newIter(unmarshalAppendTo(out))
+	iter.deadline.at = deadline
+	for iter.Next(ctx, out) {
+	}
+	lek, err := iter.LastEvaluatedKey(ctx)
+	return lek, errors.Join(iter.Err(), err)
+}
+
+// AllUntil executes this request and unmarshals results to out, which must be a pointer to a slice,
+// until all results are read or deadline is near.
+// Before each request after the first, it checks whether the slowest request so far could finish before deadline,
+// and if not, stops early instead of letting an in-flight request be canceled.
+// It returns a key you can use with StartFrom to continue this scan, which is nil if all results were read.
+// This is useful for HTTP handlers that prefer to return partial results and a cursor instead of timing out.
+// The context's own deadline, if any, is not taken into account, so deadline should be set before it.
+func (s *Scan) AllUntil(ctx context.Context, deadline time.Time, out interface{}) (PagingKey, error) {
+	itr := &scanIter{
+		scan:      s,
+		unmarshal: s.unmarshalKeys(unmarshalAppendTo(out)),
+		err:       s.sequentialErr(),
+	}
+	itr.deadline.at = deadline
+	for itr.Next(ctx, out) {
+	}
+	lek, err := itr.LastEvaluatedKey(ctx)
+	return lek, errors.Join(itr.Err(), err)
+}
+
+// pageDeadline stops paging when another request might not finish in time.
+type pageDeadline struct {
+	at time.Time
+	// duration of the slowest request so far
+	slowest time.Duration
+}
+
+// near returns true if the slowest request so far would not finish before the deadline.
+func (d *pageDeadline) near() bool {
+	return !d.at.IsZero() && time.Now().Add(d.slowest).After(d.at)
+}
+
+// observe records the duration of a request that started at start.
+func (d *pageDeadline) observe(start time.Time) {
+	if took := time.Since(start); took > d.slowest {
+		d.slowest = took
+	}
+}
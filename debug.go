@@ -18,7 +18,7 @@ import (
 
 // DumpType dumps a description of x's typedef to stdout.
 func DumpType(x any) {
-	plan, err := typedefOf(reflect.TypeOf(x))
+	plan, err := typedefOf(reflect.TypeOf(x), nil)
 	if err != nil {
 		panic(err)
 	}
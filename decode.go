@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
@@ -14,6 +15,13 @@ type Unmarshaler interface {
 	UnmarshalDynamo(av types.AttributeValue) error
 }
 
+// UnmarshalerCtx is like [Unmarshaler], but also receives the context of the request.
+// This is useful for unmarshalers that need request-scoped data, such as field decryption keys.
+// When unmarshaling without a context (for example, [UnmarshalItem]), [context.Background] is used.
+type UnmarshalerCtx interface {
+	UnmarshalDynamoContext(ctx context.Context, av types.AttributeValue) error
+}
+
 // ItemUnmarshaler is the interface implemented by objects that can unmarshal
 // an Item (a map of strings to AttributeValues) into themselves.
 type ItemUnmarshaler interface {
@@ -22,37 +30,52 @@ type ItemUnmarshaler interface {
 
 // Unmarshal decodes a DynamoDB item into out, which must be a pointer.
 func UnmarshalItem(item Item, out interface{}) error {
-	return unmarshalItem(item, out)
+	return unmarshalItem(context.Background(), item, out)
+}
+
+// UnmarshalItemContext decodes a DynamoDB item into out, which must be a pointer,
+// passing ctx to any values that implement [UnmarshalerCtx].
+func UnmarshalItemContext(ctx context.Context, item Item, out interface{}) error {
+	return unmarshalItem(ctx, item, out)
 }
 
 // Unmarshal decodes a DynamoDB value into out, which must be a pointer.
 func Unmarshal(av types.AttributeValue, out interface{}) error {
+	return UnmarshalContext(context.Background(), av, out)
+}
+
+// UnmarshalContext decodes a DynamoDB value into out, which must be a pointer,
+// passing ctx to any values that implement [UnmarshalerCtx].
+func UnmarshalContext(ctx context.Context, av types.AttributeValue, out interface{}) error {
 	switch out := out.(type) {
 	case awsEncoder:
 		return attributevalue.Unmarshal(av, out.iface)
 	}
 
 	rv := reflect.ValueOf(out)
-	plan, err := typedefOf(rv.Type())
+	plan, err := typedefOf(rv.Type(), nameMapperFrom(ctx))
 	if err != nil {
 		return err
 	}
-	return plan.decodeAttr(flagNone, av, rv)
+	return plan.decodeAttr(ctx, flagNone, av, rv)
 }
 
 // used in iterators for unmarshaling one item
-type unmarshalFunc func(Item, interface{}) error
+type unmarshalFunc func(context.Context, Item, interface{}) error
 
-func unmarshalItem(item Item, out interface{}) error {
+func unmarshalItem(ctx context.Context, item Item, out interface{}) error {
+	if pt, ok := out.(*presenceTracker); ok {
+		return pt.unmarshal(ctx, item)
+	}
 	rv := reflect.ValueOf(out)
-	plan, err := typedefOf(rv.Type())
+	plan, err := typedefOf(rv.Type(), nameMapperFrom(ctx))
 	if err != nil {
 		return err
 	}
-	return plan.decodeItem(item, rv)
+	return plan.decodeItem(ctx, item, rv)
 }
 
-func unmarshalAppend(item Item, out interface{}) error {
+func unmarshalAppend(ctx context.Context, item Item, out interface{}) error {
 	if awsenc, ok := out.(awsEncoder); ok {
 		return unmarshalAppendAWS(item, awsenc.iface)
 	}
@@ -64,7 +87,7 @@ func unmarshalAppend(item Item, out interface{}) error {
 
 	slicev := rv.Elem()
 	innerRV := reflect.New(slicev.Type().Elem())
-	if err := unmarshalItem(item, innerRV.Interface()); err != nil {
+	if err := unmarshalItem(ctx, item, innerRV.Interface()); err != nil {
 		return err
 	}
 	slicev = reflect.Append(slicev, innerRV.Elem())
@@ -73,9 +96,9 @@ func unmarshalAppend(item Item, out interface{}) error {
 	return nil
 }
 
-func unmarshalAppendTo(out interface{}) func(item Item, out interface{}) error {
+func unmarshalAppendTo(out interface{}) unmarshalFunc {
 	if awsenc, ok := out.(awsEncoder); ok {
-		return func(item Item, _ any) error {
+		return func(_ context.Context, item Item, _ any) error {
 			return unmarshalAppendAWS(item, awsenc.iface)
 		}
 	}
@@ -84,14 +107,14 @@ func unmarshalAppendTo(out interface{}) func(item Item, out interface{}) error {
 	slicet := ptr.Type().Elem()
 	membert := slicet.Elem()
 	if ptr.Kind() != reflect.Ptr || slicet.Kind() != reflect.Slice {
-		return func(item Item, _ any) error {
+		return func(_ context.Context, item Item, _ any) error {
 			return fmt.Errorf("dynamo: unmarshal append: result argument must be a slice pointer")
 		}
 	}
 
-	plan, err := typedefOf(membert)
+	plan, err := typedefOf(membert, nil)
 	if err != nil {
-		return func(item Item, _ any) error {
+		return func(_ context.Context, item Item, _ any) error {
 			return err
 		}
 	}
@@ -104,9 +127,16 @@ func unmarshalAppendTo(out interface{}) func(item Item, out interface{}) error {
 				*slice = append(*slice, *member)
 			}
 	*/
-	return func(item map[string]types.AttributeValue, _ any) error {
+	return func(ctx context.Context, item map[string]types.AttributeValue, _ any) error {
+		plan := plan
+		if names := nameMapperFrom(ctx); names != nil {
+			var err error
+			if plan, err = typedefOf(membert, names); err != nil {
+				return err
+			}
+		}
 		member := reflect.New(membert) // *T of *[]T
-		if err := plan.decodeItem(item, member); err != nil {
+		if err := plan.decodeItem(ctx, item, member); err != nil {
 			return err
 		}
 		slice := ptr.Elem()
@@ -115,3 +145,15 @@ func unmarshalAppendTo(out interface{}) func(item Item, out interface{}) error {
 		return nil
 	}
 }
+
+// unmarshalAppendRaw is like unmarshalAppendTo, but also appends each raw item to raw.
+func unmarshalAppendRaw(out interface{}, raw *[]Item) unmarshalFunc {
+	push := unmarshalAppendTo(out)
+	return func(ctx context.Context, item Item, out any) error {
+		if err := push(ctx, item, out); err != nil {
+			return err
+		}
+		*raw = append(*raw, item)
+		return nil
+	}
+}
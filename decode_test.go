@@ -1,7 +1,10 @@
 package dynamo
 
 import (
+	"context"
+	"errors"
 	"maps"
+	"math"
 	"reflect"
 	"strconv"
 	"testing"
@@ -49,6 +52,24 @@ var itemDecodeOnlyTests = []struct {
 			ExportedEmbedded: &ExportedEmbedded{},
 		},
 	},
+	{
+		// BinaryUnmarshalers can still read data stored before they were encoded as binary
+		name: "encoding.BinaryUnmarshaler legacy map",
+		given: Item{
+			"Bin": &types.AttributeValueMemberM{Value: Item{
+				"Lo": &types.AttributeValueMemberN{Value: "1"},
+				"Hi": &types.AttributeValueMemberN{Value: "2"},
+			}},
+			"BinPtr": &types.AttributeValueMemberB{Value: []byte{4, 3}},
+		},
+		expect: struct {
+			Bin    binaryMarshaler
+			BinPtr *binaryMarshaler
+		}{
+			Bin:    binaryMarshaler{Lo: 1, Hi: 2},
+			BinPtr: &binaryMarshaler{Lo: 3, Hi: 4},
+		},
+	},
 }
 
 func TestUnmarshalAsymmetric(t *testing.T) {
@@ -92,7 +113,7 @@ func TestUnmarshalAppend(t *testing.T) {
 		id := 12345 + i
 		idstr := strconv.Itoa(id)
 		item2["UserID"] = &types.AttributeValueMemberN{Value: idstr}
-		err := do(item2, &results)
+		err := do(context.Background(), item2, &results)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -107,7 +128,7 @@ func TestUnmarshalAppend(t *testing.T) {
 	var mapResults []map[string]interface{}
 
 	for range [15]struct{}{} {
-		err := unmarshalAppend(item, &mapResults)
+		err := unmarshalAppend(context.Background(), item, &mapResults)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -125,7 +146,7 @@ func TestUnmarshal(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			rv := reflect.New(reflect.TypeOf(tc.in))
 			// dec := newDecodePlan(rv.Elem())
-			// err := dec.decodeAttr(flagNone, tc.out, rv)
+			// err := dec.decodeAttr(context.Background(), flagNone, tc.out, rv)
 			err := Unmarshal(tc.out, rv.Interface())
 			if err != nil {
 				t.Errorf("%s: unexpected error: %v", tc.name, err)
@@ -143,7 +164,7 @@ func TestUnmarshalItem(t *testing.T) {
 	for _, tc := range itemEncodingTests {
 		t.Run(tc.name, func(t *testing.T) {
 			rv := reflect.New(reflect.TypeOf(tc.in))
-			err := unmarshalItem(tc.out, rv.Interface())
+			err := unmarshalItem(context.Background(), tc.out, rv.Interface())
 			if err != nil {
 				t.Errorf("%s: unexpected error: %v", tc.name, err)
 			}
@@ -255,7 +276,7 @@ func TestUnmarshalClearFields(t *testing.T) {
 func BenchmarkUnmarshalReflect(b *testing.B) {
 	var got widget
 	for i := 0; i < b.N; i++ {
-		unmarshalItem(exampleItem, &got)
+		unmarshalItem(context.Background(), exampleItem, &got)
 	}
 }
 
@@ -263,11 +284,11 @@ func TestDecode3(t *testing.T) {
 	want := exampleWant
 	var got widget
 	rv := reflect.ValueOf(&got)
-	r, err := typedefOf(rv.Type())
+	r, err := typedefOf(rv.Type(), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.decodeItem(exampleItem, rv); err != nil {
+	if err := r.decodeItem(context.Background(), exampleItem, rv); err != nil {
 		t.Error(err)
 	}
 	if !reflect.DeepEqual(want, got) {
@@ -293,3 +314,227 @@ var exampleWant = widget{
 		"Foo": "1336",
 	},
 }
+
+func TestUnmarshalOverflow(t *testing.T) {
+	num := func(n string) Item {
+		return Item{"N": &types.AttributeValueMemberN{Value: n}}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		tests := []struct {
+			item Item
+			out  any
+		}{
+			{num("128"), new(struct{ N int8 })},
+			{num("-129"), new(struct{ N int8 })},
+			{num("99999999999999999999"), new(struct{ N int64 })},
+			{num("256"), new(struct{ N uint8 })},
+			{num("-1"), new(struct{ N uint })},
+			{num("1e39"), new(struct{ N float32 })},
+		}
+		for _, tc := range tests {
+			err := UnmarshalItem(tc.item, tc.out)
+			var oe *OverflowError
+			if !errors.As(err, &oe) {
+				t.Errorf("%v into %T: want OverflowError, got: %v", tc.item["N"], tc.out, err)
+			}
+		}
+	})
+
+	t.Run("saturate", func(t *testing.T) {
+		type saturated struct {
+			I8  int8    `dynamo:",saturate"`
+			I64 int64   `dynamo:",saturate"`
+			U8  uint8   `dynamo:",saturate"`
+			U   uint    `dynamo:",saturate"`
+			F32 float32 `dynamo:",saturate"`
+		}
+		item := Item{
+			"I8":  &types.AttributeValueMemberN{Value: "-1000"},
+			"I64": &types.AttributeValueMemberN{Value: "99999999999999999999"},
+			"U8":  &types.AttributeValueMemberN{Value: "1000"},
+			"U":   &types.AttributeValueMemberN{Value: "-5"},
+			"F32": &types.AttributeValueMemberN{Value: "-1e39"},
+		}
+		want := saturated{
+			I8:  math.MinInt8,
+			I64: math.MaxInt64,
+			U8:  math.MaxUint8,
+			U:   0,
+			F32: -math.MaxFloat32,
+		}
+		var got saturated
+		if err := UnmarshalItem(item, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("bad saturated result. want: %+v, got: %+v", want, got)
+		}
+	})
+
+	t.Run("negative zero", func(t *testing.T) {
+		out := struct{ N uint }{N: 1}
+		if err := UnmarshalItem(num("-0"), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.N != 0 {
+			t.Error("want 0, got:", out.N)
+		}
+	})
+}
+
+func TestUnmarshalStringOption(t *testing.T) {
+	type lenient struct {
+		Int   int     `dynamo:",string"`
+		Uint  uint8   `dynamo:",string"`
+		Float float64 `dynamo:",string"`
+		Str   string  `dynamo:",string"`
+	}
+
+	item := Item{
+		"Int":   &types.AttributeValueMemberS{Value: "-42"},
+		"Uint":  &types.AttributeValueMemberN{Value: "7"},
+		"Float": &types.AttributeValueMemberS{Value: " 1.5 "},
+		"Str":   &types.AttributeValueMemberN{Value: "123"},
+	}
+	want := lenient{Int: -42, Uint: 7, Float: 1.5, Str: "123"}
+	var got lenient
+	if err := UnmarshalItem(item, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("bad result. want: %+v, got: %+v", want, got)
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		out, err := MarshalItem(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := Item{
+			"Int":   &types.AttributeValueMemberS{Value: "-42"},
+			"Uint":  &types.AttributeValueMemberS{Value: "7"},
+			"Float": &types.AttributeValueMemberS{Value: "1.5"},
+			"Str":   &types.AttributeValueMemberS{Value: "123"},
+		}
+		if !reflect.DeepEqual(out, expect) {
+			t.Errorf("bad marshal. want: %#v, got: %#v", expect, out)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		var strict struct {
+			Int int
+			Str string
+		}
+		if err := UnmarshalItem(Item{"Int": &types.AttributeValueMemberS{Value: "1"}}, &strict); err == nil {
+			t.Error("expected error unmarshaling S into int without string option")
+		}
+		if err := UnmarshalItem(Item{"Str": &types.AttributeValueMemberN{Value: "1"}}, &strict); err == nil {
+			t.Error("expected error unmarshaling N into string without string option")
+		}
+	})
+}
+
+func TestNumberOption(t *testing.T) {
+	type exact struct {
+		ID    Number `dynamo:",hash"`
+		Price string `dynamo:",number"`
+		Big   Number
+		Sci   *Number
+		Empty Number
+	}
+
+	sci := Number("1.50E+3")
+	item := Item{
+		"ID":    &types.AttributeValueMemberN{Value: "12345678901234567890123456789012345678"},
+		"Price": &types.AttributeValueMemberN{Value: "19.900"},
+		"Big":   &types.AttributeValueMemberN{Value: "-0.000000000000000000000000000000000001"},
+		"Sci":   &types.AttributeValueMemberN{Value: "1.50E+3"},
+	}
+	want := exact{
+		ID:    "12345678901234567890123456789012345678",
+		Price: "19.900",
+		Big:   "-0.000000000000000000000000000000000001",
+		Sci:   &sci,
+	}
+	var got exact
+	if err := UnmarshalItem(item, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad unmarshal. want: %+v, got: %+v", want, got)
+	}
+
+	out, err := MarshalItem(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, item) {
+		t.Errorf("bad marshal. want: %#v, got: %#v", item, out)
+	}
+
+	ct := NewFromIface(nil).CreateTable("Number", exact{})
+	for _, attr := range ct.input().AttributeDefinitions {
+		if *attr.AttributeName == "ID" && attr.AttributeType != types.ScalarAttributeTypeN {
+			t.Error("Number hash key should be a number. got:", attr.AttributeType)
+		}
+	}
+}
+
+func TestKeyFmtOption(t *testing.T) {
+	type padded struct {
+		ID    string `dynamo:",hash"`
+		Seq   int64  `dynamo:",range,keyfmt=%012d"`
+		Small uint8  `dynamo:",keyfmt=%03d"`
+	}
+
+	want := padded{ID: "a", Seq: 42, Small: 7}
+	item, err := MarshalItem(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := Item{
+		"ID":    &types.AttributeValueMemberS{Value: "a"},
+		"Seq":   &types.AttributeValueMemberS{Value: "000000000042"},
+		"Small": &types.AttributeValueMemberS{Value: "007"},
+	}
+	if !reflect.DeepEqual(item, expect) {
+		t.Errorf("bad marshal. want: %#v, got: %#v", expect, item)
+	}
+
+	var got padded
+	if err := UnmarshalItem(item, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("bad unmarshal. want: %+v, got: %+v", want, got)
+	}
+
+	if _, err := MarshalItem(padded{Seq: -1}); err == nil {
+		t.Error("expected error marshaling negative number")
+	}
+	if _, err := MarshalItem(padded{Seq: 1e12}); err == nil {
+		t.Error("expected error marshaling number wider than format")
+	}
+
+	ct := NewFromIface(nil).CreateTable("KeyFmt", padded{})
+	for _, attr := range ct.input().AttributeDefinitions {
+		if *attr.AttributeName == "Seq" && attr.AttributeType != types.ScalarAttributeTypeS {
+			t.Error("keyfmt range key should be a string. got:", attr.AttributeType)
+		}
+	}
+	type invalid struct {
+		ID  string `dynamo:",hash"`
+		Seq int64  `dynamo:",range,keyfmt=%12d"`
+	}
+	if _, err := MarshalItem(invalid{}); err == nil {
+		t.Error("expected error marshaling invalid keyfmt format")
+	}
+	if err := UnmarshalItem(item, &invalid{}); err == nil {
+		t.Error("expected error unmarshaling invalid keyfmt format")
+	}
+	if err := NewFromIface(nil).CreateTable("KeyFmt", invalid{}).Run(context.Background()); err == nil {
+		t.Error("expected error creating table with invalid keyfmt format")
+	}
+}
@@ -1,17 +1,22 @@
 package dynamo
 
 import (
+	"context"
+	"encoding"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-type decodeFunc func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error
+type decodeFunc func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error
 
-func decodePtr(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodePtr(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 	var elem reflect.Value
 	if rv.IsNil() {
 		if rv.CanSet() {
@@ -23,16 +28,22 @@ func decodePtr(plan *typedef, flags encodeFlags, av types.AttributeValue, rv ref
 	} else {
 		elem = rv.Elem()
 	}
-	if err := plan.decodeAttr(flags, av, elem); err != nil {
+	if err := plan.decodeAttr(ctx, flags, av, elem); err != nil {
 		return err
 	}
 	return nil
 }
 
-func decodeNull(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeNull(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 	if !rv.IsValid() {
 		return nil
 	}
+	if rv.CanAddr() && rv.Addr().CanInterface() {
+		if nd, ok := rv.Addr().Interface().(nullDecoder); ok {
+			nd.decodeNull()
+			return nil
+		}
+	}
 	if rv.CanSet() {
 		rv.SetZero()
 		return nil
@@ -40,54 +51,141 @@ func decodeNull(plan *typedef, flags encodeFlags, av types.AttributeValue, rv re
 	return nil
 }
 
-func decodeString(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeString(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	v.SetString(av.(*types.AttributeValueMemberS).Value)
 	return nil
 }
 
-func decodeInt(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
-	n, err := strconv.ParseInt(av.(*types.AttributeValueMemberN).Value, 10, 64)
-	if err != nil {
+func decodeInt(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	str := av.(*types.AttributeValueMemberN).Value
+	n, err := strconv.ParseInt(str, 10, 64)
+	overflow := errors.Is(err, strconv.ErrRange)
+	if err != nil && !overflow {
 		return err
 	}
+	if overflow || v.OverflowInt(n) {
+		if flags&flagSaturate == 0 {
+			return &OverflowError{Number: str, Type: v.Type()}
+		}
+		bits := v.Type().Bits()
+		if strings.HasPrefix(str, "-") {
+			n = -1 << (bits - 1)
+		} else {
+			n = 1<<(bits-1) - 1
+		}
+	}
 	v.SetInt(n)
 	return nil
 }
 
-func decodeUint(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
-	n, err := strconv.ParseUint(av.(*types.AttributeValueMemberN).Value, 10, 64)
-	if err != nil {
+func decodeUint(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	str := av.(*types.AttributeValueMemberN).Value
+	if strings.HasPrefix(str, "-") {
+		// negative numbers are out of range, unless they are malformed or negative zero
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return err
+		}
+		if err == nil && n == 0 {
+			v.SetUint(0)
+			return nil
+		}
+		if flags&flagSaturate == 0 {
+			return &OverflowError{Number: str, Type: v.Type()}
+		}
+		v.SetUint(0)
+		return nil
+	}
+	n, err := strconv.ParseUint(str, 10, 64)
+	overflow := errors.Is(err, strconv.ErrRange)
+	if err != nil && !overflow {
 		return err
 	}
+	if overflow || v.OverflowUint(n) {
+		if flags&flagSaturate == 0 {
+			return &OverflowError{Number: str, Type: v.Type()}
+		}
+		n = 1<<v.Type().Bits() - 1
+	}
 	v.SetUint(n)
 	return nil
 }
 
-func decodeFloat(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
-	f, err := strconv.ParseFloat(av.(*types.AttributeValueMemberN).Value, 64)
-	if err != nil {
+func decodeFloat(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	str := av.(*types.AttributeValueMemberN).Value
+	f, err := strconv.ParseFloat(str, 64)
+	overflow := errors.Is(err, strconv.ErrRange)
+	if err != nil && !overflow {
 		return err
 	}
+	if overflow || v.OverflowFloat(f) {
+		if flags&flagSaturate == 0 {
+			return &OverflowError{Number: str, Type: v.Type()}
+		}
+		max := math.MaxFloat64
+		if v.Kind() == reflect.Float32 {
+			max = math.MaxFloat32
+		}
+		f = math.Copysign(max, f)
+	}
 	v.SetFloat(f)
 	return nil
 }
 
-func decodeBool(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+// OverflowError is returned when unmarshaling a number that does not fit into the destination type.
+// Use the "saturate" struct tag option to clamp out of range values instead.
+type OverflowError struct {
+	// Number is the original number value.
+	Number string
+	// Type is the type that the number was being unmarshaled into.
+	Type reflect.Type
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("dynamo: cannot unmarshal number %s into type %s: value out of range", e.Number, e.Type.String())
+}
+
+// decodeStringAsNumber decodes S into a number type if the "string" option is set.
+func decodeStringAsNumber(decodeN decodeFunc) decodeFunc {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+		if flags&flagString == 0 {
+			return errUnmarshalType(ctx, av, v.Type())
+		}
+		str := strings.TrimSpace(av.(*types.AttributeValueMemberS).Value)
+		return decodeN(ctx, plan, flags, &types.AttributeValueMemberN{Value: str}, v)
+	}
+}
+
+// decodeNumberAsString decodes N into a string type if the "string" or "number" option is set,
+// or the type is Number.
+func decodeNumberAsString(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	if flags&(flagString|flagNumber) == 0 && v.Type() != rtypeNumber {
+		return errUnmarshalType(ctx, av, v.Type())
+	}
+	v.SetString(av.(*types.AttributeValueMemberN).Value)
+	return nil
+}
+
+func decodeBool(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	v.SetBool(av.(*types.AttributeValueMemberBOOL).Value)
 	return nil
 }
 
-func decodeBytes(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeBytes(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	v.SetBytes(av.(*types.AttributeValueMemberB).Value)
 	return nil
 }
 
-func decodeSliceL(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+var decodeBinaryUnmarshaler = decode2(func(t encoding.BinaryUnmarshaler, av types.AttributeValue) error {
+	return t.UnmarshalBinary(av.(*types.AttributeValueMemberB).Value)
+})
+
+func decodeSliceL(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	list := av.(*types.AttributeValueMemberL).Value
 	reallocSlice(v, len(list))
 	for i, innerAV := range list {
 		innerRV := v.Index(i).Addr()
-		if err := plan.decodeAttr(flags, innerAV, innerRV); err != nil {
+		if err := plan.decodeAttr(ctx, flags, innerAV, innerRV); err != nil {
 			return err
 		}
 		// debugf("slice[i=%d] %#v <- %v", i, v.Index(i).Interface(), innerAV)
@@ -105,43 +203,43 @@ func decodeSliceL(plan *typedef, flags encodeFlags, av types.AttributeValue, v r
 // 	return nil
 // }
 
-func decodeSliceBS(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeSliceBS(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	set := av.(*types.AttributeValueMemberBS).Value
 	reallocSlice(v, len(set))
 	for i, b := range set {
 		innerRV := v.Index(i).Addr()
-		if err := plan.decodeAttr(flags, &types.AttributeValueMemberB{Value: b}, innerRV); err != nil {
+		if err := plan.decodeAttr(ctx, flags, &types.AttributeValueMemberB{Value: b}, innerRV); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func decodeSliceSS(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeSliceSS(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	set := av.(*types.AttributeValueMemberSS).Value
 	reallocSlice(v, len(set))
 	for i, s := range set {
 		innerRV := v.Index(i).Addr()
-		if err := plan.decodeAttr(flags, &types.AttributeValueMemberS{Value: s}, innerRV); err != nil {
+		if err := plan.decodeAttr(ctx, flags, &types.AttributeValueMemberS{Value: s}, innerRV); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func decodeSliceNS(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeSliceNS(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	set := av.(*types.AttributeValueMemberNS).Value
 	reallocSlice(v, len(set))
 	for i, n := range set {
 		innerRV := v.Index(i).Addr()
-		if err := plan.decodeAttr(flags, &types.AttributeValueMemberN{Value: n}, innerRV); err != nil {
+		if err := plan.decodeAttr(ctx, flags, &types.AttributeValueMemberN{Value: n}, innerRV); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func decodeArrayB(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeArrayB(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	bs := av.(*types.AttributeValueMemberB).Value
 	if len(bs) > v.Len() {
 		return fmt.Errorf("dynamo: cannot marshal %s into %s; too small (dst len: %d, src len: %d)", avTypeName(av), v.Type().String(), v.Len(), len(bs))
@@ -152,33 +250,36 @@ func decodeArrayB(plan *typedef, flags encodeFlags, av types.AttributeValue, v r
 	return nil
 }
 
-func decodeArrayL(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeArrayL(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	list := av.(*types.AttributeValueMemberL).Value
 	if len(list) > v.Len() {
 		return fmt.Errorf("dynamo: cannot marshal %s into %s; too small (dst len: %d, src len: %d)", avTypeName(av), v.Type().String(), v.Len(), len(list))
 	}
 	for i, innerAV := range list {
-		if err := plan.decodeAttr(flags, innerAV, v.Index(i)); err != nil {
+		if err := plan.decodeAttr(ctx, flags, innerAV, v.Index(i)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func decodeStruct(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeStruct(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 	m := av.(*types.AttributeValueMemberM).Value
-	return visitFields(m, rv, nil, func(av types.AttributeValue, flags encodeFlags, v reflect.Value) error {
+	return visitFields(m, rv, plan.names, nil, func(av types.AttributeValue, flags encodeFlags, v reflect.Value) error {
+		if flags&flagWriteOnly != 0 {
+			return nil
+		}
 		if av == nil {
 			if v.CanSet() && !nullish(v) {
 				v.SetZero()
 			}
 			return nil
 		}
-		return plan.decodeAttr(flags, av, v)
+		return plan.decodeAttr(ctx, flags, av, v)
 	})
 }
 
-func decodeMap(decodeKey func(reflect.Value, string) error) func(plan *typedef, _ encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeMap(decodeKey func(reflect.Value, string) error) func(ctx context.Context, plan *typedef, _ encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	/*
 		Something like:
 
@@ -193,17 +294,17 @@ func decodeMap(decodeKey func(reflect.Value, string) error) func(plan *typedef,
 				out[*kp] = *vp
 			}
 	*/
-	return func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 		m := av.(*types.AttributeValueMemberM).Value
 		reallocMap(rv, len(m))
 		kp := reflect.New(rv.Type().Key())
 		for name, v := range m {
 			if err := decodeKey(kp, name); err != nil {
-				return fmt.Errorf("error decoding key %q into %v", name, kp.Type().Elem())
+				return fmt.Errorf("error decoding key %s into %v", formatName(name, errorValueLimit(ctx)), kp.Type().Elem())
 			}
 			innerRV := reflect.New(rv.Type().Elem())
-			if err := plan.decodeAttr(flags, v, innerRV.Elem()); err != nil {
-				return fmt.Errorf("error decoding map entry %q (%s) into type %v", name, avTypeName(v), innerRV.Type().Elem())
+			if err := plan.decodeAttr(ctx, flags, v, innerRV.Elem()); err != nil {
+				return fmt.Errorf("error decoding map entry %s (%s) into type %v", formatName(name, errorValueLimit(ctx)), avTypeName(v), innerRV.Type().Elem())
 			}
 			rv.SetMapIndex(kp.Elem(), innerRV.Elem())
 		}
@@ -211,8 +312,8 @@ func decodeMap(decodeKey func(reflect.Value, string) error) func(plan *typedef,
 	}
 }
 
-func decodeMapSS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
-	return func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeMapSS(decodeKey decodeKeyFunc, truthy reflect.Value) func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 		set := av.(*types.AttributeValueMemberSS).Value
 		reallocMap(rv, len(set))
 		kp := reflect.New(rv.Type().Key())
@@ -226,13 +327,13 @@ func decodeMapSS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typed
 	}
 }
 
-func decodeMapNS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
-	return func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeMapNS(decodeKey decodeKeyFunc, truthy reflect.Value) func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 		set := av.(*types.AttributeValueMemberNS).Value
 		reallocMap(rv, len(set))
 		kv := reflect.New(rv.Type().Key()).Elem()
 		for _, n := range set {
-			if err := plan.decodeAttr(flagNone, &types.AttributeValueMemberN{Value: n}, kv); err != nil {
+			if err := plan.decodeAttr(ctx, flagNone, &types.AttributeValueMemberN{Value: n}, kv); err != nil {
 				return err
 			}
 			rv.SetMapIndex(kv, truthy)
@@ -240,8 +341,8 @@ func decodeMapNS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typed
 		return nil
 	}
 }
-func decodeMapBS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
-	return func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeMapBS(decodeKey decodeKeyFunc, truthy reflect.Value) func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 		set := av.(*types.AttributeValueMemberBS).Value
 		reallocMap(rv, len(set))
 		kv := reflect.New(rv.Type().Key()).Elem()
@@ -253,8 +354,14 @@ func decodeMapBS(decodeKey decodeKeyFunc, truthy reflect.Value) func(plan *typed
 	}
 }
 
-func decode2[T any](fn func(t T, av types.AttributeValue) error) func(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
-	return func(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decode2[T any](fn func(t T, av types.AttributeValue) error) func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	return decode2ctx(func(_ context.Context, t T, av types.AttributeValue) error {
+		return fn(t, av)
+	})
+}
+
+func decode2ctx[T any](fn func(ctx context.Context, t T, av types.AttributeValue) error) func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+	return func(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 		if !rv.CanInterface() {
 			return nil
 		}
@@ -271,11 +378,11 @@ func decode2[T any](fn func(t T, av types.AttributeValue) error) func(plan *type
 			}
 			value = rv.Interface()
 		}
-		return fn(value.(T), av)
+		return fn(ctx, value.(T), av)
 	}
 }
 
-func decodeAny(plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
+func decodeAny(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, v reflect.Value) error {
 	iface, err := av2iface(av)
 	if err != nil {
 		return err
@@ -288,7 +395,7 @@ func decodeAny(plan *typedef, flags encodeFlags, av types.AttributeValue, v refl
 	return nil
 }
 
-func decodeUnixTime(plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func decodeUnixTime(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 	rv = indirect(rv)
 
 	ts, err := strconv.ParseInt(av.(*types.AttributeValueMemberN).Value, 10, 64)
@@ -0,0 +1,84 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DecodeHook is a function consulted before the standard decoding of every attribute value,
+// allowing for app-wide conversions without implementing Unmarshaler for each type.
+// Target is the type being decoded into.
+// If handled is true, the returned value (which must be assignable or convertible to target) is used as the result
+// and standard decoding is skipped. A nil value sets the target to its zero value.
+// If handled is false, decoding proceeds as usual.
+//
+// For pointer types, the hook is called for the pointer type first and then for its element type.
+type DecodeHook func(av types.AttributeValue, target reflect.Type) (value any, handled bool, err error)
+
+var decodeHook atomic.Pointer[DecodeHook]
+
+// SetDecodeHook sets the package-wide decode hook used when unmarshaling.
+// Pass nil to remove it.
+// It is safe to call SetDecodeHook concurrently with unmarshaling, but items being unmarshaled at the time
+// may have some attributes decoded with the old hook and some with the new one, so it is best set during initialization.
+func SetDecodeHook(hook DecodeHook) {
+	if hook == nil {
+		decodeHook.Store(nil)
+		return
+	}
+	decodeHook.Store(&hook)
+}
+
+// decodeHooked runs the decode hook if it is set, returning true if it handled the value.
+func decodeHooked(av types.AttributeValue, rv reflect.Value) (bool, error) {
+	hook := decodeHook.Load()
+	if hook == nil {
+		return false, nil
+	}
+
+	target := rv
+	for !target.CanSet() && target.Kind() == reflect.Pointer && !target.IsNil() {
+		target = target.Elem()
+	}
+	if !target.CanSet() {
+		return false, nil
+	}
+
+	rt := target.Type()
+	for {
+		v, ok, err := (*hook)(av, rt)
+		if err != nil {
+			return true, err
+		}
+		if ok {
+			for target.Type() != rt {
+				target = indirect(target)
+			}
+			return true, setHooked(target, v)
+		}
+		if rt.Kind() != reflect.Pointer {
+			return false, nil
+		}
+		rt = rt.Elem()
+	}
+}
+
+func setHooked(rv reflect.Value, v any) error {
+	if v == nil {
+		rv.SetZero()
+		return nil
+	}
+	x := reflect.ValueOf(v)
+	switch {
+	case x.Type().AssignableTo(rv.Type()):
+		rv.Set(x)
+	case x.Type().ConvertibleTo(rv.Type()):
+		rv.Set(x.Convert(rv.Type()))
+	default:
+		return fmt.Errorf("dynamo: decode hook returned value of type %T, which cannot be assigned to %s", v, rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestDecodeHook(t *testing.T) {
+	SetDecodeHook(func(av types.AttributeValue, target reflect.Type) (any, bool, error) {
+		s, ok := av.(*types.AttributeValueMemberS)
+		if !ok || target != rtypeTime {
+			return nil, false, nil
+		}
+		ts, err := time.Parse(time.RFC1123, s.Value)
+		if err != nil {
+			// not RFC1123, fall back to standard decoding
+			return nil, false, nil
+		}
+		return ts, true, nil
+	})
+	defer SetDecodeHook(nil)
+
+	type event struct {
+		Time    time.Time
+		Ptr     *time.Time
+		Regular time.Time
+		Name    string
+	}
+	rfc1123 := "Mon, 02 Jan 2006 15:04:05 UTC"
+	want, err := time.Parse(time.RFC1123, rfc1123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regular := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	item := Item{
+		"Time":    &types.AttributeValueMemberS{Value: rfc1123},
+		"Ptr":     &types.AttributeValueMemberS{Value: rfc1123},
+		"Regular": &types.AttributeValueMemberS{Value: regular.Format(time.RFC3339)},
+		"Name":    &types.AttributeValueMemberS{Value: rfc1123},
+	}
+	var got event
+	if err := UnmarshalItem(item, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Time.Equal(want) {
+		t.Error("bad time. want:", want, "got:", got.Time)
+	}
+	if got.Ptr == nil || !got.Ptr.Equal(want) {
+		t.Error("bad pointer time. want:", want, "got:", got.Ptr)
+	}
+	if !got.Regular.Equal(regular) {
+		t.Error("bad regular time. want:", regular, "got:", got.Regular)
+	}
+	if got.Name != rfc1123 {
+		t.Error("bad name:", got.Name)
+	}
+}
@@ -2,7 +2,6 @@ package dynamo
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -24,6 +23,8 @@ type Delete struct {
 
 	subber
 	condition string
+	modify    func(*dynamodb.DeleteItemInput)
+	optFns    []func(*dynamodb.Options)
 
 	err error
 	cc  *ConsumedCapacity
@@ -36,11 +37,10 @@ func (table Table) Delete(name string, value interface{}) *Delete {
 	d := &Delete{
 		table:   table,
 		hashKey: name,
+		subber:  subber{db: table.db},
 	}
-	d.hashValue, d.err = marshal(value, flagNone)
-	if d.hashValue == nil {
-		d.setError(fmt.Errorf("dynamo: delete hash key value is nil or omitted for attribute %q", d.hashKey))
-	}
+	d.hashValue, d.err = table.marshalKey("delete hash", name, value)
+	table.interceptDelete(d)
 	return d
 }
 
@@ -50,11 +50,8 @@ func (table Table) Delete(name string, value interface{}) *Delete {
 func (d *Delete) Range(name string, value interface{}) *Delete {
 	var err error
 	d.rangeKey = name
-	d.rangeValue, err = marshal(value, flagNone)
+	d.rangeValue, err = d.table.marshalKey("delete range", name, value)
 	d.setError(err)
-	if d.rangeValue == nil {
-		d.setError(fmt.Errorf("dynamo: delete range key value is nil or omitted for attribute %q", d.rangeKey))
-	}
 	return d
 }
 
@@ -73,6 +70,22 @@ func (d *Delete) If(expr string, args ...interface{}) *Delete {
 	return d
 }
 
+// ModifyInput sets a function that is called with the input of the DeleteItem request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+// It is not called when this delete is part of a transaction.
+func (d *Delete) ModifyInput(fn func(*dynamodb.DeleteItemInput)) *Delete {
+	d.modify = fn
+	return d
+}
+
+// RequestOptions sets options that are applied to each request made by this delete, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// It has no effect when this delete is part of a transaction.
+func (d *Delete) RequestOptions(optFns ...func(*dynamodb.Options)) *Delete {
+	d.optFns = optFns
+	return d
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (d *Delete) ConsumedCapacity(cc *ConsumedCapacity) *Delete {
 	d.cc = cc
@@ -97,7 +110,7 @@ func (d *Delete) OldValue(ctx context.Context, out interface{}) error {
 	case output.Attributes == nil:
 		return ErrNotFound
 	}
-	return unmarshalItem(output.Attributes, out)
+	return d.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // CurrentValue executes this delete.
@@ -113,7 +126,7 @@ func (d *Delete) CurrentValue(ctx context.Context, out interface{}) (wrote bool,
 	d.onCondFail = types.ReturnValuesOnConditionCheckFailureAllOld
 	_, err = d.run(ctx)
 	if err != nil {
-		if ok, err := UnmarshalItemFromCondCheckFailed(err, out); ok {
+		if ok, err := unmarshalItemFromCondCheckFailed(ctx, err, out, d.table.unmarshalItem); ok {
 			return false, err
 		}
 		return false, err
@@ -138,10 +151,20 @@ func (d *Delete) run(ctx context.Context) (*dynamodb.DeleteItemOutput, error) {
 	}
 
 	input := d.deleteInput()
+	var err error
+	if input.ExpressionAttributeValues, err = d.values(ctx); err != nil {
+		return nil, err
+	}
+	if d.modify != nil {
+		d.modify(input)
+	}
+	if err := d.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return nil, err
+	}
 	var output *dynamodb.DeleteItemOutput
-	err := d.table.db.retry(ctx, func() error {
+	err = d.table.db.retry(ctx, func() error {
 		var err error
-		output, err = d.table.db.client.DeleteItem(ctx, input)
+		output, err = d.table.db.client.DeleteItem(ctx, input, d.table.db.requestOptions(d.optFns)...)
 		d.cc.incRequests()
 		return err
 	})
@@ -169,11 +192,15 @@ func (d *Delete) deleteInput() *dynamodb.DeleteItemInput {
 	return input
 }
 
-func (d *Delete) writeTxItem() (*types.TransactWriteItem, error) {
+func (d *Delete) writeTxItem(ctx context.Context) (*types.TransactWriteItem, error) {
 	if d.err != nil {
 		return nil, d.err
 	}
 	input := d.deleteInput()
+	var err error
+	if input.ExpressionAttributeValues, err = d.values(ctx); err != nil {
+		return nil, err
+	}
 	item := &types.TransactWriteItem{
 		Delete: &types.Delete{
 			TableName:                           input.TableName,
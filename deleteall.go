@@ -0,0 +1,133 @@
+package dynamo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DeleteAll deletes every item matching this query, returning the number of items deleted.
+// Only the primary keys of matching items are read, then they are deleted using BatchWriteItem
+// in batches of up to 25, backing off and retrying unprocessed items when throttled (see [BatchWrite.Run]).
+// Filters are evaluated when reading, so an item that changes to no longer match after it is read will still be deleted;
+// use [Query.DeleteAllChecked] to avoid this.
+// Limit and SearchLimit bound the number of items read as usual.
+//
+// If an error occurs, some items may have been deleted and others not.
+// Deletes are not atomic; for that, use a transaction (see [DB.WriteTx]).
+func (q *Query) DeleteAll(ctx context.Context) (deleted int, err error) {
+	return q.deleteAll(ctx, false)
+}
+
+// DeleteAllChecked is like [Query.DeleteAll], but deletes items one by one with DeleteItem,
+// using this query's filters as the condition of each delete.
+// Items that were changed by someone else to no longer match after they were read are skipped instead of deleted.
+// This uses more write requests than DeleteAll, so prefer DeleteAll when items aren't concurrently modified.
+func (q *Query) DeleteAllChecked(ctx context.Context) (deleted int, err error) {
+	return q.deleteAll(ctx, true)
+}
+
+func (q *Query) deleteAll(ctx context.Context, checked bool) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []Item
+	iter := q.keysOnly(desc).newIter(func(_ context.Context, item Item, _ any) error {
+		pending = append(pending, item)
+		return nil
+	})
+
+	deleted := 0
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		var n int
+		var err error
+		if checked {
+			n, err = q.deleteChecked(ctx, desc, pending)
+		} else {
+			n, err = q.deleteBatch(ctx, desc, pending)
+		}
+		deleted += n
+		pending = pending[:0]
+		return err
+	}
+	for iter.Next(ctx, nil) {
+		if len(pending) < maxWriteOps {
+			continue
+		}
+		if err := flush(); err != nil {
+			return deleted, err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// keysOnly returns a copy of this query that only reads the primary keys of matching items.
+func (q *Query) keysOnly(desc Description) *Query {
+	keys := desc.keys("")
+	read := *q
+	read.modify = func(input *dynamodb.QueryInput) {
+		if q.modify != nil {
+			q.modify(input)
+		}
+		projection, names := keysProjection(input.FilterExpression, input.ExpressionAttributeNames, keys)
+		input.ProjectionExpression = &projection
+		input.ExpressionAttributeNames = names
+	}
+	return &read
+}
+
+// filterCond returns this query's filters as a condition expression, or nil if there are none.
+func (q *Query) filterCond() *ExpressionLiteral {
+	if len(q.filters) == 0 {
+		return nil
+	}
+	filter := strings.Join(q.filters, " AND ")
+	var used subber
+	used.nameExpr, used.valueExpr = usedPlaceholders(filter, q.nameExpr, q.valueExpr)
+	lit := used.literal(filter)
+	return &lit
+}
+
+func (q *Query) deleteBatch(ctx context.Context, desc Description, items []Item) (int, error) {
+	wr := q.table.Batch(desc.HashKey, desc.RangeKey).Write().ConsumedCapacity(q.cc)
+	for _, item := range items {
+		wr.Delete(Keys{item[desc.HashKey], item[desc.RangeKey]})
+	}
+	return wr.Run(ctx)
+}
+
+func (q *Query) deleteChecked(ctx context.Context, desc Description, items []Item) (int, error) {
+	cond := q.filterCond()
+	deleted := 0
+	for _, item := range items {
+		del := q.table.Delete(desc.HashKey, item[desc.HashKey]).ConsumedCapacity(q.cc)
+		if desc.RangeKey != "" {
+			del.Range(desc.RangeKey, item[desc.RangeKey])
+		}
+		if cond != nil {
+			del.If("?", *cond)
+		}
+		err := del.Run(ctx)
+		if IsCondCheckFailed(err) {
+			// changed since it was read
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
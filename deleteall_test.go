@@ -0,0 +1,116 @@
+package dynamo
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// deleteAllClient is a table keyed by User and Seq, with 30 items for user 1 in pages of 10.
+// Conditional deletes of Seq 3 fail, as if it were concurrently modified.
+type deleteAllClient struct {
+	dynamodbiface.DynamoDBAPI
+	queries []*dynamodb.QueryInput
+	batches int
+	deletes []*dynamodb.DeleteItemInput
+	deleted map[string]bool
+}
+
+func (c *deleteAllClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName: aws.String("Events"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("User"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("Seq"), KeyType: types.KeyTypeRange},
+		},
+	}}, nil
+}
+
+func (c *deleteAllClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, in)
+	start := 0
+	if in.ExclusiveStartKey != nil {
+		start, _ = strconv.Atoi(in.ExclusiveStartKey["Seq"].(*types.AttributeValueMemberN).Value)
+	}
+	out := &dynamodb.QueryOutput{}
+	for seq := start + 1; seq <= start+10; seq++ {
+		out.Items = append(out.Items, Item{
+			"User": &types.AttributeValueMemberN{Value: "1"},
+			"Seq":  &types.AttributeValueMemberN{Value: strconv.Itoa(seq)},
+		})
+	}
+	if start+10 < 30 {
+		out.LastEvaluatedKey = out.Items[len(out.Items)-1]
+	}
+	return out, nil
+}
+
+func (c *deleteAllClient) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.batches++
+	for _, req := range in.RequestItems["Events"] {
+		c.deleted[req.DeleteRequest.Key["Seq"].(*types.AttributeValueMemberN).Value] = true
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *deleteAllClient) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.deletes = append(c.deletes, in)
+	seq := in.Key["Seq"].(*types.AttributeValueMemberN).Value
+	if seq == "3" {
+		return nil, &smithy.GenericAPIError{Code: "ConditionalCheckFailedException"}
+	}
+	c.deleted[seq] = true
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestQueryDeleteAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("batch", func(t *testing.T) {
+		client := &deleteAllClient{deleted: make(map[string]bool)}
+		table := NewFromIface(client).Table("Events")
+		deleted, err := table.Get("User", 1).Project("Payload").DeleteAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 30 || len(client.deleted) != 30 {
+			t.Error("bad delete count:", deleted, len(client.deleted))
+		}
+		if client.batches != 2 {
+			t.Error("want 2 batches, got:", client.batches)
+		}
+		in := client.queries[0]
+		if got, want := *in.ProjectionExpression, "#s"+encodeName("Seq")+", #s"+encodeName("User"); got != want {
+			t.Error("bad projection:", got)
+		}
+		if len(in.ExpressionAttributeNames) != 2 {
+			t.Error("unused names:", in.ExpressionAttributeNames)
+		}
+	})
+
+	t.Run("checked", func(t *testing.T) {
+		client := &deleteAllClient{deleted: make(map[string]bool)}
+		table := NewFromIface(client).Table("Events")
+		deleted, err := table.Get("User", 1).Filter("Kind = ?", "temp").DeleteAllChecked(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 29 || client.deleted["3"] {
+			t.Error("bad delete count:", deleted, client.deleted)
+		}
+		if client.batches != 0 || len(client.deletes) != 30 {
+			t.Error("unexpected requests:", client.batches, len(client.deletes))
+		}
+		del := client.deletes[0]
+		if del.ConditionExpression == nil || len(del.ExpressionAttributeValues) != 1 {
+			t.Error("bad condition:", del.ConditionExpression, del.ExpressionAttributeValues)
+		}
+	})
+}
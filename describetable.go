@@ -26,6 +26,10 @@ type Description struct {
 	Throughput Throughput
 	// OnDemand is true if on-demand (pay per request) billing mode is enabled.
 	OnDemand bool
+	// Maximum throughput for this table in on-demand mode.
+	OnDemandThroughput OnDemandThroughput
+	// Warm throughput for this table: the reads and writes per second it can instantly support.
+	WarmThroughput WarmThroughput
 
 	// The number of items of the table, updated every 6 hours.
 	Items int64
@@ -63,6 +67,27 @@ type Throughput struct {
 	DecsToday int64
 }
 
+// OnDemandThroughput is the maximum throughput of an on-demand table or global secondary index.
+type OnDemandThroughput struct {
+	// Maximum read request units per second, or zero for no maximum.
+	MaxRead int64
+	// Maximum write request units per second, or zero for no maximum.
+	MaxWrite int64
+}
+
+// WarmThroughput is the number of reads and writes per second that a table or global secondary index
+// can instantly support, regardless of its billing mode. It can be raised ahead of time
+// (for example, before a launch or a large import) with [CreateTable.WarmThroughput] or [UpdateTable.WarmThroughput].
+type WarmThroughput struct {
+	// Read units per second.
+	Read int64
+	// Write units per second.
+	Write int64
+	// Status is UpdatingStatus while the warm throughput is being increased, and ActiveStatus once it is ready.
+	// It is only set for descriptions.
+	Status Status
+}
+
 type Index struct {
 	Name        string
 	ARN         string
@@ -81,6 +106,10 @@ type Index struct {
 
 	// The provisioned throughput for this index.
 	Throughput Throughput
+	// Maximum throughput for this index in on-demand mode (only for GSI).
+	OnDemandThroughput OnDemandThroughput
+	// Warm throughput for this index (only for GSI).
+	WarmThroughput WarmThroughput
 
 	Items int64
 	Size  int64
@@ -116,6 +145,10 @@ func newDescription(table *types.TableDescription) Description {
 	if table.ProvisionedThroughput != nil {
 		desc.Throughput = newThroughput(table.ProvisionedThroughput)
 	}
+	desc.OnDemandThroughput = newOnDemandThroughput(table.OnDemandThroughput)
+	if wt := table.WarmThroughput; wt != nil {
+		desc.WarmThroughput = newWarmThroughput(wt.ReadUnitsPerSecond, wt.WriteUnitsPerSecond, Status(wt.Status))
+	}
 
 	if table.ItemCount != nil {
 		desc.Items = *table.ItemCount
@@ -130,6 +163,11 @@ func newDescription(table *types.TableDescription) Description {
 			ARN:        *index.IndexArn,
 			Status:     Status(index.IndexStatus),
 			Throughput: newThroughput(index.ProvisionedThroughput),
+
+			OnDemandThroughput: newOnDemandThroughput(index.OnDemandThroughput),
+		}
+		if wt := index.WarmThroughput; wt != nil {
+			idx.WarmThroughput = newWarmThroughput(wt.ReadUnitsPerSecond, wt.WriteUnitsPerSecond, Status(wt.Status))
 		}
 		if index.Projection != nil && index.Projection.ProjectionType != "" {
 			idx.ProjectionType = IndexProjection(index.Projection.ProjectionType)
@@ -242,10 +280,62 @@ func (desc Description) keys(index string) map[string]struct{} {
 	return keys
 }
 
+// keySchema returns the primary key schema of the table, or of the given index if index is not blank.
+// It returns false if the index doesn't exist.
+func (desc Description) keySchema(index string) (hashKey string, hashType KeyType, rangeKey string, rangeType KeyType, ok bool) {
+	if index == "" {
+		return desc.HashKey, desc.HashKeyType, desc.RangeKey, desc.RangeKeyType, true
+	}
+	for _, gsi := range desc.GSI {
+		if gsi.Name == index {
+			return gsi.HashKey, gsi.HashKeyType, gsi.RangeKey, gsi.RangeKeyType, true
+		}
+	}
+	for _, lsi := range desc.LSI {
+		if lsi.Name == index {
+			return lsi.HashKey, lsi.HashKeyType, lsi.RangeKey, lsi.RangeKeyType, true
+		}
+	}
+	return "", NoneType, "", NoneType, false
+}
+
+// index returns the secondary index with the given name, or false if it doesn't exist.
+func (desc Description) index(name string) (Index, bool) {
+	for _, gsi := range desc.GSI {
+		if gsi.Name == name {
+			return gsi, true
+		}
+	}
+	for _, lsi := range desc.LSI {
+		if lsi.Name == name {
+			return lsi, true
+		}
+	}
+	return Index{}, false
+}
+
+// projected returns the attributes projected by idx, or nil if all attributes are projected.
+func (desc Description) projected(idx Index) map[string]struct{} {
+	if idx.ProjectionType == AllProjection {
+		return nil
+	}
+	attrs := make(map[string]struct{})
+	for _, name := range []string{desc.HashKey, desc.RangeKey, idx.HashKey, idx.RangeKey} {
+		if name != "" {
+			attrs[name] = struct{}{}
+		}
+	}
+	for _, name := range idx.ProjectionAttribs {
+		attrs[name] = struct{}{}
+	}
+	return attrs
+}
+
 // DescribeTable is a request for information about a table and its indexes.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DescribeTable.html
 type DescribeTable struct {
-	table Table
+	table  Table
+	optFns []func(*dynamodb.Options)
 }
 
 // Describe begins a new request to describe this table.
@@ -253,14 +343,24 @@ func (table Table) Describe() *DescribeTable {
 	return &DescribeTable{table: table}
 }
 
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (dt *DescribeTable) RequestOptions(optFns ...func(*dynamodb.Options)) *DescribeTable {
+	dt.optFns = optFns
+	return dt
+}
+
 // Run executes this request and describe the table.
 func (dt *DescribeTable) Run(ctx context.Context) (Description, error) {
 	input := dt.input()
+	if err := dt.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return Description{}, err
+	}
 
 	var result *dynamodb.DescribeTableOutput
 	err := dt.table.db.retry(ctx, func() error {
 		var err error
-		result, err = dt.table.db.client.DescribeTable(ctx, input)
+		result, err = dt.table.db.client.DescribeTable(ctx, input, dt.table.db.requestOptions(dt.optFns)...)
 		return err
 	})
 	if err != nil {
@@ -300,6 +400,58 @@ func newThroughput(td *types.ProvisionedThroughputDescription) Throughput {
 	return thru
 }
 
+func newOnDemandThroughput(odt *types.OnDemandThroughput) OnDemandThroughput {
+	var thru OnDemandThroughput
+	if odt == nil {
+		return thru
+	}
+	// -1 means no maximum
+	if odt.MaxReadRequestUnits != nil && *odt.MaxReadRequestUnits > 0 {
+		thru.MaxRead = *odt.MaxReadRequestUnits
+	}
+	if odt.MaxWriteRequestUnits != nil && *odt.MaxWriteRequestUnits > 0 {
+		thru.MaxWrite = *odt.MaxWriteRequestUnits
+	}
+	return thru
+}
+
+// input returns the API representation of thru, where -1 means no maximum.
+func (thru OnDemandThroughput) input() *types.OnDemandThroughput {
+	unlimited := func(n int64) *int64 {
+		if n <= 0 {
+			n = -1
+		}
+		return &n
+	}
+	return &types.OnDemandThroughput{
+		MaxReadRequestUnits:  unlimited(thru.MaxRead),
+		MaxWriteRequestUnits: unlimited(thru.MaxWrite),
+	}
+}
+
+func newWarmThroughput(read, write *int64, status Status) WarmThroughput {
+	thru := WarmThroughput{Status: status}
+	if read != nil {
+		thru.Read = *read
+	}
+	if write != nil {
+		thru.Write = *write
+	}
+	return thru
+}
+
+// input returns the API representation of thru, leaving out zero values.
+func (thru WarmThroughput) input() *types.WarmThroughput {
+	wt := new(types.WarmThroughput)
+	if thru.Read > 0 {
+		wt.ReadUnitsPerSecond = &thru.Read
+	}
+	if thru.Write > 0 {
+		wt.WriteUnitsPerSecond = &thru.Write
+	}
+	return wt
+}
+
 func schemaKeys(schema []types.KeySchemaElement) (hashKey, rangeKey string) {
 	for _, ks := range schema {
 		switch ks.KeyType {
@@ -0,0 +1,119 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultDistinctLimit is the number of values remembered by [Query.DistinctOn] and [Scan.DistinctOn].
+const DefaultDistinctLimit = 100_000
+
+// ErrDistinctLimit is returned by queries and scans using DistinctOn that find more than [DefaultDistinctLimit] distinct values,
+// as duplicates of values past the limit can't be detected.
+var ErrDistinctLimit = errors.New("dynamo: DistinctOn limit reached")
+
+// DistinctOn skips results whose value at the given document path was already returned by this query,
+// such as when querying an index that can have multiple entries for each logical entity.
+// Results without a value at path are never skipped.
+// Duplicates are removed client-side, so they still consume read capacity and count towards [Query.SearchLimit].
+// Up to [DefaultDistinctLimit] values are remembered; past that, the request fails with [ErrDistinctLimit].
+func (q *Query) DistinctOn(path string) *Query {
+	_, err := parsePath(path)
+	q.setError(err)
+	q.distinct = path
+	return q
+}
+
+// DistinctOn skips results whose value at the given document path was already returned by this scan,
+// such as when scanning an index that can have multiple entries for each logical entity.
+// Parallel scans share the values seen across segments.
+// Results without a value at path are never skipped.
+// Duplicates are removed client-side, so they still consume read capacity and count towards [Scan.SearchLimit].
+// Up to [DefaultDistinctLimit] values are remembered; past that, the request fails with [ErrDistinctLimit].
+func (s *Scan) DistinctOn(path string) *Scan {
+	_, err := parsePath(path)
+	s.setError(err)
+	s.distinct = path
+	return s
+}
+
+// distinctSet tracks values already seen for DistinctOn.
+type distinctSet struct {
+	path  string
+	limit int
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func newDistinctSet(path string) *distinctSet {
+	if path == "" {
+		return nil
+	}
+	return &distinctSet{
+		path:  path,
+		limit: DefaultDistinctLimit,
+		seen:  make(map[string]struct{}),
+	}
+}
+
+// filter removes items with values that were already seen, in place.
+func (d *distinctSet) filter(items []Item) ([]Item, error) {
+	if d == nil {
+		return items, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	kept := items[:0]
+	for _, item := range items {
+		ok, err := d.add(item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, item)
+		}
+	}
+	clear(items[len(kept):])
+	return kept, nil
+}
+
+// add records the value of item and returns true if it hasn't been seen before.
+func (d *distinctSet) add(item Item) (bool, error) {
+	av, err := lookupPath(item, d.path)
+	if err != nil {
+		return true, nil
+	}
+	id, ok := distinctValueID(av)
+	if !ok {
+		return true, nil
+	}
+	if _, dupe := d.seen[id]; dupe {
+		return false, nil
+	}
+	if len(d.seen) >= d.limit {
+		return false, fmt.Errorf("%w: more than %d values of %s", ErrDistinctLimit, d.limit, d.path)
+	}
+	d.seen[id] = struct{}{}
+	return true, nil
+}
+
+// distinctValueID returns a string uniquely identifying the value of av.
+func distinctValueID(av types.AttributeValue) (string, bool) {
+	if id := keyValueID(av); id != "" {
+		return id, true
+	}
+	v, err := av2json(av)
+	if err != nil {
+		return "", false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return avTypeName(av) + string(data), true
+}
@@ -0,0 +1,114 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// dupePager returns two pages of index entries, with several entries per entity.
+type dupePager struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (dupePager) page(esk Item) ([]Item, Item) {
+	entry := func(id int, entity string) Item {
+		item := Item{"ID": &types.AttributeValueMemberN{Value: strconv.Itoa(id)}}
+		if entity != "" {
+			item["Entity"] = &types.AttributeValueMemberM{Value: Item{
+				"Ref": &types.AttributeValueMemberS{Value: entity},
+			}}
+		}
+		return item
+	}
+	if esk == nil {
+		return []Item{entry(1, "a"), entry(2, "b"), entry(3, "a")}, Item{"ID": &types.AttributeValueMemberN{Value: "3"}}
+	}
+	return []Item{entry(4, "b"), entry(5, ""), entry(6, ""), entry(7, "c")}, nil
+}
+
+func (c dupePager) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	items, lek := c.page(in.ExclusiveStartKey)
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: lek}, nil
+}
+
+func (c dupePager) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	items, lek := c.page(in.ExclusiveStartKey)
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: lek}, nil
+}
+
+func TestDistinctOn(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(dupePager{}).Table("Entries")
+
+	type entry struct {
+		ID int
+	}
+	runs := map[string]func(out *[]entry) error{
+		"query": func(out *[]entry) error {
+			return table.Get("Group", 1).DistinctOn("Entity.Ref").All(ctx, out)
+		},
+		"scan": func(out *[]entry) error {
+			return table.Scan().DistinctOn("Entity.Ref").All(ctx, out)
+		},
+	}
+	// entries without an entity are kept
+	want := []entry{{1}, {2}, {5}, {6}, {7}}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			var got []entry
+			if err := run(&got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("bad results. want: %v, got: %v", want, got)
+			}
+		})
+	}
+
+	t.Run("parallel scan", func(t *testing.T) {
+		// each segment gets the same entries, so the second one is all duplicates
+		var got []entry
+		if err := table.Scan().DistinctOn("Entity.Ref").AllParallel(ctx, 2, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 7 {
+			// 3 distinct entities, plus 2 entries without an entity per segment
+			t.Errorf("want 7 results, got %d: %v", len(got), got)
+		}
+	})
+
+	t.Run("bad path", func(t *testing.T) {
+		var got []entry
+		if err := table.Get("Group", 1).DistinctOn("Entity[").All(ctx, &got); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		seen := newDistinctSet("N")
+		seen.limit = 2
+		num := func(n string) Item {
+			return Item{"N": &types.AttributeValueMemberN{Value: n}}
+		}
+		items := []Item{num("1"), num("1.0"), num("2"), num("1")}
+		got, err := seen.filter(items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Item{num("1"), num("2")}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("bad results. want: %v, got: %v", want, got)
+		}
+		if _, err := seen.filter([]Item{num("2"), num("3")}); !errors.Is(err, ErrDistinctLimit) {
+			t.Error("want ErrDistinctLimit, got:", err)
+		}
+	})
+}
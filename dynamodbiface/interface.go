@@ -81,3 +81,24 @@ type DynamoDBAPI interface {
 	DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error)
 	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
+
+// DescribeLimitsAPI is implemented by clients that support DescribeLimits, such as *dynamodb.Client.
+// It is separate from DynamoDBAPI so that existing implementations of DynamoDBAPI don't need to add it.
+// Clients that wrap another client can implement Unwrap() DynamoDBAPI instead, to use the wrapped client for it.
+type DescribeLimitsAPI interface {
+	DescribeLimits(ctx context.Context, params *dynamodb.DescribeLimitsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeLimitsOutput, error)
+}
+
+// TagsAPI is implemented by clients that support the tagging operations, such as *dynamodb.Client.
+// Like DescribeLimitsAPI, it is separate from DynamoDBAPI.
+type TagsAPI interface {
+	ListTagsOfResource(ctx context.Context, params *dynamodb.ListTagsOfResourceInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTagsOfResourceOutput, error)
+	TagResource(ctx context.Context, params *dynamodb.TagResourceInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *dynamodb.UntagResourceInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UntagResourceOutput, error)
+}
+
+// BatchExecuteStatementAPI is implemented by clients that support PartiQL batches, such as *dynamodb.Client.
+// Like DescribeLimitsAPI, it is separate from DynamoDBAPI.
+type BatchExecuteStatementAPI interface {
+	BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error)
+}
@@ -0,0 +1,27 @@
+package dynamotest
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// AuditExpressions returns an option for [dynamo.New] (or [dynamodb.NewFromConfig]) that checks the expressions
+// of every request made by the client while the test runs (see [dynamo.ExpressionAudit]).
+// When the test and its subtests finish, each warning fails the test.
+//
+//	func TestWidgets(t *testing.T) {
+//		db := dynamo.New(cfg, dynamotest.AuditExpressions(t))
+//		// ...
+//	}
+func AuditExpressions(t testing.TB) func(*dynamodb.Options) {
+	audit := new(dynamo.ExpressionAudit)
+	t.Cleanup(func() {
+		for _, w := range audit.Warnings() {
+			t.Error(w)
+		}
+	})
+	return dynamo.WithExpressionAudit(audit)
+}
@@ -0,0 +1,51 @@
+package dynamotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// recordingTB records errors and cleanups instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Error(args ...any) {
+	for _, arg := range args {
+		tb.errors = append(tb.errors, arg.(dynamo.Warning).String())
+	}
+}
+
+func (tb *recordingTB) Cleanup(fn func()) {
+	tb.cleanups = append(tb.cleanups, fn)
+}
+
+func TestAuditExpressions(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		BaseEndpoint:     aws.String("http://127.0.0.1:1"),
+		RetryMaxAttempts: 1,
+	}, AuditExpressions(tb))
+	db := dynamo.NewFromIface(client)
+
+	// the request fails, but its expressions are checked before it is sent
+	var out []widget
+	_ = db.Table("Audit").Get("UserID", 1).Filter("Size > ?", 1).All(context.Background(), &out)
+
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "Size") {
+		t.Error("expected warning about Size, got:", tb.errors)
+	}
+}
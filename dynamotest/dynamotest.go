@@ -0,0 +1,74 @@
+// Package dynamotest provides helpers for writing tests against DynamoDB,
+// such as DynamoDB Local.
+package dynamotest
+
+import (
+	"context"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Timeout is the maximum amount of time that CreateTable waits for a table to be created or deleted.
+var Timeout = 2 * time.Minute
+
+// maximum length of a table name
+const maxNameLength = 255
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// CreateTable creates a new on-demand table with a unique name derived from the test's name,
+// using model to determine its keys and indexes (see [dynamo.DB.CreateTable]).
+// It waits for the table to become active, then writes seedItems to it.
+// The table is deleted when the test and its subtests finish.
+// Any error fails the test immediately.
+//
+//	func TestWidgets(t *testing.T) {
+//		table := dynamotest.CreateTable(t, db, widget{}, widget{ID: 1}, widget{ID: 2})
+//		// ...
+//	}
+func CreateTable(t testing.TB, db *dynamo.DB, model any, seedItems ...any) dynamo.Table {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	name := TableName(t)
+	if err := db.CreateTable(name, model).OnDemand(true).Run(ctx); err != nil {
+		t.Fatalf("dynamotest: creating table %s: %v", name, err)
+	}
+	table := db.Table(name)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		if err := table.DeleteTable().Run(ctx); err != nil {
+			t.Errorf("dynamotest: deleting table %s: %v", name, err)
+		}
+	})
+
+	if err := table.Wait(ctx); err != nil {
+		t.Fatalf("dynamotest: waiting for table %s: %v", name, err)
+	}
+
+	if len(seedItems) > 0 {
+		if _, err := table.Batch().Write().Put(seedItems...).Run(ctx); err != nil {
+			t.Fatalf("dynamotest: seeding table %s: %v", name, err)
+		}
+	}
+
+	return table
+}
+
+// TableName returns a unique, valid table name derived from the test's name.
+func TableName(t testing.TB) string {
+	suffix := "-" + strconv.FormatInt(time.Now().UnixMilli(), 10) + "-" + strconv.FormatUint(uint64(rand.Uint32()), 36)
+	prefix := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if len(prefix)+len(suffix) > maxNameLength {
+		prefix = prefix[:maxNameLength-len(suffix)]
+	}
+	return prefix + suffix
+}
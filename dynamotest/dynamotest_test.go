@@ -0,0 +1,88 @@
+package dynamotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2"
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+type widget struct {
+	UserID int `dynamo:",hash"`
+	Msg    string
+}
+
+type fakeClient struct {
+	dynamodbiface.DynamoDBAPI
+	created string
+	deleted string
+	writes  int
+}
+
+func (c *fakeClient) CreateTable(_ context.Context, in *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.created = *in.TableName
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName:   in.TableName,
+		TableStatus: types.TableStatusActive,
+	}}, nil
+}
+
+func (c *fakeClient) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, reqs := range in.RequestItems {
+		c.writes += len(reqs)
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *fakeClient) DeleteTable(_ context.Context, in *dynamodb.DeleteTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	c.deleted = *in.TableName
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+func TestCreateTable(t *testing.T) {
+	client := new(fakeClient)
+	db := dynamo.NewFromIface(client)
+
+	var name string
+	t.Run("seeded/table", func(t *testing.T) {
+		table := CreateTable(t, db, widget{}, widget{UserID: 1}, widget{UserID: 2}, widget{UserID: 3})
+		name = table.Name()
+		if client.created != name {
+			t.Error("bad created table. want:", name, "got:", client.created)
+		}
+		if client.writes != 3 {
+			t.Error("bad seed writes. want:", 3, "got:", client.writes)
+		}
+		if client.deleted != "" {
+			t.Error("table deleted too early")
+		}
+	})
+
+	if !strings.HasPrefix(name, "TestCreateTable_seeded_table-") {
+		t.Error("bad table name:", name)
+	}
+	if client.deleted != name {
+		t.Error("table not cleaned up. want:", name, "got:", client.deleted)
+	}
+}
+
+func TestTableName(t *testing.T) {
+	t.Run(strings.Repeat("x", 300), func(t *testing.T) {
+		a, b := TableName(t), TableName(t)
+		if a == b {
+			t.Error("table names not unique:", a)
+		}
+		if len(a) > maxNameLength {
+			t.Error("table name too long:", len(a))
+		}
+	})
+}
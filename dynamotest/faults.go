@@ -0,0 +1,288 @@
+package dynamotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// Faults configures the failures simulated by [WithFaults].
+// Probabilities range from 0 (never) to 1 (always).
+type Faults struct {
+	// Throttle is the probability that an operation fails
+	// with a ProvisionedThroughputExceededException, without being sent.
+	Throttle float64
+	// Unprocessed is the probability that each key of a BatchGetItem request,
+	// or each write of a BatchWriteItem request, is returned as unprocessed without being sent.
+	// It should be less than 1 so that retries eventually succeed.
+	Unprocessed float64
+	// TxConflict is the probability that a transaction fails with a TransactionCanceledException
+	// whose first cancellation reason is TransactionConflict, without being sent.
+	TxConflict float64
+	// Latency is the probability that an operation is delayed, by a random duration up to MaxLatency.
+	Latency float64
+	// MaxLatency is the longest delay added to delayed operations.
+	MaxLatency time.Duration
+	// Seed seeds the random choice of faults, making them reproducible.
+	// If zero, a random seed is used.
+	Seed int64
+}
+
+// WithFaults wraps client so that item, query, batch, and transaction operations
+// randomly fail or are delayed according to faults, before being passed on to client.
+// This is useful for exercising retry logic in tests, including dynamo's handling of unprocessed batch items:
+//
+//	client := dynamotest.WithFaults(dynamodb.NewFromConfig(cfg), dynamotest.Faults{
+//		Throttle:    0.1,
+//		Unprocessed: 0.5,
+//	})
+//	db := dynamo.NewFromIface(client)
+//
+// Faults are injected outside of the AWS SDK's retryer, so simulated errors are returned as-is.
+// The returned client is safe for concurrent use if client is.
+func WithFaults(client dynamodbiface.DynamoDBAPI, faults Faults) dynamodbiface.DynamoDBAPI {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &faultyClient{
+		DynamoDBAPI: client,
+		faults:      faults,
+		rng:         rand.New(rand.NewSource(seed)),
+	}
+}
+
+type faultyClient struct {
+	dynamodbiface.DynamoDBAPI
+	faults Faults
+	mu     sync.Mutex
+	rng    *rand.Rand
+}
+
+// Unwrap returns the wrapped client, which is used for operations that aren't part of DynamoDBAPI.
+func (c *faultyClient) Unwrap() dynamodbiface.DynamoDBAPI {
+	return c.DynamoDBAPI
+}
+
+// chance returns true with probability p.
+func (c *faultyClient) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < p
+}
+
+// inject delays the operation and returns a throttling error, as configured.
+func (c *faultyClient) inject(ctx context.Context, op string) error {
+	if c.faults.MaxLatency > 0 && c.chance(c.faults.Latency) {
+		c.mu.Lock()
+		delay := time.Duration(c.rng.Int63n(int64(c.faults.MaxLatency) + 1))
+		c.mu.Unlock()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if c.chance(c.faults.Throttle) {
+		return &types.ProvisionedThroughputExceededException{
+			Message: aws.String("dynamotest: simulated throttling of " + op),
+		}
+	}
+	return nil
+}
+
+// txConflict returns a simulated transaction conflict for a transaction of n items, as configured.
+func (c *faultyClient) txConflict(n int) error {
+	if !c.chance(c.faults.TxConflict) {
+		return nil
+	}
+	reasons := make([]types.CancellationReason, n)
+	for i := range reasons {
+		reasons[i].Code = aws.String("None")
+	}
+	if n > 0 {
+		reasons[0] = types.CancellationReason{
+			Code:    aws.String("TransactionConflict"),
+			Message: aws.String("dynamotest: simulated transaction conflict"),
+		}
+	}
+	return &types.TransactionCanceledException{
+		Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+		CancellationReasons: reasons,
+	}
+}
+
+func (c *faultyClient) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := c.inject(ctx, "GetItem"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.GetItem(ctx, in, optFns...)
+}
+
+func (c *faultyClient) PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := c.inject(ctx, "PutItem"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.PutItem(ctx, in, optFns...)
+}
+
+func (c *faultyClient) UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := c.inject(ctx, "UpdateItem"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.UpdateItem(ctx, in, optFns...)
+}
+
+func (c *faultyClient) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := c.inject(ctx, "DeleteItem"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.DeleteItem(ctx, in, optFns...)
+}
+
+func (c *faultyClient) Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := c.inject(ctx, "Query"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.Query(ctx, in, optFns...)
+}
+
+func (c *faultyClient) Scan(ctx context.Context, in *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := c.inject(ctx, "Scan"); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.Scan(ctx, in, optFns...)
+}
+
+func (c *faultyClient) BatchExecuteStatement(ctx context.Context, in *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	client, ok := c.DynamoDBAPI.(dynamodbiface.BatchExecuteStatementAPI)
+	if !ok {
+		return nil, fmt.Errorf("dynamotest: client %T does not support BatchExecuteStatement: %w", c.DynamoDBAPI, errors.ErrUnsupported)
+	}
+	if err := c.inject(ctx, "BatchExecuteStatement"); err != nil {
+		return nil, err
+	}
+	return client.BatchExecuteStatement(ctx, in, optFns...)
+}
+
+func (c *faultyClient) BatchGetItem(ctx context.Context, in *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if err := c.inject(ctx, "BatchGetItem"); err != nil {
+		return nil, err
+	}
+
+	send := make(map[string]types.KeysAndAttributes, len(in.RequestItems))
+	unprocessed := make(map[string]types.KeysAndAttributes)
+	for table, req := range in.RequestItems {
+		var keep, skip []map[string]types.AttributeValue
+		for _, key := range req.Keys {
+			if c.chance(c.faults.Unprocessed) {
+				skip = append(skip, key)
+			} else {
+				keep = append(keep, key)
+			}
+		}
+		if len(keep) > 0 {
+			req.Keys = keep
+			send[table] = req
+		}
+		if len(skip) > 0 {
+			req.Keys = skip
+			unprocessed[table] = req
+		}
+	}
+	if len(unprocessed) == 0 {
+		return c.DynamoDBAPI.BatchGetItem(ctx, in, optFns...)
+	}
+
+	out := &dynamodb.BatchGetItemOutput{}
+	if len(send) > 0 {
+		sent := *in
+		sent.RequestItems = send
+		var err error
+		if out, err = c.DynamoDBAPI.BatchGetItem(ctx, &sent, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if out.UnprocessedKeys == nil {
+		out.UnprocessedKeys = make(map[string]types.KeysAndAttributes)
+	}
+	for table, req := range unprocessed {
+		if prev, ok := out.UnprocessedKeys[table]; ok {
+			req.Keys = append(prev.Keys, req.Keys...)
+		}
+		out.UnprocessedKeys[table] = req
+	}
+	return out, nil
+}
+
+func (c *faultyClient) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := c.inject(ctx, "BatchWriteItem"); err != nil {
+		return nil, err
+	}
+
+	send := make(map[string][]types.WriteRequest, len(in.RequestItems))
+	unprocessed := make(map[string][]types.WriteRequest)
+	for table, reqs := range in.RequestItems {
+		for _, req := range reqs {
+			if c.chance(c.faults.Unprocessed) {
+				unprocessed[table] = append(unprocessed[table], req)
+			} else {
+				send[table] = append(send[table], req)
+			}
+		}
+	}
+	if len(unprocessed) == 0 {
+		return c.DynamoDBAPI.BatchWriteItem(ctx, in, optFns...)
+	}
+
+	out := &dynamodb.BatchWriteItemOutput{}
+	if len(send) > 0 {
+		sent := *in
+		sent.RequestItems = send
+		var err error
+		if out, err = c.DynamoDBAPI.BatchWriteItem(ctx, &sent, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	if out.UnprocessedItems == nil {
+		out.UnprocessedItems = make(map[string][]types.WriteRequest)
+	}
+	for table, reqs := range unprocessed {
+		out.UnprocessedItems[table] = append(out.UnprocessedItems[table], reqs...)
+	}
+	return out, nil
+}
+
+func (c *faultyClient) TransactGetItems(ctx context.Context, in *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	if err := c.inject(ctx, "TransactGetItems"); err != nil {
+		return nil, err
+	}
+	if err := c.txConflict(len(in.TransactItems)); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.TransactGetItems(ctx, in, optFns...)
+}
+
+func (c *faultyClient) TransactWriteItems(ctx context.Context, in *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := c.inject(ctx, "TransactWriteItems"); err != nil {
+		return nil, err
+	}
+	if err := c.txConflict(len(in.TransactItems)); err != nil {
+		return nil, err
+	}
+	return c.DynamoDBAPI.TransactWriteItems(ctx, in, optFns...)
+}
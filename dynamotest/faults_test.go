@@ -0,0 +1,54 @@
+package dynamotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+func TestWithFaults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unprocessed", func(t *testing.T) {
+		client := new(fakeClient)
+		db := dynamo.NewFromIface(WithFaults(client, Faults{Unprocessed: 0.3, Seed: 1}))
+		items := make([]any, 20)
+		for i := range items {
+			items[i] = widget{UserID: i}
+		}
+		wrote, err := db.Table("Faults").Batch().Write().Put(items...).Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wrote != len(items) || client.writes != len(items) {
+			t.Errorf("want %d writes, got %d (sent %d)", len(items), wrote, client.writes)
+		}
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		client := new(fakeClient)
+		db := dynamo.NewFromIface(WithFaults(client, Faults{Throttle: 1}))
+		_, err := db.Table("Faults").Batch().Write().Put(widget{UserID: 1}).Run(ctx)
+		var pte *types.ProvisionedThroughputExceededException
+		if !errors.As(err, &pte) {
+			t.Error("want throttling error, got:", err)
+		}
+		if client.writes != 0 {
+			t.Error("throttled request was sent")
+		}
+	})
+
+	t.Run("tx conflict", func(t *testing.T) {
+		db := dynamo.NewFromIface(WithFaults(new(fakeClient), Faults{TxConflict: 1}))
+		table := db.Table("Faults")
+		err := db.WriteTx().Put(table.Put(widget{UserID: 1})).Run(ctx)
+		var txe *types.TransactionCanceledException
+		if !errors.As(err, &txe) || *txe.CancellationReasons[0].Code != "TransactionConflict" {
+			t.Error("want transaction conflict, got:", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrEmptyKey is returned when a key value given to Get, Delete, Update, Check, or their Range methods
+// is an empty string or binary value, unless empty keys are allowed by [DB.AllowEmptyKey].
+var ErrEmptyKey = errors.New("dynamo: key value is empty")
+
+// AllowEmptyKey returns a copy of db that accepts empty string and binary key values,
+// sending them as-is instead of returning [ErrEmptyKey].
+// DynamoDB itself rejects empty key values, so this is mostly useful for DynamoDB-compatible databases that don't,
+// or for existing code that relies on DynamoDB to report the error.
+// The returned DB shares db's table description cache.
+func (db *DB) AllowEmptyKey(allow bool) *DB {
+	cp := *db
+	cp.allowEmptyKey = allow
+	return &cp
+}
+
+// marshalKey encodes a key value given to Get, Delete, Update, Check, or their Range methods.
+// What describes the key for error messages, such as "query hash".
+func (table Table) marshalKey(what, name string, value interface{}) (types.AttributeValue, error) {
+	return table.marshalKeyFlags(what, name, value, flagNone)
+}
+
+// marshalKeyFlags is like marshalKey, but encodes value with the given flags of the key's struct field.
+func (table Table) marshalKeyFlags(what, name string, value interface{}, flags encodeFlags) (types.AttributeValue, error) {
+	av, err := marshal(value, keyFlags(flags)|flagAllowEmpty)
+	if err == nil {
+		av = stringerValue(value, av)
+	}
+	switch {
+	case err != nil:
+		return nil, err
+	case av == nil:
+		return nil, fmt.Errorf("dynamo: %s key value is nil or omitted for attribute %q", what, name)
+	case isEmptyKey(av) && (table.db == nil || !table.db.allowEmptyKey):
+		return nil, fmt.Errorf("%w: %s key for attribute %q", ErrEmptyKey, what, name)
+	}
+	return av, nil
+}
+
+func isEmptyKey(av types.AttributeValue) bool {
+	switch av := av.(type) {
+	case *types.AttributeValueMemberS:
+		return av.Value == ""
+	case *types.AttributeValueMemberB:
+		return len(av.Value) == 0
+	}
+	return false
+}
+
+// keyFlags returns the flags of a key field that affect how its value is encoded,
+// dropping those that would omit or nullify it.
+func keyFlags(flags encodeFlags) encodeFlags {
+	return flags &^ (flagOmitEmpty | flagOmitEmptyElem | flagNull)
+}
@@ -1,9 +1,13 @@
 package dynamo
 
 import (
+	"context"
 	"encoding"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -14,6 +18,16 @@ type Marshaler interface {
 	MarshalDynamo() (types.AttributeValue, error)
 }
 
+// MarshalerCtx is like [Marshaler], but also receives the context of the request.
+// This is useful for marshalers that need request-scoped data, such as field encryption keys.
+// Values given to request builders (for example, [Table.Put] or [Update.Set]) are marshaled
+// when the request is run, with the context passed to Run.
+// When marshaling without a request (for example, [MarshalItem]), [context.Background] is used.
+// Use [MarshalItemContext] to marshal items ahead of time with a specific context.
+type MarshalerCtx interface {
+	MarshalDynamoContext(ctx context.Context) (types.AttributeValue, error)
+}
+
 // ItemMarshaler is the interface implemented by objects that can marshal themselves
 // into an Item (a map of strings to AttributeValues).
 type ItemMarshaler interface {
@@ -25,15 +39,25 @@ func MarshalItem(v interface{}) (Item, error) {
 	return marshalItem(v)
 }
 
+// MarshalItemContext converts the given struct into a DynamoDB item,
+// passing ctx to any values that implement [MarshalerCtx].
+func MarshalItemContext(ctx context.Context, v interface{}) (Item, error) {
+	return marshalItemContext(ctx, v)
+}
+
 func marshalItem(v interface{}) (Item, error) {
+	return marshalItemContext(context.Background(), v)
+}
+
+func marshalItemContext(ctx context.Context, v interface{}) (Item, error) {
 	rv := reflect.ValueOf(v)
 	rt := rv.Type()
-	plan, err := typedefOf(rt)
+	plan, err := typedefOf(rt, nameMapperFrom(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	return plan.encodeItem(rv)
+	return plan.encodeItem(ctx, rv)
 }
 
 // Marshal converts the given value into a DynamoDB attribute value.
@@ -41,14 +65,24 @@ func Marshal(v interface{}) (types.AttributeValue, error) {
 	return marshal(v, flagNone)
 }
 
+// MarshalContext converts the given value into a DynamoDB attribute value,
+// passing ctx to any values that implement [MarshalerCtx].
+func MarshalContext(ctx context.Context, v interface{}) (types.AttributeValue, error) {
+	return marshalContext(ctx, v, flagNone)
+}
+
 func marshal(v interface{}, flags encodeFlags) (types.AttributeValue, error) {
+	return marshalContext(context.Background(), v, flags)
+}
+
+func marshalContext(ctx context.Context, v interface{}, flags encodeFlags) (types.AttributeValue, error) {
 	rv := reflect.ValueOf(v)
 	if !rv.IsValid() {
 		return nil, nil
 	}
 
 	rt := rv.Type()
-	def, err := typedefOf(rt)
+	def, err := typedefOf(rt, nameMapperFrom(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -61,13 +95,66 @@ func marshal(v interface{}, flags encodeFlags) (types.AttributeValue, error) {
 	if !rv.IsValid() {
 		return nil, nil
 	}
-	return enc(rv, flags)
+	return enc(ctx, rv, flags)
+}
+
+// deferredMarshal is a context value for marshaling values ahead of a request, such as when building a [Put].
+// Values implementing MarshalerCtx are replaced with placeholders instead of being marshaled,
+// and found is set so that the caller knows to marshal again with the request's context.
+type deferredMarshal struct {
+	found bool
+}
+
+type deferredMarshalKey struct{}
+
+// contextForwarder is implemented by wrappers such as [Null] that only pass the context on to their values,
+// so they don't need to be deferred themselves.
+type contextForwarder interface {
+	forwardContext()
+}
+
+func forwardsContext(x MarshalerCtx) bool {
+	_, ok := x.(contextForwarder)
+	return ok
+}
+
+// deferredPlaceholder stands in for values that will be marshaled with the request's context.
+var deferredPlaceholder types.AttributeValue = &types.AttributeValueMemberNULL{Value: true}
+
+// marshalDeferred is like marshal, but defers values implementing MarshalerCtx.
+// If deferred is true, v should be marshaled again with the request's context.
+func marshalDeferred(ctx context.Context, v interface{}, flags encodeFlags) (av types.AttributeValue, deferred bool, err error) {
+	d := new(deferredMarshal)
+	av, err = marshalContext(context.WithValue(ctx, deferredMarshalKey{}, d), v, flags)
+	return av, d.found, err
+}
+
+// marshalItemDeferred is like marshalItem, but defers values implementing MarshalerCtx.
+// If deferred is true, v should be marshaled again with the request's context.
+func marshalItemDeferred(ctx context.Context, v interface{}) (item Item, deferred bool, err error) {
+	d := new(deferredMarshal)
+	item, err = marshalItemContext(context.WithValue(ctx, deferredMarshalKey{}, d), v)
+	return item, d.found, err
+}
+
+// stringerValue returns av, the encoded value of v, unless av isn't a scalar and v implements fmt.Stringer,
+// in which case v's String method is used as a string (S) instead.
+// This lets enums without a scalar encoding, such as structs with unexported fields, be used as key and expression values.
+func stringerValue(v interface{}, av types.AttributeValue) types.AttributeValue {
+	switch av.(type) {
+	case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB, *types.AttributeValueMemberBOOL:
+		return av
+	}
+	if x, ok := v.(fmt.Stringer); ok && !isNilValue(reflect.ValueOf(x)) {
+		return &types.AttributeValueMemberS{Value: x.String()}
+	}
+	return av
 }
 
-func marshalSliceNoOmit(values []interface{}) ([]types.AttributeValue, error) {
+func marshalSliceNoOmit(ctx context.Context, values []interface{}) ([]types.AttributeValue, error) {
 	avs := make([]types.AttributeValue, 0, len(values))
 	for _, v := range values {
-		av, err := marshal(v, flagAllowEmpty)
+		av, err := marshalContext(ctx, v, flagAllowEmpty)
 		if err != nil {
 			return nil, err
 		}
@@ -76,15 +163,28 @@ func marshalSliceNoOmit(values []interface{}) ([]types.AttributeValue, error) {
 	return avs, nil
 }
 
-func encodeItem(fields []structField, rv reflect.Value) (Item, error) {
+func encodeItem(ctx context.Context, fields []structField, rv reflect.Value) (Item, error) {
 	item := make(Item, len(fields))
 	for _, field := range fields {
+		if field.flags&flagReadOnly != 0 {
+			continue
+		}
 		fv := dig(rv, field.index)
 		if !fv.IsValid() {
 			// TODO: encode NULL?
 			continue
 		}
 
+		if mode := NilMode(nilMode.Load()); mode != NilDefault && isNilValue(fv) {
+			switch mode {
+			case NilNull:
+				item[field.name] = nullAV
+			case NilError:
+				return nil, fmt.Errorf("dynamo: marshal: field %q: %w", field.name, ErrNilField)
+			}
+			continue
+		}
+
 		if field.flags&flagOmitEmpty != 0 && field.isZero != nil {
 			if field.isZero(fv) {
 				continue
@@ -93,9 +193,9 @@ func encodeItem(fields []structField, rv reflect.Value) (Item, error) {
 		if field.enc == nil {
 			continue
 		}
-		av, err := field.enc(fv, field.flags)
+		av, err := field.enc(ctx, fv, field.flags)
 		if err != nil {
-			return nil, err
+			return nil, floatPath(err, field.name)
 		}
 		if av == nil {
 			if field.flags&flagNull != 0 {
@@ -108,6 +208,42 @@ func encodeItem(fields []structField, rv reflect.Value) (Item, error) {
 	return item, nil
 }
 
+// NilMode controls how nil pointers and interfaces in struct fields are marshaled.
+// See: [SetNilMode].
+type NilMode int32
+
+// Nil modes.
+const (
+	// NilDefault follows struct tags: nil values are omitted, unless the "null" option is set.
+	NilDefault NilMode = iota
+	// NilOmit always omits nil values, ignoring the "null" option.
+	NilOmit
+	// NilNull always marshals nil values as NULL, ignoring the "omitempty" option.
+	NilNull
+	// NilError returns an error wrapping ErrNilField when marshaling nil values.
+	NilError
+)
+
+// ErrNilField is returned when marshaling a nil pointer or interface struct field with the NilError mode.
+var ErrNilField = errors.New("dynamo: nil value")
+
+var nilMode atomic.Int32
+
+// SetNilMode sets how nil pointers and interfaces in struct fields are marshaled package-wide,
+// overriding the behavior specified by struct tags.
+// The default is NilDefault.
+func SetNilMode(mode NilMode) {
+	nilMode.Store(int32(mode))
+}
+
+func isNilValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
 type isZeroer interface {
 	IsZero() bool
 }
@@ -122,6 +258,10 @@ func (def *typedef) isZeroFunc(rt reflect.Type) func(rv reflect.Value) bool {
 	// simplified check for certain interfaces
 	// their output will be checked during encoding process
 	switch {
+	case rt.Implements(rtypeMarshalerCtx):
+		return isZeroIface(rt, func(v MarshalerCtx) bool {
+			return false
+		})
 	case rt.Implements(rtypeMarshaler):
 		return isZeroIface(rt, func(v Marshaler) bool {
 			return false
@@ -130,6 +270,10 @@ func (def *typedef) isZeroFunc(rt reflect.Type) func(rv reflect.Value) bool {
 		return isZeroIface(rt, func(v encoding.TextMarshaler) bool {
 			return false
 		})
+	case rt.Implements(rtypeBinaryMarshaler):
+		return isZeroIface(rt, func(v encoding.BinaryMarshaler) bool {
+			return false
+		})
 	}
 
 	switch rt.Kind() {
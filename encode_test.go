@@ -1,7 +1,11 @@
 package dynamo
 
 import (
+	"context"
+	"errors"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -205,3 +209,304 @@ func TestMarshalItemBypass(t *testing.T) {
 		t.Error("bad unmarshal")
 	}
 }
+
+func TestMarshalSeq(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	seq := func(yield func(string) bool) {
+		for _, s := range []string{"a", "b"} {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+
+	in := struct {
+		Chan  <-chan int
+		Seq   func(yield func(string) bool)
+		Nil   chan int
+		Empty func(yield func(int) bool) `dynamo:",omitempty"`
+	}{
+		Chan:  ch,
+		Seq:   seq,
+		Empty: func(yield func(int) bool) {},
+	}
+	want := Item{
+		"Chan": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberN{Value: "1"},
+			&types.AttributeValueMemberN{Value: "2"},
+			&types.AttributeValueMemberN{Value: "3"},
+		}},
+		"Seq": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "a"},
+			&types.AttributeValueMemberS{Value: "b"},
+		}},
+	}
+
+	got, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad marshal. want: %#v, got: %#v", want, got)
+	}
+}
+
+type ctxKey struct{}
+
+// tenantString prefixes its value with the tenant found in the context.
+type tenantString string
+
+func (s tenantString) MarshalDynamoContext(ctx context.Context) (types.AttributeValue, error) {
+	tenant, _ := ctx.Value(ctxKey{}).(string)
+	return &types.AttributeValueMemberS{Value: tenant + ":" + string(s)}, nil
+}
+
+func (s *tenantString) UnmarshalDynamoContext(ctx context.Context, av types.AttributeValue) error {
+	tenant, _ := ctx.Value(ctxKey{}).(string)
+	*s = tenantString(strings.TrimPrefix(av.(*types.AttributeValueMemberS).Value, tenant+":"))
+	return nil
+}
+
+func TestMarshalContext(t *testing.T) {
+	type object struct {
+		Name tenantString
+		List []tenantString
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "acme")
+	in := object{Name: "widget", List: []tenantString{"a"}}
+
+	item, err := MarshalItemContext(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Item{
+		"Name": &types.AttributeValueMemberS{Value: "acme:widget"},
+		"List": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "acme:a"},
+		}},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("bad marshal. want: %#v, got: %#v", want, item)
+	}
+
+	var out object
+	if err := UnmarshalItemContext(ctx, item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("bad unmarshal. want: %#v, got: %#v", in, out)
+	}
+}
+
+func TestMarshalContextRequests(t *testing.T) {
+	type object struct {
+		ID   int
+		Name tenantString
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "acme")
+	client := new(recordingClient)
+	table := NewFromIface(client).Table("Tenants")
+	name := func(item Item) string {
+		return item["Name"].(*types.AttributeValueMemberS).Value
+	}
+
+	// values are marshaled with the context given to Run, not when the request is built
+	if err := table.db.WriteTx().Put(table.Put(object{ID: 1, Name: "widget"})).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := name(client.txs[0].TransactItems[0].Put.Item); got != "acme:widget" {
+		t.Error("bad tx put:", got)
+	}
+
+	if _, err := table.Batch("ID").Write().Put(object{ID: 2, Name: "gadget"}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := name(client.batches[0].RequestItems["Tenants"][0].PutRequest.Item); got != "acme:gadget" {
+		t.Error("bad batch put:", got)
+	}
+
+	err := table.Update("ID", 3).Set("Name", tenantString("gizmo")).DeleteFromSet("Tags", tenantString("old")).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := client.updates[0].ExpressionAttributeValues
+	want := []types.AttributeValue{
+		&types.AttributeValueMemberS{Value: "acme:gizmo"},
+		&types.AttributeValueMemberSS{Value: []string{"acme:old"}},
+	}
+	if got := []types.AttributeValue{values[":v0"], values[":v1"]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad update values. want: %#v, got: %#v", want, got)
+	}
+}
+
+func TestNilMode(t *testing.T) {
+	type object struct {
+		ID    int
+		Ptr   *string
+		Iface any    `dynamo:",omitempty"`
+		Null  *int   `dynamo:",null"`
+		Str   string // not nil
+	}
+	in := object{ID: 1}
+
+	defer SetNilMode(NilDefault)
+
+	tests := []struct {
+		mode NilMode
+		want Item
+	}{
+		{NilDefault, Item{
+			"ID":   &types.AttributeValueMemberN{Value: "1"},
+			"Null": nullAV,
+		}},
+		{NilOmit, Item{
+			"ID": &types.AttributeValueMemberN{Value: "1"},
+		}},
+		{NilNull, Item{
+			"ID":    &types.AttributeValueMemberN{Value: "1"},
+			"Ptr":   nullAV,
+			"Iface": nullAV,
+			"Null":  nullAV,
+		}},
+	}
+	for _, tc := range tests {
+		SetNilMode(tc.mode)
+		got, err := MarshalItem(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("mode %d: bad result. want: %#v, got: %#v", tc.mode, tc.want, got)
+		}
+	}
+
+	SetNilMode(NilError)
+	if _, err := MarshalItem(in); !errors.Is(err, ErrNilField) {
+		t.Error("expected ErrNilField, got:", err)
+	}
+}
+
+func TestNonFiniteFloats(t *testing.T) {
+	type stats struct {
+		Scores []float64
+		ByName map[string]float32
+	}
+	type object struct {
+		ID    int
+		Stats stats
+		Set   []float64 `dynamo:",set"`
+		Str   float64   `dynamo:",string"`
+	}
+
+	tests := []struct {
+		in   object
+		path string
+	}{
+		{object{Stats: stats{Scores: []float64{1, math.NaN()}}}, "Stats.Scores[1]"},
+		{object{Stats: stats{ByName: map[string]float32{"a": float32(math.Inf(1))}}}, "Stats.ByName.a"},
+		{object{Set: []float64{1, math.Inf(-1)}}, "Set"},
+	}
+	for _, tc := range tests {
+		_, err := MarshalItem(tc.in)
+		var fe *FloatError
+		if !errors.As(err, &fe) {
+			t.Errorf("%s: expected FloatError, got: %v", tc.path, err)
+			continue
+		}
+		if fe.Path != tc.path {
+			t.Errorf("bad path. want: %s, got: %s", tc.path, fe.Path)
+		}
+	}
+
+	got, err := MarshalItem(object{Str: math.Inf(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&types.AttributeValueMemberS{Value: "+Inf"}); !reflect.DeepEqual(got["Str"], want) {
+		t.Errorf("string option: want: %#v, got: %#v", want, got["Str"])
+	}
+
+	db := NewFromIface(nil).NonFiniteFloats(func(f float64) (types.AttributeValue, error) {
+		if math.IsNaN(f) {
+			return nil, nil
+		}
+		return &types.AttributeValueMemberN{Value: "9e99"}, nil
+	})
+	in := stats{Scores: []float64{math.NaN(), math.Inf(1)}}
+	got, err = MarshalItemContext(db.codecContext(context.Background()), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Item{"Scores": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+		nullAV,
+		&types.AttributeValueMemberN{Value: "9e99"},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sentinel: want: %#v, got: %#v", want, got)
+	}
+	// requests use db's option
+	client := new(recordingClient)
+	db.client = client
+	if err := db.Table("Stats").Update("ID", 1).Set("Score", math.Inf(1)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.updates[0].ExpressionAttributeValues[":v0"]; !reflect.DeepEqual(got, &types.AttributeValueMemberN{Value: "9e99"}) {
+		t.Errorf("update: bad value: %#v", got)
+	}
+	// only db is affected
+	var fe *FloatError
+	if _, err := MarshalItem(in); !errors.As(err, &fe) {
+		t.Error("expected FloatError without db's option, got:", err)
+	}
+}
+
+func TestReadOnlyWriteOnly(t *testing.T) {
+	type audit struct {
+		By      string `dynamo:",writeonly"`
+		Version int    `dynamo:",readonly"`
+	}
+	type record struct {
+		ID       string
+		Computed int    `dynamo:",readonly"`
+		Secret   string `dynamo:",writeonly"`
+		Audit    audit
+	}
+
+	in := record{ID: "a", Computed: 42, Secret: "hunter2", Audit: audit{By: "admin", Version: 3}}
+	item, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Item{
+		"ID":     &types.AttributeValueMemberS{Value: "a"},
+		"Secret": &types.AttributeValueMemberS{Value: "hunter2"},
+		"Audit": &types.AttributeValueMemberM{Value: Item{
+			"By": &types.AttributeValueMemberS{Value: "admin"},
+		}},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("bad marshal. want: %#v got: %#v", want, item)
+	}
+
+	stored := Item{
+		"ID":       &types.AttributeValueMemberS{Value: "a"},
+		"Computed": &types.AttributeValueMemberN{Value: "42"},
+		"Secret":   &types.AttributeValueMemberS{Value: "hunter2"},
+		"Audit": &types.AttributeValueMemberM{Value: Item{
+			"By":      &types.AttributeValueMemberS{Value: "admin"},
+			"Version": &types.AttributeValueMemberN{Value: "3"},
+		}},
+	}
+	out := record{Secret: "kept"}
+	if err := UnmarshalItem(stored, &out); err != nil {
+		t.Fatal(err)
+	}
+	if wantOut := (record{ID: "a", Computed: 42, Secret: "kept", Audit: audit{Version: 3}}); out != wantOut {
+		t.Errorf("bad unmarshal. want: %#v got: %#v", wantOut, out)
+	}
+}
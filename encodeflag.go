@@ -1,6 +1,11 @@
 package dynamo
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 type encodeFlags uint
 
@@ -12,14 +17,57 @@ const (
 	flagAllowEmptyElem
 	flagNull
 	flagUnixTime
+	flagSaturate
+	flagString
+	flagKeyFmt
+	flagReadOnly
+	flagWriteOnly
+	flagNumber
 
 	flagNone encodeFlags = 0
 )
 
-func fieldInfo(field reflect.StructField) (name string, flags encodeFlags) {
+// keyFmtShift is the position of the keyfmt option's padding width within encodeFlags.
+const keyFmtShift = 24
+
+// keyWidth returns the zero-padded width specified by the keyfmt option.
+func (flags encodeFlags) keyWidth() int {
+	if flags&flagKeyFmt == 0 {
+		return 0
+	}
+	return int(flags >> keyFmtShift)
+}
+
+// parseKeyFmt parses the keyfmt option's format, which must be like %012d.
+// The width must be between 1 and 255.
+func parseKeyFmt(format string) (encodeFlags, bool) {
+	if !strings.HasPrefix(format, "%0") || !strings.HasSuffix(format, "d") {
+		return flagNone, false
+	}
+	width, err := strconv.Atoi(format[2 : len(format)-1])
+	if err != nil || width < 1 || width > 255 {
+		return flagNone, false
+	}
+	return flagString | flagKeyFmt | encodeFlags(width)<<keyFmtShift, true
+}
+
+// checkFieldTag returns an error if field's dynamo struct tag has an invalid option.
+func checkFieldTag(field reflect.StructField) error {
+	_, opts, _ := strings.Cut(field.Tag.Get("dynamo"), ",")
+	for _, part := range strings.Split(opts, ",") {
+		if format, ok := strings.CutPrefix(part, "keyfmt="); ok {
+			if _, ok := parseKeyFmt(format); !ok {
+				return fmt.Errorf("dynamo: field %s: invalid keyfmt format %q (must be like %%012d)", field.Name, format)
+			}
+		}
+	}
+	return nil
+}
+
+func fieldInfo(field reflect.StructField, names *NameMapper) (name string, flags encodeFlags) {
 	tag := field.Tag.Get("dynamo")
 	if tag == "" {
-		return field.Name, flagNone
+		return mapFieldName(names, field.Name), flagNone
 	}
 
 	begin := 0
@@ -32,7 +80,7 @@ func fieldInfo(field reflect.StructField) (name string, flags encodeFlags) {
 
 		if name == "" {
 			if part == "" {
-				name = field.Name
+				name = mapFieldName(names, field.Name)
 			} else {
 				name = part
 			}
@@ -54,6 +102,22 @@ func fieldInfo(field reflect.StructField) (name string, flags encodeFlags) {
 			flags |= flagNull
 		case "unixtime":
 			flags |= flagUnixTime
+		case "saturate":
+			flags |= flagSaturate
+		case "string":
+			flags |= flagString
+		case "readonly":
+			flags |= flagReadOnly
+		case "writeonly":
+			flags |= flagWriteOnly
+		case "number":
+			flags |= flagNumber
+		default:
+			if format, ok := strings.CutPrefix(part, "keyfmt="); ok {
+				if keyfmt, ok := parseKeyFmt(format); ok {
+					flags |= keyfmt
+				}
+			}
 		}
 	}
 
@@ -1,17 +1,21 @@
 package dynamo
 
 import (
+	"bytes"
+	"context"
 	"encoding"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-type encodeFunc func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error)
+type encodeFunc func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error)
 
 func (def *typedef) encodeType(rt reflect.Type, flags encodeFlags, info *structInfo) (encodeFunc, error) {
 	encKey := encodeKey{rt, flags}
@@ -106,6 +110,14 @@ func (def *typedef) encodeType(rt reflect.Type, flags encodeFlags, info *structI
 			}
 		}
 		switch {
+		case try.Implements(rtypeMarshalerCtx):
+			return encode2ctx(func(ctx context.Context, x MarshalerCtx, _ encodeFlags) (types.AttributeValue, error) {
+				if d, ok := ctx.Value(deferredMarshalKey{}).(*deferredMarshal); ok && !forwardsContext(x) {
+					d.found = true
+					return deferredPlaceholder, nil
+				}
+				return x.MarshalDynamoContext(ctx)
+			}), nil
 		case try.Implements(rtypeMarshaler):
 			return encode2(func(x Marshaler, _ encodeFlags) (types.AttributeValue, error) {
 				return x.MarshalDynamo()
@@ -117,6 +129,8 @@ func (def *typedef) encodeType(rt reflect.Type, flags encodeFlags, info *structI
 			}), nil
 		case try.Implements(rtypeTextMarshaler):
 			return encodeTextMarshaler, nil
+		case try.Implements(rtypeBinaryMarshaler):
+			return encodeBinaryMarshaler, nil
 		}
 		if try.Kind() == reflect.Pointer {
 			try = try.Elem()
@@ -131,20 +145,38 @@ func (def *typedef) encodeType(rt reflect.Type, flags encodeFlags, info *structI
 
 	// BOOL
 	case reflect.Bool:
-		return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 			return &types.AttributeValueMemberBOOL{Value: rv.Bool()}, nil
 		}, nil
 
-	// N
+	// N (or S with the "string" option)
 	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		if width := flags.keyWidth(); width > 0 {
+			return encodeKeyFmt((reflect.Value).Int, strconv.FormatInt, width), nil
+		}
+		if flags&flagString != 0 {
+			return encodeNS((reflect.Value).Int, strconv.FormatInt), nil
+		}
 		return encodeN((reflect.Value).Int, strconv.FormatInt), nil
 	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		if width := flags.keyWidth(); width > 0 {
+			return encodeKeyFmt((reflect.Value).Uint, strconv.FormatUint, width), nil
+		}
+		if flags&flagString != 0 {
+			return encodeNS((reflect.Value).Uint, strconv.FormatUint), nil
+		}
 		return encodeN((reflect.Value).Uint, strconv.FormatUint), nil
 	case reflect.Float32, reflect.Float64:
-		return encodeN((reflect.Value).Float, formatFloat), nil
+		if flags&flagString != 0 {
+			return encodeNS((reflect.Value).Float, formatFloat), nil
+		}
+		return encodeFloat, nil
 
-	// S
+	// S (or N with the "number" option)
 	case reflect.String:
+		if flags&flagNumber != 0 || rt == rtypeNumber {
+			return encodeNumberString, nil
+		}
 		return encodeString, nil
 
 	case reflect.Slice, reflect.Array:
@@ -175,6 +207,17 @@ func (def *typedef) encodeType(rt reflect.Type, flags encodeFlags, info *structI
 		if rt.NumMethod() == 0 {
 			return def.encodeAny, nil
 		}
+		return def.encodeRegistered, nil
+
+	// L (drained)
+	case reflect.Chan:
+		if rt.ChanDir()&reflect.RecvDir != 0 {
+			return def.encodeChan(rt, flags, info)
+		}
+	case reflect.Func:
+		if elem, ok := seqElem(rt); ok {
+			return def.encodeSeq(rt, elem, flags, info)
+		}
 	}
 	return nil, fmt.Errorf("dynamo marshal: unsupported type %s", rt.String())
 }
@@ -184,21 +227,27 @@ func (def *typedef) encodePtr(rt reflect.Type, flags encodeFlags, info *structIn
 	if err != nil {
 		return nil, err
 	}
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		if rv.IsNil() {
 			if flags&flagNull != 0 {
 				return nullAV, nil
 			}
 			return nil, nil
 		}
-		return elem(rv.Elem(), flags)
+		return elem(ctx, rv.Elem(), flags)
 	}, nil
 }
 
-func encode2[T any](fn func(T, encodeFlags) (types.AttributeValue, error)) func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func encode2[T any](fn func(T, encodeFlags) (types.AttributeValue, error)) func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return encode2ctx(func(_ context.Context, v T, flags encodeFlags) (types.AttributeValue, error) {
+		return fn(v, flags)
+	})
+}
+
+func encode2ctx[T any](fn func(context.Context, T, encodeFlags) (types.AttributeValue, error)) func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	target := reflect.TypeOf((*T)(nil)).Elem()
 	interfacing := target.Kind() == reflect.Interface
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		if !rv.IsValid() || !rv.CanInterface() {
 			return nil, nil
 		}
@@ -214,11 +263,11 @@ func encode2[T any](fn func(T, encodeFlags) (types.AttributeValue, error)) func(
 		}
 
 		v := rv.Interface().(T)
-		return fn(v, flags)
+		return fn(ctx, v, flags)
 	}
 }
 
-func encodeString(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func encodeString(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	s := rv.String()
 	if len(s) == 0 {
 		if flags&flagAllowEmpty != 0 {
@@ -232,6 +281,18 @@ func encodeString(rv reflect.Value, flags encodeFlags) (types.AttributeValue, er
 	return &types.AttributeValueMemberS{Value: s}, nil
 }
 
+// encodeNumberString encodes a string as N, as-is.
+func encodeNumberString(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	s := rv.String()
+	if len(s) == 0 {
+		if flags&flagNull != 0 {
+			return nullAV, nil
+		}
+		return nil, nil
+	}
+	return &types.AttributeValueMemberN{Value: s}, nil
+}
+
 var encodeTextMarshaler = encode2[encoding.TextMarshaler](func(x encoding.TextMarshaler, flags encodeFlags) (types.AttributeValue, error) {
 	text, err := x.MarshalText()
 	switch {
@@ -247,10 +308,24 @@ var encodeTextMarshaler = encode2[encoding.TextMarshaler](func(x encoding.TextMa
 	return &types.AttributeValueMemberS{Value: str}, nil
 })
 
+var encodeBinaryMarshaler = encode2[encoding.BinaryMarshaler](func(x encoding.BinaryMarshaler, flags encodeFlags) (types.AttributeValue, error) {
+	data, err := x.MarshalBinary()
+	switch {
+	case err != nil:
+		return nil, err
+	case len(data) == 0:
+		if flags&flagAllowEmpty != 0 {
+			return emptyB, nil
+		}
+		return nil, nil
+	}
+	return &types.AttributeValueMemberB{Value: data}, nil
+})
+
 func encodeBytes(rt reflect.Type, flags encodeFlags) encodeFunc {
 	if rt.Kind() == reflect.Array {
 		size := rt.Len()
-		return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 			if rv.IsZero() {
 				switch {
 				case flags&flagNull != 0:
@@ -266,7 +341,7 @@ func encodeBytes(rt reflect.Type, flags encodeFlags) encodeFunc {
 		}
 	}
 
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		if rv.IsNil() {
 			if flags&flagNull != 0 {
 				return nullAV, nil
@@ -294,8 +369,8 @@ func (def *typedef) encodeStruct(rt reflect.Type, flags encodeFlags, info *struc
 		fields = append(fields, *field)
 	}
 
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
-		item, err := encodeItem(fields, rv)
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		item, err := encodeItem(ctx, fields, rv)
 		if err != nil {
 			return nil, err
 		}
@@ -317,7 +392,7 @@ func encodeSliceSet(rt /* []T */ reflect.Type, flags encodeFlags) (encodeFunc, e
 	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
 		return encodeSliceNS((reflect.Value).Uint, strconv.FormatUint), nil
 	case reflect.Float64, reflect.Float32:
-		return encodeSliceNS((reflect.Value).Float, formatFloat), nil
+		return encodeFloatSet(encodeSliceNS((reflect.Value).Float, formatFloat)), nil
 
 	// SS
 	case reflect.String:
@@ -333,7 +408,7 @@ func encodeSliceSet(rt /* []T */ reflect.Type, flags encodeFlags) (encodeFunc, e
 	return nil, fmt.Errorf("dynamo: invalid type for set: %v", rt)
 }
 
-func encodeSliceTMSS(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func encodeSliceTMSS(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	ss := make([]string, 0, rv.Len())
 	for i := 0; i < rv.Len(); i++ {
 		tm := rv.Index(i).Interface().(encoding.TextMarshaler)
@@ -352,7 +427,7 @@ func encodeSliceTMSS(rv reflect.Value, flags encodeFlags) (types.AttributeValue,
 	return &types.AttributeValueMemberSS{Value: ss}, nil
 }
 
-func encodeSliceSS(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func encodeSliceSS(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	ss := make([]string, 0, rv.Len())
 	for i := 0; i < rv.Len(); i++ {
 		s := rv.Index(i).String()
@@ -367,7 +442,7 @@ func encodeSliceSS(rv reflect.Value, flags encodeFlags) (types.AttributeValue, e
 	return &types.AttributeValueMemberSS{Value: ss}, nil
 }
 
-func encodeSliceBS(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func encodeSliceBS(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	bs := make([][]byte, 0, rv.Len())
 	for i := 0; i < rv.Len(); i++ {
 		b := rv.Index(i).Bytes()
@@ -403,7 +478,7 @@ func (def *typedef) encodeMapM(rt reflect.Type, flags encodeFlags, info *structI
 		return nil, err
 	}
 
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		if rv.IsNil() {
 			if flags&flagAllowEmpty != 0 {
 				return &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}, nil
@@ -418,17 +493,17 @@ func (def *typedef) encodeMapM(rt reflect.Type, flags encodeFlags, info *structI
 
 		iter := rv.MapRange()
 		for iter.Next() {
-			v, err := valueEnc(iter.Value(), subflags)
+			kstr, err := keyString(iter.Key())
 			if err != nil {
 				return nil, err
 			}
-			if v == nil {
-				continue
-			}
 
-			kstr, err := keyString(iter.Key())
+			v, err := valueEnc(ctx, iter.Value(), subflags)
 			if err != nil {
-				return nil, err
+				return nil, floatPath(err, kstr)
+			}
+			if v == nil {
+				continue
 			}
 
 			avs[kstr] = v
@@ -450,7 +525,7 @@ func encodeMapSet(rt /* map[T]bool | map[T]struct{} */ reflect.Type, flags encod
 	}
 
 	if rt.Key().Implements(rtypeTextMarshaler) {
-		return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 			length := rv.Len()
 			ss := make([]string, 0, length)
 			iter := rv.MapRange()
@@ -471,6 +546,9 @@ func encodeMapSet(rt /* map[T]bool | map[T]struct{} */ reflect.Type, flags encod
 			if len(ss) == 0 {
 				return nil, nil
 			}
+			if sortSets.Load() {
+				slices.Sort(ss)
+			}
 			return &types.AttributeValueMemberSS{Value: ss}, nil
 		}, nil
 	}
@@ -482,11 +560,11 @@ func encodeMapSet(rt /* map[T]bool | map[T]struct{} */ reflect.Type, flags encod
 	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
 		return encodeMapNS[uint64](truthy, (reflect.Value).Uint, strconv.FormatUint), nil
 	case reflect.Float32, reflect.Float64:
-		return encodeMapNS[float64](truthy, (reflect.Value).Float, formatFloat), nil
+		return encodeFloatSet(encodeMapNS[float64](truthy, (reflect.Value).Float, formatFloat)), nil
 
 	// SS
 	case reflect.String:
-		return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 			ss := make([]string, 0, rv.Len())
 			iter := rv.MapRange()
 			for iter.Next() {
@@ -502,6 +580,9 @@ func encodeMapSet(rt /* map[T]bool | map[T]struct{} */ reflect.Type, flags encod
 			if len(ss) == 0 {
 				return nil, nil
 			}
+			if sortSets.Load() {
+				slices.Sort(ss)
+			}
 			return &types.AttributeValueMemberSS{Value: ss}, nil
 		}, nil
 
@@ -509,21 +590,23 @@ func encodeMapSet(rt /* map[T]bool | map[T]struct{} */ reflect.Type, flags encod
 	case reflect.Array:
 		if rt.Key().Elem().Kind() == reflect.Uint8 {
 			size := rt.Key().Len()
-			return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+			return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 				bs := make([][]byte, 0, rv.Len())
-				key := make([]byte, size)
-				keyv := reflect.ValueOf(key)
 				iter := rv.MapRange()
 				for iter.Next() {
 					if useBool && !iter.Value().Equal(truthy) {
 						continue
 					}
-					reflect.Copy(keyv, iter.Key())
+					key := make([]byte, size)
+					reflect.Copy(reflect.ValueOf(key), iter.Key())
 					bs = append(bs, key)
 				}
 				if len(bs) == 0 {
 					return nil, nil
 				}
+				if sortSets.Load() {
+					slices.SortFunc(bs, bytes.Compare)
+				}
 				return &types.AttributeValueMemberBS{Value: bs}, nil
 			}, nil
 		}
@@ -537,14 +620,38 @@ type numberType interface {
 }
 
 func encodeN[T numberType](get func(reflect.Value) T, format func(T, int) string) encodeFunc {
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		str := format(get(rv), 10)
 		return &types.AttributeValueMemberN{Value: str}, nil
 	}
 }
 
+// encodeNS encodes a number as a string (S).
+func encodeNS[T numberType](get func(reflect.Value) T, format func(T, int) string) encodeFunc {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		str := format(get(rv), 10)
+		return &types.AttributeValueMemberS{Value: str}, nil
+	}
+}
+
+// encodeKeyFmt encodes an integer as a string (S), zero-padded to width digits (the "keyfmt" option),
+// so that lexicographic order matches numeric order.
+func encodeKeyFmt[T int64 | uint64](get func(reflect.Value) T, format func(T, int) string, width int) encodeFunc {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		n := get(rv)
+		if n < 0 {
+			return nil, fmt.Errorf("dynamo: keyfmt cannot encode negative number %d", n)
+		}
+		str := format(n, 10)
+		if len(str) > width {
+			return nil, fmt.Errorf("dynamo: keyfmt cannot encode number %s in %d digits", str, width)
+		}
+		return &types.AttributeValueMemberS{Value: strings.Repeat("0", width-len(str)) + str}, nil
+	}
+}
+
 func encodeSliceNS[T numberType](get func(reflect.Value) T, format func(T, int) string) encodeFunc {
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		ns := make([]string, 0, rv.Len())
 		for i := 0; i < rv.Len(); i++ {
 			n := get(rv.Index(i))
@@ -563,8 +670,8 @@ func encodeSliceNS[T numberType](get func(reflect.Value) T, format func(T, int)
 
 func encodeMapNS[T numberType](truthy reflect.Value, get func(reflect.Value) T, format func(T, int) string) encodeFunc {
 	useBool := truthy.Kind() == reflect.Bool
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
-		ns := make([]string, 0, rv.Len())
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		nums := make([]T, 0, rv.Len())
 		iter := rv.MapRange()
 		for iter.Next() {
 			if useBool && !iter.Value().Equal(truthy) {
@@ -574,12 +681,18 @@ func encodeMapNS[T numberType](truthy reflect.Value, get func(reflect.Value) T,
 			if flags&flagOmitEmptyElem != 0 && n == 0 {
 				continue
 			}
-			str := format(n, 10)
-			ns = append(ns, str)
+			nums = append(nums, n)
 		}
-		if len(ns) == 0 {
+		if len(nums) == 0 {
 			return nil, nil
 		}
+		if sortSets.Load() {
+			slices.Sort(nums)
+		}
+		ns := make([]string, len(nums))
+		for i, n := range nums {
+			ns[i] = format(n, 10)
+		}
 		return &types.AttributeValueMemberNS{Value: ns}, nil
 	}
 }
@@ -595,7 +708,7 @@ func encodeSet(rt /* []T | map[T]bool | map[T]struct{} */ reflect.Type, flags en
 	return nil, fmt.Errorf("dynamo: marshal: invalid type for set %s", rt.String())
 }
 
-func (def *typedef) encodeList(rt reflect.Type, flags encodeFlags, info *structInfo) (encodeFunc, error) {
+func listSubflags(flags encodeFlags) encodeFlags {
 	// lists CAN be empty
 	subflags := flagNone
 	if flags&flagOmitEmptyElem == 0 {
@@ -608,38 +721,133 @@ func (def *typedef) encodeList(rt reflect.Type, flags encodeFlags, info *structI
 		// e.g. maps inside a list
 		subflags |= flagAllowEmptyElem
 	}
+	return subflags
+}
+
+// appendListElem encodes a list element and appends it to avs.
+func appendListElem(ctx context.Context, avs []types.AttributeValue, enc encodeFunc, rv reflect.Value, flags, subflags encodeFlags) ([]types.AttributeValue, error) {
+	av, err := enc(ctx, rv, flags|subflags)
+	if err != nil {
+		return avs, err
+	}
+	if av == nil {
+		if flags&flagOmitEmptyElem != 0 {
+			return avs, nil
+		}
+		av = nullAV
+	}
+	return append(avs, av), nil
+}
 
+func (def *typedef) encodeList(rt reflect.Type, flags encodeFlags, info *structInfo) (encodeFunc, error) {
+	subflags := listSubflags(flags)
 	valueEnc, err := def.encodeType(rt.Elem(), subflags, info)
 	if err != nil {
 		return nil, err
 	}
 
-	return func(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 		avs := make([]types.AttributeValue, 0, rv.Len())
 		for i := 0; i < rv.Len(); i++ {
-			innerVal := rv.Index(i)
-			av, err := valueEnc(innerVal, flags|subflags)
+			var err error
+			avs, err = appendListElem(ctx, avs, valueEnc, rv.Index(i), flags, subflags)
 			if err != nil {
-				return nil, err
+				return nil, floatPath(err, "["+strconv.Itoa(i)+"]")
 			}
-			if av == nil {
-				if flags&flagOmitEmptyElem != 0 {
-					continue
-				}
-				av = nullAV
+		}
+		if flags&flagOmitEmpty != 0 && len(avs) == 0 {
+			return nil, nil
+		}
+		return &types.AttributeValueMemberL{Value: avs}, nil
+	}, nil
+}
+
+// encodeChan encodes a receivable channel as a list, receiving values until it is closed.
+func (def *typedef) encodeChan(rt reflect.Type, flags encodeFlags, info *structInfo) (encodeFunc, error) {
+	subflags := listSubflags(flags)
+	valueEnc, err := def.encodeType(rt.Elem(), subflags, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		if rv.IsNil() {
+			if flags&flagNull != 0 {
+				return nullAV, nil
+			}
+			return nil, nil
+		}
+		var avs []types.AttributeValue
+		for {
+			v, ok := rv.Recv()
+			if !ok {
+				break
+			}
+			var err error
+			avs, err = appendListElem(ctx, avs, valueEnc, v, flags, subflags)
+			if err != nil {
+				return nil, err
 			}
-			if av != nil {
-				avs = append(avs, av)
+		}
+		if flags&flagOmitEmpty != 0 && len(avs) == 0 {
+			return nil, nil
+		}
+		if avs == nil {
+			avs = []types.AttributeValue{}
+		}
+		return &types.AttributeValueMemberL{Value: avs}, nil
+	}, nil
+}
+
+// encodeSeq encodes an iterator function (iter.Seq[T]) as a list.
+func (def *typedef) encodeSeq(rt, elem reflect.Type, flags encodeFlags, info *structInfo) (encodeFunc, error) {
+	subflags := listSubflags(flags)
+	valueEnc, err := def.encodeType(elem, subflags, info)
+	if err != nil {
+		return nil, err
+	}
+	yieldType := rt.In(0)
+
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		if rv.IsNil() {
+			if flags&flagNull != 0 {
+				return nullAV, nil
 			}
+			return nil, nil
+		}
+		var avs []types.AttributeValue
+		var err error
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			avs, err = appendListElem(ctx, avs, valueEnc, args[0], flags, subflags)
+			return []reflect.Value{reflect.ValueOf(err == nil).Convert(yieldType.Out(0))}
+		})
+		rv.Call([]reflect.Value{yield})
+		if err != nil {
+			return nil, err
 		}
 		if flags&flagOmitEmpty != 0 && len(avs) == 0 {
 			return nil, nil
 		}
+		if avs == nil {
+			avs = []types.AttributeValue{}
+		}
 		return &types.AttributeValueMemberL{Value: avs}, nil
 	}, nil
 }
 
-func (def *typedef) encodeAny(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+// seqElem returns T if rt is of the form func(yield func(T) bool), like iter.Seq[T].
+func seqElem(rt reflect.Type) (reflect.Type, bool) {
+	if rt.Kind() != reflect.Func || rt.NumIn() != 1 || rt.NumOut() != 0 {
+		return nil, false
+	}
+	yield := rt.In(0)
+	if yield.Kind() != reflect.Func || yield.NumIn() != 1 || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return yield.In(0), true
+}
+
+func (def *typedef) encodeAny(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	if !rv.CanInterface() || rv.IsNil() {
 		if flags&flagNull != 0 {
 			return nullAV, nil
@@ -650,7 +858,7 @@ func (def *typedef) encodeAny(rv reflect.Value, flags encodeFlags) (types.Attrib
 	if err != nil {
 		return nil, err
 	}
-	return enc(rv.Elem(), flags)
+	return enc(ctx, rv.Elem(), flags)
 }
 
 func encodeUnixTime(rt reflect.Type) encodeFunc {
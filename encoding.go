@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"context"
 	"encoding"
 	"fmt"
 	"reflect"
@@ -10,16 +11,25 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-var typeCache sync.Map // unmarshalKey → *typedef
+var typeCache sync.Map // typeKey → *typedef
+
+// typeKey identifies a typedef: types are analyzed separately for each name mapper.
+type typeKey struct {
+	rt    reflect.Type
+	names *NameMapper
+}
 
 type typedef struct {
 	decoders map[unmarshalKey]decodeFunc
 	fields   []structField
 	info     *structInfo
+	// names of untagged fields, see DB.NameMapper
+	names *NameMapper
 }
 
-func newTypedef(rt reflect.Type) (*typedef, error) {
+func newTypedef(rt reflect.Type, names *NameMapper) (*typedef, error) {
 	def := &typedef{
+		names:    names,
 		decoders: make(map[unmarshalKey]decodeFunc),
 		// encoders: make(map[encodeKey]encodeFunc),
 	}
@@ -55,21 +65,23 @@ func (def *typedef) init(rt reflect.Type) error {
 	return nil
 }
 
-func registerTypedef(gotype reflect.Type, def *typedef) *typedef {
-	canon, _ := typeCache.LoadOrStore(gotype, def)
+func registerTypedef(key typeKey, def *typedef) *typedef {
+	canon, _ := typeCache.LoadOrStore(key, def)
 	return canon.(*typedef)
 }
 
-func typedefOf(rt reflect.Type) (*typedef, error) {
-	v, ok := typeCache.Load(rt)
+// typedefOf returns the typedef of rt, naming untagged fields with names (see DB.NameMapper).
+func typedefOf(rt reflect.Type, names *NameMapper) (*typedef, error) {
+	key := typeKey{rt: rt, names: names}
+	v, ok := typeCache.Load(key)
 	if ok {
 		return v.(*typedef), nil
 	}
-	def, err := newTypedef(rt)
+	def, err := newTypedef(rt, names)
 	if err != nil {
 		return nil, err
 	}
-	def = registerTypedef(rt, def)
+	def = registerTypedef(key, def)
 	return def, nil
 }
 
@@ -86,7 +98,7 @@ func (def *typedef) handle(key unmarshalKey, fn decodeFunc) {
 	// debugf("handle %#v -> %s", key, runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name())
 }
 
-func (def *typedef) encodeItem(rv reflect.Value) (Item, error) {
+func (def *typedef) encodeItem(ctx context.Context, rv reflect.Value) (Item, error) {
 	rv = indirectPtrNoAlloc(rv)
 	if shouldBypassEncodeItem(rv.Type()) {
 		return def.encodeItemBypass(rv.Interface())
@@ -99,19 +111,19 @@ func (def *typedef) encodeItem(rv reflect.Value) (Item, error) {
 	rv = indirectNoAlloc(rv)
 	switch rv.Kind() {
 	case reflect.Struct:
-		return encodeItem(def.fields, rv)
+		return encodeItem(ctx, def.fields, rv)
 	case reflect.Map:
 		enc, err := def.encodeMapM(rv.Type(), flagNone, def.info)
 		if err != nil {
 			return nil, err
 		}
-		av, err := enc(rv, flagNone)
+		av, err := enc(ctx, rv, flagNone)
 		if err != nil {
 			return nil, err
 		}
 		return av.(*types.AttributeValueMemberM).Value, err
 	}
-	return encodeItem(def.fields, rv)
+	return encodeItem(ctx, def.fields, rv)
 }
 
 func (def *typedef) encodeItemBypass(in any) (item map[string]types.AttributeValue, err error) {
@@ -131,7 +143,7 @@ func (def *typedef) encodeItemBypass(in any) (item map[string]types.AttributeVal
 	return
 }
 
-func (def *typedef) decodeItem(item map[string]types.AttributeValue, outv reflect.Value) error {
+func (def *typedef) decodeItem(ctx context.Context, item map[string]types.AttributeValue, outv reflect.Value) error {
 	out := outv
 	outv = indirectPtr(outv)
 	if shouldBypassDecodeItem(outv.Type()) {
@@ -149,9 +161,9 @@ func (def *typedef) decodeItem(item map[string]types.AttributeValue, outv reflec
 	// debugf("decode item: %v -> %T(%v)", item, out, out)
 	switch outv.Kind() {
 	case reflect.Struct:
-		return decodeStruct(def, flagNone, &types.AttributeValueMemberM{Value: item}, outv)
+		return decodeStruct(ctx, def, flagNone, &types.AttributeValueMemberM{Value: item}, outv)
 	case reflect.Map:
-		return def.decodeAttr(flagNone, &types.AttributeValueMemberM{Value: item}, outv)
+		return def.decodeAttr(ctx, flagNone, &types.AttributeValueMemberM{Value: item}, outv)
 	}
 
 bad:
@@ -171,22 +183,26 @@ func (def *typedef) decodeItemBypass(item Item, out any) error {
 	return nil
 }
 
-func (def *typedef) decodeAttr(flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+func (def *typedef) decodeAttr(ctx context.Context, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
 	if !rv.IsValid() || av == nil {
 		return nil
 	}
 
 	// debugf("decodeAttr: %v(%v) <- %v", rv.Type(), rv, av)
 
+	if ok, err := decodeHooked(av, rv); ok {
+		return err
+	}
+
 	if _, isNull := av.(*types.AttributeValueMemberNULL); isNull {
-		return decodeNull(def, flags, av, rv)
+		return decodeNull(ctx, def, flags, av, rv)
 	}
 
 	rv = indirectPtr(rv)
 
 retry:
 	gotype := rv.Type()
-	ok, err := def.decodeType(unmarshalKey{gotype: gotype, shape: shapeOf(av)}, flags, av, rv)
+	ok, err := def.decodeType(ctx, unmarshalKey{gotype: gotype, shape: shapeOf(av)}, flags, av, rv)
 	if err != nil {
 		return err
 	}
@@ -194,7 +210,7 @@ retry:
 		// debugf("lookup1 %#v -> %v", unmarshalKey{gotype: gotype, shape: shapeOf(av)}, rv)
 		return nil
 	}
-	ok, err = def.decodeType(unmarshalKey{gotype: gotype, shape: shapeAny}, flags, av, rv)
+	ok, err = def.decodeType(ctx, unmarshalKey{gotype: gotype, shape: shapeAny}, flags, av, rv)
 	if err != nil {
 		return err
 	}
@@ -209,15 +225,22 @@ retry:
 	}
 
 	// debugf("lookup fail %#v.", unmarshalKey{gotype: gotype, shape: shapeOf(av)})
-	return fmt.Errorf("dynamo: cannot unmarshal %s attribute value into type %s", avTypeName(av), rv.Type().String())
+	return errUnmarshalType(ctx, av, rv.Type())
+}
+
+func errUnmarshalType(ctx context.Context, av types.AttributeValue, rt reflect.Type) error {
+	if errorValueLimit(ctx) == 0 {
+		return fmt.Errorf("dynamo: cannot unmarshal %s attribute value into type %s", avTypeName(av), rt.String())
+	}
+	return fmt.Errorf("dynamo: cannot unmarshal %s attribute value %s into type %s", avTypeName(av), formatAV(av, errorValueLimit(ctx)), rt.String())
 }
 
-func (def *typedef) decodeType(key unmarshalKey, flags encodeFlags, av types.AttributeValue, rv reflect.Value) (bool, error) {
+func (def *typedef) decodeType(ctx context.Context, key unmarshalKey, flags encodeFlags, av types.AttributeValue, rv reflect.Value) (bool, error) {
 	do, ok := def.decoders[key]
 	if !ok {
 		return false, nil
 	}
-	err := do(def, flags, av, rv)
+	err := do(ctx, def, flags, av, rv)
 	return true, err
 }
 
@@ -241,6 +264,7 @@ func (def *typedef) learn(rt reflect.Type) {
 	if try.Kind() != reflect.Pointer {
 		try = reflect.PointerTo(try)
 	}
+methods:
 	for {
 		switch try {
 		case rtypeAttrB:
@@ -307,6 +331,11 @@ func (def *typedef) learn(rt reflect.Type) {
 		// 		*dst = src
 		// 		return nil
 		// 	}))
+		case try.Implements(rtypeUnmarshalerCtx):
+			def.handle(this(shapeAny), decode2ctx(func(ctx context.Context, t UnmarshalerCtx, av types.AttributeValue) error {
+				return t.UnmarshalDynamoContext(ctx, av)
+			}))
+			return
 		case try.Implements(rtypeUnmarshaler):
 			def.handle(this(shapeAny), decode2(func(t Unmarshaler, av types.AttributeValue) error {
 				return t.UnmarshalDynamo(av)
@@ -321,7 +350,15 @@ func (def *typedef) learn(rt reflect.Type) {
 			def.handle(this(shapeS), decode2(func(t encoding.TextUnmarshaler, av types.AttributeValue) error {
 				return t.UnmarshalText([]byte(av.(*types.AttributeValueMemberS).Value))
 			}))
+			if try.Implements(rtypeBinaryUnmarshaler) {
+				def.handle(this(shapeB), decodeBinaryUnmarshaler)
+			}
 			return
+		case try.Implements(rtypeBinaryUnmarshaler):
+			def.handle(this(shapeB), decodeBinaryUnmarshaler)
+			// other shapes are decoded as they were before binary support,
+			// so that existing data (such as structs stored as maps) can still be read
+			break methods
 		}
 
 		if try.Kind() == reflect.Pointer {
@@ -341,18 +378,22 @@ func (def *typedef) learn(rt reflect.Type) {
 
 	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
 		def.handle(this(shapeN), decodeInt)
+		def.handle(this(shapeS), decodeStringAsNumber(decodeInt))
 
 	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
 		def.handle(this(shapeN), decodeUint)
+		def.handle(this(shapeS), decodeStringAsNumber(decodeUint))
 
 	case reflect.Float64, reflect.Float32:
 		def.handle(this(shapeN), decodeFloat)
+		def.handle(this(shapeS), decodeStringAsNumber(decodeFloat))
 
 	case reflect.String:
 		def.handle(this(shapeS), decodeString)
+		def.handle(this(shapeN), decodeNumberAsString)
 
 	case reflect.Struct:
-		visitTypeFields(rt, nil, nil, func(_ string, _ []int, flags encodeFlags, vt reflect.Type) error {
+		visitTypeFields(rt, def.names, nil, nil, func(_ string, _ []int, flags encodeFlags, vt reflect.Type) error {
 			def.learn(vt)
 			return nil
 		})
@@ -367,7 +408,7 @@ func (def *typedef) learn(rt reflect.Type) {
 
 		truthy := truthy(rt)
 		if !truthy.IsValid() {
-			bad := func(_ *typedef, _ encodeFlags, _ types.AttributeValue, _ reflect.Value) error {
+			bad := func(ctx context.Context, _ *typedef, _ encodeFlags, _ types.AttributeValue, _ reflect.Value) error {
 				return fmt.Errorf("dynamo: unmarshal map set: value type must be struct{} or bool, got %v", rt)
 			}
 			def.handle(this(shapeSS), bad)
@@ -401,7 +442,10 @@ func (def *typedef) learn(rt reflect.Type) {
 		// interface{}
 		if rt.NumMethod() == 0 {
 			def.handle(this(shapeAny), decodeAny)
+			return
 		}
+		// registered types (see RegisterType)
+		def.handle(this(shapeM), decodeRegistered)
 	}
 }
 
@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -104,7 +105,7 @@ func TestAWSItems(t *testing.T) {
 	}
 
 	var unmarshaled, unmarshaledOfficial Foo
-	err = unmarshalItem(official, AWSEncoding(&unmarshaled))
+	err = unmarshalItem(context.Background(), official, AWSEncoding(&unmarshaled))
 	if err != nil {
 		t.Error(err)
 	}
@@ -131,7 +132,7 @@ func TestAWSUnmarshalAppend(t *testing.T) {
 		A: "two",
 		B: 222,
 	}
-	err := unmarshalAppend(Item{
+	err := unmarshalAppend(context.Background(), Item{
 		"one": &types.AttributeValueMemberS{Value: "test"},
 		"two": &types.AttributeValueMemberN{Value: "555"},
 	}, &list)
@@ -141,7 +142,7 @@ func TestAWSUnmarshalAppend(t *testing.T) {
 	if len(list) != 1 && reflect.DeepEqual(list, []foo{expect1}) {
 		t.Error("bad AWS unmarshal append:", list)
 	}
-	err = unmarshalAppend(Item{
+	err = unmarshalAppend(context.Background(), Item{
 		"one": &types.AttributeValueMemberS{Value: ("two")},
 		"two": &types.AttributeValueMemberN{Value: ("222")},
 	}, &list)
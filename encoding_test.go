@@ -3,6 +3,7 @@ package dynamo
 import (
 	"encoding"
 	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -144,6 +145,11 @@ var encodingTests = []struct {
 		in:   textMarshaler(true),
 		out:  &types.AttributeValueMemberS{Value: "true"},
 	},
+	{
+		name: "encoding.BinaryMarshaler",
+		in:   binaryMarshaler{Lo: 1, Hi: 2},
+		out:  &types.AttributeValueMemberB{Value: []byte{2, 1}},
+	},
 	{
 		name: "dynamodb.AttributeValue",
 		in: &types.AttributeValueMemberL{Value: []types.AttributeValue{
@@ -339,6 +345,7 @@ var itemEncodingTests = []struct {
 			NilCustom  *customMarshaler
 			NilText    *textMarshaler
 			NilAWS     *attributevalue.UnixTime
+			EmptyBin   binaryMarshaler
 		}{
 			OK:     "OK",
 			EmptyL: []int{},
@@ -953,6 +960,26 @@ func (tm *ptrTextMarshaler) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// binaryMarshaler is a struct with a binary encoding, which takes priority over encoding its fields.
+type binaryMarshaler struct {
+	Lo, Hi uint8
+}
+
+func (bm binaryMarshaler) MarshalBinary() ([]byte, error) {
+	if bm == (binaryMarshaler{}) {
+		return nil, nil
+	}
+	return []byte{bm.Hi, bm.Lo}, nil
+}
+
+func (bm *binaryMarshaler) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("binaryMarshaler: bad length %d", len(data))
+	}
+	bm.Hi, bm.Lo = data[0], data[1]
+	return nil
+}
+
 type customItemMarshaler struct {
 	Thing interface{} `dynamo:"thing"`
 }
@@ -1043,4 +1070,7 @@ var (
 	_ encoding.TextUnmarshaler = new(textMarshaler)
 	_ encoding.TextMarshaler   = new(ptrTextMarshaler)
 	_ encoding.TextUnmarshaler = new(ptrTextMarshaler)
+
+	_ encoding.BinaryMarshaler   = new(binaryMarshaler)
+	_ encoding.BinaryUnmarshaler = new(binaryMarshaler)
 )
@@ -0,0 +1,289 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/guregu/dynamo/v2/internal/exprs"
+)
+
+// Warning is a likely mistake in an expression, found by [CheckExpressions] or [ExpressionAudit].
+type Warning struct {
+	// Expr is the expression.
+	Expr string
+	// Pos is the byte offset of the problem within Expr.
+	Pos int
+	// Text is the problematic part of Expr, such as an attribute name.
+	Text string
+	// Reason describes the problem.
+	Reason string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("dynamo: %s: %s (at position %d of %q)", w.Text, w.Reason, w.Pos, w.Expr)
+}
+
+// expression keywords and functions, which are reserved words that don't need escaping
+var (
+	exprKeywords = map[string]bool{
+		"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+		"SET": true, "REMOVE": true, "ADD": true, "DELETE": true,
+	}
+	exprFunctions = map[string]bool{
+		"attribute_exists": true, "attribute_not_exists": true, "attribute_type": true,
+		"begins_with": true, "contains": true, "size": true,
+		"if_not_exists": true, "list_append": true,
+	}
+)
+
+// CheckExpressions checks expressions written for this package's methods, such as [Query.Filter] or [Update.If],
+// for mistakes that would make DynamoDB reject them or that are easy to make by accident. It reports:
+//   - reserved words used as attribute names without being escaped with single quotes or $ placeholders
+//   - unknown functions
+//   - number literals, which must be ? placeholders instead (except for list indexes like [0])
+//   - DynamoDB-style #name and :value placeholders, which aren't substituted by this package
+//   - double-quoted names, which must use single quotes instead
+//   - empty quoted names, and quoted names that look like document paths, such as 'Info.Name',
+//     which refer to a single top-level attribute with a dot in its name
+//
+// It is meant to be run in tests, against expressions that are constants or built by the application.
+// To check the expressions sent to DynamoDB while tests run, see [ExpressionAudit].
+func CheckExpressions(exprs ...string) []Warning {
+	var warnings []Warning
+	for _, expr := range exprs {
+		warnings = append(warnings, checkExpr(expr, false)...)
+	}
+	return warnings
+}
+
+// checkExpr checks expr, which is raw if it is in DynamoDB's syntax instead of this package's.
+func checkExpr(expr string, raw bool) []Warning {
+	var warnings []Warning
+	warn := func(pos int, text, reason string) {
+		warnings = append(warnings, Warning{Expr: expr, Pos: pos, Text: text, Reason: reason})
+	}
+	if raw {
+		checkExprText(expr, 0, true, warn)
+		return warnings
+	}
+	lexed, err := exprs.Parse(expr)
+	if err != nil {
+		warn(0, expr, err.Error())
+		return warnings
+	}
+	for _, item := range lexed.Items {
+		// Pos is the end of each item
+		start := item.Pos - len(item.Val)
+		switch item.Type {
+		case exprs.ItemText:
+			checkExprText(item.Val, start, false, warn)
+		case exprs.ItemQuotedName:
+			name := item.Val[1 : len(item.Val)-1]
+			switch {
+			case name == "":
+				warn(start, item.Val, "empty attribute name")
+			case strings.ContainsAny(name, ".["):
+				warn(start, item.Val, "quoted name is a single attribute name, not a path; quote each part of a path separately")
+			}
+		}
+	}
+	return warnings
+}
+
+// checkExprText checks the unescaped text of an expression, starting at offset within the whole expression.
+func checkExprText(text string, offset int, raw bool, warn func(pos int, text, reason string)) {
+	isWord := func(c byte) bool {
+		return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	// prev returns the last non-space byte before i, or 0.
+	prev := func(i int) byte {
+		for i--; i >= 0; i-- {
+			if text[i] != ' ' && text[i] != '\t' && text[i] != '\n' {
+				return text[i]
+			}
+		}
+		return 0
+	}
+	// next returns the first non-space byte at or after i, or 0.
+	next := func(i int) byte {
+		for ; i < len(text); i++ {
+			if text[i] != ' ' && text[i] != '\t' && text[i] != '\n' {
+				return text[i]
+			}
+		}
+		return 0
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		start := i
+		switch {
+		case c == '#' || c == ':':
+			i++
+			for i < len(text) && isWord(text[i]) {
+				i++
+			}
+			if !raw && i > start+1 {
+				warn(offset+start, text[start:i], "DynamoDB placeholders aren't substituted; use $ or ? placeholders instead")
+			}
+		case c == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end == -1 {
+				i = len(text)
+			} else {
+				i += end + 2
+			}
+			warn(offset+start, text[start:i], "double quotes aren't valid in expressions; use single quotes to escape names")
+		case c >= '0' && c <= '9':
+			for i < len(text) && isWord(text[i]) {
+				i++
+			}
+			if prev(start) != '[' {
+				warn(offset+start, text[start:i], "number literals aren't valid in expressions; use a ? placeholder instead")
+			}
+		case isWord(c):
+			for i < len(text) && isWord(text[i]) {
+				i++
+			}
+			word := text[start:i]
+			upper := strings.ToUpper(word)
+			if exprKeywords[upper] {
+				continue
+			}
+			if next(i) == '(' {
+				if !exprFunctions[word] {
+					warn(offset+start, word, "unknown function")
+				}
+				continue
+			}
+			if reserved[upper] {
+				if raw {
+					warn(offset+start, word, "reserved word used as an attribute name; use an expression attribute name placeholder instead")
+				} else {
+					warn(offset+start, word, "reserved word used as an attribute name; quote it ('"+word+"') or use a $ placeholder instead")
+				}
+			}
+		default:
+			i++
+		}
+	}
+}
+
+// ExpressionAudit checks every expression sent to DynamoDB by a client for unescaped reserved words
+// and other likely mistakes, as [CheckExpressions] does. Use [WithExpressionAudit] to enable it.
+// It is meant for tests, to check the expressions an application builds at runtime:
+//
+//	var audit dynamo.ExpressionAudit
+//	db := dynamo.New(cfg, dynamo.WithExpressionAudit(&audit))
+//	// ... run tests
+//	for _, w := range audit.Warnings() {
+//		t.Error(w)
+//	}
+//
+// Expressions are checked after placeholders are substituted, in DynamoDB's own syntax.
+// Each distinct expression is only checked once. An ExpressionAudit is safe for concurrent use.
+type ExpressionAudit struct {
+	mu       sync.Mutex
+	seen     map[string]struct{}
+	warnings []Warning
+}
+
+// Warnings returns the warnings found so far.
+func (audit *ExpressionAudit) Warnings() []Warning {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	return append([]Warning(nil), audit.warnings...)
+}
+
+func (audit *ExpressionAudit) check(expr string) {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if _, ok := audit.seen[expr]; ok {
+		return
+	}
+	if audit.seen == nil {
+		audit.seen = make(map[string]struct{})
+	}
+	audit.seen[expr] = struct{}{}
+	audit.warnings = append(audit.warnings, checkExpr(expr, true)...)
+}
+
+// WithExpressionAudit returns an option for [New] (or [dynamodb.NewFromConfig]) that checks
+// the expressions of every request made by the client, recording warnings in audit.
+// Requests are sent regardless of warnings.
+func WithExpressionAudit(audit *ExpressionAudit) func(*dynamodb.Options) {
+	id := middlewareID("dynamo.ExpressionAudit")
+	return func(opts *dynamodb.Options) {
+		opts.APIOptions = append(opts.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(&auditMiddleware{id: id, audit: audit}, middleware.Before)
+		})
+	}
+}
+
+type auditMiddleware struct {
+	id    string
+	audit *ExpressionAudit
+}
+
+func (m *auditMiddleware) ID() string {
+	return m.id
+}
+
+func (m *auditMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	for _, expr := range inputExpressions(in.Parameters) {
+		m.audit.check(expr)
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+// inputExpressions returns the expressions of an operation's input,
+// found in fields such as FilterExpression, including those of batch and transaction items.
+func inputExpressions(input any) []string {
+	var found []string
+	var walk func(rv reflect.Value)
+	walk = func(rv reflect.Value) {
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if !rv.IsNil() {
+				walk(rv.Elem())
+			}
+		case reflect.Struct:
+			rt := rv.Type()
+			for i := 0; i < rt.NumField(); i++ {
+				field := rt.Field(i)
+				if !field.IsExported() {
+					continue
+				}
+				if strings.HasSuffix(field.Name, "Expression") {
+					if expr, ok := rv.Field(i).Interface().(*string); ok && expr != nil && *expr != "" {
+						found = append(found, *expr)
+					}
+					continue
+				}
+				walk(rv.Field(i))
+			}
+		case reflect.Slice:
+			if kind := rv.Type().Elem().Kind(); kind != reflect.Struct && kind != reflect.Pointer {
+				return
+			}
+			for i := 0; i < rv.Len(); i++ {
+				walk(rv.Index(i))
+			}
+		case reflect.Map:
+			if rv.Type().Elem().Kind() == reflect.Struct {
+				iter := rv.MapRange()
+				for iter.Next() {
+					walk(iter.Value())
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(input))
+	return found
+}
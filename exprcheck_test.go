@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestCheckExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string // Text of each warning
+	}{
+		{"'Count' > ? AND attribute_exists($)", nil},
+		{"size(Tags) > ? AND Tags[0] = ?", nil},
+		{"NOT begins_with(UserName, ?) OR Msg IN (?, ?)", nil},
+		{"SET 'Name' = ?, Seq = Seq + ? REMOVE Obsolete", nil},
+		{"Count > ?", []string{"Count"}},
+		{"Info.Status = ?", []string{"Status"}},
+		{"#n = :v", []string{"#n", ":v"}},
+		{"Seq > 10", []string{"10"}},
+		{`"Name" = ?`, []string{`"Name"`}},
+		{"'' = ? OR 'Info.Name' = ?", []string{"''", "'Info.Name'"}},
+		{"startswith(Msg, ?)", []string{"startswith"}},
+		{"'unterminated", []string{"'unterminated"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, w := range CheckExpressions(test.expr) {
+			got = append(got, w.Text)
+			if w.Pos < 0 || w.Pos >= len(test.expr) {
+				t.Errorf("%s: bad position: %v", test.expr, w)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: want warnings for %v, got: %v", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestExpressionAudit(t *testing.T) {
+	var audit ExpressionAudit
+	m := &auditMiddleware{audit: &audit}
+	next := middleware.InitializeHandlerFunc(func(ctx context.Context, in middleware.InitializeInput) (middleware.InitializeOutput, middleware.Metadata, error) {
+		return middleware.InitializeOutput{}, middleware.Metadata{}, nil
+	})
+	inputs := []any{
+		&dynamodb.QueryInput{
+			KeyConditionExpression: aws.String("#k = :v"),
+			FilterExpression:       aws.String("Size > :n"),
+		},
+		&dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{UpdateExpression: aws.String("SET #a = :a"), ConditionExpression: aws.String("Size > :n")}},
+			{ConditionCheck: &types.ConditionCheck{ConditionExpression: aws.String("attribute_exists(Comment)")}},
+		}},
+		&dynamodb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{
+			"Table": {ProjectionExpression: aws.String("ID, #n")},
+		}},
+	}
+	for _, in := range inputs {
+		if _, _, err := m.HandleInitialize(context.Background(), middleware.InitializeInput{Parameters: in}, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, w := range audit.Warnings() {
+		got = append(got, w.Expr+": "+w.Text)
+	}
+	want := []string{"Size > :n: Size", "attribute_exists(Comment): Comment"}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("bad warnings. want:", want, "got:", got)
+	}
+}
+
+func TestExpressionAuditCombined(t *testing.T) {
+	var first, second ExpressionAudit
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		BaseEndpoint:     aws.String("http://127.0.0.1:1"),
+		RetryMaxAttempts: 1,
+	}, WithExpressionAudit(&first), WithExpressionAudit(&second))
+	db := NewFromIface(client)
+
+	// the request fails to connect, but both audits check it before it is sent
+	var out []widget
+	_ = db.Table("Audit").Get("UserID", 1).Filter("Size > ?", 1).All(context.Background(), &out)
+	for _, audit := range []*ExpressionAudit{&first, &second} {
+		if len(audit.Warnings()) != 1 {
+			t.Error("expected 1 warning, got:", audit.Warnings())
+		}
+	}
+}
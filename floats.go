@@ -0,0 +1,103 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// FloatError is returned when marshaling a NaN or infinite float as a number,
+// because DynamoDB numbers can't represent them.
+// See [DB.NonFiniteFloats] to marshal them differently.
+type FloatError struct {
+	// Value is the float that could not be marshaled.
+	Value float64
+	// Path is the document path of the value within the item, such as "Stats.Scores[2]",
+	// or empty if the value was marshaled by itself.
+	Path string
+}
+
+func (e *FloatError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("dynamo: marshal: cannot marshal %v as a number", e.Value)
+	}
+	return fmt.Sprintf("dynamo: marshal: %s: cannot marshal %v as a number", e.Path, e.Value)
+}
+
+// NonFiniteFloatFunc returns the attribute value to use in place of f, which is NaN or infinite.
+// A nil value is treated like other empty values: it is omitted from items and maps, and NULL within lists.
+// Returning an error fails marshaling.
+type NonFiniteFloatFunc func(f float64) (types.AttributeValue, error)
+
+// NonFiniteFloats returns a copy of db that marshals NaN and infinite floats with fn.
+// By default, or if fn is nil, marshaling them returns a [*FloatError].
+// For example, this can map them to sentinel values chosen by the caller:
+//
+//	db = db.NonFiniteFloats(func(f float64) (types.AttributeValue, error) {
+//		return &types.AttributeValueMemberS{Value: strconv.FormatFloat(f, 'f', -1, 64)}, nil
+//	})
+//
+// fn applies to items and values marshaled by the returned DB's requests,
+// but not to package-level functions such as [MarshalItem].
+// It isn't used for number sets, which always return an error for these values,
+// nor for fields with the "string" option, which are marshaled as strings such as "NaN" and "+Inf".
+// The returned DB shares db's table description cache.
+func (db *DB) NonFiniteFloats(fn NonFiniteFloatFunc) *DB {
+	cp := *db
+	cp.floats = fn
+	return &cp
+}
+
+type nonFiniteFloatKey struct{}
+
+// encodeFloat encodes a float as a number (N), handling NaN and infinities.
+func encodeFloat(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	f := rv.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if fn, ok := ctx.Value(nonFiniteFloatKey{}).(NonFiniteFloatFunc); ok {
+			return fn(f)
+		}
+		return nil, &FloatError{Value: f}
+	}
+	return &types.AttributeValueMemberN{Value: formatFloat(f, 10)}, nil
+}
+
+// encodeFloatSet wraps an encoder of a float set (NS), rejecting NaN and infinities.
+func encodeFloatSet(enc encodeFunc) encodeFunc {
+	return func(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+		av, err := enc(ctx, rv, flags)
+		if ns, ok := av.(*types.AttributeValueMemberNS); ok {
+			for _, n := range ns.Value {
+				switch n {
+				case "NaN", "+Inf", "-Inf":
+					f, _ := strconv.ParseFloat(n, 64)
+					return nil, &FloatError{Value: f}
+				}
+			}
+		}
+		return av, err
+	}
+}
+
+// floatPath prepends a path step (a name or list index like [0]) to the path of a FloatError.
+func floatPath(err error, step string) error {
+	var fe *FloatError
+	if !errors.As(err, &fe) {
+		return err
+	}
+	switch {
+	case fe.Path == "":
+		fe.Path = step
+	case strings.HasPrefix(fe.Path, "["):
+		fe.Path = step + fe.Path
+	default:
+		fe.Path = step + "." + fe.Path
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package dynamo
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// hedgeSamples is the number of recent latencies kept by a Hedger.
+	hedgeSamples = 200
+	// hedgeMinSamples is the number of latencies a Hedger needs before using its percentile.
+	hedgeMinSamples = 20
+)
+
+// Hedger decides when to send hedged requests: duplicates of slow requests, sent in the hope
+// that one of the two is answered sooner. See [Query.Hedge] and [Scan.Hedge].
+// A Hedger tracks the latency of the requests made with it, so it should be reused across requests,
+// typically one per read path. It is safe for concurrent use.
+type Hedger struct {
+	percentile float64
+	fallback   time.Duration
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// NewHedger returns a Hedger that sends a duplicate request when a request takes longer than the given
+// percentile (between 0 and 1, such as 0.99 for p99) of the latencies recently observed by requests using it.
+// Until enough latencies are observed, or if percentile is zero, it waits for fallback instead.
+func NewHedger(percentile float64, fallback time.Duration) *Hedger {
+	return &Hedger{
+		percentile: min(max(percentile, 0), 1),
+		fallback:   fallback,
+	}
+}
+
+// Delay returns how long a request can take before a duplicate is sent.
+func (h *Hedger) Delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.percentile == 0 || len(h.samples) < hedgeMinSamples {
+		return h.fallback
+	}
+	sorted := slices.Clone(h.samples)
+	slices.Sort(sorted)
+	i := int(math.Ceil(h.percentile*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// observe records the latency of a successful request.
+func (h *Hedger) observe(took time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < hedgeSamples {
+		h.samples = append(h.samples, took)
+		return
+	}
+	h.samples[h.next] = took
+	h.next = (h.next + 1) % hedgeSamples
+}
+
+// Hedge makes this query send a duplicate of any request that hasn't been answered within the delay given by h,
+// using whichever successful response arrives first and canceling the other request.
+// This trades extra read capacity for lower tail latency, and is meant for latency-critical read paths.
+// At most one duplicate is sent for each request, including each page of results.
+//
+// Only the response that was used is added to [Query.ConsumedCapacity] and counts towards [Query.Throttle],
+// so capacity is never counted twice, but the capacity consumed by abandoned requests is not reported.
+// Both requests are counted in the Requests field of ConsumedCapacity.
+func (q *Query) Hedge(h *Hedger) *Query {
+	q.hedger = h
+	return q
+}
+
+// Hedge makes this scan send a duplicate of any request that hasn't been answered within the delay given by h,
+// using whichever successful response arrives first and canceling the other request.
+// This trades extra read capacity for lower tail latency, and is meant for latency-critical read paths.
+// At most one duplicate is sent for each request, including each page of results.
+//
+// Only the response that was used is added to [Scan.ConsumedCapacity] and counts towards [Scan.Throttle],
+// so capacity is never counted twice, but the capacity consumed by abandoned requests is not reported.
+// Both requests are counted in the Requests field of ConsumedCapacity.
+func (s *Scan) Hedge(h *Hedger) *Scan {
+	s.hedger = h
+	return s
+}
+
+// hedge calls send, and if it hasn't returned by h's delay, calls it again concurrently,
+// returning the first successful result and canceling the other call.
+// If both calls fail, the first error is returned.
+// onHedge is called (from the calling goroutine) when the duplicate is sent.
+// If h is nil, send is called once.
+func hedge[T any](ctx context.Context, h *Hedger, send func(context.Context) (T, error), onHedge func()) (T, error) {
+	if h == nil {
+		return send(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type response struct {
+		result T
+		err    error
+		took   time.Duration
+	}
+	// buffered so that the abandoned call doesn't block
+	responses := make(chan response, 2)
+	call := func() {
+		start := time.Now()
+		result, err := send(ctx)
+		responses <- response{result: result, err: err, took: time.Since(start)}
+	}
+
+	go call()
+	pending := 1
+	timer := time.NewTimer(h.Delay())
+	defer timer.Stop()
+
+	var first error
+	for {
+		select {
+		case <-timer.C:
+			go call()
+			pending++
+			if onHedge != nil {
+				onHedge()
+			}
+		case res := <-responses:
+			pending--
+			if res.err == nil {
+				h.observe(res.took)
+				return res.result, nil
+			}
+			if first == nil {
+				first = res.err
+			}
+			if pending == 0 {
+				timer.Stop()
+				var zero T
+				return zero, first
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package dynamo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// slowFirstClient hangs on the first request until it's canceled, answering the rest right away.
+type slowFirstClient struct {
+	dynamodbiface.DynamoDBAPI
+	mu       sync.Mutex
+	calls    int
+	canceled chan struct{}
+}
+
+func (c *slowFirstClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	c.calls++
+	first := c.calls == 1
+	c.mu.Unlock()
+	if !first {
+		return nil
+	}
+	<-ctx.Done()
+	close(c.canceled)
+	return ctx.Err()
+}
+
+func (c *slowFirstClient) Query(ctx context.Context, _ *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{
+		Items:            []Item{{"ID": &types.AttributeValueMemberN{Value: "1"}}},
+		ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (c *slowFirstClient) Scan(ctx context.Context, _ *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{
+		Items:            []Item{{"ID": &types.AttributeValueMemberN{Value: "1"}}},
+		ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+	runs := map[string]func(table Table, h *Hedger, cc *ConsumedCapacity, out *[]Item) error{
+		"query": func(table Table, h *Hedger, cc *ConsumedCapacity, out *[]Item) error {
+			return table.Get("ID", 1).Hedge(h).ConsumedCapacity(cc).All(ctx, out)
+		},
+		"scan": func(table Table, h *Hedger, cc *ConsumedCapacity, out *[]Item) error {
+			return table.Scan().Hedge(h).ConsumedCapacity(cc).All(ctx, out)
+		},
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			client := &slowFirstClient{canceled: make(chan struct{})}
+			table := NewFromIface(client).Table("Hedged")
+			var cc ConsumedCapacity
+			var got []Item
+			if err := run(table, NewHedger(0, 10*time.Millisecond), &cc, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Error("bad results:", got)
+			}
+			if cc.Requests != 2 {
+				t.Error("want 2 requests, got:", cc.Requests)
+			}
+			if cc.Total != 1 {
+				t.Error("capacity counted more than once:", cc.Total)
+			}
+			select {
+			case <-client.canceled:
+			case <-time.After(time.Second):
+				t.Error("slow request wasn't canceled")
+			}
+		})
+	}
+}
+
+func TestHedgeErrors(t *testing.T) {
+	ctx := context.Background()
+	h := NewHedger(0, time.Millisecond)
+	errA, errB := context.DeadlineExceeded, context.Canceled
+
+	// fails before the delay: not hedged
+	calls := 0
+	_, err := hedge(ctx, h, func(context.Context) (int, error) {
+		calls++
+		return 0, errA
+	}, nil)
+	if err != errA || calls != 1 {
+		t.Error("unexpected result:", err, calls)
+	}
+
+	// both fail: first error is returned
+	var mu sync.Mutex
+	calls = 0
+	hedged := 0
+	_, err = hedge(ctx, h, func(context.Context) (int, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			time.Sleep(20 * time.Millisecond)
+			return 0, errB
+		}
+		return 0, errA
+	}, func() { hedged++ })
+	if err != errA || hedged != 1 {
+		t.Error("unexpected result:", err, hedged)
+	}
+}
+
+func TestHedgerDelay(t *testing.T) {
+	h := NewHedger(0.9, time.Second)
+	if got := h.Delay(); got != time.Second {
+		t.Error("want fallback delay before enough samples, got:", got)
+	}
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if got := h.Delay(); got != 90*time.Millisecond {
+		t.Error("bad p90 delay:", got)
+	}
+	for i := 0; i < hedgeSamples; i++ {
+		h.observe(time.Millisecond)
+	}
+	if got := h.Delay(); got != time.Millisecond {
+		t.Error("old samples not replaced:", got)
+	}
+}
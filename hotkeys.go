@@ -0,0 +1,306 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/base64"
+	"hash/maphash"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+const (
+	hotKeyDepth = 4
+	hotKeyWidth = 2048
+	// how long to wait before retrying a failed DescribeTable
+	hotKeyRetryInterval = 30 * time.Second
+)
+
+// HotKeySampler estimates the most frequently accessed hash key (partition key) values,
+// which can help diagnose throttling caused by hot partitions.
+// Counts are tracked with a Count-Min sketch, so memory usage is fixed
+// and counts may be overestimated (but never underestimated).
+//
+// Use [HotKeySampler.WrapClient] to sample every request made through a client:
+//
+//	sampler := dynamo.NewHotKeySampler(10)
+//	db := dynamo.NewFromIface(sampler.WrapClient(dynamodb.NewFromConfig(cfg)))
+//	// ... later
+//	for _, hot := range sampler.Top() {
+//		log.Println(hot.Table, hot.Key, hot.Count)
+//	}
+type HotKeySampler struct {
+	n      int
+	seeds  [hotKeyDepth]maphash.Seed
+	sketch [hotKeyDepth][hotKeyWidth]uint64
+	top    map[hotKeyID]uint64
+	mu     sync.Mutex
+
+	hashKeys sync.Map // table name → hashKeyLookup
+}
+
+type hashKeyLookup struct {
+	name string
+	// retry is when to try again if the lookup failed
+	retry time.Time
+}
+
+// HotKey is a frequently accessed hash key value.
+type HotKey struct {
+	Table string
+	// Key is the hash key's value: strings and numbers as-is, and binary encoded as base64.
+	Key string
+	// Count is the estimated number of times this key was accessed.
+	Count uint64
+}
+
+type hotKeyID struct {
+	table string
+	key   string
+}
+
+// NewHotKeySampler creates a sampler that tracks the top n hottest keys.
+func NewHotKeySampler(n int) *HotKeySampler {
+	s := &HotKeySampler{
+		n:   n,
+		top: make(map[hotKeyID]uint64, n+1),
+	}
+	for i := range s.seeds {
+		s.seeds[i] = maphash.MakeSeed()
+	}
+	return s
+}
+
+// Observe records an access of the given hash key value in table.
+// Values that are not strings, numbers, or binary are ignored.
+func (s *HotKeySampler) Observe(table string, hashKey types.AttributeValue) {
+	var key string
+	switch v := hashKey.(type) {
+	case *types.AttributeValueMemberS:
+		key = "S" + v.Value
+	case *types.AttributeValueMemberN:
+		key = "N" + v.Value
+	case *types.AttributeValueMemberB:
+		key = "B" + base64.StdEncoding.EncodeToString(v.Value)
+	default:
+		return
+	}
+	id := hotKeyID{table: table, key: key}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var est uint64
+	for i := range s.sketch {
+		var h maphash.Hash
+		h.SetSeed(s.seeds[i])
+		h.WriteString(table)
+		h.WriteByte(0)
+		h.WriteString(key)
+		cell := &s.sketch[i][h.Sum64()%hotKeyWidth]
+		*cell++
+		if i == 0 || *cell < est {
+			est = *cell
+		}
+	}
+
+	if _, ok := s.top[id]; ok || len(s.top) < s.n {
+		s.top[id] = est
+		return
+	}
+	// replace the coldest key, if we're hotter
+	var coldest hotKeyID
+	coldestCount := est
+	for other, count := range s.top {
+		if count < coldestCount {
+			coldest, coldestCount = other, count
+		}
+	}
+	if coldestCount < est {
+		delete(s.top, coldest)
+		s.top[id] = est
+	}
+}
+
+// Top returns the hottest keys seen so far, sorted by count in descending order.
+func (s *HotKeySampler) Top() []HotKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hot := make([]HotKey, 0, len(s.top))
+	for id, count := range s.top {
+		hot = append(hot, HotKey{
+			Table: id.table,
+			Key:   id.key[1:],
+			Count: count,
+		})
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Count != hot[j].Count {
+			return hot[i].Count > hot[j].Count
+		}
+		if hot[i].Table != hot[j].Table {
+			return hot[i].Table < hot[j].Table
+		}
+		return hot[i].Key < hot[j].Key
+	})
+	return hot
+}
+
+// Reset clears all recorded counts.
+func (s *HotKeySampler) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sketch = [hotKeyDepth][hotKeyWidth]uint64{}
+	s.top = make(map[hotKeyID]uint64, s.n+1)
+}
+
+// WrapClient returns a client that samples the hash keys of item reads and writes
+// (including batches, transactions, and queries) made through client.
+// Each table's hash key is determined by calling DescribeTable once, with the options of the request that needed it.
+// If that fails, requests to the table are not sampled until it is retried 30 seconds later.
+// Scans are not sampled.
+func (s *HotKeySampler) WrapClient(client dynamodbiface.DynamoDBAPI) dynamodbiface.DynamoDBAPI {
+	return &sampledClient{DynamoDBAPI: client, sampler: s}
+}
+
+func (s *HotKeySampler) hashKey(ctx context.Context, client dynamodbiface.DynamoDBAPI, table string, optFns []func(*dynamodb.Options)) string {
+	if v, ok := s.hashKeys.Load(table); ok {
+		lookup := v.(hashKeyLookup)
+		if lookup.retry.IsZero() || time.Now().Before(lookup.retry) {
+			return lookup.name
+		}
+	}
+	out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &table}, optFns...)
+	if err != nil || out.Table == nil {
+		// remember the failure for a while, so every request doesn't wait on DescribeTable
+		s.hashKeys.Store(table, hashKeyLookup{retry: time.Now().Add(hotKeyRetryInterval)})
+		return ""
+	}
+	name := newDescription(out.Table).HashKey
+	s.hashKeys.Store(table, hashKeyLookup{name: name})
+	return name
+}
+
+func (s *HotKeySampler) observeItem(ctx context.Context, client dynamodbiface.DynamoDBAPI, table *string, item Item, optFns []func(*dynamodb.Options)) {
+	if table == nil || item == nil {
+		return
+	}
+	if name := s.hashKey(ctx, client, *table, optFns); name != "" {
+		if av, ok := item[name]; ok {
+			s.Observe(*table, av)
+		}
+	}
+}
+
+type sampledClient struct {
+	dynamodbiface.DynamoDBAPI
+	sampler *HotKeySampler
+}
+
+func (c *sampledClient) Unwrap() dynamodbiface.DynamoDBAPI {
+	return c.DynamoDBAPI
+}
+
+func (c *sampledClient) observe(ctx context.Context, table *string, item Item, optFns []func(*dynamodb.Options)) {
+	c.sampler.observeItem(ctx, c.DynamoDBAPI, table, item, optFns)
+}
+
+func (c *sampledClient) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.observe(ctx, in.TableName, in.Key, optFns)
+	return c.DynamoDBAPI.GetItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.observe(ctx, in.TableName, in.Item, optFns)
+	return c.DynamoDBAPI.PutItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.observe(ctx, in.TableName, in.Key, optFns)
+	return c.DynamoDBAPI.UpdateItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.observe(ctx, in.TableName, in.Key, optFns)
+	return c.DynamoDBAPI.DeleteItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) BatchGetItem(ctx context.Context, in *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	for table, kas := range in.RequestItems {
+		table := table
+		for _, key := range kas.Keys {
+			c.observe(ctx, &table, key, optFns)
+		}
+	}
+	return c.DynamoDBAPI.BatchGetItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for table, reqs := range in.RequestItems {
+		table := table
+		for _, req := range reqs {
+			switch {
+			case req.PutRequest != nil:
+				c.observe(ctx, &table, req.PutRequest.Item, optFns)
+			case req.DeleteRequest != nil:
+				c.observe(ctx, &table, req.DeleteRequest.Key, optFns)
+			}
+		}
+	}
+	return c.DynamoDBAPI.BatchWriteItem(ctx, in, optFns...)
+}
+
+func (c *sampledClient) TransactGetItems(ctx context.Context, in *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	for _, item := range in.TransactItems {
+		if item.Get != nil {
+			c.observe(ctx, item.Get.TableName, item.Get.Key, optFns)
+		}
+	}
+	return c.DynamoDBAPI.TransactGetItems(ctx, in, optFns...)
+}
+
+func (c *sampledClient) TransactWriteItems(ctx context.Context, in *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, item := range in.TransactItems {
+		switch {
+		case item.Put != nil:
+			c.observe(ctx, item.Put.TableName, item.Put.Item, optFns)
+		case item.Update != nil:
+			c.observe(ctx, item.Update.TableName, item.Update.Key, optFns)
+		case item.Delete != nil:
+			c.observe(ctx, item.Delete.TableName, item.Delete.Key, optFns)
+		case item.ConditionCheck != nil:
+			c.observe(ctx, item.ConditionCheck.TableName, item.ConditionCheck.Key, optFns)
+		}
+	}
+	return c.DynamoDBAPI.TransactWriteItems(ctx, in, optFns...)
+}
+
+// matches "name = :value" in key condition expressions
+var keyCondEqualRegexp = regexp.MustCompile(`([#A-Za-z0-9_]+)\s*=\s*(:[A-Za-z0-9_]+)`)
+
+func (c *sampledClient) Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	// only sample queries against the table's primary key, not indexes
+	if in.IndexName == nil && in.KeyConditionExpression != nil && in.TableName != nil {
+		if hashKey := c.sampler.hashKey(ctx, c.DynamoDBAPI, *in.TableName, optFns); hashKey != "" {
+			for _, match := range keyCondEqualRegexp.FindAllStringSubmatch(*in.KeyConditionExpression, -1) {
+				name := match[1]
+				if sub, ok := in.ExpressionAttributeNames[name]; ok {
+					name = sub
+				}
+				if name == hashKey {
+					if av, ok := in.ExpressionAttributeValues[match[2]]; ok {
+						c.sampler.Observe(*in.TableName, av)
+					}
+					break
+				}
+			}
+		}
+	}
+	return c.DynamoDBAPI.Query(ctx, in, optFns...)
+}
@@ -0,0 +1,120 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// hotClient is a fake table with a hash key of UserID that accepts reads and writes.
+type hotClient struct {
+	keysClient
+}
+
+func (hotClient) GetItem(_ context.Context, _ *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (hotClient) PutItem(_ context.Context, _ *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (hotClient) Query(_ context.Context, _ *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+// flakyDescribeClient is a hotClient whose first DescribeTable call fails.
+type flakyDescribeClient struct {
+	hotClient
+	calls  int
+	optFns int
+}
+
+func (c *flakyDescribeClient) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.calls++
+	c.optFns = len(optFns)
+	if c.calls == 1 {
+		return nil, errors.New("describe failed")
+	}
+	return c.hotClient.DescribeTable(ctx, in, optFns...)
+}
+
+func TestHotKeySampler(t *testing.T) {
+	ctx := context.Background()
+	sampler := NewHotKeySampler(2)
+	table := NewFromIface(sampler.WrapClient(&hotClient{})).Table("Hot")
+
+	type item struct {
+		UserID int
+		Time   string
+	}
+	for i := 0; i < 10; i++ {
+		if err := table.Put(item{UserID: 1, Time: "a"}).Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		table.Get("UserID", 2).Range("Time", Equal, "b").One(ctx, new(item))
+	}
+	for i := 0; i < 3; i++ {
+		table.Get("UserID", 3).All(ctx, new([]item))
+	}
+	table.Get("UserID", 4).One(ctx, new(item))
+
+	top := sampler.Top()
+	want := []HotKey{
+		{Table: "Hot", Key: "1", Count: 10},
+		{Table: "Hot", Key: "2", Count: 5},
+	}
+	if len(top) != len(want) {
+		t.Fatal("bad top keys. want:", want, "got:", top)
+	}
+	for i := range want {
+		if top[i] != want[i] {
+			t.Error("bad top keys. want:", want, "got:", top)
+			break
+		}
+	}
+
+	sampler.Reset()
+	if top := sampler.Top(); len(top) != 0 {
+		t.Error("expected no keys after reset, got:", top)
+	}
+
+	t.Run("describe failure", func(t *testing.T) {
+		sampler := NewHotKeySampler(1)
+		client := &flakyDescribeClient{}
+		table := NewFromIface(sampler.WrapClient(client)).Table("Flaky")
+		opt := func(*dynamodb.Options) {}
+		for i := 0; i < 2; i++ {
+			if err := table.Put(item{UserID: 1, Time: "a"}).RequestOptions(opt).Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if client.calls != 1 {
+			t.Error("want failed describe cached, got calls:", client.calls)
+		}
+		if client.optFns != 1 {
+			t.Error("request options not passed to DescribeTable:", client.optFns)
+		}
+		if top := sampler.Top(); len(top) != 0 {
+			t.Error("want no keys sampled while describe is failing, got:", top)
+		}
+
+		// pretend the retry interval has passed
+		sampler.hashKeys.Store("Flaky", hashKeyLookup{retry: time.Now().Add(-time.Second)})
+		if err := table.Put(item{UserID: 1, Time: "a"}).Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if client.calls != 2 {
+			t.Error("want describe retried, got calls:", client.calls)
+		}
+		top := sampler.Top()
+		if len(top) != 1 || top[0].Count != 1 {
+			t.Error("want key sampled after retried describe, got:", top)
+		}
+	})
+}
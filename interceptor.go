@@ -0,0 +1,122 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Interceptor hooks into every request made with a table, see [Table.Intercept].
+// This is useful for enforcing invariants across a codebase, such as tenant isolation in tables shared by many tenants:
+// writes can be tagged with the tenant, conditions and filters can be added to requests,
+// and items read from the table can be checked to make sure they belong to the tenant.
+//
+// All fields are optional. Request hooks are called when the request is created, before any other methods,
+// so changes made by a hook are combined with the caller's in the same way as calling the methods twice.
+type Interceptor struct {
+	// Query is called with every new query or get of the table, made by [Table.Get].
+	// It may add filters, for example.
+	// Note that queries with filters can't use the GetItem API or be used in transactions.
+	Query func(q *Query)
+	// Scan is called with every new scan of the table.
+	Scan func(s *Scan)
+	// Update is called with every new update of the table.
+	// It may add conditions or set attributes, for example.
+	Update func(u *Update)
+	// Delete is called with every new delete of the table.
+	// It may add conditions, for example. Deletes made with [BatchWrite] don't support conditions and are not intercepted.
+	Delete func(d *Delete)
+	// Write is called with every item put in the table by [Table.Put] and [BatchWrite], after marshaling.
+	// It may modify the item, for example by adding a tenant attribute. Returning an error fails the request.
+	Write func(item Item) error
+	// Read is called with every item read from the table by queries, scans, batch gets, get transactions,
+	// and writes that return items, before unmarshaling.
+	// Returning an error fails the request, and the item is not unmarshaled.
+	// Items might be missing attributes, for example when using projections or [Update.OnlyUpdatedValue].
+	Read func(item Item) error
+}
+
+// Intercept returns a copy of this table that calls the hooks of ic for its requests.
+// Multiple interceptors are called in the order they were added.
+func (table Table) Intercept(ic Interceptor) Table {
+	n := len(table.interceptors)
+	table.interceptors = append(table.interceptors[:n:n], ic)
+	return table
+}
+
+func (table Table) interceptQuery(q *Query) {
+	for _, ic := range table.interceptors {
+		if ic.Query != nil {
+			ic.Query(q)
+		}
+	}
+}
+
+func (table Table) interceptScan(s *Scan) {
+	for _, ic := range table.interceptors {
+		if ic.Scan != nil {
+			ic.Scan(s)
+		}
+	}
+}
+
+func (table Table) interceptUpdate(u *Update) {
+	for _, ic := range table.interceptors {
+		if ic.Update != nil {
+			ic.Update(u)
+		}
+	}
+}
+
+func (table Table) interceptDelete(d *Delete) {
+	for _, ic := range table.interceptors {
+		if ic.Delete != nil {
+			ic.Delete(d)
+		}
+	}
+}
+
+func (table Table) interceptWrite(item Item) error {
+	for _, ic := range table.interceptors {
+		if ic.Write == nil {
+			continue
+		}
+		if err := ic.Write(item); err != nil {
+			return fmt.Errorf("dynamo: table %s: write intercepted: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+func (table Table) interceptRead(item Item) error {
+	for _, ic := range table.interceptors {
+		if ic.Read == nil {
+			continue
+		}
+		if err := ic.Read(item); err != nil {
+			return fmt.Errorf("dynamo: table %s: read intercepted: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// unmarshaler wraps fn with the DB's error settings and this table's read interceptors.
+func (table Table) unmarshaler(fn unmarshalFunc) unmarshalFunc {
+	fn = table.db.unmarshaler(fn)
+	if len(table.interceptors) == 0 {
+		return fn
+	}
+	return func(ctx context.Context, item Item, out interface{}) error {
+		if err := table.interceptRead(item); err != nil {
+			return err
+		}
+		return fn(ctx, item, out)
+	}
+}
+
+// unmarshalItem unmarshals an item read from this table.
+func (table Table) unmarshalItem(ctx context.Context, item Item, out interface{}) error {
+	if err := table.interceptRead(item); err != nil {
+		return err
+	}
+	return unmarshalItem(table.db.codecContext(ctx), item, out)
+}
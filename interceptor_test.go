@@ -0,0 +1,170 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// tenantClient records writes and serves items belonging to two tenants.
+type tenantClient struct {
+	dynamodbiface.DynamoDBAPI
+	put    *dynamodb.PutItemInput
+	update *dynamodb.UpdateItemInput
+	query  *dynamodb.QueryInput
+}
+
+func (c *tenantClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.put = in
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *tenantClient) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.update = in
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *tenantClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.query = in
+	return &dynamodb.QueryOutput{Items: []Item{
+		{"ID": &types.AttributeValueMemberS{Value: "1"}, "Tenant": &types.AttributeValueMemberS{Value: "a"}},
+		{"ID": &types.AttributeValueMemberS{Value: "1"}, "Tenant": &types.AttributeValueMemberS{Value: "b"}},
+	}}, nil
+}
+
+// condFailClient rejects every write, returning an item belonging to tenant b,
+// and serves an item belonging to tenant a in read transactions.
+type condFailClient struct {
+	tenantClient
+}
+
+var otherTenantItem = Item{"ID": &types.AttributeValueMemberS{Value: "1"}, "Tenant": &types.AttributeValueMemberS{Value: "b"}}
+
+func (c *condFailClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, &types.ConditionalCheckFailedException{Item: otherTenantItem}
+}
+
+func (c *condFailClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return nil, &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+		{Code: aws.String("ConditionalCheckFailed"), Item: otherTenantItem},
+	}}
+}
+
+func (c *condFailClient) TransactGetItems(_ context.Context, in *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	return &dynamodb.TransactGetItemsOutput{Responses: []types.ItemResponse{
+		{Item: Item{"ID": &types.AttributeValueMemberS{Value: "1"}, "Tenant": &types.AttributeValueMemberS{Value: "a"}}},
+	}}, nil
+}
+
+func tenantInterceptor(tenant string) Interceptor {
+	return Interceptor{
+		Query: func(q *Query) {
+			q.Filter("'Tenant' = ?", tenant)
+		},
+		Update: func(u *Update) {
+			u.If("'Tenant' = ?", tenant)
+		},
+		Write: func(item Item) error {
+			item["Tenant"] = &types.AttributeValueMemberS{Value: tenant}
+			return nil
+		},
+		Read: func(item Item) error {
+			if got, ok := item["Tenant"].(*types.AttributeValueMemberS); !ok || got.Value != tenant {
+				return fmt.Errorf("item belongs to another tenant: %v", item["Tenant"])
+			}
+			return nil
+		},
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	ctx := context.Background()
+	client := new(tenantClient)
+	shared := NewFromIface(client).Table("Shared")
+	table := shared.Intercept(tenantInterceptor("a"))
+
+	t.Run("write", func(t *testing.T) {
+		if err := table.Put(map[string]string{"ID": "1"}).Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := client.put.Item["Tenant"]; !reflect.DeepEqual(got, &types.AttributeValueMemberS{Value: "a"}) {
+			t.Error("tenant not added to put. got:", got)
+		}
+
+		failing := shared.Intercept(Interceptor{Write: func(Item) error { return errors.New("nope") }})
+		if err := failing.Put(map[string]string{"ID": "1"}).Run(ctx); err == nil || !strings.Contains(err.Error(), "nope") {
+			t.Error("expected write error. got:", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		if err := table.Update("ID", "1").Set("Name", "x").Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if client.update.ConditionExpression == nil {
+			t.Error("tenant condition not added to update")
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		var items []Item
+		err := table.Get("ID", "1").All(ctx, &items)
+		if err == nil || !strings.Contains(err.Error(), "another tenant") {
+			t.Error("expected read to be rejected. got:", err)
+		}
+		if client.query.FilterExpression == nil {
+			t.Error("tenant filter not added to query")
+		}
+	})
+
+	t.Run("condition check failure", func(t *testing.T) {
+		table := NewFromIface(new(condFailClient)).Table("Shared").Intercept(tenantInterceptor("a"))
+		var item Item
+		_, err := table.Put(map[string]string{"ID": "1"}).If("attribute_not_exists(ID)").CurrentValue(ctx, &item)
+		if err == nil || !strings.Contains(err.Error(), "another tenant") {
+			t.Error("expected current value to be rejected. got:", err)
+		}
+
+		err = table.db.WriteTx().PutReturning(table.Put(map[string]string{"ID": "1"}), &item).Run(ctx)
+		if err == nil || !strings.Contains(err.Error(), "another tenant") {
+			t.Error("expected canceled transaction item to be rejected. got:", err)
+		}
+	})
+
+	t.Run("read transaction", func(t *testing.T) {
+		reads := 0
+		table := NewFromIface(new(condFailClient)).Table("Shared").Intercept(Interceptor{
+			Read: func(Item) error {
+				reads++
+				return nil
+			},
+		})
+		var one Item
+		var all []Item
+		if err := table.db.GetTx().GetOne(table.Get("ID", "1"), &one).All(ctx, &all); err != nil {
+			t.Fatal(err)
+		}
+		if reads != 1 || len(all) != 1 || one == nil {
+			t.Error("want 1 intercepted read, got:", reads, all, one)
+		}
+	})
+
+	t.Run("unintercepted", func(t *testing.T) {
+		var items []Item
+		if err := shared.Get("ID", "1").All(ctx, &items); err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || client.query.FilterExpression != nil {
+			t.Error("original table should not be intercepted. got:", items, client.query.FilterExpression)
+		}
+	})
+}
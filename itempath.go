@@ -0,0 +1,138 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UnmarshalItemPath decodes the attribute at the given document path of item into out, which must be a pointer.
+// This is useful for decoding part of a nested document, such as one fetched with a projection,
+// without a struct that models the rest of the item.
+//
+// Paths are made of attribute names and map keys separated by dots, and list indexes in brackets,
+// such as "Meta.tags[0]". Use single quotes for names containing dots or brackets, as in "Meta.'animal.cow'".
+// If the path does not exist in item, the returned error wraps [ErrNotFound].
+func UnmarshalItemPath(item Item, path string, out interface{}) error {
+	return UnmarshalItemPathContext(context.Background(), item, path, out)
+}
+
+// UnmarshalItemPathContext is like [UnmarshalItemPath],
+// passing ctx to any values that implement [UnmarshalerCtx].
+func UnmarshalItemPathContext(ctx context.Context, item Item, path string, out interface{}) error {
+	av, err := lookupPath(item, path)
+	if err != nil {
+		return err
+	}
+	if err := UnmarshalContext(ctx, av, out); err != nil {
+		return fmt.Errorf("dynamo: path %q: %w", path, err)
+	}
+	return nil
+}
+
+// pathStep is a map key or list index in a document path.
+type pathStep struct {
+	name  string
+	index int // if name is empty
+}
+
+func lookupPath(item Item, path string) (types.AttributeValue, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	var av types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for i, step := range steps {
+		var ok bool
+		switch cur := av.(type) {
+		case *types.AttributeValueMemberM:
+			if step.name == "" {
+				return nil, fmt.Errorf("dynamo: path %q: can't index map %s", path, formatPathSteps(steps[:i]))
+			}
+			av, ok = cur.Value[step.name]
+		case *types.AttributeValueMemberL:
+			if step.name != "" {
+				return nil, fmt.Errorf("dynamo: path %q: can't get key %q of list %s", path, step.name, formatPathSteps(steps[:i]))
+			}
+			if ok = step.index < len(cur.Value); ok {
+				av = cur.Value[step.index]
+			}
+		default:
+			return nil, fmt.Errorf("dynamo: path %q: %s is a %s attribute, not a map or list", path, formatPathSteps(steps[:i]), avTypeName(av))
+		}
+		if !ok {
+			return nil, fmt.Errorf("dynamo: path %q: %w", path, ErrNotFound)
+		}
+	}
+	return av, nil
+}
+
+// parsePath parses a document path such as Meta.tags[0] or 'a.b'.c.
+func parsePath(path string) ([]pathStep, error) {
+	bad := func(reason string) error {
+		return fmt.Errorf("dynamo: invalid path %q: %s", path, reason)
+	}
+	var steps []pathStep
+	rest := path
+	for {
+		// name
+		var name string
+		if strings.HasPrefix(rest, "'") {
+			end := strings.IndexByte(rest[1:], '\'')
+			if end == -1 {
+				return nil, bad("unterminated quote")
+			}
+			name, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name, rest = rest[:end], rest[end:]
+			if name == "" {
+				return nil, bad("empty name")
+			}
+		}
+		steps = append(steps, pathStep{name: name})
+
+		// indexes
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, bad("unterminated index")
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, bad("bad index " + rest[:end+1])
+			}
+			steps = append(steps, pathStep{index: idx})
+			rest = rest[end+1:]
+		}
+
+		if rest == "" {
+			return steps, nil
+		}
+		if rest[0] != '.' {
+			return nil, bad("unexpected " + strconv.Quote(rest[:1]))
+		}
+		rest = rest[1:]
+	}
+}
+
+func formatPathSteps(steps []pathStep) string {
+	var b strings.Builder
+	for i, step := range steps {
+		switch {
+		case step.name == "":
+			b.WriteString("[" + strconv.Itoa(step.index) + "]")
+		case i > 0:
+			b.WriteString("." + step.name)
+		default:
+			b.WriteString(step.name)
+		}
+	}
+	return b.String()
+}
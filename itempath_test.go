@@ -0,0 +1,59 @@
+package dynamo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestUnmarshalItemPath(t *testing.T) {
+	type tag struct {
+		Name  string
+		Count int
+	}
+	item, err := MarshalItem(map[string]any{
+		"ID": 1,
+		"Meta": map[string]any{
+			"animal.cow": "moo",
+			"tags":       []tag{{"a", 1}, {"b", 2}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sound string
+	if err := UnmarshalItemPath(item, "Meta.'animal.cow'", &sound); err != nil || sound != "moo" {
+		t.Error("bad quoted path:", sound, err)
+	}
+	var second tag
+	if err := UnmarshalItemPath(item, "Meta.tags[1]", &second); err != nil || second != (tag{"b", 2}) {
+		t.Error("bad index path:", second, err)
+	}
+	var count int
+	if err := UnmarshalItemPath(item, "Meta.tags[0].Count", &count); err != nil || count != 1 {
+		t.Error("bad nested path:", count, err)
+	}
+	var tags []tag
+	if err := UnmarshalItemPath(item, "Meta.tags", &tags); err != nil || !reflect.DeepEqual(tags, []tag{{"a", 1}, {"b", 2}}) {
+		t.Error("bad list path:", tags, err)
+	}
+
+	for _, path := range []string{"Nope", "Meta.tags[5]", "Meta.nope.deeper"} {
+		if err := UnmarshalItemPath(item, path, &sound); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: want ErrNotFound, got: %v", path, err)
+		}
+	}
+	for _, path := range []string{"", "Meta.", "Meta['x']", "Meta.tags[0", "'Meta", "ID.x", "Meta[0]", "Meta.tags.Name"} {
+		if err := UnmarshalItemPath(item, path, &sound); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: want error, got: %v", path, err)
+		}
+	}
+
+	item["Meta"].(*types.AttributeValueMemberM).Value["animal.cow"] = &types.AttributeValueMemberN{Value: "1"}
+	if err := UnmarshalItemPath(item, "Meta.'animal.cow'", &second); err == nil {
+		t.Error("want type error")
+	}
+}
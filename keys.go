@@ -1,5 +1,12 @@
 package dynamo
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
 // KeyType is used to specify the type of hash and range keys for tables and indexes.
 type KeyType string
 
@@ -29,3 +36,138 @@ func (k Keys) HashKey() interface{} { return k[0] }
 
 // RangeKey returns the range key's value.
 func (k Keys) RangeKey() interface{} { return k[1] }
+
+// NamedKeys is like [Keys], but with named fields and optional attribute names.
+// When HashName or RangeName are set, they are checked against the key names given to batch operations
+// and against the table's schema by [Table.ValidateKeys].
+//
+//	table.Batch("ID", "Month").
+//		Get(dynamo.NamedKeys{Hash: 1, Range: "2015-10"}, dynamo.NamedKeys{HashName: "ID", Hash: 42, RangeName: "Month", Range: "2015-12"}).
+//		All(&results)
+type NamedKeys struct {
+	// HashName is the name of the hash key attribute (optional).
+	HashName string
+	// Hash is the hash key's value.
+	Hash interface{}
+	// RangeName is the name of the range key attribute (optional).
+	RangeName string
+	// Range is the range key's value, or nil for tables without a range key.
+	Range interface{}
+}
+
+// HashKey returns the hash key's value.
+func (k NamedKeys) HashKey() interface{} { return k.Hash }
+
+// RangeKey returns the range key's value.
+func (k NamedKeys) RangeKey() interface{} { return k.Range }
+
+// KeyNames returns the names of the hash and range key attributes, if specified.
+func (k NamedKeys) KeyNames() (hashKey, rangeKey string) { return k.HashName, k.RangeName }
+
+// keyNamer is implemented by keys that know their attribute names, such as NamedKeys.
+type keyNamer interface {
+	KeyNames() (hashKey, rangeKey string)
+}
+
+// KeyValidationError is returned when keys don't match a table's primary key schema.
+type KeyValidationError struct {
+	// Table is the name of the table whose schema was violated.
+	Table string
+	// Attribute is the name of the offending key attribute. Empty for key-wide problems.
+	Attribute string
+	Reason    string
+}
+
+func (e *KeyValidationError) Error() string {
+	if e.Attribute == "" {
+		return fmt.Sprintf("dynamo: invalid key for table %s: %s", e.Table, e.Reason)
+	}
+	return fmt.Sprintf("dynamo: invalid key for table %s (attribute %s): %s", e.Table, e.Attribute, e.Reason)
+}
+
+// checkKeyNames returns an error if key's names (see NamedKeys) don't match the given names.
+func checkKeyNames(table, hashKey, rangeKey string, key Keyed) error {
+	namer, ok := key.(keyNamer)
+	if !ok {
+		return nil
+	}
+	hk, rk := namer.KeyNames()
+	if hk != "" && hk != hashKey {
+		return &KeyValidationError{Table: table, Attribute: hk, Reason: fmt.Sprintf("hash key name mismatch, want %q", hashKey)}
+	}
+	if rk != "" && rk != rangeKey {
+		if rangeKey == "" {
+			return &KeyValidationError{Table: table, Attribute: rk, Reason: "range key given but none expected"}
+		}
+		return &KeyValidationError{Table: table, Attribute: rk, Reason: fmt.Sprintf("range key name mismatch, want %q", rangeKey)}
+	}
+	return nil
+}
+
+// ValidateKeys checks that the given keys match this table's primary key schema,
+// returning a [*KeyValidationError] describing the first mismatch found.
+// Each key must have a hash key value of the correct type, and a range key value of the correct type
+// if and only if the table has a range key. Key names given by [NamedKeys] must match the schema as well.
+// The table's description is cached after the first DescribeTable call.
+func (table Table) ValidateKeys(ctx context.Context, keys ...Keyed) error {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := desc.validateKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (desc Description) validateKey(key Keyed) error {
+	if key == nil {
+		return &KeyValidationError{Table: desc.Name, Reason: "nil key"}
+	}
+	if err := checkKeyNames(desc.Name, desc.HashKey, desc.RangeKey, key); err != nil {
+		return err
+	}
+	if err := desc.validateKeyValue(desc.HashKey, desc.HashKeyType, key.HashKey()); err != nil {
+		return err
+	}
+	if desc.RangeKey == "" {
+		if key.RangeKey() != nil {
+			return &KeyValidationError{Table: desc.Name, Reason: "range key value given, but table has no range key"}
+		}
+		return nil
+	}
+	return desc.validateKeyValue(desc.RangeKey, desc.RangeKeyType, key.RangeKey())
+}
+
+func (desc Description) validateKeyValue(name string, want KeyType, value interface{}) error {
+	if value == nil {
+		return &KeyValidationError{Table: desc.Name, Attribute: name, Reason: "missing value"}
+	}
+	av, err := marshal(value, flagNone)
+	if err != nil {
+		return &KeyValidationError{Table: desc.Name, Attribute: name, Reason: err.Error()}
+	}
+	got, ok := keyTypeOf(av)
+	if !ok {
+		return &KeyValidationError{Table: desc.Name, Attribute: name, Reason: fmt.Sprintf("unsupported key type %T", value)}
+	}
+	if want != NoneType && got != want {
+		return &KeyValidationError{Table: desc.Name, Attribute: name, Reason: fmt.Sprintf("type mismatch, want %s but got %s (%T)", want, got, value)}
+	}
+	return nil
+}
+
+// keyTypeOf returns the key type of av, or false if av can't be used as a key.
+func keyTypeOf(av types.AttributeValue) (KeyType, bool) {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return StringType, true
+	case *types.AttributeValueMemberN:
+		return NumberType, true
+	case *types.AttributeValueMemberB:
+		return BinaryType, true
+	}
+	return NoneType, false
+}
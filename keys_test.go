@@ -0,0 +1,211 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestValidateKeys(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(&keysClient{}).Table("Keys")
+
+	valid := []Keyed{
+		Keys{1, "a"},
+		NamedKeys{Hash: 2, Range: "b"},
+		NamedKeys{HashName: "UserID", Hash: int64(3), RangeName: "Time", Range: "c"},
+	}
+	if err := table.ValidateKeys(ctx, valid...); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	tests := []struct {
+		key  Keyed
+		attr string
+	}{
+		{Keys{nil, "a"}, "UserID"},
+		{Keys{1, nil}, "Time"},
+		{Keys{"1", "a"}, "UserID"},
+		{Keys{1, 2}, "Time"},
+		{NamedKeys{HashName: "ID", Hash: 1, Range: "a"}, "ID"},
+		{NamedKeys{Hash: 1, RangeName: "Date", Range: "a"}, "Date"},
+	}
+	for _, test := range tests {
+		err := table.ValidateKeys(ctx, test.key)
+		var kerr *KeyValidationError
+		if !errors.As(err, &kerr) {
+			t.Errorf("%v: expected KeyValidationError, got: %v", test.key, err)
+			continue
+		}
+		if kerr.Attribute != test.attr || kerr.Table != "Keys" {
+			t.Errorf("%v: bad error: %v", test.key, err)
+		}
+	}
+}
+
+func TestBatchNamedKeys(t *testing.T) {
+	table := NewFromIface(&keysClient{}).Table("Keys")
+	batch := table.Batch("UserID", "Time")
+
+	get := batch.Get(NamedKeys{HashName: "UserID", Hash: 1, RangeName: "Time", Range: "a"})
+	if get.err != nil {
+		t.Error("unexpected error:", get.err)
+	}
+	get = batch.Get(NamedKeys{HashName: "ID", Hash: 1, Range: "a"})
+	var kerr *KeyValidationError
+	if !errors.As(get.err, &kerr) {
+		t.Error("expected KeyValidationError, got:", get.err)
+	}
+
+	del := batch.Write().Delete(NamedKeys{Hash: 1, RangeName: "Date", Range: "a"})
+	if !errors.As(del.err, &kerr) {
+		t.Error("expected KeyValidationError, got:", del.err)
+	}
+}
+
+// bigKeyClient echoes keys back as items, for testing keys that don't fit in int64 or float64.
+type bigKeyClient struct {
+	*keysClient
+	queries []*dynamodb.QueryInput
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *bigKeyClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, in)
+	if in.ExclusiveStartKey != nil {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	item := Item{
+		"UserID": &types.AttributeValueMemberN{Value: "18446744073709551615"},
+		"Time":   &types.AttributeValueMemberS{Value: "a"},
+	}
+	return &dynamodb.QueryOutput{Items: []Item{item}, LastEvaluatedKey: item}, nil
+}
+
+func (c *bigKeyClient) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: in.Key}, nil
+}
+
+func (c *bigKeyClient) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, in)
+	return &dynamodb.UpdateItemOutput{Attributes: in.Key}, nil
+}
+
+func (c *bigKeyClient) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{Responses: make(map[string][]Item)}
+	for table, ka := range in.RequestItems {
+		out.Responses[table] = ka.Keys
+	}
+	return out, nil
+}
+
+func TestUint64Keys(t *testing.T) {
+	type widget struct {
+		UserID uint64 `dynamo:",hash"`
+		Time   string `dynamo:",range"`
+	}
+	const maxStr = "18446744073709551615"
+	keys := []uint64{math.MaxInt64 + 1, 1<<53 + 1, math.MaxUint64}
+
+	ctx := context.Background()
+	client := &bigKeyClient{keysClient: new(keysClient)}
+	table := NewFromIface(client).Table("Keys")
+
+	t.Run("query", func(t *testing.T) {
+		var got widget
+		err := table.Get("UserID", uint64(math.MaxUint64)).Range("Time", Equal, "a").All(ctx, &[]widget{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cond := client.queries[0].KeyConditions["UserID"]
+		if n := cond.AttributeValueList[0].(*types.AttributeValueMemberN).Value; n != maxStr {
+			t.Error("bad key condition value:", n)
+		}
+		if err := table.Get("UserID", uint64(math.MaxUint64)).Range("Time", Equal, "a").One(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.UserID != math.MaxUint64 {
+			t.Error("bad hash key:", got.UserID)
+		}
+
+		desc, err := table.Describe().Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inferred, err := LEKFromItem(got, desc, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := PagingKey{
+			"UserID": &types.AttributeValueMemberN{Value: maxStr},
+			"Time":   &types.AttributeValueMemberS{Value: "a"},
+		}
+		if !reflect.DeepEqual(inferred, want) {
+			t.Errorf("inferred LEK mismatch. want: %#v, got: %#v", want, inferred)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		for _, key := range keys {
+			var got widget
+			err := table.Update("UserID", key).Range("Time", "a").Set("Msg", "hi").Value(ctx, &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := client.updates[len(client.updates)-1]
+			if n := in.Key["UserID"].(*types.AttributeValueMemberN).Value; n != strconv.FormatUint(key, 10) {
+				t.Errorf("bad key sent for %d: %s", key, n)
+			}
+			if got.UserID != key {
+				t.Errorf("bad key returned. want: %d, got: %d", key, got.UserID)
+			}
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		batch := make([]Keyed, 0, len(keys))
+		for _, key := range keys {
+			batch = append(batch, Keys{key, "a"})
+		}
+		var got []widget
+		if err := table.Batch("UserID", "Time").Get(batch...).All(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(keys) {
+			t.Fatal("bad results:", got)
+		}
+		found := make(map[uint64]bool)
+		for _, w := range got {
+			found[w.UserID] = true
+		}
+		for _, key := range keys {
+			if !found[key] {
+				t.Error("missing key:", key)
+			}
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		// keys unmarshaled into interface{} must not lose precision when used again
+		var got map[string]any
+		if err := table.Get("UserID", uint64(math.MaxUint64)).Range("Time", Equal, "a").One(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["UserID"] != uint64(math.MaxUint64) {
+			t.Errorf("bad hash key: %v (%T)", got["UserID"], got["UserID"])
+		}
+		var w widget
+		if err := table.Update("UserID", got["UserID"]).Range("Time", got["Time"]).Set("Msg", "hi").Value(ctx, &w); err != nil {
+			t.Fatal(err)
+		}
+		if w.UserID != math.MaxUint64 {
+			t.Error("bad round trip:", w.UserID)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// KeyStruct returns a struct type representing this table's primary key, for code that handles tables
+// whose schemas aren't known at compile time. It has a field named Hash for the hash key,
+// and a field named Range for the range key if the table has one, tagged with the key attribute names
+// (as in `dynamo:"ID,hash"`). String keys are represented by string fields, numbers by [Number], and binary by []byte.
+// Values of the struct can be created with [reflect.New] and used like any other item,
+// for example to unmarshal the keys of an item or as the input of [DB.CreateTable].
+func (d Description) KeyStruct() reflect.Type {
+	fields := []reflect.StructField{keyStructField("Hash", d.HashKey, d.HashKeyType, "hash")}
+	if d.RangeKey != "" {
+		fields = append(fields, keyStructField("Range", d.RangeKey, d.RangeKeyType, "range"))
+	}
+	return reflect.StructOf(fields)
+}
+
+func keyStructField(field, name string, typ KeyType, option string) reflect.StructField {
+	var rt reflect.Type
+	switch typ {
+	case NumberType:
+		rt = rtypeNumber
+	case BinaryType:
+		rt = reflect.TypeOf([]byte(nil))
+	default:
+		rt = reflect.TypeOf("")
+	}
+	return reflect.StructField{
+		Name: field,
+		Type: rt,
+		Tag:  reflect.StructTag(`dynamo:` + strconv.Quote(name+","+option)),
+	}
+}
+
+// ValidateKeyStruct checks that v, a struct or pointer to a struct, has fields for this table's primary key
+// of the correct types, returning a [*KeyValidationError] describing the first mismatch found.
+// Fields are matched by their attribute names, as they would be encoded without a name mapper (see [DB.NameMapper]), and fields tagged as
+// hash or range keys (as in `dynamo:",hash"`) must be the table's hash or range key respectively.
+// The types of interface fields are determined by their values, so set them when v is not a zero value.
+// It does not make any requests. See also [Table.ValidateKeys] for checking key values.
+func (d Description) ValidateKeyStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv = reflect.Zero(rv.Type().Elem())
+			continue
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return &KeyValidationError{Table: d.Name, Reason: fmt.Sprintf("%T is not a struct", v)}
+	}
+	def, err := typedefOf(rv.Type(), nil)
+	if err != nil {
+		return &KeyValidationError{Table: d.Name, Reason: err.Error()}
+	}
+
+	found := make(map[string]bool, 2)
+	for _, field := range def.fields {
+		sf := rv.Type().FieldByIndex(field.index)
+		tag := sf.Tag.Get("dynamo")
+		var want KeyType
+		isKey := true
+		switch {
+		case field.name == d.HashKey:
+			want = d.HashKeyType
+		case d.RangeKey != "" && field.name == d.RangeKey:
+			want = d.RangeKeyType
+		default:
+			isKey = false
+		}
+		switch keyTypeFromTag(tag) {
+		case types.KeyTypeHash:
+			if field.name != d.HashKey {
+				return &KeyValidationError{Table: d.Name, Attribute: field.name, Reason: fmt.Sprintf("tagged as hash key, but the hash key is %q", d.HashKey)}
+			}
+		case types.KeyTypeRange:
+			if d.RangeKey == "" {
+				return &KeyValidationError{Table: d.Name, Attribute: field.name, Reason: "tagged as range key, but table has no range key"}
+			}
+			if field.name != d.RangeKey {
+				return &KeyValidationError{Table: d.Name, Attribute: field.name, Reason: fmt.Sprintf("tagged as range key, but the range key is %q", d.RangeKey)}
+			}
+		}
+		if !isKey {
+			continue
+		}
+
+		fv, err := rv.FieldByIndexErr(field.index)
+		if err != nil {
+			// nil embedded pointer
+			fv = reflect.Zero(sf.Type)
+		}
+		if fv.Kind() == reflect.Interface && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		got := KeyType(typeOf(fv, tag))
+		switch {
+		case got == NoneType:
+			return &KeyValidationError{Table: d.Name, Attribute: field.name, Reason: fmt.Sprintf("unsupported key type %s", sf.Type)}
+		case want != NoneType && got != want:
+			return &KeyValidationError{Table: d.Name, Attribute: field.name, Reason: fmt.Sprintf("type mismatch, want %s but got %s (%s)", want, got, sf.Type)}
+		}
+		found[field.name] = true
+	}
+
+	if !found[d.HashKey] {
+		return &KeyValidationError{Table: d.Name, Attribute: d.HashKey, Reason: "missing hash key field"}
+	}
+	if d.RangeKey != "" && !found[d.RangeKey] {
+		return &KeyValidationError{Table: d.Name, Attribute: d.RangeKey, Reason: "missing range key field"}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package dynamo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestKeyStruct(t *testing.T) {
+	desc := Description{
+		Name:         "Keys",
+		HashKey:      "UserID",
+		HashKeyType:  NumberType,
+		RangeKey:     "Time",
+		RangeKeyType: StringType,
+	}
+	rt := desc.KeyStruct()
+	if rt.NumField() != 2 {
+		t.Fatal("want 2 fields, got", rt.NumField())
+	}
+
+	// round trip an item's keys through the generated struct
+	item := Item{
+		"UserID": &types.AttributeValueMemberN{Value: "123456789012345678901234567890"},
+		"Time":   &types.AttributeValueMemberS{Value: "2024-01-01"},
+		"Msg":    &types.AttributeValueMemberS{Value: "hello"},
+	}
+	key := reflect.New(rt)
+	if err := UnmarshalItem(item, key.Interface()); err != nil {
+		t.Fatal(err)
+	}
+	if got := key.Elem().Field(0).Interface(); got != Number("123456789012345678901234567890") {
+		t.Error("bad hash key:", got)
+	}
+	encoded, err := MarshalItem(key.Interface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Item{"UserID": item["UserID"], "Time": item["Time"]}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Error("bad key item. want:", want, "got:", encoded)
+	}
+
+	if err := desc.ValidateKeyStruct(key.Interface()); err != nil {
+		t.Error("generated struct is invalid:", err)
+	}
+	hashOnly := Description{Name: "Hash", HashKey: "ID", HashKeyType: BinaryType}
+	if got := hashOnly.KeyStruct(); got.NumField() != 1 || got.Field(0).Type != reflect.TypeOf([]byte(nil)) {
+		t.Error("bad hash-only struct:", got)
+	}
+}
+
+func TestValidateKeyStruct(t *testing.T) {
+	desc := Description{
+		Name:         "Keys",
+		HashKey:      "UserID",
+		HashKeyType:  NumberType,
+		RangeKey:     "Time",
+		RangeKeyType: StringType,
+	}
+
+	type embedded struct {
+		UserID int64
+	}
+	valid := []interface{}{
+		widget{},
+		&widget{},
+		(*widget)(nil),
+		struct {
+			ID   int       `dynamo:"UserID"`
+			When time.Time `dynamo:"Time"`
+		}{},
+		struct {
+			embedded
+			Time string
+		}{},
+		struct {
+			UserID interface{}
+			Time   string
+		}{UserID: 1},
+	}
+	for _, v := range valid {
+		if err := desc.ValidateKeyStruct(v); err != nil {
+			t.Errorf("%T: unexpected error: %v", v, err)
+		}
+	}
+
+	tests := []struct {
+		v    interface{}
+		attr string
+	}{
+		{struct{ Time string }{}, "UserID"},
+		{struct{ UserID int }{}, "Time"},
+		{struct {
+			UserID string
+			Time   string
+		}{}, "UserID"},
+		{struct {
+			UserID int
+			Time   time.Time `dynamo:",unixtime"`
+		}{}, "Time"},
+		{struct {
+			UserID int
+			Time   string
+			Other  string `dynamo:",hash"`
+		}{}, "Other"},
+		{struct {
+			UserID interface{}
+			Time   string
+		}{}, "UserID"},
+	}
+	for _, test := range tests {
+		err := desc.ValidateKeyStruct(test.v)
+		var kerr *KeyValidationError
+		if !errors.As(err, &kerr) {
+			t.Errorf("%T: expected KeyValidationError, got: %v", test.v, err)
+			continue
+		}
+		if kerr.Attribute != test.attr || kerr.Table != "Keys" {
+			t.Errorf("%T: bad error: %v", test.v, err)
+		}
+	}
+
+	if err := desc.ValidateKeyStruct(42); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
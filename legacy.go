@@ -0,0 +1,127 @@
+package dynamo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// LegacyProjection returns a copy of db that sends simple projections (see [Query.Project] and [Scan.Project])
+// using the legacy AttributesToGet parameter instead of ProjectionExpression.
+// This is useful for testing doubles and older proxies that don't support projection expressions.
+// Projections of nested paths, such as "Map.Key" or "List[0]", can't be expressed with AttributesToGet and are sent as-is.
+// Queries and scans with filters are also sent as-is, as DynamoDB doesn't allow legacy parameters
+// to be mixed with expressions in the same request.
+// Transactions are not affected, as TransactGetItems has no AttributesToGet equivalent.
+// The returned DB shares db's table description cache.
+func (db *DB) LegacyProjection() *DB {
+	cp := *db
+	cp.client = &legacyProjectionClient{DynamoDBAPI: db.client}
+	return &cp
+}
+
+type legacyProjectionClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (c *legacyProjectionClient) Unwrap() dynamodbiface.DynamoDBAPI {
+	return c.DynamoDBAPI
+}
+
+func (c *legacyProjectionClient) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if attrs, ok := attributesToGet(in.ProjectionExpression, in.ExpressionAttributeNames); ok {
+		legacy := *in
+		legacy.AttributesToGet = attrs
+		legacy.ProjectionExpression = nil
+		legacy.ExpressionAttributeNames = nil
+		in = &legacy
+	}
+	return c.DynamoDBAPI.GetItem(ctx, in, optFns...)
+}
+
+func (c *legacyProjectionClient) Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if in.KeyConditionExpression != nil || in.FilterExpression != nil {
+		return c.DynamoDBAPI.Query(ctx, in, optFns...)
+	}
+	if attrs, ok := attributesToGet(in.ProjectionExpression, in.ExpressionAttributeNames); ok {
+		legacy := *in
+		legacy.AttributesToGet = attrs
+		legacy.ProjectionExpression = nil
+		legacy.ExpressionAttributeNames = nil
+		in = &legacy
+	}
+	return c.DynamoDBAPI.Query(ctx, in, optFns...)
+}
+
+func (c *legacyProjectionClient) Scan(ctx context.Context, in *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if in.FilterExpression != nil {
+		return c.DynamoDBAPI.Scan(ctx, in, optFns...)
+	}
+	if attrs, ok := attributesToGet(in.ProjectionExpression, in.ExpressionAttributeNames); ok {
+		legacy := *in
+		legacy.AttributesToGet = attrs
+		legacy.ProjectionExpression = nil
+		legacy.ExpressionAttributeNames = nil
+		in = &legacy
+	}
+	return c.DynamoDBAPI.Scan(ctx, in, optFns...)
+}
+
+func (c *legacyProjectionClient) BatchGetItem(ctx context.Context, in *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	var legacy *dynamodb.BatchGetItemInput
+	for table, kas := range in.RequestItems {
+		attrs, ok := attributesToGet(kas.ProjectionExpression, kas.ExpressionAttributeNames)
+		if !ok {
+			continue
+		}
+		if legacy == nil {
+			copied := *in
+			copied.RequestItems = make(map[string]types.KeysAndAttributes, len(in.RequestItems))
+			for k, v := range in.RequestItems {
+				copied.RequestItems[k] = v
+			}
+			legacy = &copied
+		}
+		kas.AttributesToGet = attrs
+		kas.ProjectionExpression = nil
+		kas.ExpressionAttributeNames = nil
+		legacy.RequestItems[table] = kas
+	}
+	if legacy != nil {
+		in = legacy
+	}
+	return c.DynamoDBAPI.BatchGetItem(ctx, in, optFns...)
+}
+
+// matches a top-level attribute name or name placeholder, without any nested paths
+var simpleProjectionRegexp = regexp.MustCompile(`^#?[A-Za-z0-9_]+$`)
+
+// attributesToGet translates a projection expression of top-level attributes into a list of attribute names,
+// resolving name placeholders. It returns false if there is no projection or it uses nested paths.
+func attributesToGet(projection *string, names map[string]string) ([]string, bool) {
+	if projection == nil || *projection == "" {
+		return nil, false
+	}
+	paths := strings.Split(*projection, ",")
+	attrs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !simpleProjectionRegexp.MatchString(path) {
+			return nil, false
+		}
+		if strings.HasPrefix(path, "#") {
+			name, ok := names[path]
+			if !ok {
+				return nil, false
+			}
+			path = name
+		}
+		attrs = append(attrs, path)
+	}
+	return attrs, true
+}
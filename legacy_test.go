@@ -0,0 +1,107 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+type legacyRecorder struct {
+	dynamodbiface.DynamoDBAPI
+	get   *dynamodb.GetItemInput
+	query *dynamodb.QueryInput
+	scan  *dynamodb.ScanInput
+	batch *dynamodb.BatchGetItemInput
+}
+
+func (c *legacyRecorder) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.get = in
+	return &dynamodb.GetItemOutput{Item: Item{"ID": &types.AttributeValueMemberN{Value: "1"}}}, nil
+}
+
+func (c *legacyRecorder) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.query = in
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (c *legacyRecorder) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.scan = in
+	return &dynamodb.ScanOutput{}, nil
+}
+
+func (c *legacyRecorder) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.batch = in
+	return &dynamodb.BatchGetItemOutput{}, nil
+}
+
+func TestLegacyProjection(t *testing.T) {
+	ctx := context.Background()
+	client := new(legacyRecorder)
+	table := NewFromIface(client).LegacyProjection().Table("Legacy")
+	want := []string{"ID", "Name"}
+
+	var item Item
+	if err := table.Get("ID", 1).Project("ID", "Name").One(ctx, &item); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(client.get.AttributesToGet, want) || client.get.ProjectionExpression != nil || client.get.ExpressionAttributeNames != nil {
+		t.Error("GetItem not translated:", client.get.AttributesToGet, client.get.ProjectionExpression, client.get.ExpressionAttributeNames)
+	}
+
+	var items []Item
+	if err := table.Scan().Project("ID", "Name").All(ctx, &items); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(client.scan.AttributesToGet, want) || client.scan.ProjectionExpression != nil {
+		t.Error("Scan not translated:", client.scan.AttributesToGet, client.scan.ProjectionExpression)
+	}
+
+	t.Run("filtered", func(t *testing.T) {
+		if err := table.Scan().Project("ID", "Name").Filter("'Count' > ?", 1).All(ctx, &items); err != nil {
+			t.Fatal(err)
+		}
+		if client.scan.AttributesToGet != nil || client.scan.ProjectionExpression == nil {
+			t.Error("filtered scan projection should be sent as-is:", client.scan.AttributesToGet, client.scan.ProjectionExpression)
+		}
+		if err := table.Get("ID", 1).Project("ID", "Name").Filter("'Count' > ?", 1).All(ctx, &items); err != nil {
+			t.Fatal(err)
+		}
+		if client.query.AttributesToGet != nil || client.query.ProjectionExpression == nil {
+			t.Error("filtered query projection should be sent as-is:", client.query.AttributesToGet, client.query.ProjectionExpression)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		if err := table.Get("ID", 1).Project("ID", "Name").All(ctx, &items); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(client.query.AttributesToGet, want) || client.query.ProjectionExpression != nil {
+			t.Error("Query not translated:", client.query.AttributesToGet, client.query.ProjectionExpression)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		if err := table.Get("ID", 1).Range("Seq", Greater, 1).Project("ID", "Map.Key").All(ctx, &items); err != nil {
+			t.Fatal(err)
+		}
+		if client.query.AttributesToGet != nil || client.query.ProjectionExpression == nil {
+			t.Error("nested projection should be sent as-is:", client.query.AttributesToGet, client.query.ProjectionExpression)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		err := table.Batch("ID").Get(Keys{1}).Project("ID", "Name").All(ctx, &items)
+		if err != nil && err != ErrNotFound {
+			t.Fatal(err)
+		}
+		kas := client.batch.RequestItems["Legacy"]
+		if !reflect.DeepEqual(kas.AttributesToGet, want) || kas.ProjectionExpression != nil {
+			t.Error("BatchGetItem not translated:", kas.AttributesToGet, kas.ProjectionExpression)
+		}
+	})
+}
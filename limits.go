@@ -0,0 +1,72 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// Limits are the provisioned capacity quotas for the current account and region.
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DescribeLimits.html
+type Limits struct {
+	// Maximum total read capacity units for all tables and global secondary indexes in the account.
+	AccountMaxRead int64
+	// Maximum total write capacity units for all tables and global secondary indexes in the account.
+	AccountMaxWrite int64
+	// Maximum read capacity units for a single table or global secondary index.
+	TableMaxRead int64
+	// Maximum write capacity units for a single table or global secondary index.
+	TableMaxWrite int64
+}
+
+// DescribeLimits returns the provisioned capacity quotas for the current account and region.
+// The client must implement [dynamodbiface.DescribeLimitsAPI], otherwise an error wrapping [errors.ErrUnsupported] is returned.
+func (db *DB) DescribeLimits(ctx context.Context) (Limits, error) {
+	client, err := clientAs[dynamodbiface.DescribeLimitsAPI](db.client, "DescribeLimits")
+	if err != nil {
+		return Limits{}, err
+	}
+	var out *dynamodb.DescribeLimitsOutput
+	err = db.retry(ctx, func() error {
+		var err error
+		out, err = client.DescribeLimits(ctx, &dynamodb.DescribeLimitsInput{}, db.optFns...)
+		return err
+	})
+	if err != nil {
+		return Limits{}, err
+	}
+	return newLimits(out), nil
+}
+
+func newLimits(out *dynamodb.DescribeLimitsOutput) Limits {
+	var limits Limits
+	if out.AccountMaxReadCapacityUnits != nil {
+		limits.AccountMaxRead = *out.AccountMaxReadCapacityUnits
+	}
+	if out.AccountMaxWriteCapacityUnits != nil {
+		limits.AccountMaxWrite = *out.AccountMaxWriteCapacityUnits
+	}
+	if out.TableMaxReadCapacityUnits != nil {
+		limits.TableMaxRead = *out.TableMaxReadCapacityUnits
+	}
+	if out.TableMaxWriteCapacityUnits != nil {
+		limits.TableMaxWrite = *out.TableMaxWriteCapacityUnits
+	}
+	return limits
+}
+
+// CheckThroughput returns an error if the given provisioned throughput
+// exceeds the per-table (or per-index) limits.
+// This is useful for validating settings before calling CreateTable or UpdateTable.
+func (limits Limits) CheckThroughput(thru Throughput) error {
+	if limits.TableMaxRead > 0 && thru.Read > limits.TableMaxRead {
+		return fmt.Errorf("dynamo: read capacity %d exceeds table limit of %d", thru.Read, limits.TableMaxRead)
+	}
+	if limits.TableMaxWrite > 0 && thru.Write > limits.TableMaxWrite {
+		return fmt.Errorf("dynamo: write capacity %d exceeds table limit of %d", thru.Write, limits.TableMaxWrite)
+	}
+	return nil
+}
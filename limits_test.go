@@ -0,0 +1,68 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+type limitsClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (limitsClient) DescribeLimits(_ context.Context, _ *dynamodb.DescribeLimitsInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeLimitsOutput, error) {
+	return &dynamodb.DescribeLimitsOutput{
+		AccountMaxReadCapacityUnits:  aws.Int64(80000),
+		AccountMaxWriteCapacityUnits: aws.Int64(80000),
+		TableMaxReadCapacityUnits:    aws.Int64(40000),
+		TableMaxWriteCapacityUnits:   aws.Int64(40000),
+	}, nil
+}
+
+func TestDescribeLimits(t *testing.T) {
+	limits, err := NewFromIface(limitsClient{}).DescribeLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Limits{
+		AccountMaxRead:  80000,
+		AccountMaxWrite: 80000,
+		TableMaxRead:    40000,
+		TableMaxWrite:   40000,
+	}
+	if limits != want {
+		t.Error("bad limits. want:", want, "got:", limits)
+	}
+
+	if err := limits.CheckThroughput(Throughput{Read: 40000, Write: 10}); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if err := limits.CheckThroughput(Throughput{Read: 1, Write: 40001}); err == nil {
+		t.Error("expected error")
+	}
+
+	t.Run("wrapped client", func(t *testing.T) {
+		client := NewHotKeySampler(1).WrapClient(limitsClient{})
+		got, err := NewFromIface(client).DescribeLimits(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Error("bad limits. want:", want, "got:", got)
+		}
+	})
+
+	t.Run("unsupported client", func(t *testing.T) {
+		_, err := NewFromIface(struct{ dynamodbiface.DynamoDBAPI }{}).DescribeLimits(context.Background())
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Error("want ErrUnsupported, got:", err)
+		}
+	})
+}
+
+var _ dynamodbiface.DescribeLimitsAPI = (*dynamodb.Client)(nil)
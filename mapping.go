@@ -0,0 +1,179 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Mapping describes how a struct type is marshaled to and unmarshaled from items,
+// as determined by its struct tags. See [Describe].
+type Mapping struct {
+	// Type is the struct type described.
+	Type reflect.Type
+	// Fields are the struct's encoded fields in declaration order,
+	// including the fields of embedded structs.
+	Fields []FieldMapping
+	// HashKey and RangeKey are the attribute names of the table's primary keys,
+	// as specified by the hash and range options of the dynamo struct tag.
+	// They are empty if not specified.
+	HashKey  string
+	RangeKey string
+}
+
+// Field returns the field mapped to the given attribute name.
+func (m Mapping) Field(attribute string) (FieldMapping, bool) {
+	for _, field := range m.Fields {
+		if field.Name == attribute {
+			return field, true
+		}
+	}
+	return FieldMapping{}, false
+}
+
+// FieldMapping describes a struct field and its attribute.
+type FieldMapping struct {
+	// GoName is the name of the struct field.
+	GoName string
+	// Index is the field's index sequence, for use with [reflect.Value.FieldByIndex].
+	Index []int
+	// Name is the attribute name.
+	Name string
+	// Type is the field's Go type.
+	Type reflect.Type
+	// KeyType is the attribute's type when used as a key, as used by [DB.CreateTable].
+	// It is NoneType if the field's type can't be used as a key.
+	KeyType KeyType
+	// Options are the options given in the dynamo struct tag.
+	Options FieldOptions
+	// Keys are the key roles of this attribute for the table and its indexes.
+	Keys []KeyRole
+}
+
+// FieldOptions are the options of a field's dynamo struct tag.
+type FieldOptions struct {
+	Set            bool
+	OmitEmpty      bool
+	OmitEmptyElem  bool
+	AllowEmpty     bool
+	AllowEmptyElem bool
+	Null           bool
+	UnixTime       bool
+	Saturate       bool
+	String         bool
+	Number         bool
+	ReadOnly       bool
+	WriteOnly      bool
+	// KeyFmt is the zero-padded width given by the keyfmt option, or 0 if not specified.
+	KeyFmt int
+}
+
+// KeyRole is the role of an attribute in the key schema of a table or one of its indexes.
+type KeyRole struct {
+	// Index is the name of the index, or empty for the table's primary key.
+	Index string
+	// Local is true for local secondary indexes.
+	Local bool
+	// KeyType is either types.KeyTypeHash or types.KeyTypeRange.
+	KeyType types.KeyType
+}
+
+// Describe returns the mapping between T's struct fields and item attributes,
+// using the same rules as marshaling and [DB.CreateTable].
+// Untagged fields are named as-is, as when no name mapper is set (see [DB.NameMapper]).
+// T must be a struct or a pointer to a struct.
+func Describe[T any]() (Mapping, error) {
+	return describeType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// DescribeTag returns the mapping of a struct field named goName with the given struct tag,
+// using the same rules as [Describe]. Only GoName, Name, Options, and Keys are set,
+// as the rest depend on the field's type and position.
+// Name is "-" if the field is not encoded.
+func DescribeTag(goName string, tag reflect.StructTag) FieldMapping {
+	field := reflect.StructField{Name: goName, Tag: tag}
+	name, flags := fieldInfo(field, nil)
+	return FieldMapping{
+		GoName:  goName,
+		Name:    name,
+		Options: fieldOptions(flags),
+		Keys:    keyRoles(field),
+	}
+}
+
+func describeType(rt reflect.Type) (Mapping, error) {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return Mapping{}, fmt.Errorf("dynamo: Describe: %v is not a struct", rt)
+	}
+
+	m := Mapping{Type: rt}
+	err := visitTypeFields(rt, nil, nil, nil, func(name string, index []int, flags encodeFlags, ft reflect.Type) error {
+		field := rt.FieldByIndex(index)
+		fm := FieldMapping{
+			GoName:  field.Name,
+			Index:   index,
+			Name:    name,
+			Type:    ft,
+			KeyType: KeyType(typeOf(reflect.New(ft).Elem(), field.Tag.Get("dynamo"))),
+			Options: fieldOptions(flags),
+			Keys:    keyRoles(field),
+		}
+		for _, role := range fm.Keys {
+			switch {
+			case role.Index != "":
+			case role.KeyType == types.KeyTypeHash:
+				m.HashKey = name
+			case role.KeyType == types.KeyTypeRange:
+				m.RangeKey = name
+			}
+		}
+		m.Fields = append(m.Fields, fm)
+		return nil
+	})
+	return m, err
+}
+
+func fieldOptions(flags encodeFlags) FieldOptions {
+	return FieldOptions{
+		Set:            flags&flagSet != 0,
+		OmitEmpty:      flags&flagOmitEmpty != 0,
+		OmitEmptyElem:  flags&flagOmitEmptyElem != 0,
+		AllowEmpty:     flags&flagAllowEmpty != 0,
+		AllowEmptyElem: flags&flagAllowEmptyElem != 0,
+		Null:           flags&flagNull != 0,
+		UnixTime:       flags&flagUnixTime != 0,
+		Saturate:       flags&flagSaturate != 0,
+		String:         flags&flagString != 0,
+		Number:         flags&flagNumber != 0,
+		ReadOnly:       flags&flagReadOnly != 0,
+		WriteOnly:      flags&flagWriteOnly != 0,
+		KeyFmt:         flags.keyWidth(),
+	}
+}
+
+func keyRoles(field reflect.StructField) []KeyRole {
+	var roles []KeyRole
+	if keyType := keyTypeFromTag(field.Tag.Get("dynamo")); keyType != "" {
+		roles = append(roles, KeyRole{KeyType: keyType})
+	}
+	for _, tag := range []string{"index", "localIndex"} {
+		specs, _ := tagLookup(string(field.Tag), tag)
+		for _, spec := range specs {
+			keyType := keyTypeFromTag(spec)
+			if keyType == "" {
+				continue
+			}
+			roles = append(roles, KeyRole{
+				Index:   spec[:strings.LastIndexByte(spec, ',')],
+				Local:   tag == "localIndex",
+				KeyType: keyType,
+			})
+		}
+	}
+	return roles
+}
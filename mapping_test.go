@@ -0,0 +1,72 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestDescribe(t *testing.T) {
+	type embedded struct {
+		Created time.Time `dynamo:",unixtime"`
+	}
+	type action struct {
+		UserID string    `dynamo:"ID,hash" index:"Seq-ID-index,range"`
+		Time   time.Time `dynamo:",range"`
+		Seq    int64     `localIndex:"ID-Seq-index,sort" index:"Seq-ID-index,hash"`
+		Tags   []string  `dynamo:",set,omitempty"`
+		Shard  int       `dynamo:",keyfmt=%04d"`
+		Skip   string    `dynamo:"-"`
+		secret string
+		embedded
+	}
+
+	m, err := Describe[*action]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != reflect.TypeOf(action{}) {
+		t.Error("bad type:", m.Type)
+	}
+	if m.HashKey != "ID" || m.RangeKey != "Time" {
+		t.Error("bad primary keys:", m.HashKey, m.RangeKey)
+	}
+	var names []string
+	for _, f := range m.Fields {
+		names = append(names, f.Name)
+	}
+	if want := []string{"ID", "Time", "Seq", "Tags", "Shard", "Created"}; !reflect.DeepEqual(names, want) {
+		t.Error("bad fields. want:", want, "got:", names)
+	}
+
+	id, _ := m.Field("ID")
+	if id.GoName != "UserID" || id.KeyType != StringType {
+		t.Error("bad ID field:", id)
+	}
+	seq, _ := m.Field("Seq")
+	wantRoles := []KeyRole{
+		{Index: "Seq-ID-index", KeyType: types.KeyTypeHash},
+		{Index: "ID-Seq-index", Local: true, KeyType: types.KeyTypeRange},
+	}
+	if !reflect.DeepEqual(seq.Keys, wantRoles) || seq.KeyType != NumberType {
+		t.Error("bad Seq field:", seq)
+	}
+	tags, _ := m.Field("Tags")
+	if !tags.Options.Set || !tags.Options.OmitEmpty || tags.KeyType != NoneType {
+		t.Error("bad Tags options:", tags.Options)
+	}
+	shard, _ := m.Field("Shard")
+	if shard.Options.KeyFmt != 4 || shard.KeyType != StringType {
+		t.Error("bad Shard field:", shard)
+	}
+	created, _ := m.Field("Created")
+	if !created.Options.UnixTime || !reflect.DeepEqual(created.Index, []int{7, 0}) {
+		t.Error("bad embedded field:", created)
+	}
+
+	if _, err := Describe[string](); err == nil {
+		t.Error("want error for non-struct type")
+	}
+}
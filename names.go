@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// NameMapper converts a Go struct field name into an attribute name.
+// See [DB.NameMapper].
+type NameMapper func(fieldName string) string
+
+// NameMapper returns a copy of db that names the attributes of struct fields
+// that don't specify a name in their dynamo struct tag with mapper, such as [SnakeCase].
+// Explicitly named fields (`dynamo:"Name"`) are never mapped. Pass nil to use field names as-is (the default).
+// The mapper applies to items marshaled and unmarshaled by the returned DB's requests and to the tables it creates,
+// but not to package-level functions such as [MarshalItem].
+// The returned DB shares db's table description cache.
+//
+// Struct types are analyzed once per mapper and cached, so call NameMapper once, such as during initialization,
+// and reuse the returned DB instead of calling it for every request.
+func (db *DB) NameMapper(mapper NameMapper) *DB {
+	cp := *db
+	cp.names = nil
+	if mapper != nil {
+		cp.names = &mapper
+	}
+	return &cp
+}
+
+// nameMapper returns db's name mapper, or nil if db is nil.
+func (db *DB) nameMapper() *NameMapper {
+	if db == nil {
+		return nil
+	}
+	return db.names
+}
+
+type nameMapperKey struct{}
+
+func nameMapperFrom(ctx context.Context) *NameMapper {
+	names, _ := ctx.Value(nameMapperKey{}).(*NameMapper)
+	return names
+}
+
+// mapFieldName returns the attribute name for an untagged struct field.
+func mapFieldName(names *NameMapper, name string) string {
+	if names != nil {
+		return (*names)(name)
+	}
+	return name
+}
+
+// SnakeCase is a [NameMapper] that converts field names to snake_case.
+// Acronyms are kept together, so "UserID" becomes "user_id" and "HTTPStatus" becomes "http_status".
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	var sb strings.Builder
+	sb.Grow(len(fieldName) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
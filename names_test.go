@@ -0,0 +1,88 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"CreatedAt":  "created_at",
+		"HTTPStatus": "http_status",
+		"Field2":     "field2",
+		"V2Name":     "v2_name",
+		"already":    "already",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameMapper(t *testing.T) {
+	type embedded struct {
+		LastSeen int
+	}
+	type user struct {
+		UserID    string
+		FullName  string `dynamo:",omitempty"`
+		Nickname  string `dynamo:"nick"`
+		Untouched string `dynamo:"-"`
+		embedded
+	}
+
+	ctx := context.Background()
+	client := new(recordingClient)
+	db := NewFromIface(client).NameMapper(SnakeCase)
+
+	in := user{UserID: "u1", FullName: "Ada", Nickname: "A", Untouched: "x", embedded: embedded{LastSeen: 1}}
+	if _, err := db.Table("Users").Batch("user_id").Write().Put(in).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	item := client.batches[0].RequestItems["Users"][0].PutRequest.Item
+	want := Item{
+		"user_id":   &types.AttributeValueMemberS{Value: "u1"},
+		"full_name": &types.AttributeValueMemberS{Value: "Ada"},
+		"nick":      &types.AttributeValueMemberS{Value: "A"},
+		"last_seen": &types.AttributeValueMemberN{Value: "1"},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("bad marshal. want: %#v got: %#v", want, item)
+	}
+
+	var out user
+	if err := unmarshalItem(db.codecContext(ctx), item, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Untouched = ""
+	if out != in {
+		t.Errorf("bad unmarshal. want: %#v got: %#v", in, out)
+	}
+
+	// values substituted into expressions are mapped too
+	if err := db.Table("Users").Update("user_id", "u1").Set("seen", embedded{LastSeen: 2}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, av := range client.updates[0].ExpressionAttributeValues {
+		if m, ok := av.(*types.AttributeValueMemberM); ok {
+			if _, ok := m.Value["last_seen"]; !ok {
+				t.Error("bad substituted value:", m.Value)
+			}
+		}
+	}
+
+	// other DBs and package-level functions are unaffected
+	plain, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain["UserID"]; !ok {
+		t.Error("name mapper leaked:", plain)
+	}
+}
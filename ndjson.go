@@ -0,0 +1,132 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// NDJSONReader returns a reader of this query's results as newline-delimited JSON, one item per line.
+// Results are fetched as they are read, so only about one page of results is held in memory at a time,
+// and requests are paused while the reader isn't being read.
+// This is useful for streaming results into HTTP responses or files.
+//
+// Items are encoded as plain JSON: numbers keep their exact value, binary data is base64-encoded,
+// sets become arrays, and NULL becomes null. Object keys are sorted.
+// If the query fails, Read returns its error.
+// Close the reader when done to stop the query.
+func (q *Query) NDJSONReader(ctx context.Context) io.ReadCloser {
+	return newNDJSONReader(ctx, q.Iter())
+}
+
+type ndjsonReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	iter   PagingIter
+	pr     *io.PipeReader
+	pw     *io.PipeWriter
+	once   sync.Once
+}
+
+func newNDJSONReader(ctx context.Context, iter PagingIter) *ndjsonReader {
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	return &ndjsonReader{
+		ctx:    ctx,
+		cancel: cancel,
+		iter:   iter,
+		pr:     pr,
+		pw:     pw,
+	}
+}
+
+func (r *ndjsonReader) Read(p []byte) (int, error) {
+	r.once.Do(func() {
+		go r.run()
+	})
+	return r.pr.Read(p)
+}
+
+func (r *ndjsonReader) Close() error {
+	r.cancel()
+	return r.pr.Close()
+}
+
+func (r *ndjsonReader) run() {
+	for {
+		var item Item
+		if !r.iter.Next(r.ctx, &item) {
+			break
+		}
+		line, err := itemJSON(item)
+		if err != nil {
+			r.pw.CloseWithError(err)
+			return
+		}
+		if _, err := r.pw.Write(append(line, '\n')); err != nil {
+			// closed by the reader
+			return
+		}
+	}
+	r.pw.CloseWithError(r.iter.Err())
+}
+
+// itemJSON encodes item as a JSON object.
+func itemJSON(item Item) ([]byte, error) {
+	v, err := av2json(&types.AttributeValueMemberM{Value: item})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// av2json converts av into a value to be encoded with encoding/json, keeping numbers as-is.
+func av2json(av types.AttributeValue) (interface{}, error) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberB:
+		return v.Value, nil
+	case *types.AttributeValueMemberBS:
+		return v.Value, nil
+	case *types.AttributeValueMemberBOOL:
+		return v.Value, nil
+	case *types.AttributeValueMemberN:
+		return json.Number(v.Value), nil
+	case *types.AttributeValueMemberS:
+		return v.Value, nil
+	case *types.AttributeValueMemberL:
+		list := make([]interface{}, 0, len(v.Value))
+		for _, elem := range v.Value {
+			x, err := av2json(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, x)
+		}
+		return list, nil
+	case *types.AttributeValueMemberNS:
+		set := make([]json.Number, 0, len(v.Value))
+		for _, n := range v.Value {
+			set = append(set, json.Number(n))
+		}
+		return set, nil
+	case *types.AttributeValueMemberSS:
+		return v.Value, nil
+	case *types.AttributeValueMemberM:
+		m := make(map[string]interface{}, len(v.Value))
+		for k, elem := range v.Value {
+			x, err := av2json(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = x
+		}
+		return m, nil
+	case *types.AttributeValueMemberNULL:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("dynamo: unsupported AV: %#v", av)
+}
@@ -0,0 +1,86 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Null is an attribute of type T that can also be NULL or absent,
+// for models that need to tell all three states apart in one field.
+// Struct tag options such as omitempty, null, and allowempty can't express this on their own,
+// because the zero value of T is ambiguous.
+//
+// The zero value is absent: the attribute is omitted when marshaling,
+// and Null is left as-is when unmarshaling an item without the attribute.
+// A NULL attribute unmarshals as Null set to true, and any other attribute is unmarshaled into Value with Valid set to true.
+// Valid values are always marshaled, even if they are empty, such as Null[string]{Valid: true}.
+//
+// Struct tag options such as set or unixtime don't apply to Value,
+// and the null option should be avoided because it marshals absent values as NULL.
+type Null[T any] struct {
+	Value T
+	// Valid is true if the attribute is set to Value.
+	Valid bool
+	// Null is true if the attribute is NULL. It is ignored if Valid is true.
+	Null bool
+}
+
+// NullValue returns a valid Null containing v.
+func NullValue[T any](v T) Null[T] {
+	return Null[T]{Value: v, Valid: true}
+}
+
+// Absent returns true if n is neither valid nor NULL.
+func (n Null[T]) Absent() bool {
+	return !n.Valid && !n.Null
+}
+
+// IsZero returns true if n is absent, for use with the omitempty option.
+func (n Null[T]) IsZero() bool {
+	return n.Absent()
+}
+
+// Ptr returns a pointer to a copy of Value if n is valid, otherwise nil.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Value
+}
+
+// MarshalDynamoContext implements [MarshalerCtx].
+func (n Null[T]) MarshalDynamoContext(ctx context.Context) (types.AttributeValue, error) {
+	switch {
+	case n.Valid:
+		return marshalContext(ctx, n.Value, flagAllowEmpty|flagAllowEmptyElem)
+	case n.Null:
+		return nullAV, nil
+	}
+	return nil, nil
+}
+
+func (Null[T]) forwardContext() {}
+
+// UnmarshalDynamoContext implements [UnmarshalerCtx].
+func (n *Null[T]) UnmarshalDynamoContext(ctx context.Context, av types.AttributeValue) error {
+	if _, ok := av.(*types.AttributeValueMemberNULL); ok {
+		n.decodeNull()
+		return nil
+	}
+	var v T
+	if err := UnmarshalContext(ctx, av, &v); err != nil {
+		return err
+	}
+	*n = Null[T]{Value: v, Valid: true}
+	return nil
+}
+
+func (n *Null[T]) decodeNull() {
+	*n = Null[T]{Null: true}
+}
+
+// nullDecoder is implemented by types that unmarshal NULL attributes differently from zero values.
+type nullDecoder interface {
+	decodeNull()
+}
@@ -0,0 +1,51 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestNull(t *testing.T) {
+	type record struct {
+		Absent Null[string]
+		Null   Null[int]
+		Value  Null[int]
+		Empty  Null[string]
+		Omit   Null[string] `dynamo:",omitempty"`
+	}
+	in := record{
+		Null:  Null[int]{Null: true},
+		Value: NullValue(42),
+		Empty: NullValue(""),
+	}
+	item, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Item{
+		"Null":  &types.AttributeValueMemberNULL{Value: true},
+		"Value": &types.AttributeValueMemberN{Value: "42"},
+		"Empty": &types.AttributeValueMemberS{Value: ""},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("bad marshal. want:\n%#v\ngot:\n%#v", want, item)
+	}
+
+	out := record{Null: NullValue(1)}
+	if err := UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("bad unmarshal. want:\n%#v\ngot:\n%#v", in, out)
+	}
+	if !out.Absent.Absent() || out.Null.Absent() || out.Null.Ptr() != nil || *out.Value.Ptr() != 42 {
+		t.Error("bad state:", out)
+	}
+
+	var bad Null[int]
+	if err := Unmarshal(&types.AttributeValueMemberS{Value: "x"}, &bad); err == nil || bad.Valid {
+		t.Error("want error, got:", err, bad)
+	}
+}
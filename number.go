@@ -0,0 +1,16 @@
+package dynamo
+
+// Number is a DynamoDB number (N) kept as text.
+// Unlike float64 or int64, it can hold numbers of any precision DynamoDB supports without rounding.
+// Note that DynamoDB normalizes numbers when storing them, so formatting such as
+// trailing zeros and scientific notation isn't preserved.
+// Strings with the "number" struct tag option are treated the same way.
+//
+// Number is not validated when marshaling; invalid numbers are rejected by DynamoDB.
+// An empty Number is omitted.
+type Number string
+
+// String returns n as a string.
+func (n Number) String() string {
+	return string(n)
+}
@@ -0,0 +1,97 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// OneOf is an attribute that holds either a value of type A or a value of type B,
+// for tables where an attribute's type changed over time, such as an ID that used to be a number and is now a string.
+// Declaring the field as OneOf[string, int64] lets old and new items unmarshal instead of failing.
+//
+// When unmarshaling, the attribute is unmarshaled into A if possible, otherwise into B,
+// so A should be the type that is preferred when both could match.
+// The zero value is absent: the attribute is omitted when marshaling,
+// and OneOf is left as-is when unmarshaling an item without the attribute or with a NULL attribute.
+//
+// Struct tag options such as set or unixtime don't apply to A or B.
+type OneOf[A, B any] struct {
+	A A
+	B B
+	// Which is 1 if the attribute is A, 2 if it is B, or 0 if it is absent.
+	Which int
+}
+
+// OneOfA returns a OneOf containing a.
+func OneOfA[A, B any](a A) OneOf[A, B] {
+	return OneOf[A, B]{A: a, Which: 1}
+}
+
+// OneOfB returns a OneOf containing b.
+func OneOfB[A, B any](b B) OneOf[A, B] {
+	return OneOf[A, B]{B: b, Which: 2}
+}
+
+// IsA returns true if o holds an A.
+func (o OneOf[A, B]) IsA() bool {
+	return o.Which == 1
+}
+
+// IsB returns true if o holds a B.
+func (o OneOf[A, B]) IsB() bool {
+	return o.Which == 2
+}
+
+// IsZero returns true if o is absent, for use with the omitempty option.
+func (o OneOf[A, B]) IsZero() bool {
+	return o.Which == 0
+}
+
+// Value returns the value o holds, or nil if it is absent.
+func (o OneOf[A, B]) Value() any {
+	switch o.Which {
+	case 1:
+		return o.A
+	case 2:
+		return o.B
+	}
+	return nil
+}
+
+// MarshalDynamoContext implements [MarshalerCtx].
+func (o OneOf[A, B]) MarshalDynamoContext(ctx context.Context) (types.AttributeValue, error) {
+	switch o.Which {
+	case 0:
+		return nil, nil
+	case 1:
+		return marshalContext(ctx, o.A, flagAllowEmpty|flagAllowEmptyElem)
+	case 2:
+		return marshalContext(ctx, o.B, flagAllowEmpty|flagAllowEmptyElem)
+	}
+	return nil, fmt.Errorf("dynamo: invalid OneOf[%T, %T] with Which = %d", o.A, o.B, o.Which)
+}
+
+func (OneOf[A, B]) forwardContext() {}
+
+// UnmarshalDynamoContext implements [UnmarshalerCtx].
+func (o *OneOf[A, B]) UnmarshalDynamoContext(ctx context.Context, av types.AttributeValue) error {
+	if _, ok := av.(*types.AttributeValueMemberNULL); ok {
+		return nil
+	}
+	var a A
+	errA := UnmarshalContext(ctx, av, &a)
+	if errA == nil {
+		*o = OneOf[A, B]{A: a, Which: 1}
+		return nil
+	}
+	var b B
+	errB := UnmarshalContext(ctx, av, &b)
+	if errB == nil {
+		*o = OneOf[A, B]{B: b, Which: 2}
+		return nil
+	}
+	return fmt.Errorf("dynamo: cannot unmarshal %s attribute into OneOf[%T, %T]: %w", avTypeName(av), a, b, errors.Join(errA, errB))
+}
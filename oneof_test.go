@@ -0,0 +1,60 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestOneOf(t *testing.T) {
+	type widget struct {
+		ID    OneOf[string, int64]
+		Owner OneOf[string, int64] `dynamo:",omitempty"`
+	}
+
+	items := []struct {
+		item Item
+		want widget
+	}{
+		{
+			item: Item{"ID": &types.AttributeValueMemberS{Value: "w-1"}},
+			want: widget{ID: OneOfA[string, int64]("w-1")},
+		},
+		{
+			item: Item{"ID": &types.AttributeValueMemberN{Value: "42"}},
+			want: widget{ID: OneOfB[string](int64(42))},
+		},
+		{
+			item: Item{"ID": &types.AttributeValueMemberN{Value: "7"}, "Owner": &types.AttributeValueMemberNULL{Value: true}},
+			want: widget{ID: OneOfB[string](int64(7))},
+		},
+	}
+	for _, tc := range items {
+		var got widget
+		if err := UnmarshalItem(tc.item, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("bad unmarshal. want: %#v, got: %#v", tc.want, got)
+		}
+		if got.Owner.Value() != nil {
+			t.Error("want absent owner, got:", got.Owner.Value())
+		}
+
+		// round trip, without the absent owner
+		item, err := MarshalItem(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(item, Item{"ID": tc.item["ID"]}) {
+			t.Errorf("bad marshal. want: %#v, got: %#v", tc.item["ID"], item)
+		}
+	}
+
+	var got widget
+	err := UnmarshalItem(Item{"ID": &types.AttributeValueMemberBOOL{Value: true}}, &got)
+	if err == nil {
+		t.Error("expected error for boolean ID")
+	}
+}
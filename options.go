@@ -0,0 +1,50 @@
+package dynamo
+
+import (
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// WithAPIOptions returns an option that adds the given smithy middleware stack functions to requests,
+// such as for custom signing, user agents, or headers.
+// It can be given to [New], [DB.RequestOptions], or the RequestOptions method of a request, such as [Query.RequestOptions].
+//
+//	import awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+//
+//	db := dynamo.New(cfg, dynamo.WithAPIOptions(
+//		awsmiddleware.AddUserAgentKeyValue("my-app", "1.0"),
+//	))
+//
+//	// or only for one request:
+//	err := table.Get("ID", 42).
+//		RequestOptions(dynamo.WithAPIOptions(addHeader)).
+//		One(ctx, &item)
+func WithAPIOptions(fns ...func(*middleware.Stack) error) func(*dynamodb.Options) {
+	return func(opts *dynamodb.Options) {
+		opts.APIOptions = append(opts.APIOptions, fns...)
+	}
+}
+
+// RequestOptions returns a copy of db that applies the given options to every request it makes,
+// after the options of the underlying client. This allows for variations of a client's configuration,
+// such as a different endpoint or extra middleware (see [WithAPIOptions]), without creating a new client.
+// Options given to a request's RequestOptions method, such as [Query.RequestOptions], are applied after these.
+// Methods that don't return a request, such as [Table.Tags] and [DB.DescribeLimits], only use these options.
+// The returned DB shares db's table description cache.
+//
+// Options are passed to the client's methods, so they have no effect on clients created by [NewFromIface] that ignore them.
+func (db *DB) RequestOptions(optFns ...func(*dynamodb.Options)) *DB {
+	cp := *db
+	cp.optFns = append(slices.Clip(db.optFns), optFns...)
+	return &cp
+}
+
+// requestOptions returns the options for a request: db's options, followed by extra.
+func (db *DB) requestOptions(extra []func(*dynamodb.Options)) []func(*dynamodb.Options) {
+	if len(extra) == 0 {
+		return db.optFns
+	}
+	return append(slices.Clip(db.optFns), extra...)
+}
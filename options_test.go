@@ -0,0 +1,121 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestRequestOptions(t *testing.T) {
+	ctx := context.Background()
+	errStop := errors.New("stop")
+	var called []string
+	// record adds a middleware that records its name and stops the request before it is sent
+	record := func(name string) func(*dynamodb.Options) {
+		return WithAPIOptions(func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(name, func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				called = append(called, name+":"+stack.ID())
+				if name == "request" {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, errStop
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.After)
+		})
+	}
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		BaseEndpoint:     aws.String("http://127.0.0.1:1"),
+		RetryMaxAttempts: 1,
+	})
+	db := NewFromIface(client)
+	custom := db.RequestOptions(record("db"))
+	if custom.Table("Options").db == db {
+		t.Fatal("RequestOptions should return a copy")
+	}
+
+	t.Run("put", func(t *testing.T) {
+		called = nil
+		err := custom.Table("Options").Put(widget{UserID: 1}).RequestOptions(record("request")).Run(ctx)
+		if !errors.Is(err, errStop) {
+			t.Fatal("unexpected error:", err)
+		}
+		want := []string{"db:PutItem", "request:PutItem"}
+		if !reflect.DeepEqual(called, want) {
+			t.Error("bad middleware calls. want:", want, "got:", called)
+		}
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		called = nil
+		tx := custom.WriteTx().Put(custom.Table("Options").Put(widget{UserID: 1})).RequestOptions(record("request"))
+		if err := tx.Run(ctx); !errors.Is(err, errStop) {
+			t.Fatal("unexpected error:", err)
+		}
+		want := []string{"db:TransactWriteItems", "request:TransactWriteItems"}
+		if !reflect.DeepEqual(called, want) {
+			t.Error("bad middleware calls. want:", want, "got:", called)
+		}
+	})
+
+	t.Run("table management", func(t *testing.T) {
+		table := custom.Table("Options")
+		runs := map[string]func() error{
+			"CreateTable": func() error {
+				return custom.CreateTable("Options", widget{}).RequestOptions(record("request")).Run(ctx)
+			},
+			"DescribeTable": func() error {
+				_, err := table.Describe().RequestOptions(record("request")).Run(ctx)
+				return err
+			},
+			"UpdateTable": func() error {
+				_, err := table.UpdateTable().OnDemand(true).RequestOptions(record("request")).Run(ctx)
+				return err
+			},
+			"DeleteTable": func() error {
+				return table.DeleteTable().RequestOptions(record("request")).Run(ctx)
+			},
+			"UpdateTimeToLive": func() error {
+				return table.UpdateTTL("TTL", true).RequestOptions(record("request")).Run(ctx)
+			},
+			"DescribeTimeToLive": func() error {
+				_, err := table.DescribeTTL().RequestOptions(record("request")).Run(ctx)
+				return err
+			},
+			"ListTables": func() error {
+				_, err := custom.ListTables().RequestOptions(record("request")).All(ctx)
+				return err
+			},
+			"BatchExecuteStatement": func() error {
+				return custom.BatchQuery(Statement{Query: "DELETE FROM Options WHERE UserID = 1"}).RequestOptions(record("request")).All(ctx, nil)
+			},
+		}
+		for op, run := range runs {
+			called = nil
+			if err := run(); !errors.Is(err, errStop) {
+				t.Fatal(op, "unexpected error:", err)
+			}
+			want := []string{"db:" + op, "request:" + op}
+			if !reflect.DeepEqual(called, want) {
+				t.Error("bad middleware calls. want:", want, "got:", called)
+			}
+		}
+	})
+
+	t.Run("original db", func(t *testing.T) {
+		called = nil
+		err := db.Table("Options").Delete("UserID", 1).Range("Time", "a").RequestOptions(record("request")).Run(ctx)
+		if !errors.Is(err, errStop) {
+			t.Fatal("unexpected error:", err)
+		}
+		want := []string{"request:DeleteItem"}
+		if !reflect.DeepEqual(called, want) {
+			t.Error("bad middleware calls. want:", want, "got:", called)
+		}
+	})
+}
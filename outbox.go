@@ -0,0 +1,191 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Outbox attribute names and statuses.
+const (
+	// OutboxID is the hash key of an outbox table: a ULID assigned to each event, so events sort by creation time.
+	OutboxID = "OutboxID"
+	// OutboxStatus is the status of an event, either [OutboxPending] or [OutboxClaimed].
+	OutboxStatus = "OutboxStatus"
+	// OutboxClaimedUntil is the Unix time in seconds at which a claimed event's lease ends.
+	OutboxClaimedUntil = "OutboxClaimedUntil"
+
+	OutboxPending = "PENDING"
+	OutboxClaimed = "CLAIMED"
+)
+
+// DefaultOutboxLease is the default time a relay has to deliver an event before another can claim it.
+const DefaultOutboxLease = 5 * time.Minute
+
+// Outbox implements the transactional outbox pattern: events are written to an outbox table
+// in the same transaction as the changes that caused them, and later relayed (for example, to a message queue)
+// by polling the outbox. This makes sure that events are published if and only if their changes are committed.
+//
+// The outbox table's hash key must be the string attribute [OutboxID], and it needs a global secondary index
+// whose hash key is [OutboxStatus] and range key is [OutboxID], projecting all attributes.
+// Events are deleted from the outbox once they are relayed.
+type Outbox struct {
+	table Table
+	index string
+	lease time.Duration
+}
+
+// NewOutbox returns an outbox using table, which is polled using the given index.
+// See [Outbox] for the required table schema.
+func NewOutbox(table Table, index string) *Outbox {
+	return &Outbox{
+		table: table,
+		index: index,
+		lease: DefaultOutboxLease,
+	}
+}
+
+// Lease sets how long a relay has to deliver an event before it can be claimed again.
+// The default is [DefaultOutboxLease].
+func (ob *Outbox) Lease(d time.Duration) *Outbox {
+	ob.lease = d
+	return ob
+}
+
+// Put adds event to tx, to be written to the outbox if and only if the rest of tx succeeds.
+// Event is marshaled like any other item, with the outbox attributes ([OutboxID] and [OutboxStatus]) added.
+// Events must not have any outbox attributes of their own.
+func (ob *Outbox) Put(tx *WriteTx, event interface{}) *WriteTx {
+	db := ob.table.db
+	item, deferred, err := marshalItemDeferred(db.codecContext(context.Background()), event)
+	if err == nil {
+		err = fillAuto(event, item, db.nameMapper())
+	}
+	if err != nil {
+		tx.setError(err)
+		return tx
+	}
+	for _, name := range []string{OutboxID, OutboxStatus, OutboxClaimedUntil} {
+		if _, ok := item[name]; ok {
+			tx.setError(fmt.Errorf("dynamo: outbox event already has reserved attribute %q", name))
+			return tx
+		}
+	}
+	id, err := NewULID()
+	if err != nil {
+		tx.setError(err)
+		return tx
+	}
+	attrs := Item{
+		OutboxID:     &types.AttributeValueMemberS{Value: id},
+		OutboxStatus: &types.AttributeValueMemberS{Value: OutboxPending},
+	}
+	maps.Copy(item, attrs)
+	put := ob.table.Put(item)
+	if deferred {
+		// marshal event again with the transaction's context
+		put.value = event
+		put.extra = attrs
+	}
+	return tx.Put(put.If("attribute_not_exists($)", OutboxID))
+}
+
+// OutboxEvent is an event claimed from an outbox.
+type OutboxEvent struct {
+	ID string
+	// Item is the event's item, including the outbox attributes.
+	Item Item
+
+	db *DB
+}
+
+// Unmarshal unmarshals the event's item into out, using the outbox's DB options (such as [DB.NameMapper]).
+func (e OutboxEvent) Unmarshal(out interface{}) error {
+	return unmarshalItem(e.db.codecContext(context.Background()), e.Item, out)
+}
+
+// Relay claims up to limit pending events, oldest first, and calls fn for each of them.
+// Events whose lease has expired, because a previous relay failed to deliver them in time, are claimed again.
+// Each event is claimed with a conditional update, so concurrent relays never deliver the same event at once,
+// but an event can be delivered more than once if its lease expires before fn returns, so consumers should be idempotent.
+//
+// If fn succeeds, the event is deleted from the outbox. Otherwise, it is released to be retried later.
+// Relay returns the number of events delivered, and the errors of any events that could not be delivered.
+func (ob *Outbox) Relay(ctx context.Context, limit int, fn func(context.Context, OutboxEvent) error) (int, error) {
+	var items []Item
+	err := ob.table.Get(OutboxStatus, OutboxPending).Index(ob.index).SearchLimit(limit).All(ctx, &items)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) < limit {
+		var expired []Item
+		err := ob.table.Get(OutboxStatus, OutboxClaimed).Index(ob.index).
+			Filter("$ < ?", OutboxClaimedUntil, time.Now().Unix()).
+			SearchLimit(limit-len(items)).
+			All(ctx, &expired)
+		if err != nil {
+			return 0, err
+		}
+		items = append(items, expired...)
+	}
+
+	var relayed int
+	var errs []error
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		idv, ok := item[OutboxID].(*types.AttributeValueMemberS)
+		if !ok {
+			errs = append(errs, fmt.Errorf("dynamo: outbox event is missing %s: %v", OutboxID, item))
+			continue
+		}
+		event := OutboxEvent{ID: idv.Value, Item: item, db: ob.table.db}
+		lease, err := ob.claim(ctx, event.ID)
+		if IsCondCheckFailed(err) {
+			// claimed by another relay
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := fn(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("dynamo: outbox event %s: %w", event.ID, err))
+			err = ob.table.Update(OutboxID, event.ID).
+				Set(OutboxStatus, OutboxPending).
+				Remove(OutboxClaimedUntil).
+				If("$ = ?", OutboxClaimedUntil, lease).
+				Run(ctx)
+			if err != nil && !IsCondCheckFailed(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		err = ob.table.Delete(OutboxID, event.ID).If("$ = ?", OutboxClaimedUntil, lease).Run(ctx)
+		if err != nil && !IsCondCheckFailed(err) {
+			errs = append(errs, err)
+		}
+		relayed++
+	}
+	return relayed, errors.Join(errs...)
+}
+
+// claim leases an event, returning the end of its lease.
+// The lease starts when the event is claimed, not when the batch of events was read.
+func (ob *Outbox) claim(ctx context.Context, id string) (int64, error) {
+	now := time.Now()
+	lease := now.Add(ob.lease).Unix()
+	err := ob.table.Update(OutboxID, id).
+		Set(OutboxStatus, OutboxClaimed).
+		Set(OutboxClaimedUntil, lease).
+		If("$ = ? OR $ < ?", OutboxStatus, OutboxPending, OutboxClaimedUntil, now.Unix()).
+		Run(ctx)
+	return lease, err
+}
@@ -0,0 +1,161 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// outboxClient serves pending outbox events, failing claims for events in taken.
+type outboxClient struct {
+	dynamodbiface.DynamoDBAPI
+	pending []Item
+	taken   map[string]bool
+	txs     []*dynamodb.TransactWriteItemsInput
+	updates []*dynamodb.UpdateItemInput
+	deletes []string
+}
+
+func (c *outboxClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.txs = append(c.txs, in)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *outboxClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	status := in.KeyConditions[OutboxStatus].AttributeValueList[0].(*types.AttributeValueMemberS).Value
+	if status != OutboxPending {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	return &dynamodb.QueryOutput{Items: c.pending, Count: int32(len(c.pending))}, nil
+}
+
+func (c *outboxClient) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	id := in.Key[OutboxID].(*types.AttributeValueMemberS).Value
+	if c.taken[id] {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	c.updates = append(c.updates, in)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *outboxClient) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.deletes = append(c.deletes, in.Key[OutboxID].(*types.AttributeValueMemberS).Value)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestOutbox(t *testing.T) {
+	type event struct {
+		ID   string
+		Kind string
+	}
+	ctx := context.Background()
+	client := &outboxClient{taken: map[string]bool{"B": true}}
+	db := NewFromIface(client)
+	outbox := NewOutbox(db.Table("Outbox"), "Status-index")
+
+	tx := db.WriteTx().Put(db.Table("Orders").Put(map[string]string{"ID": "order-1"}))
+	if err := outbox.Put(tx, event{ID: "evt-1", Kind: "OrderPlaced"}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	put := client.txs[0].TransactItems[1].Put
+	var got event
+	if err := UnmarshalItem(put.Item, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "evt-1" || got.Kind != "OrderPlaced" {
+		t.Error("bad event:", got)
+	}
+	if id := put.Item[OutboxID].(*types.AttributeValueMemberS).Value; len(id) != 26 {
+		t.Error("bad outbox ID:", id)
+	}
+	if status := put.Item[OutboxStatus].(*types.AttributeValueMemberS).Value; status != OutboxPending {
+		t.Error("bad status:", status)
+	}
+	if put.ConditionExpression == nil {
+		t.Error("missing condition")
+	}
+
+	for _, id := range []string{"A", "B", "C"} {
+		client.pending = append(client.pending, Item{
+			OutboxID: &types.AttributeValueMemberS{Value: id},
+			"Kind":   &types.AttributeValueMemberS{Value: "OrderPlaced"},
+		})
+	}
+	var delivered []string
+	relayed, err := outbox.Relay(ctx, 10, func(_ context.Context, e OutboxEvent) error {
+		if e.ID == "C" {
+			return errors.New("queue unavailable")
+		}
+		delivered = append(delivered, e.ID)
+		return nil
+	})
+	if relayed != 1 || err == nil || !strings.Contains(err.Error(), "queue unavailable") {
+		t.Error("bad relay:", relayed, err)
+	}
+	if len(delivered) != 1 || delivered[0] != "A" {
+		t.Error("bad deliveries:", delivered)
+	}
+	if len(client.deletes) != 1 || client.deletes[0] != "A" {
+		t.Error("bad deletes:", client.deletes)
+	}
+	// claim A, claim C, release C
+	if len(client.updates) != 3 {
+		t.Fatal("bad updates:", len(client.updates))
+	}
+	release := client.updates[2]
+	if !strings.Contains(*release.UpdateExpression, "REMOVE") {
+		t.Error("C not released:", *release.UpdateExpression)
+	}
+
+	t.Run("reserved attributes", func(t *testing.T) {
+		tx := db.WriteTx()
+		if err := outbox.Put(tx, map[string]string{OutboxStatus: "mine"}).Run(ctx); err == nil {
+			t.Error("expected error for event with outbox attribute")
+		}
+	})
+
+	t.Run("db options", func(t *testing.T) {
+		type tenantEvent struct {
+			EventKind string
+			Tenant    tenantString
+		}
+		client := &outboxClient{}
+		db := NewFromIface(client).NameMapper(SnakeCase)
+		outbox := NewOutbox(db.Table("Outbox"), "Status-index")
+		ctx := context.WithValue(ctx, ctxKey{}, "t1")
+		if err := outbox.Put(db.WriteTx(), tenantEvent{EventKind: "Placed", Tenant: "x"}).Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		item := client.txs[0].TransactItems[0].Put.Item
+		if kind, ok := item["event_kind"].(*types.AttributeValueMemberS); !ok || kind.Value != "Placed" {
+			t.Error("name mapper not used:", item)
+		}
+		if tenant, ok := item["tenant"].(*types.AttributeValueMemberS); !ok || tenant.Value != "t1:x" {
+			t.Error("not marshaled with request context:", item["tenant"])
+		}
+		if _, ok := item[OutboxID].(*types.AttributeValueMemberS); !ok {
+			t.Error("missing outbox ID after marshaling again:", item)
+		}
+
+		client.pending = []Item{item}
+		_, err := outbox.Relay(ctx, 1, func(_ context.Context, e OutboxEvent) error {
+			var got tenantEvent
+			if err := e.Unmarshal(&got); err != nil {
+				return err
+			}
+			if got.EventKind != "Placed" {
+				t.Error("name mapper not used to unmarshal:", got)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
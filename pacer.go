@@ -0,0 +1,69 @@
+package dynamo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	smithytime "github.com/aws/smithy-go/time"
+)
+
+// pacer spaces out operations to at most a given number per second,
+// or, using delay and charge, limits the rate of units (such as capacity units) consumed by operations.
+// A nil pacer doesn't wait.
+type pacer struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func newPacer(perSecond float64) *pacer {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &pacer{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next operation is allowed.
+func (p *pacer) wait(ctx context.Context) error {
+	if p == nil {
+		return ctx.Err()
+	}
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mu.Unlock()
+	return smithytime.SleepWithContext(ctx, at.Sub(now))
+}
+
+// delay blocks until units charged so far are paid off, without reserving anything.
+func (p *pacer) delay(ctx context.Context) error {
+	if p == nil {
+		return ctx.Err()
+	}
+	p.mu.Lock()
+	wait := time.Until(p.next)
+	p.mu.Unlock()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	return smithytime.SleepWithContext(ctx, wait)
+}
+
+// charge records that units were consumed, delaying subsequent operations.
+func (p *pacer) charge(units float64) {
+	if p == nil || units <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	p.next = p.next.Add(time.Duration(units * float64(p.interval)))
+}
@@ -0,0 +1,77 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+)
+
+// Presence reports which attributes were present in an unmarshaled item,
+// distinguishing attributes that were absent from attributes that were present with a zero value.
+// Keys are attribute names, true if present and false if absent.
+// When unmarshaling into a struct, every field's attribute name is included; NULL attributes count as present.
+type Presence map[string]bool
+
+// Has returns true if the attribute with the given name was present.
+func (p Presence) Has(name string) bool {
+	return p[name]
+}
+
+// Missing returns the sorted names of the struct fields whose attributes were absent.
+func (p Presence) Missing() []string {
+	var missing []string
+	for name, present := range p {
+		if !present {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// TrackPresence wraps out, which must be a pointer, so that unmarshaling an item into it
+// also records which attributes were present in presence.
+// It can be used anywhere a single item is unmarshaled, such as [Query.One], [Iter.Next], or [UnmarshalItem].
+// This is useful for partial updates, which should only set the fields that were actually loaded:
+//
+//	var user User
+//	var presence dynamo.Presence
+//	err := table.Get("ID", id).Project("ID", "Name").One(ctx, dynamo.TrackPresence(&user, &presence))
+//	// ...
+//	if presence.Has("Name") {
+//		update.Set("Name", user.Name)
+//	}
+func TrackPresence(out interface{}, presence *Presence) ItemUnmarshaler {
+	return &presenceTracker{out: out, presence: presence}
+}
+
+type presenceTracker struct {
+	out      interface{}
+	presence *Presence
+}
+
+// UnmarshalDynamoItem implements [ItemUnmarshaler].
+func (pt *presenceTracker) UnmarshalDynamoItem(item Item) error {
+	return pt.unmarshal(context.Background(), item)
+}
+
+func (pt *presenceTracker) unmarshal(ctx context.Context, item Item) error {
+	if err := unmarshalItem(ctx, item, pt.out); err != nil {
+		return err
+	}
+	presence := make(Presence, len(item))
+	if rt := reflect.TypeOf(pt.out); rt != nil {
+		plan, err := typedefOf(rt, nameMapperFrom(ctx))
+		if err != nil {
+			return err
+		}
+		for _, field := range plan.fields {
+			presence[field.name] = false
+		}
+	}
+	for name := range item {
+		presence[name] = true
+	}
+	*pt.presence = presence
+	return nil
+}
@@ -0,0 +1,67 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+func TestTrackPresence(t *testing.T) {
+	type user struct {
+		ID    int
+		Name  string
+		Age   int
+		Email *string
+	}
+	item := Item{
+		"ID":    &types.AttributeValueMemberN{Value: "1"},
+		"Age":   &types.AttributeValueMemberN{Value: "0"},
+		"Email": &types.AttributeValueMemberNULL{Value: true},
+		"Extra": &types.AttributeValueMemberS{Value: "x"},
+	}
+
+	var got user
+	var presence Presence
+	if err := UnmarshalItem(item, TrackPresence(&got, &presence)); err != nil {
+		t.Fatal(err)
+	}
+	if want := (user{ID: 1}); !reflect.DeepEqual(got, want) {
+		t.Error("bad unmarshal. want:", want, "got:", got)
+	}
+	want := Presence{"ID": true, "Name": false, "Age": true, "Email": true, "Extra": true}
+	if !reflect.DeepEqual(presence, want) {
+		t.Error("bad presence. want:", want, "got:", presence)
+	}
+	if !presence.Has("Age") || presence.Has("Name") || presence.Has("Nope") {
+		t.Error("bad Has:", presence)
+	}
+	if missing := presence.Missing(); !reflect.DeepEqual(missing, []string{"Name"}) {
+		t.Error("bad Missing:", missing)
+	}
+
+	t.Run("query", func(t *testing.T) {
+		table := NewFromIface(echoGetter{item: item}).Table("Users")
+		var got user
+		var presence Presence
+		if err := table.Get("ID", 1).One(context.Background(), TrackPresence(&got, &presence)); err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != 1 || !reflect.DeepEqual(presence, want) {
+			t.Error("bad result:", got, presence)
+		}
+	})
+}
+
+type echoGetter struct {
+	dynamodbiface.DynamoDBAPI
+	item Item
+}
+
+func (c echoGetter) GetItem(_ context.Context, _ *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
@@ -0,0 +1,96 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RequireProjected makes this query check, before it runs, that the index it queries projects
+// every attribute of the struct that results are unmarshaled into.
+// Indexes with a KEYS_ONLY or INCLUDE projection only return some attributes,
+// and fields for the others would silently be left as zero values.
+// The table's description is used to find the index's projection; it is cached after the first DescribeTable call.
+//
+// If attributes are missing, the query fails with a [*ProjectionError].
+// If onMissing is not nil, it is called with the error instead, and the query continues.
+// Queries of the table itself, or of indexes that project all attributes, always pass the check,
+// as do queries unmarshaling into something other than a struct, such as an [Item].
+// Local secondary indexes fetch attributes they don't project from the table,
+// so queries of them are only checked when they use a projection (see [Query.Project]).
+func (q *Query) RequireProjected(onMissing func(ctx context.Context, err *ProjectionError)) *Query {
+	q.projected = true
+	q.onMissing = onMissing
+	return q
+}
+
+// ProjectionError is returned by queries using [Query.RequireProjected]
+// when the queried index doesn't project attributes expected by the destination struct.
+type ProjectionError struct {
+	// Table is the name of the queried table.
+	Table string
+	// Index is the name of the queried index.
+	Index string
+	// Projection is the index's projection type.
+	Projection IndexProjection
+	// Type is the struct type results are unmarshaled into.
+	Type reflect.Type
+	// Missing are the attribute names of the fields that the index doesn't project.
+	Missing []string
+}
+
+func (e *ProjectionError) Error() string {
+	return fmt.Sprintf("dynamo: index %s of table %s (projection %s) is missing attributes of %v: %s",
+		e.Index, e.Table, e.Projection, e.Type, strings.Join(e.Missing, ", "))
+}
+
+// checkProjected implements RequireProjected, checking the projection of the queried index against out's type.
+func (q *Query) checkProjected(ctx context.Context, out any) error {
+	if !q.projected || q.index == "" {
+		return nil
+	}
+	rt := structTypeOf(out)
+	if rt == nil {
+		return nil
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return err
+	}
+	idx, ok := desc.index(q.index)
+	if !ok {
+		return fmt.Errorf("dynamo: RequireProjected: index %s not found in table %s", q.index, desc.Name)
+	}
+	if idx.Local && q.projection == "" {
+		return nil
+	}
+	attrs := desc.projected(idx)
+	if attrs == nil {
+		return nil
+	}
+	var missing []string
+	visitTypeFields(rt, q.table.db.nameMapper(), nil, nil, func(name string, _ []int, _ encodeFlags, _ reflect.Type) error {
+		if _, ok := attrs[name]; !ok {
+			missing = append(missing, name)
+		}
+		return nil
+	})
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	perr := &ProjectionError{
+		Table:      desc.Name,
+		Index:      idx.Name,
+		Projection: idx.ProjectionType,
+		Type:       rt,
+		Missing:    missing,
+	}
+	if q.onMissing != nil {
+		q.onMissing(ctx, perr)
+		return nil
+	}
+	return perr
+}
@@ -0,0 +1,116 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// projClient queries the Name-index of driftClient, which projects Nickname.
+type projClient struct {
+	*driftClient
+	queries int
+}
+
+func (c *projClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries++
+	return &dynamodb.QueryOutput{Items: []Item{{
+		"ID":       &types.AttributeValueMemberS{Value: "1"},
+		"Name":     &types.AttributeValueMemberS{Value: "Alice"},
+		"Nickname": &types.AttributeValueMemberS{Value: "Al"},
+	}}}, nil
+}
+
+func TestRequireProjected(t *testing.T) {
+	type projected struct {
+		ID       string
+		Name     string
+		Nickname string
+	}
+	type full struct {
+		projected
+		Email string
+		Age   int `dynamo:"age"`
+		Skip  int `dynamo:"-"`
+	}
+
+	ctx := context.Background()
+	client := &projClient{driftClient: new(driftClient)}
+	table := NewFromIface(client).Table("Users")
+
+	t.Run("ok", func(t *testing.T) {
+		var got []projected
+		if err := table.Get("Name", "Alice").Index("Name-index").RequireProjected(nil).All(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Nickname != "Al" {
+			t.Error("bad results:", got)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		queries := client.queries
+		var got full
+		err := table.Get("Name", "Alice").Index("Name-index").RequireProjected(nil).One(ctx, &got)
+		var perr *ProjectionError
+		if !errors.As(err, &perr) {
+			t.Fatal("expected ProjectionError, got:", err)
+		}
+		if want := []string{"Email", "age"}; !reflect.DeepEqual(perr.Missing, want) {
+			t.Error("bad missing attributes. want:", want, "got:", perr.Missing)
+		}
+		if perr.Index != "Name-index" || perr.Projection != IncludeProjection || perr.Type != reflect.TypeOf(got) {
+			t.Errorf("bad error: %#v", perr)
+		}
+		if client.queries != queries {
+			t.Error("query was sent")
+		}
+
+		var items []full
+		err = table.Get("Name", "Alice").Index("Name-index").RequireProjected(nil).All(ctx, &items)
+		if !errors.As(err, &perr) {
+			t.Error("expected ProjectionError, got:", err)
+		}
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var warned *ProjectionError
+		var got []full
+		err := table.Get("Name", "Alice").Index("Name-index").RequireProjected(func(_ context.Context, err *ProjectionError) {
+			warned = err
+		}).All(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if warned == nil {
+			t.Error("onMissing not called")
+		}
+		if len(got) != 1 || got[0].Nickname != "Al" {
+			t.Error("bad results:", got)
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		// local indexes fetch the rest from the table
+		var got []full
+		if err := table.Get("ID", "1").Index("Age-index").RequireProjected(nil).All(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		err := table.Get("ID", "1").Index("Age-index").Project("ID", "Email").RequireProjected(nil).All(ctx, &got)
+		var perr *ProjectionError
+		if !errors.As(err, &perr) {
+			t.Error("expected ProjectionError, got:", err)
+		}
+	})
+
+	t.Run("items", func(t *testing.T) {
+		var got []Item
+		if err := table.Get("Name", "Alice").Index("Name-index").RequireProjected(nil).All(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package dynamo
 
 import (
 	"context"
+	"maps"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -16,8 +17,17 @@ type Put struct {
 	onCondFail types.ReturnValuesOnConditionCheckFailure
 
 	item Item
+	// value is the item before marshaling, if it has values implementing MarshalerCtx.
+	// Such items are marshaled again with the request's context (see marshal).
+	value interface{}
+	// extra attributes are added to value each time it is marshaled, such as those of outbox events.
+	extra    Item
+	hashAttr string
+	validate bool
 	subber
 	condition string
+	modify    func(*dynamodb.PutItemInput)
+	optFns    []func(*dynamodb.Options)
 
 	err error
 	cc  *ConsumedCapacity
@@ -25,12 +35,23 @@ type Put struct {
 
 // Put creates a new request to create or replace an item.
 func (table Table) Put(item interface{}) *Put {
-	encoded, err := marshalItem(item)
-	return &Put{
-		table: table,
-		item:  encoded,
-		err:   err,
+	encoded, deferred, err := marshalItemDeferred(table.db.codecContext(context.Background()), item)
+	if err == nil {
+		err = fillAuto(item, encoded, table.db.nameMapper())
 	}
+	if err == nil {
+		err = table.interceptWrite(encoded)
+	}
+	p := &Put{
+		table:  table,
+		subber: subber{db: table.db},
+		item:   encoded,
+		err:    err,
+	}
+	if deferred {
+		p.value = item
+	}
+	return p
 }
 
 // If specifies a conditional expression for this put to succeed.
@@ -48,6 +69,44 @@ func (p *Put) If(expr string, args ...interface{}) *Put {
 	return p
 }
 
+// ContentHash stores the content hash of the item (see [HashItem]) in the attribute name,
+// replacing any existing value. The attribute itself is excluded from the hash.
+// Call it after any other changes to the item.
+func (p *Put) ContentHash(name string) *Put {
+	if p.item != nil {
+		p.item[name] = &types.AttributeValueMemberS{Value: HashItem(p.item, name)}
+	}
+	p.hashAttr = name
+	return p
+}
+
+// Validate checks the item to put against DynamoDB's limits before making a request.
+// If the item is invalid, executing this put will return an *ItemValidationError.
+// See: [ValidateItem].
+func (p *Put) Validate() *Put {
+	if p.err == nil {
+		p.setError(ValidateItem(p.item))
+	}
+	p.validate = true
+	return p
+}
+
+// ModifyInput sets a function that is called with the input of the PutItem request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+// It is not called when this put is part of a transaction.
+func (p *Put) ModifyInput(fn func(*dynamodb.PutItemInput)) *Put {
+	p.modify = fn
+	return p
+}
+
+// RequestOptions sets options that are applied to each request made by this put, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// It has no effect when this put is part of a transaction.
+func (p *Put) RequestOptions(optFns ...func(*dynamodb.Options)) *Put {
+	p.optFns = optFns
+	return p
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (p *Put) ConsumedCapacity(cc *ConsumedCapacity) *Put {
 	p.cc = cc
@@ -72,7 +131,7 @@ func (p *Put) OldValue(ctx context.Context, out interface{}) error {
 	case output.Attributes == nil:
 		return ErrNotFound
 	}
-	return unmarshalItem(output.Attributes, out)
+	return p.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // CurrentValue executes this put.
@@ -89,10 +148,10 @@ func (p *Put) CurrentValue(ctx context.Context, out interface{}) (wrote bool, er
 	item, _, err := p.run(ctx)
 	wrote = err == nil
 	if err != nil {
-		_, err = UnmarshalItemFromCondCheckFailed(err, out)
+		_, err = unmarshalItemFromCondCheckFailed(ctx, err, out, p.table.unmarshalItem)
 		return
 	}
-	err = unmarshalItem(item, out)
+	err = p.table.unmarshalItem(ctx, item, out)
 	return
 }
 
@@ -113,9 +172,21 @@ func (p *Put) run(ctx context.Context) (item Item, output *dynamodb.PutItemOutpu
 	}
 
 	req := p.input()
+	if req.Item, err = p.marshal(ctx); err != nil {
+		return
+	}
+	if req.ExpressionAttributeValues, err = p.values(ctx); err != nil {
+		return
+	}
 	item = req.Item
+	if p.modify != nil {
+		p.modify(req)
+	}
+	if err = p.table.db.resolveName(ctx, &req.TableName); err != nil {
+		return
+	}
 	p.table.db.retry(ctx, func() error {
-		output, err = p.table.db.client.PutItem(ctx, req)
+		output, err = p.table.db.client.PutItem(ctx, req, p.table.db.requestOptions(p.optFns)...)
 		p.cc.incRequests()
 		return err
 	})
@@ -143,11 +214,43 @@ func (p *Put) input() *dynamodb.PutItemInput {
 	return input
 }
 
-func (p *Put) writeTxItem() (*types.TransactWriteItem, error) {
+// marshal returns the item to put, marshaling it again with ctx if it has values implementing MarshalerCtx.
+func (p *Put) marshal(ctx context.Context) (Item, error) {
+	if p.value == nil {
+		return p.item, nil
+	}
+	item, err := marshalItemContext(p.table.db.codecContext(ctx), p.value)
+	if err == nil {
+		keepAuto(p.value, p.item, item, p.table.db.nameMapper())
+		maps.Copy(item, p.extra)
+		err = p.table.interceptWrite(item)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.hashAttr != "" {
+		item[p.hashAttr] = &types.AttributeValueMemberS{Value: HashItem(item, p.hashAttr)}
+	}
+	if p.validate {
+		if err := ValidateItem(item); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+func (p *Put) writeTxItem(ctx context.Context) (*types.TransactWriteItem, error) {
 	if p.err != nil {
 		return nil, p.err
 	}
 	input := p.input()
+	var err error
+	if input.Item, err = p.marshal(ctx); err != nil {
+		return nil, err
+	}
+	if input.ExpressionAttributeValues, err = p.values(ctx); err != nil {
+		return nil, err
+	}
 	item := &types.TransactWriteItem{
 		Put: &types.Put{
 			TableName:                           input.TableName,
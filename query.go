@@ -5,10 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	smithytime "github.com/aws/smithy-go/time"
+	"github.com/cenkalti/backoff/v4"
 )
 
 // Query is a request to get one or more items in a table.
@@ -26,14 +30,31 @@ type Query struct {
 	rangeKey    string
 	rangeValues []types.AttributeValue
 	rangeOp     Operator
+	rangeTimes  []time.Time
 
 	projection  string
 	filters     []string
 	consistent  bool
+	fallback    bool
+	fallbackN   int
+	served      *bool
 	limit       int
 	searchLimit int32
 	reqLimit    int
+	byteLimit   int
 	order       *Order
+	exactly     bool
+	restart     bool
+	modify      func(*dynamodb.QueryInput)
+	modifyGet   func(*dynamodb.GetItemInput)
+	optFns      []func(*dynamodb.Options)
+	distinct    string
+	throttle    *pacer
+	hedger      *Hedger
+	forceQuery  bool
+	api         *string
+	projected   bool
+	onMissing   func(context.Context, *ProjectionError)
 
 	subber
 
@@ -76,16 +97,21 @@ var selectCount types.Select = "COUNT"
 
 // Get creates a new request to get an item.
 // Name is the name of the hash key (a.k.a. partition key).
-// Value is the value of the hash key.
+// Value is the value of the hash key, encoded the same way as a struct field of its type
+// (for example, types implementing encoding.TextMarshaler are encoded as strings).
+// Values implementing fmt.Stringer that would otherwise not be encoded as a scalar are encoded as their String.
 func (table Table) Get(name string, value interface{}) *Query {
+	return table.get(name, value, flagNone)
+}
+
+func (table Table) get(name string, value interface{}, flags encodeFlags) *Query {
 	q := &Query{
 		table:   table,
 		hashKey: name,
+		subber:  subber{db: table.db},
 	}
-	q.hashValue, q.err = marshal(value, flagNone)
-	if q.hashValue == nil {
-		q.setError(fmt.Errorf("dynamo: query hash key value is nil or omitted for attribute %q", q.hashKey))
-	}
+	q.hashValue, q.err = table.marshalKeyFlags("query hash", name, value, flags)
+	table.interceptQuery(q)
 	return q
 }
 
@@ -93,24 +119,142 @@ func (table Table) Get(name string, value interface{}) *Query {
 // For single item requests using One, op must be Equal.
 // Name is the name of the range key.
 // Op specifies the operator to use when comparing values.
+// Values are encoded the same way as Get's hash key value.
 func (q *Query) Range(name string, op Operator, values ...interface{}) *Query {
-	var err error
+	return q.rangeFlags(name, op, flagNone, values...)
+}
+
+func (q *Query) rangeFlags(name string, op Operator, flags encodeFlags, values ...interface{}) *Query {
 	q.rangeKey = name
 	q.rangeOp = op
-	q.rangeValues, err = marshalSliceNoOmit(values)
-	q.setError(err)
-	for i, v := range q.rangeValues {
-		if v == nil {
-			q.setError(fmt.Errorf("dynamo: query range key value is nil or omitted for attribute %q (range key #%d of %d)", q.rangeKey, i+1, len(q.rangeValues)))
+	q.rangeTimes = nil
+	q.rangeValues = make([]types.AttributeValue, 0, len(values))
+	for i, v := range values {
+		av, err := q.table.marshalKeyFlags("query range", name, v, flags)
+		if err != nil {
+			q.setError(fmt.Errorf("%w (range key #%d of %d)", err, i+1, len(values)))
 			break
 		}
+		q.rangeValues = append(q.rangeValues, av)
 	}
 	if len(q.rangeValues) == 0 {
 		q.setError(fmt.Errorf("dynamo: query range key values are missing for attribute %q", q.rangeKey))
 	}
+	switch {
+	case op == NotEqual:
+		q.setError(fmt.Errorf("dynamo: operator %s cannot be used in key conditions (range key %q); use Filter instead", op, q.rangeKey))
+	case op == Between && len(q.rangeValues) != 2:
+		q.setError(fmt.Errorf("dynamo: operator %s requires 2 values for range key %q, got %d", op, q.rangeKey, len(q.rangeValues)))
+	case op != Between && len(q.rangeValues) > 1:
+		q.setError(fmt.Errorf("dynamo: operator %s requires 1 value for range key %q, got %d", op, q.rangeKey, len(q.rangeValues)))
+	}
 	return q
 }
 
+// RangeTime specifies a range key (a.k.a. sort key) condition for times between from and to, inclusive.
+// If from is zero, results are limited to times up to and including to, and if to is zero, results begin at from.
+// Name is the name of the range key.
+// The times are encoded the same way as the range key's field of the struct that results are unmarshaled to,
+// so that they match the format used when the items were written.
+// For example, times are encoded as Unix timestamps if the field has the unixtime option,
+// and as integers if the field is an integer type.
+// Otherwise, such as for Count, times are encoded as RFC 3339 strings.
+func (q *Query) RangeTime(name string, from, to time.Time) *Query {
+	q.rangeKey = name
+	switch {
+	case from.IsZero() && to.IsZero():
+		q.setError(fmt.Errorf("dynamo: query range times are missing for attribute %q", name))
+	case from.IsZero():
+		q.rangeOp = LessOrEqual
+		q.rangeTimes = []time.Time{to}
+	case to.IsZero():
+		q.rangeOp = GreaterOrEqual
+		q.rangeTimes = []time.Time{from}
+	default:
+		q.rangeOp = Between
+		q.rangeTimes = []time.Time{from, to}
+	}
+	q.rangeValues = nil
+	q.setError(q.encodeRangeTimes(context.Background(), nil))
+	return q
+}
+
+// encodeRangeTimes encodes the times specified by RangeTime according to
+// the range key field of out's type, if it is a struct (or a slice of structs).
+// Otherwise, the previous encoding is kept, defaulting to the standard time encoding.
+func (q *Query) encodeRangeTimes(ctx context.Context, out any) error {
+	if len(q.rangeTimes) == 0 {
+		return nil
+	}
+
+	convert := func(t time.Time) (any, encodeFlags) { return t, flagNone }
+	found := false
+	if rt := structTypeOf(out); rt != nil {
+		visitTypeFields(rt, q.table.db.nameMapper(), nil, nil, func(name string, _ []int, flags encodeFlags, ft reflect.Type) error {
+			if name != q.rangeKey || found {
+				return nil
+			}
+			found = true
+			base := ft
+			for base.Kind() == reflect.Pointer {
+				base = base.Elem()
+			}
+			switch {
+			case base.Kind() >= reflect.Int && base.Kind() <= reflect.Uint64:
+				convert = func(t time.Time) (any, encodeFlags) { return t.Unix(), flagNone }
+			case base != rtypeTime && rtypeTime.ConvertibleTo(base):
+				// custom time types, which might implement Marshaler
+				convert = func(t time.Time) (any, encodeFlags) {
+					return reflect.ValueOf(t).Convert(base).Interface(), flags
+				}
+			default:
+				convert = func(t time.Time) (any, encodeFlags) { return t, flags }
+			}
+			return nil
+		})
+	}
+	if !found && q.rangeValues != nil {
+		return nil
+	}
+
+	values := make([]types.AttributeValue, 0, len(q.rangeTimes))
+	for _, t := range q.rangeTimes {
+		v, flags := convert(t)
+		av, err := marshalContext(q.table.db.codecContext(ctx), v, flags)
+		if err != nil {
+			return err
+		}
+		values = append(values, av)
+	}
+	q.rangeValues = values
+	return nil
+}
+
+// structTypeOf returns the struct type of out, such as a pointer to a struct or a pointer to a slice of structs.
+// It returns nil if out is not struct-like.
+func structTypeOf(out any) reflect.Type {
+	if pt, ok := out.(*presenceTracker); ok {
+		out = pt.out
+	}
+	rt := reflect.TypeOf(out)
+	if rt == nil {
+		return nil
+	}
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
+		rt = rt.Elem()
+	}
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	return rt
+}
+
 // StartFrom makes this query continue from a previous one.
 // Use Query.Iter's LastEvaluatedKey.
 func (q *Query) StartFrom(key PagingKey) *Query {
@@ -162,6 +306,33 @@ func (q *Query) Filter(expr string, args ...interface{}) *Query {
 	return q
 }
 
+// OrFilter combines all filters added so far with the given filter expression using OR.
+// Filters added afterwards are combined with the result using AND, so that filters read from left to right:
+//
+//	// (A AND B) OR C
+//	q.Filter(A).Filter(B).OrFilter(C)
+//	// (A OR B) AND C
+//	q.Filter(A).OrFilter(B).Filter(C)
+//
+// If there are no other filters, OrFilter is the same as [Query.Filter].
+// The expression is specified as in [Query.Filter].
+func (q *Query) OrFilter(expr string, args ...interface{}) *Query {
+	expr, err := q.subExprN(expr, args...)
+	q.setError(err)
+	q.filters = orFilters(q.filters, wrapExpr(expr))
+	return q
+}
+
+// NotFilter takes a filter expression that items must not match.
+// It is combined with other filters using AND.
+// The expression is specified as in [Query.Filter].
+func (q *Query) NotFilter(expr string, args ...interface{}) *Query {
+	expr, err := q.subExprN(expr, args...)
+	q.setError(err)
+	q.filters = append(q.filters, notExpr(expr))
+	return q
+}
+
 // Consistent will, if on is true, make this query a strongly consistent read.
 // Queries are eventually consistent by default.
 // Strongly consistent reads are more resource-heavy than eventually consistent reads.
@@ -170,16 +341,57 @@ func (q *Query) Consistent(on bool) *Query {
 	return q
 }
 
+// ConsistentFallback makes this query a strongly consistent read that
+// automatically retries as an eventually consistent read when throttled.
+// This is useful for read paths that prefer fresh data but must stay available.
+// If served is not nil, it will be set to true if all data was served by strongly consistent reads,
+// or false if the query fell back to eventually consistent reads.
+func (q *Query) ConsistentFallback(served *bool) *Query {
+	q.consistent = true
+	q.fallback = true
+	q.served = served
+	return q
+}
+
+// FallbackAfter sets the number of throttled strongly consistent attempts to make before falling back
+// to an eventually consistent read (see [Query.ConsistentFallback] and [Query.OneOrOlder]).
+// Throttled attempts are retried with exponential backoff. The default is 1, falling back after the first throttle.
+// Note that each attempt may include the retries made by the AWS SDK's retryer.
+func (q *Query) FallbackAfter(attempts int) *Query {
+	q.fallbackN = attempts
+	return q
+}
+
+// RestartOnBadKey makes this query start over from the beginning if DynamoDB rejects the paging key
+// given to [Query.StartFrom], instead of returning [ErrBadPagingKey].
+// This is useful for long-lived resumable jobs whose saved keys can go stale
+// when the table or index's key schema changes.
+func (q *Query) RestartOnBadKey(on bool) *Query {
+	q.restart = on
+	return q
+}
+
 // Limit specifies the maximum amount of results to return.
+// Results are counted across pages, so exactly limit results are returned
+// unless fewer match or another limit (such as [Query.RequestLimit]) is reached first.
 func (q *Query) Limit(limit int) *Query {
 	q.limit = limit
 	return q
 }
 
+// Exactly, when on, keeps issuing requests until [Query.Limit] results have been collected
+// or every result has been examined, even if [Query.SearchLimit] is set.
+// SearchLimit then determines the size of each page instead of implying RequestLimit(1).
+// Use [Query.RequestLimit] to bound the number of requests made.
+func (q *Query) Exactly(on bool) *Query {
+	q.exactly = on
+	return q
+}
+
 // SearchLimit specifies the maximum amount of results to examine.
 // If a filter is not specified, the number of results will be limited.
 // If a filter is specified, the number of results to consider for filtering will be limited.
-// SearchLimit > 0 implies RequestLimit(1).
+// SearchLimit > 0 implies RequestLimit(1), unless [Query.Exactly] is on.
 // Note: limit will be capped to MaxInt32 as that is the maximum number the DynamoDB API will accept.
 func (q *Query) SearchLimit(limit int) *Query {
 	q.searchLimit = int32(min(limit, math.MaxInt32))
@@ -193,6 +405,16 @@ func (q *Query) RequestLimit(limit int) *Query {
 	return q
 }
 
+// ByteLimit stops iteration after approximately limit bytes worth of items have been returned,
+// as calculated by [ItemSize]. Iteration stops once the total reaches or exceeds the limit,
+// so the last item returned may put the total over it.
+// Combine with [Query.AllWithLastEvaluatedKey] or [PagingIter.LastEvaluatedKey] to paginate by payload size.
+// A limit of zero or less means no limit.
+func (q *Query) ByteLimit(limit int) *Query {
+	q.byteLimit = limit
+	return q
+}
+
 // Order specifies the desired result order.
 // Requires a range key (a.k.a. partition key) to be specified.
 func (q *Query) Order(order Order) *Query {
@@ -200,48 +422,122 @@ func (q *Query) Order(order Order) *Query {
 	return q
 }
 
+// ModifyInput sets a function that is called with the input of each Query request just before it is first sent,
+// allowing for API parameters that this library does not support yet.
+// Changes that conflict with this query's other settings may lead to unexpected results.
+// Requests that use GetItem instead (see [Query.One]) are modified by [Query.ModifyGetItemInput].
+func (q *Query) ModifyInput(fn func(*dynamodb.QueryInput)) *Query {
+	q.modify = fn
+	return q
+}
+
+// ModifyGetItemInput sets a function that is called with the input of each GetItem request just before it is sent.
+// See [Query.ModifyInput].
+func (q *Query) ModifyGetItemInput(fn func(*dynamodb.GetItemInput)) *Query {
+	q.modifyGet = fn
+	return q
+}
+
+// RequestOptions sets options that are applied to each request made by this query, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// It applies to both Query and GetItem requests (see [Query.One]).
+func (q *Query) RequestOptions(optFns ...func(*dynamodb.Options)) *Query {
+	q.optFns = optFns
+	return q
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (q *Query) ConsumedCapacity(cc *ConsumedCapacity) *Query {
 	q.cc = cc
 	return q
 }
 
+// ForceQuery makes [Query.One] always use the DynamoDB Query API, instead of GetItem when possible.
+// This is useful when the consumed capacity or behavior of Query is expected, such as in tests.
+func (q *Query) ForceQuery() *Query {
+	q.forceQuery = true
+	return q
+}
+
+// ServedBy makes [Query.One] set api to the name of the DynamoDB API used to serve it, either "GetItem" or "Query".
+// This is useful for diagnostics, such as logging why a request consumed more capacity than expected.
+// The name is also available to tracers as [Operation.Name].
+func (q *Query) ServedBy(api *string) *Query {
+	q.api = api
+	return q
+}
+
 // One executes this query and retrieves a single result,
 // unmarshaling the result to out.
-// This uses the DynamoDB GetItem API when possible, otherwise Query.
+// This uses the DynamoDB GetItem API when possible, otherwise Query (see [Query.ForceQuery]).
 // If the query returns more than one result, [ErrTooMany] may be returned. This is intended as a diagnostic for query mistakes.
 // To avoid [ErrTooMany], set the [Query.Limit] to 1.
 func (q *Query) One(ctx context.Context, out interface{}) error {
 	if q.err != nil {
 		return q.err
 	}
+	if err := q.encodeRangeTimes(ctx, out); err != nil {
+		return err
+	}
+	if err := q.checkProjected(ctx, out); err != nil {
+		return err
+	}
+	if err := q.checkCachedKeys(); err != nil {
+		return err
+	}
+
+	q.resetServed()
 
 	// Can we use the GetItem API?
-	if q.canGetItem() {
+	if !q.forceQuery && q.canGetItem() {
+		q.servedBy("GetItem")
 		req := q.getItemInput()
+		if q.modifyGet != nil {
+			q.modifyGet(req)
+		}
+		if err := q.table.db.resolveName(ctx, &req.TableName); err != nil {
+			return err
+		}
 
-		var res *dynamodb.GetItemOutput
-		err := q.table.db.retry(ctx, func() error {
-			var err error
-			res, err = q.table.db.client.GetItem(ctx, req)
-			q.cc.incRequests()
-			if err != nil {
+		res, err := q.table.coalesce.do(ctx, req, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+			var res *dynamodb.GetItemOutput
+			send := func() error {
+				var err error
+				res, err = hedge(ctx, q.hedger, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+					return q.table.db.client.GetItem(ctx, req, q.table.db.requestOptions(q.optFns)...)
+				}, q.cc.incRequests)
+				q.cc.incRequests()
 				return err
 			}
-			if res.Item == nil {
-				return ErrNotFound
+			err := q.table.db.retry(ctx, func() error {
+				err := q.retryConsistent(ctx, req.ConsistentRead, send)
+				if q.shouldFallback(req.ConsistentRead, err) {
+					req.ConsistentRead = nil
+					err = send()
+				}
+				if err != nil {
+					return err
+				}
+				if res.Item == nil {
+					return ErrNotFound
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
 			}
-			return nil
+			q.cc.add(res.ConsumedCapacity)
+			return res, nil
 		})
 		if err != nil {
 			return err
 		}
-		q.cc.add(res.ConsumedCapacity)
 
-		return unmarshalItem(res.Item, out)
+		return q.table.unmarshalItem(ctx, res.Item, out)
 	}
 
 	// If not, try a Query.
+	q.servedBy("Query")
 	iter := q.newIter(unmarshalItem)
 	var item Item
 	ok := iter.Next(ctx, &item)
@@ -255,27 +551,95 @@ func (q *Query) One(ctx context.Context, out interface{}) error {
 	if iter.hasMore() {
 		return ErrTooMany
 	}
-	return unmarshalItem(item, out)
+	return unmarshalItem(q.table.db.codecContext(ctx), item, out)
+}
+
+// OneOrOlder is like [Query.One], but prefers a strongly consistent read and
+// falls back to an eventually consistent read if strongly consistent reads are throttled,
+// trading freshness for availability. Use [Query.FallbackAfter] to set how many throttled attempts to make first.
+// If the result was served by an eventually consistent read, stale will be true,
+// meaning that it might not reflect the most recent writes.
+func (q *Query) OneOrOlder(ctx context.Context, out interface{}) (stale bool, err error) {
+	// don't clobber q's own consistency settings
+	fresh := *q
+	var served bool
+	fresh.ConsistentFallback(&served)
+	err = fresh.One(ctx, out)
+	return !served, err
 }
 
 // Count executes this request, returning the number of results.
 func (q *Query) Count(ctx context.Context) (int, error) {
+	count, _, err := q.count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountWithLastEvaluatedKey is like [Query.Count], but also returns a PagingKey you can use with [Query.StartFrom]
+// to resume counting where it left off, or nil if the end of the results was reached.
+// Combined with [Query.SearchLimit] or [Query.RequestLimit], this allows for very large counts to be split up
+// and checkpointed across multiple invocations.
+// If counting is interrupted by an error, such as ctx's deadline passing,
+// the count so far is returned along with the key to resume from and the error.
+// When a limit is combined with a filter, the count is capped at the limit
+// but the returned key may be past the last item counted.
+func (q *Query) CountWithLastEvaluatedKey(ctx context.Context) (int, PagingKey, error) {
+	return q.count(ctx)
+}
+
+func (q *Query) count(ctx context.Context) (int, PagingKey, error) {
 	if q.err != nil {
-		return 0, q.err
+		return 0, q.startKey, q.err
 	}
+	if err := q.checkCachedKeys(); err != nil {
+		return 0, q.startKey, err
+	}
+
+	q.resetServed()
 
 	var count int
 	var scanned int32
 	var reqs int
 	var res *dynamodb.QueryOutput
+	var eventual bool
 	for {
 		input := q.queryInput()
 		input.Select = selectCount
+		q.limitRemaining(input, count)
+		var err error
+		if input.ExpressionAttributeValues, err = q.values(ctx); err != nil {
+			return count, PagingKey(input.ExclusiveStartKey), err
+		}
+		if q.modify != nil {
+			q.modify(input)
+		}
+		if eventual {
+			input.ConsistentRead = nil
+		}
+		if err := q.table.db.resolveName(ctx, &input.TableName); err != nil {
+			return count, PagingKey(input.ExclusiveStartKey), err
+		}
+		if err := q.throttle.delay(ctx); err != nil {
+			return count, PagingKey(input.ExclusiveStartKey), err
+		}
 
-		err := q.table.db.retry(ctx, func() error {
+		send := func() error {
 			var err error
-			res, err = q.table.db.client.Query(ctx, input)
+			res, err = hedge(ctx, q.hedger, func(ctx context.Context) (*dynamodb.QueryOutput, error) {
+				return q.table.db.client.Query(ctx, input, q.table.db.requestOptions(q.optFns)...)
+			}, q.cc.incRequests)
 			q.cc.incRequests()
+			return err
+		}
+		err = q.table.db.retry(ctx, func() error {
+			err := q.retryConsistent(ctx, input.ConsistentRead, send)
+			if q.shouldFallback(input.ConsistentRead, err) {
+				input.ConsistentRead = nil
+				eventual = true
+				err = send()
+			}
 			if err != nil {
 				return err
 			}
@@ -286,28 +650,39 @@ func (q *Query) Count(ctx context.Context) (int, error) {
 
 			return nil
 		})
+		if shouldRestart(q.restart, reqs, input.ExclusiveStartKey, err) {
+			// start over from the beginning
+			q.startKey = nil
+			continue
+		}
 		if err != nil {
-			return 0, err
+			return count, PagingKey(input.ExclusiveStartKey), pagingKeyErr(err, input.ExclusiveStartKey)
 		}
 		q.cc.add(res.ConsumedCapacity)
+		q.throttle.charge(readUnits(res.ConsumedCapacity, nil, input.ConsistentRead))
 
 		q.startKey = res.LastEvaluatedKey
 		if res.LastEvaluatedKey == nil ||
 			(q.limit > 0 && count >= q.limit) ||
-			(q.searchLimit > 0 && scanned >= q.searchLimit) ||
+			(q.searchLimit > 0 && scanned >= q.searchLimit && !q.exactly) ||
 			(q.reqLimit > 0 && reqs >= q.reqLimit) {
 			break
 		}
 	}
 
-	return count, nil
+	if q.limit > 0 && count > q.limit {
+		count = q.limit
+	}
+	return count, res.LastEvaluatedKey, nil
 }
 
 func (q *Query) newIter(unmarshal unmarshalFunc) *queryIter {
+	q.resetServed()
 	return &queryIter{
 		query:     q,
-		unmarshal: unmarshal,
+		unmarshal: q.table.unmarshaler(unmarshal),
 		err:       q.err,
+		seen:      newDistinctSet(q.distinct),
 	}
 }
 
@@ -320,6 +695,7 @@ type queryIter struct {
 	idx    int
 	n      int
 	reqs   int
+	bytes  int
 
 	// last item evaluated
 	last Item
@@ -329,6 +705,12 @@ type queryIter struct {
 	exLEK  Item
 	exESK  Item
 	keyErr error
+	// see AllUntil
+	deadline pageDeadline
+	// see AllWithMetadata
+	meta *QueryMetadata
+	// see DistinctOn
+	seen *distinctSet
 
 	unmarshal unmarshalFunc
 }
@@ -345,7 +727,7 @@ func (itr *queryIter) Next(ctx context.Context, out interface{}) bool {
 	}
 
 	// stop if exceed limit
-	if itr.query.limit > 0 && itr.n == itr.query.limit {
+	if itr.limitReached() {
 		// proactively grab the keys for LEK inferral, but don't count it as a real error yet to keep backwards compat
 		itr.keys, itr.keyErr = itr.query.table.primaryKeys(ctx, itr.exLEK, itr.exESK, itr.query.index)
 		return false
@@ -355,49 +737,108 @@ func (itr *queryIter) Next(ctx context.Context, out interface{}) bool {
 	if itr.output != nil && itr.idx < len(itr.output.Items) {
 		item := itr.output.Items[itr.idx]
 		itr.last = item
-		itr.err = itr.unmarshal(item, out)
+		itr.err = itr.unmarshal(ctx, item, out)
 		itr.idx++
 		itr.n++
+		itr.countBytes(item)
 		return itr.err == nil
 	}
 
 	// new query
 	if itr.input == nil {
+		if itr.err = itr.query.encodeRangeTimes(ctx, out); itr.err != nil {
+			return false
+		}
+		if itr.err = itr.query.checkProjected(ctx, out); itr.err != nil {
+			return false
+		}
+		if itr.err = itr.query.checkCachedKeys(); itr.err != nil {
+			return false
+		}
 		itr.input = itr.query.queryInput()
+		if itr.input.ExpressionAttributeValues, itr.err = itr.query.values(ctx); itr.err != nil {
+			return false
+		}
+		itr.meta.requestCapacity(itr.input)
+		if itr.query.modify != nil {
+			itr.query.modify(itr.input)
+		}
+		if itr.err = itr.query.table.db.resolveName(ctx, &itr.input.TableName); itr.err != nil {
+			return false
+		}
 	}
 	if len(itr.input.ExclusiveStartKey) > len(itr.exESK) {
 		itr.exESK = itr.input.ExclusiveStartKey
 	}
 	if itr.output != nil && itr.idx >= len(itr.output.Items) {
 		// have we exhausted all results?
-		if itr.output.LastEvaluatedKey == nil || itr.query.searchLimit > 0 {
+		if itr.output.LastEvaluatedKey == nil || (itr.query.searchLimit > 0 && !itr.query.exactly) {
 			return false
 		}
 		// have we hit the request limit?
 		if itr.query.reqLimit > 0 && itr.reqs == itr.query.reqLimit {
 			return false
 		}
+		// is there time for another request?
+		if itr.deadline.near() {
+			return false
+		}
 
 		// no, prepare next request and reset index
 		itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
+		itr.query.limitRemaining(itr.input, itr.n)
 		itr.idx = 0
 	}
 
-	itr.err = itr.query.table.db.retry(ctx, func() error {
+	query := func() error {
 		var err error
-		itr.output, err = itr.query.table.db.client.Query(ctx, itr.input)
+		itr.output, err = hedge(ctx, itr.query.hedger, func(ctx context.Context) (*dynamodb.QueryOutput, error) {
+			return itr.query.table.db.client.Query(ctx, itr.input, itr.query.table.db.requestOptions(itr.query.optFns)...)
+		}, func() {
+			itr.query.cc.incRequests()
+			itr.meta.countRequest()
+		})
 		itr.query.cc.incRequests()
+		itr.meta.countRequest()
 		return err
-	})
+	}
+	send := func() error {
+		err := itr.query.retryConsistent(ctx, itr.input.ConsistentRead, query)
+		if itr.query.shouldFallback(itr.input.ConsistentRead, err) {
+			// subsequent pages will also be eventually consistent
+			itr.input.ConsistentRead = nil
+			err = query()
+		}
+		return err
+	}
+	if itr.err = itr.query.throttle.delay(ctx); itr.err != nil {
+		return false
+	}
+	start := time.Now()
+	itr.err = itr.query.table.db.retry(ctx, send)
+	if shouldRestart(itr.query.restart, itr.reqs, itr.input.ExclusiveStartKey, itr.err) {
+		// start over from the beginning
+		itr.input.ExclusiveStartKey = nil
+		itr.exESK = nil
+		itr.err = itr.query.table.db.retry(ctx, send)
+	}
 
 	if itr.err != nil {
+		itr.err = pagingKeyErr(itr.err, itr.input.ExclusiveStartKey)
 		return false
 	}
+	itr.deadline.observe(start)
 	itr.query.cc.add(itr.output.ConsumedCapacity)
+	itr.query.throttle.charge(readUnits(itr.output.ConsumedCapacity, itr.output.Items, itr.input.ConsistentRead))
+	itr.meta.record(itr.output)
 	if len(itr.output.LastEvaluatedKey) > len(itr.exLEK) {
 		itr.exLEK = itr.output.LastEvaluatedKey
 	}
 	itr.reqs++
+	itr.output.Items, itr.err = itr.seen.filter(itr.output.Items)
+	if itr.err != nil {
+		return false
+	}
 
 	if len(itr.output.Items) == 0 {
 		if itr.query.reqLimit > 0 && itr.reqs == itr.query.reqLimit {
@@ -413,14 +854,30 @@ func (itr *queryIter) Next(ctx context.Context, out interface{}) bool {
 
 	item := itr.output.Items[itr.idx]
 	itr.last = item
-	itr.err = itr.unmarshal(item, out)
+	itr.err = itr.unmarshal(ctx, item, out)
 	itr.idx++
 	itr.n++
+	itr.countBytes(item)
 	return itr.err == nil
 }
 
-func (itr *queryIter) hasMore() bool {
+func (itr *queryIter) limitReached() bool {
 	if itr.query.limit > 0 && itr.n == itr.query.limit {
+		return true
+	}
+	return itr.query.byteLimit > 0 && itr.bytes >= itr.query.byteLimit
+}
+
+func (itr *queryIter) countBytes(item Item) {
+	if itr.query.byteLimit > 0 {
+		// items from DynamoDB are valid, so this can't fail
+		n, _ := ItemSize(item)
+		itr.bytes += n
+	}
+}
+
+func (itr *queryIter) hasMore() bool {
+	if itr.limitReached() {
 		return false
 	}
 	return itr.output != nil && itr.idx < len(itr.output.Items)
@@ -468,6 +925,16 @@ func (q *Query) All(ctx context.Context, out interface{}) error {
 	return iter.Err()
 }
 
+// AllWithRaw executes this request and unmarshals all results to out, which must be a pointer to a slice.
+// The raw items are also appended to raw, in the same order as out.
+// This is useful when you need the original attributes as well, such as for checksums or passing them through.
+func (q *Query) AllWithRaw(ctx context.Context, out interface{}, raw *[]Item) error {
+	iter := q.newIter(unmarshalAppendRaw(out, raw))
+	for iter.Next(ctx, out) {
+	}
+	return iter.Err()
+}
+
 // AllWithLastEvaluatedKey executes this request and unmarshals all results to out, which must be a pointer to a slice.
 // This returns a PagingKey you can use with StartFrom to split up results.
 func (q *Query) AllWithLastEvaluatedKey(ctx context.Context, out interface{}) (PagingKey, error) {
@@ -483,6 +950,92 @@ func (q *Query) Iter() PagingIter {
 	return q.newIter(unmarshalItem)
 }
 
+// Validate checks this query's key conditions against the table's key schema (or the index's, see [Query.Index]),
+// returning a [*KeyValidationError] describing the problem if they can't work.
+// For example, it reports range key operators such as [BeginsWith] used on the hash key,
+// [BeginsWith] used on a number range key, and key values of the wrong type.
+// The table's description is cached after the first DescribeTable call.
+// Once a table's description is cached (by this, [Table.Describe], or similar),
+// queries are checked automatically before they are run.
+func (q *Query) Validate(ctx context.Context) error {
+	if q.err != nil {
+		return q.err
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, _, _, ok := desc.keySchema(q.index); !ok {
+		return fmt.Errorf("dynamo: unknown index %s on table %s", q.index, q.table.Name())
+	}
+	return q.checkKeys(desc)
+}
+
+// checkCachedKeys validates key conditions if the table's description is already cached.
+func (q *Query) checkCachedKeys() error {
+	desc, ok := q.table.db.loadDesc(q.table.name)
+	if !ok {
+		return nil
+	}
+	return q.checkKeys(desc)
+}
+
+func (q *Query) checkKeys(desc Description) error {
+	hashKey, hashType, rangeKey, rangeType, ok := desc.keySchema(q.index)
+	if !ok {
+		// stale description, let DynamoDB decide
+		return nil
+	}
+	table := desc.Name
+	if q.index != "" {
+		table += " (index " + q.index + ")"
+	}
+	invalid := func(attr, reason string, args ...any) error {
+		return &KeyValidationError{Table: table, Attribute: attr, Reason: fmt.Sprintf(reason, args...)}
+	}
+
+	switch {
+	case q.hashKey == rangeKey && rangeKey != "":
+		return invalid(q.hashKey, "%q is the range key (sort key), not the hash key (partition key) %q; use Range for range keys", q.hashKey, hashKey)
+	case q.hashKey != hashKey:
+		return invalid(q.hashKey, "not the hash key (partition key), want %q", hashKey)
+	}
+	if err := checkKeyType(invalid, q.hashKey, hashType, q.hashValue); err != nil {
+		return err
+	}
+
+	if q.rangeKey == "" || q.rangeOp == "" {
+		return nil
+	}
+	switch {
+	case q.rangeKey == hashKey:
+		return invalid(q.rangeKey, "operator %s can only be used on the range key (sort key), but %q is the hash key (partition key); hash keys can only be matched with Get", q.rangeOp, q.rangeKey)
+	case rangeKey == "":
+		return invalid(q.rangeKey, "range key condition given, but there is no range key (sort key)")
+	case q.rangeKey != rangeKey:
+		return invalid(q.rangeKey, "not the range key (sort key), want %q", rangeKey)
+	case q.rangeOp == BeginsWith && rangeType == NumberType:
+		return invalid(q.rangeKey, "operator %s requires a string or binary range key, but it is a number", q.rangeOp)
+	}
+	for _, v := range q.rangeValues {
+		if err := checkKeyType(invalid, q.rangeKey, rangeType, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkKeyType(invalid func(attr, reason string, args ...any) error, name string, want KeyType, av types.AttributeValue) error {
+	got, ok := keyTypeOf(av)
+	switch {
+	case !ok:
+		return invalid(name, "unsupported key type %T", av)
+	case want != NoneType && got != want:
+		return invalid(name, "type mismatch, want %s but got %s", want, got)
+	}
+	return nil
+}
+
 // can we use the get item API?
 func (q *Query) canGetItem() bool {
 	switch {
@@ -533,10 +1086,21 @@ func (q *Query) queryInput() *dynamodb.QueryInput {
 	}
 	if q.cc != nil {
 		req.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	} else if q.throttle != nil {
+		req.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
 	}
 	return req
 }
 
+// limitRemaining lowers the page size of a follow-up request to the number of results still wanted,
+// given that n results have already been collected.
+func (q *Query) limitRemaining(input *dynamodb.QueryInput, n int) {
+	if q.limit > 0 && n < q.limit && len(q.filters) == 0 && q.searchLimit == 0 {
+		limit := int32(min(math.MaxInt32, q.limit-n))
+		input.Limit = &limit
+	}
+}
+
 func (q *Query) keyConditions() map[string]types.Condition {
 	conds := map[string]types.Condition{
 		q.hashKey: {
@@ -608,6 +1172,49 @@ func (q *Query) keysAndAttribs() types.KeysAndAttributes {
 	return kas
 }
 
+func (q *Query) servedBy(api string) {
+	if q.api != nil {
+		*q.api = api
+	}
+}
+
+func (q *Query) resetServed() {
+	if q.served != nil {
+		*q.served = q.consistent
+	}
+}
+
+// retryConsistent calls send, retrying throttled strongly consistent reads
+// until the number of attempts set by [Query.FallbackAfter] is reached.
+func (q *Query) retryConsistent(ctx context.Context, consistent *bool, send func() error) error {
+	err := send()
+	if !q.fallback || q.fallbackN <= 1 {
+		return err
+	}
+	boff := backoff.NewExponentialBackOff()
+	// the SDK's retryer has already backed off by now
+	boff.InitialInterval = 100 * time.Millisecond
+	boff.Reset()
+	for attempt := 1; attempt < q.fallbackN && consistent != nil && *consistent && isThrottle(err); attempt++ {
+		if err := smithytime.SleepWithContext(ctx, boff.NextBackOff()); err != nil {
+			return err
+		}
+		err = send()
+	}
+	return err
+}
+
+// shouldFallback reports whether a throttled consistent read should be retried as eventually consistent.
+func (q *Query) shouldFallback(consistent *bool, err error) bool {
+	if !q.fallback || consistent == nil || !*consistent || !isThrottle(err) {
+		return false
+	}
+	if q.served != nil {
+		*q.served = false
+	}
+	return true
+}
+
 func (q *Query) setError(err error) {
 	if q.err == nil {
 		q.err = err
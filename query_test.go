@@ -1,14 +1,22 @@
 package dynamo
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 func TestGetAllCount(t *testing.T) {
@@ -221,6 +229,55 @@ func TestQueryPaging(t *testing.T) {
 	}
 }
 
+func TestQueryByteLimit(t *testing.T) {
+	if testDB == nil {
+		t.Skip(offlineSkipMsg)
+	}
+	ctx := context.TODO()
+	table := testDB.Table(testTableWidgets)
+
+	widgets := []interface{}{
+		widget{
+			UserID: 1970,
+			Time:   time.Date(1970, 4, 1, 0, 0, 0, 0, time.UTC),
+			Msg:    "first widget",
+		},
+		widget{
+			UserID: 1970,
+			Time:   time.Date(1970, 4, 10, 0, 0, 0, 0, time.UTC),
+			Msg:    "second widget",
+		},
+		widget{
+			UserID: 1970,
+			Time:   time.Date(1970, 4, 20, 0, 0, 0, 0, time.UTC),
+			Msg:    "third widget",
+		},
+	}
+	if _, err := table.Batch().Write().Put(widgets...).Run(ctx); err != nil {
+		t.Fatal("couldn't write byte limit prep data", err)
+	}
+
+	var got []widget
+	lek, err := table.Get("UserID", 1970).ByteLimit(1).AllWithLastEvaluatedKey(ctx, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], widgets[0]) {
+		t.Error("bad result:", got)
+	}
+	if lek == nil {
+		t.Error("expected LastEvaluatedKey")
+	}
+
+	var rest []widget
+	if err := table.Get("UserID", 1970).StartFrom(lek).All(ctx, &rest); err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 2 {
+		t.Error("bad remaining result:", rest)
+	}
+}
+
 func TestQueryMagicLEK(t *testing.T) {
 	if testDB == nil {
 		t.Skip(offlineSkipMsg)
@@ -336,3 +393,837 @@ func TestQueryBadKeys(t *testing.T) {
 		}
 	})
 }
+
+type queryRecorder struct {
+	dynamodbiface.DynamoDBAPI
+	queries []*dynamodb.QueryInput
+	items   []Item
+}
+
+func (c *queryRecorder) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, in)
+	return &dynamodb.QueryOutput{Items: c.items}, nil
+}
+
+func TestQueryRangeTime(t *testing.T) {
+	ctx := context.Background()
+	client := new(queryRecorder)
+	table := NewFromIface(client).Table("Times")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	type rfcEvent struct {
+		ID   int `dynamo:",hash"`
+		Time time.Time
+	}
+	type unixEvent struct {
+		ID   int       `dynamo:",hash"`
+		When time.Time `dynamo:"Time,unixtime"`
+	}
+	type intEvent struct {
+		ID   int `dynamo:",hash"`
+		Time int64
+	}
+
+	tests := []struct {
+		name string
+		out  any
+		run  func(q *Query, ctx context.Context, out any) error
+		want []types.AttributeValue
+	}{
+		{
+			name: "rfc3339",
+			out:  new([]rfcEvent),
+			run:  (*Query).All,
+			want: []types.AttributeValue{
+				&types.AttributeValueMemberS{Value: "2024-01-01T00:00:00Z"},
+				&types.AttributeValueMemberS{Value: "2024-02-01T00:00:00Z"},
+			},
+		},
+		{
+			name: "unixtime",
+			out:  new([]*unixEvent),
+			run:  (*Query).All,
+			want: []types.AttributeValue{
+				&types.AttributeValueMemberN{Value: "1704067200"},
+				&types.AttributeValueMemberN{Value: "1706745600"},
+			},
+		},
+		{
+			name: "int",
+			out:  new(intEvent),
+			run: func(q *Query, ctx context.Context, out any) error {
+				if err := q.One(ctx, out); err != ErrNotFound {
+					return err
+				}
+				return nil
+			},
+			want: []types.AttributeValue{
+				&types.AttributeValueMemberN{Value: "1704067200"},
+				&types.AttributeValueMemberN{Value: "1706745600"},
+			},
+		},
+		{
+			name: "unknown",
+			out:  new([]Item),
+			run:  (*Query).All,
+			want: []types.AttributeValue{
+				&types.AttributeValueMemberS{Value: "2024-01-01T00:00:00Z"},
+				&types.AttributeValueMemberS{Value: "2024-02-01T00:00:00Z"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := table.Get("ID", 1).RangeTime("Time", from, to)
+			if err := test.run(q, ctx, test.out); err != nil {
+				t.Fatal(err)
+			}
+			cond := client.queries[len(client.queries)-1].KeyConditions["Time"]
+			if cond.ComparisonOperator != types.ComparisonOperatorBetween {
+				t.Error("bad operator:", cond.ComparisonOperator)
+			}
+			if !reflect.DeepEqual(cond.AttributeValueList, test.want) {
+				t.Error("bad values. want:", test.want, "got:", cond.AttributeValueList)
+			}
+		})
+	}
+
+	t.Run("open-ended", func(t *testing.T) {
+		var out []unixEvent
+		if err := table.Get("ID", 1).RangeTime("Time", from, time.Time{}).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		cond := client.queries[len(client.queries)-1].KeyConditions["Time"]
+		want := []types.AttributeValue{&types.AttributeValueMemberN{Value: "1704067200"}}
+		if cond.ComparisonOperator != types.ComparisonOperatorGe || !reflect.DeepEqual(cond.AttributeValueList, want) {
+			t.Error("bad condition:", cond.ComparisonOperator, cond.AttributeValueList)
+		}
+	})
+}
+
+// keysQueryClient is a keysClient that records queries.
+type keysQueryClient struct {
+	queryRecorder
+}
+
+func (c *keysQueryClient) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, opts ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return new(keysClient).DescribeTable(ctx, in, opts...)
+}
+
+func TestQueryValidate(t *testing.T) {
+	ctx := context.Background()
+	client := new(keysQueryClient)
+	table := NewFromIface(client).Table("Keys")
+
+	tests := []struct {
+		name  string
+		query *Query
+		ok    bool
+	}{
+		{"valid", table.Get("UserID", 1).Range("Time", BeginsWith, "2024-"), true},
+		{"valid between", table.Get("UserID", 1).Range("Time", Between, "a", "b"), true},
+		{"begins_with on hash key", table.Get("UserID", 1).Range("UserID", BeginsWith, "1"), false},
+		{"range key as hash key", table.Get("Time", "2024-"), false},
+		{"unknown hash key", table.Get("Msg", "hello"), false},
+		{"hash key type", table.Get("UserID", "1"), false},
+		{"range key type", table.Get("UserID", 1).Range("Time", Greater, 2024), false},
+		{"unknown index", table.Get("UserID", 1).Index("nope"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.query.Validate(ctx)
+			if test.ok && err != nil {
+				t.Error("unexpected error:", err)
+			}
+			if !test.ok && err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+
+	t.Run("not equal", func(t *testing.T) {
+		if err := table.Get("UserID", 1).Range("Time", NotEqual, "x").Validate(ctx); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+
+	t.Run("between arity", func(t *testing.T) {
+		if err := table.Get("UserID", 1).Range("Time", Between, "x").Validate(ctx); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		// description is now cached, so queries are checked before running
+		var v []any
+		err := table.Get("UserID", 1).Range("UserID", BeginsWith, "1").All(ctx, &v)
+		var kerr *KeyValidationError
+		if !errors.As(err, &kerr) {
+			t.Fatal("want KeyValidationError, got", err)
+		}
+		if kerr.Attribute != "UserID" {
+			t.Error("bad attribute:", kerr.Attribute)
+		}
+		if len(client.queries) != 0 {
+			t.Error("invalid query was sent:", client.queries)
+		}
+	})
+}
+
+func TestQueryAllWithRaw(t *testing.T) {
+	client := &queryRecorder{items: []Item{
+		{"ID": &types.AttributeValueMemberN{Value: "1"}, "Seq": &types.AttributeValueMemberN{Value: "1"}, "Extra": &types.AttributeValueMemberS{Value: "a"}},
+		{"ID": &types.AttributeValueMemberN{Value: "1"}, "Seq": &types.AttributeValueMemberN{Value: "2"}, "Extra": &types.AttributeValueMemberS{Value: "b"}},
+	}}
+	table := NewFromIface(client).Table("Raw")
+
+	type row struct {
+		ID  int
+		Seq int
+	}
+	var rows []row
+	var raw []Item
+	if err := table.Get("ID", 1).AllWithRaw(context.Background(), &rows, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if want := []row{{1, 1}, {1, 2}}; !reflect.DeepEqual(rows, want) {
+		t.Error("bad typed results. want:", want, "got:", rows)
+	}
+	if !reflect.DeepEqual(raw, client.items) {
+		t.Error("bad raw results. want:", client.items, "got:", raw)
+	}
+}
+
+// staleKeyClient rejects every ExclusiveStartKey, as if the key schema had changed.
+type staleKeyClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls int
+}
+
+var errStaleKey = &smithy.GenericAPIError{Code: "ValidationException", Message: "The provided starting key is invalid: The provided key element does not match the schema"}
+
+func (c *staleKeyClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.calls++
+	if in.ExclusiveStartKey != nil {
+		return nil, errStaleKey
+	}
+	return &dynamodb.QueryOutput{Items: []Item{{"ID": &types.AttributeValueMemberN{Value: "1"}}}, Count: 1}, nil
+}
+
+func (c *staleKeyClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.calls++
+	if in.ExclusiveStartKey != nil {
+		return nil, errStaleKey
+	}
+	return &dynamodb.ScanOutput{Items: []Item{{"ID": &types.AttributeValueMemberN{Value: "1"}}}, Count: 1}, nil
+}
+
+func TestRestartOnBadKey(t *testing.T) {
+	ctx := context.Background()
+	client := new(staleKeyClient)
+	table := NewFromIface(client).Table("Stale")
+	stale := PagingKey{"OldKey": &types.AttributeValueMemberS{Value: "x"}}
+
+	t.Run("query error", func(t *testing.T) {
+		var out []Item
+		err := table.Get("ID", 1).StartFrom(stale).All(ctx, &out)
+		if !errors.Is(err, ErrBadPagingKey) {
+			t.Error("want ErrBadPagingKey, got", err)
+		}
+		var ae smithy.APIError
+		if !errors.As(err, &ae) || ae.ErrorCode() != "ValidationException" {
+			t.Error("original error not wrapped:", err)
+		}
+	})
+
+	t.Run("query restart", func(t *testing.T) {
+		var out []Item
+		if err := table.Get("ID", 1).StartFrom(stale).RestartOnBadKey(true).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 1 {
+			t.Error("want 1 result, got", len(out))
+		}
+		n, err := table.Get("ID", 1).StartFrom(stale).RestartOnBadKey(true).Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Error("bad count:", n)
+		}
+	})
+
+	t.Run("scan error", func(t *testing.T) {
+		var out []Item
+		err := table.Scan().StartFrom(stale).All(ctx, &out)
+		if !errors.Is(err, ErrBadPagingKey) {
+			t.Error("want ErrBadPagingKey, got", err)
+		}
+		_, err = table.Scan().StartFrom(stale).Count(ctx)
+		if !errors.Is(err, ErrBadPagingKey) {
+			t.Error("want ErrBadPagingKey, got", err)
+		}
+	})
+
+	t.Run("scan restart", func(t *testing.T) {
+		var out []Item
+		if err := table.Scan().StartFrom(stale).RestartOnBadKey(true).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 1 {
+			t.Error("want 1 result, got", len(out))
+		}
+		n, err := table.Scan().StartFrom(stale).RestartOnBadKey(true).Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Error("bad count:", n)
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		err := pagingKeyErr(&smithy.GenericAPIError{Code: "ValidationException", Message: "Invalid FilterExpression"}, Item(stale))
+		if errors.Is(err, ErrBadPagingKey) {
+			t.Error("unrelated validation error translated:", err)
+		}
+	})
+}
+
+// pagedClient serves 5 items in pages of at most 2, honoring Limit.
+type pagedClient struct {
+	dynamodbiface.DynamoDBAPI
+	limits []int32
+}
+
+func (c *pagedClient) page(esk Item, limit *int32) ([]Item, Item) {
+	start := 0
+	if seq, ok := esk["Seq"].(*types.AttributeValueMemberN); ok {
+		start, _ = strconv.Atoi(seq.Value)
+	}
+	size := int32(2)
+	if limit != nil {
+		size = min(size, *limit)
+		c.limits = append(c.limits, *limit)
+	} else {
+		c.limits = append(c.limits, 0)
+	}
+	var items []Item
+	for i := start + 1; i <= 5 && len(items) < int(size); i++ {
+		items = append(items, Item{
+			"ID":  &types.AttributeValueMemberN{Value: "1"},
+			"Seq": &types.AttributeValueMemberN{Value: strconv.Itoa(i)},
+		})
+	}
+	if len(items) == 0 || items[len(items)-1]["Seq"].(*types.AttributeValueMemberN).Value == "5" {
+		return items, nil
+	}
+	return items, items[len(items)-1]
+}
+
+func (c *pagedClient) Query(ctx context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	items, lek := c.page(in.ExclusiveStartKey, in.Limit)
+	return &dynamodb.QueryOutput{Items: items, Count: int32(len(items)), ScannedCount: int32(len(items)), LastEvaluatedKey: lek}, nil
+}
+
+func (c *pagedClient) Scan(ctx context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	items, lek := c.page(in.ExclusiveStartKey, in.Limit)
+	return &dynamodb.ScanOutput{Items: items, Count: int32(len(items)), ScannedCount: int32(len(items)), LastEvaluatedKey: lek}, nil
+}
+
+func TestLimitAcrossPages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("remaining page size", func(t *testing.T) {
+		client := new(pagedClient)
+		var out []Item
+		if err := NewFromIface(client).Table("Paged").Get("ID", 1).Limit(3).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 3 {
+			t.Error("want 3 results, got", len(out))
+		}
+		if want := []int32{3, 1}; !reflect.DeepEqual(client.limits, want) {
+			t.Error("bad page limits. want:", want, "got:", client.limits)
+		}
+	})
+
+	t.Run("search limit", func(t *testing.T) {
+		client := new(pagedClient)
+		var out []Item
+		if err := NewFromIface(client).Table("Paged").Get("ID", 1).Limit(3).SearchLimit(2).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 || len(client.limits) != 1 {
+			t.Error("want 2 results from 1 request, got", len(out), "from", len(client.limits))
+		}
+	})
+
+	t.Run("exactly", func(t *testing.T) {
+		client := new(pagedClient)
+		var out []Item
+		if err := NewFromIface(client).Table("Paged").Get("ID", 1).Limit(3).SearchLimit(2).Exactly(true).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 3 || len(client.limits) != 2 {
+			t.Error("want 3 results from 2 requests, got", len(out), "from", len(client.limits))
+		}
+
+		client = new(pagedClient)
+		out = nil
+		if err := NewFromIface(client).Table("Paged").Scan().Limit(3).SearchLimit(2).Exactly(true).RequestLimit(1).All(ctx, &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 || len(client.limits) != 1 {
+			t.Error("want 2 results from 1 request, got", len(out), "from", len(client.limits))
+		}
+	})
+
+	t.Run("count", func(t *testing.T) {
+		n, err := NewFromIface(new(pagedClient)).Table("Paged").Get("ID", 1).Filter("Seq > ?", 0).Limit(3).Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Error("want count of 3, got", n)
+		}
+		n, err = NewFromIface(new(pagedClient)).Table("Paged").Scan().Limit(3).SearchLimit(2).Exactly(true).Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Error("want count of 3, got", n)
+		}
+	})
+}
+
+func TestCountWithLastEvaluatedKey(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(new(pagedClient)).Table("Paged")
+
+	// count 2 pages at a time, resuming from the last key
+	var total, runs int
+	var lek PagingKey
+	for {
+		n, next, err := table.Get("ID", 1).StartFrom(lek).RequestLimit(2).CountWithLastEvaluatedKey(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += n
+		runs++
+		if next == nil {
+			break
+		}
+		lek = next
+	}
+	if total != 5 || runs != 2 {
+		t.Error("want count of 5 in 2 runs, got", total, "in", runs)
+	}
+
+	n, lek, err := table.Scan().RequestLimit(1).CountWithLastEvaluatedKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || lek["Seq"].(*types.AttributeValueMemberN).Value != "2" {
+		t.Error("bad scan count or key:", n, lek)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, lek, err = table.Scan().StartFrom(lek).CountWithLastEvaluatedKey(canceled)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if lek["Seq"].(*types.AttributeValueMemberN).Value != "2" {
+		t.Error("want key to resume from after error, got:", lek)
+	}
+
+	// errors before the first request also return the start key
+	start := lek
+	_, lek, err = table.Scan().StartFrom(start).Filter("Bad = ?", func() {}).CountWithLastEvaluatedKey(ctx)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !reflect.DeepEqual(lek, start) {
+		t.Error("scan: want start key after error, got:", lek)
+	}
+	_, lek, err = table.Get("ID", 1).StartFrom(start).Filter("Bad = ?", func() {}).CountWithLastEvaluatedKey(ctx)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !reflect.DeepEqual(lek, start) {
+		t.Error("query: want start key after error, got:", lek)
+	}
+}
+
+func TestOrNotFilter(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		build func(q *Query) *Query
+		want  string
+	}{
+		{
+			name:  "or",
+			build: func(q *Query) *Query { return q.Filter("A = ?", 1).Filter("B = ?", 2).OrFilter("C = ?", 3) },
+			want:  "(((A = :v0) AND (B = :v1)) OR (C = :v2))",
+		},
+		{
+			name:  "or then and",
+			build: func(q *Query) *Query { return q.Filter("A = ?", 1).OrFilter("B = ?", 2).Filter("C = ?", 3) },
+			want:  "((A = :v0) OR (B = :v1)) AND (C = :v2)",
+		},
+		{
+			name:  "or alone",
+			build: func(q *Query) *Query { return q.OrFilter("A = ?", 1) },
+			want:  "(A = :v0)",
+		},
+		{
+			name:  "not",
+			build: func(q *Query) *Query { return q.Filter("A = ?", 1).NotFilter("B = ? OR C = ?", 2, 3) },
+			want:  "(A = :v0) AND (NOT (B = :v1 OR C = :v2))",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := new(queryRecorder)
+			var out []Item
+			q := test.build(NewFromIface(client).Table("Filters").Get("ID", 1))
+			if err := q.All(ctx, &out); err != nil {
+				t.Fatal(err)
+			}
+			if got := *client.queries[0].FilterExpression; got != test.want {
+				t.Errorf("bad filter. want: %s got: %s", test.want, got)
+			}
+		})
+	}
+}
+
+type enumStatus int
+
+const (
+	statusActive enumStatus = iota + 1
+)
+
+func (s enumStatus) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("status-%d", int(s))), nil
+}
+
+// enumColor is an enum without a scalar encoding of its own, encoded by its String method.
+type enumColor struct {
+	name string
+}
+
+func (c enumColor) String() string {
+	return c.name
+}
+
+func TestQueryEnumKeys(t *testing.T) {
+	ctx := context.Background()
+	client := new(queryRecorder)
+	table := NewFromIface(client).Table("Widgets")
+
+	type widget struct {
+		Status enumStatus `dynamo:",hash"`
+		Kind   enumStatus `dynamo:",range"`
+	}
+	item, err := MarshalItem(widget{Status: statusActive, Kind: statusActive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := item["Status"]
+
+	err = table.Get("Status", statusActive).
+		Range("Kind", Equal, statusActive).
+		Filter("$ = ? AND $ = ?", "Status", statusActive, "Kind", statusActive).
+		All(ctx, &[]widget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := client.queries[0]
+	values := []types.AttributeValue{
+		in.KeyConditions["Status"].AttributeValueList[0],
+		in.KeyConditions["Kind"].AttributeValueList[0],
+		in.ExpressionAttributeValues[":v0"],
+		in.ExpressionAttributeValues[":v1"],
+	}
+	for i, v := range values {
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("value %d: want %#v, got %#v", i, want, v)
+		}
+	}
+	names := make(map[string]bool)
+	for _, name := range in.ExpressionAttributeNames {
+		names[name] = true
+	}
+	if !names["Status"] || !names["Kind"] {
+		t.Error("missing names:", in.ExpressionAttributeNames)
+	}
+
+	red := enumColor{name: "red"}
+	err = table.Get("Color", red).
+		Range("Shade", Equal, red).
+		Filter("Tint = ?", red).
+		All(ctx, &[]widget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in = client.queries[1]
+	want = &types.AttributeValueMemberS{Value: "red"}
+	values = []types.AttributeValue{
+		in.KeyConditions["Color"].AttributeValueList[0],
+		in.KeyConditions["Shade"].AttributeValueList[0],
+		in.ExpressionAttributeValues[":v0"],
+	}
+	for i, v := range values {
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("stringer value %d: want %#v, got %#v", i, want, v)
+		}
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	ctx := context.Background()
+	client := new(queryRecorder)
+	table := NewFromIface(client).Table("Widgets")
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"get", table.Get("UserID", "").All(ctx, &[]widget{})},
+		{"range", table.Get("UserID", 1).Range("Time", Between, "a", "").All(ctx, &[]widget{})},
+		{"delete", table.Delete("UserID", 1).Range("Time", []byte{}).Run(ctx)},
+		{"update", table.Update("UserID", "").Set("Msg", "hi").Run(ctx)},
+		{"check", table.Check("UserID", 1).Range("Time", "").err},
+	}
+	for _, test := range tests {
+		if !errors.Is(test.err, ErrEmptyKey) {
+			t.Errorf("%s: want ErrEmptyKey, got: %v", test.name, test.err)
+		}
+	}
+	if len(client.queries) != 0 {
+		t.Error("unexpected request:", client.queries)
+	}
+
+	if err := table.Get("UserID", (*string)(nil)).All(ctx, &[]widget{}); err == nil || errors.Is(err, ErrEmptyKey) {
+		t.Error("nil key: want nil or omitted error, got:", err)
+	}
+
+	table = NewFromIface(client).AllowEmptyKey(true).Table("Widgets")
+	if err := table.Get("UserID", "").All(ctx, &[]widget{}); err != nil {
+		t.Fatal(err)
+	}
+	cond := client.queries[0].KeyConditions["UserID"]
+	if av, ok := cond.AttributeValueList[0].(*types.AttributeValueMemberS); !ok || av.Value != "" {
+		t.Error("bad key value:", cond.AttributeValueList)
+	}
+}
+
+// slowPager returns pages of one item each, taking delay per request, with n pages in total.
+type slowPager struct {
+	dynamodbiface.DynamoDBAPI
+	delay time.Duration
+	n     int
+	reqs  int
+}
+
+func (c *slowPager) page(esk Item) (Item, Item) {
+	next := 0
+	if esk != nil {
+		next, _ = strconv.Atoi(esk["UserID"].(*types.AttributeValueMemberN).Value)
+		next++
+	}
+	item := Item{"UserID": &types.AttributeValueMemberN{Value: strconv.Itoa(next)}}
+	if next == c.n-1 {
+		return item, nil
+	}
+	return item, item
+}
+
+func (c *slowPager) Query(ctx context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.reqs++
+	time.Sleep(c.delay)
+	item, lek := c.page(in.ExclusiveStartKey)
+	return &dynamodb.QueryOutput{Items: []Item{item}, LastEvaluatedKey: lek}, nil
+}
+
+func (c *slowPager) Scan(ctx context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.reqs++
+	time.Sleep(c.delay)
+	item, lek := c.page(in.ExclusiveStartKey)
+	return &dynamodb.ScanOutput{Items: []Item{item}, LastEvaluatedKey: lek}, nil
+}
+
+func TestAllUntil(t *testing.T) {
+	ctx := context.Background()
+	client := &slowPager{delay: 20 * time.Millisecond, n: 1000}
+	table := NewFromIface(client).Table("Pages")
+
+	type row struct {
+		UserID int
+	}
+	runs := map[string]func(deadline time.Time, out *[]row) (PagingKey, error){
+		"query": func(deadline time.Time, out *[]row) (PagingKey, error) {
+			return table.Get("Group", 1).AllUntil(ctx, deadline, out)
+		},
+		"scan": func(deadline time.Time, out *[]row) (PagingKey, error) {
+			return table.Scan().AllUntil(ctx, deadline, out)
+		},
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			client.reqs = 0
+			client.n = 1000
+			var out []row
+			deadline := time.Now().Add(100 * time.Millisecond)
+			lek, err := run(deadline, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if time.Now().After(deadline.Add(client.delay)) {
+				t.Error("deadline exceeded")
+			}
+			if lek == nil || len(out) == 0 || len(out) != client.reqs {
+				t.Fatal("want partial results, got:", len(out), "items", client.reqs, "requests", lek)
+			}
+			if last := lek["UserID"].(*types.AttributeValueMemberN).Value; last != strconv.Itoa(out[len(out)-1].UserID) {
+				t.Error("bad LastEvaluatedKey:", last)
+			}
+
+			client.n = 3
+			out = nil
+			lek, err = run(time.Now().Add(time.Second), &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lek != nil || len(out) != 3 {
+				t.Error("want all results, got:", len(out), lek)
+			}
+		})
+	}
+}
+
+func TestQueryAllWithMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := &slowPager{n: 3}
+	table := NewFromIface(client).Table("Pages")
+
+	var out []Item
+	meta, err := table.Get("Group", 1).AllWithMetadata(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Requests != 3 || meta.Pages != 3 || meta.Returned != 3 || meta.Retries != 0 || meta.LastEvaluatedKey != nil {
+		t.Errorf("bad metadata: %+v", meta)
+	}
+	if meta.Duration <= 0 {
+		t.Error("duration not recorded")
+	}
+
+	out = nil
+	meta, err = table.Get("Group", 1).RequestLimit(2).AllWithMetadata(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Pages != 2 || meta.Returned != 2 || len(out) != 2 {
+		t.Errorf("bad metadata with request limit: %+v", meta)
+	}
+	if want := PagingKey(Item{"UserID": &types.AttributeValueMemberN{Value: "1"}}); !reflect.DeepEqual(meta.LastEvaluatedKey, want) {
+		t.Errorf("bad LastEvaluatedKey. want: %v, got: %v", want, meta.LastEvaluatedKey)
+	}
+}
+
+func TestQueryNDJSONReader(t *testing.T) {
+	ctx := context.Background()
+	client := &slowPager{n: 3}
+	table := NewFromIface(client).Table("Pages")
+
+	r := table.Get("Group", 1).NDJSONReader(ctx)
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	if want := "{\"UserID\":0}\n{\"UserID\":1}\n{\"UserID\":2}\n"; string(got) != want {
+		t.Errorf("bad output. want: %q, got: %q", want, got)
+	}
+
+	t.Run("close early", func(t *testing.T) {
+		client := &slowPager{n: 1000}
+		table := NewFromIface(client).Table("Pages")
+		r := table.Get("Group", 1).NDJSONReader(ctx)
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "{\"UserID\":0}\n" {
+			t.Error("bad first line:", line)
+		}
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+		if _, err := r.Read(make([]byte, 1)); err == nil {
+			t.Error("expected error reading after close")
+		}
+	})
+
+	t.Run("exact values", func(t *testing.T) {
+		line, err := itemJSON(Item{
+			"N":    &types.AttributeValueMemberN{Value: "1.50E+3"},
+			"B":    &types.AttributeValueMemberB{Value: []byte("hi")},
+			"SS":   &types.AttributeValueMemberSS{Value: []string{"a"}},
+			"Null": &types.AttributeValueMemberNULL{Value: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"B":"aGk=","N":1.50E+3,"Null":null,"SS":["a"]}`; string(line) != want {
+			t.Errorf("bad JSON. want: %s, got: %s", want, line)
+		}
+	})
+}
+
+// getRecorder is a queryRecorder that also serves GetItem, counting calls.
+type getRecorder struct {
+	queryRecorder
+	gets int
+}
+
+func (c *getRecorder) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.gets++
+	return &dynamodb.GetItemOutput{Item: in.Key}, nil
+}
+
+func TestQueryForceQuery(t *testing.T) {
+	ctx := context.Background()
+	client := &getRecorder{queryRecorder: queryRecorder{
+		items: []Item{{"UserID": &types.AttributeValueMemberN{Value: "1"}}},
+	}}
+	table := NewFromIface(client).Table("Force")
+
+	var api string
+	var got Item
+	if err := table.Get("UserID", 1).ServedBy(&api).One(ctx, &got); err != nil {
+		t.Fatal(err)
+	}
+	if api != "GetItem" || client.gets != 1 || len(client.queries) != 0 {
+		t.Error("expected GetItem, got:", api, client.gets, len(client.queries))
+	}
+
+	if err := table.Get("UserID", 1).ForceQuery().ServedBy(&api).One(ctx, &got); err != nil {
+		t.Fatal(err)
+	}
+	if api != "Query" || client.gets != 1 || len(client.queries) != 1 {
+		t.Error("expected Query, got:", api, client.gets, len(client.queries))
+	}
+}
@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// QueryMetadata summarizes the work done by a query, see [Query.AllWithMetadata].
+// It is useful for diagnostics, such as response headers or logs.
+type QueryMetadata struct {
+	// Requests is the number of Query requests made, including failed ones.
+	Requests int
+	// Pages is the number of pages of results received.
+	Pages int
+	// Retries is the number of attempts retried by the AWS SDK, such as after throttling.
+	Retries int
+	// Throttles is the number of throttled attempts, including those that succeeded after being retried.
+	Throttles int
+	// Scanned is the number of items evaluated, before any filter is applied.
+	Scanned int
+	// Returned is the number of items unmarshaled into the output.
+	Returned int
+	// CapacityUnits is the total number of capacity units consumed.
+	CapacityUnits float64
+	// Duration is the total time taken by the query, including unmarshaling.
+	Duration time.Duration
+	// LastEvaluatedKey can be used with [Query.StartFrom] to continue the query, or is nil if there are no more results.
+	LastEvaluatedKey PagingKey
+}
+
+// AllWithMetadata executes this request and unmarshals all results to out, which must be a pointer to a slice.
+// It returns a summary of the requests made, including the LastEvaluatedKey,
+// which is filled in as far as the query got even if it fails.
+// Consumed capacity is requested even if [Query.ConsumedCapacity] isn't used.
+func (q *Query) AllWithMetadata(ctx context.Context, out interface{}) (QueryMetadata, error) {
+	meta := new(QueryMetadata)
+	start := time.Now()
+	iter := q.newIter(unmarshalAppendTo(out))
+	iter.meta = meta
+	for iter.Next(ctx, out) {
+	}
+	lek, err := iter.LastEvaluatedKey(ctx)
+	meta.Returned = iter.n
+	meta.LastEvaluatedKey = lek
+	meta.Duration = time.Since(start)
+	return *meta, errors.Join(iter.Err(), err)
+}
+
+func (m *QueryMetadata) countRequest() {
+	if m == nil {
+		return
+	}
+	m.Requests++
+}
+
+// record adds the results of a successful Query request.
+func (m *QueryMetadata) record(out *dynamodb.QueryOutput) {
+	if m == nil {
+		return
+	}
+	m.Pages++
+	m.Scanned += int(out.ScannedCount)
+	if out.ConsumedCapacity != nil && out.ConsumedCapacity.CapacityUnits != nil {
+		m.CapacityUnits += *out.ConsumedCapacity.CapacityUnits
+	}
+	if attempts, ok := retry.GetAttemptResults(out.ResultMetadata); ok && len(attempts.Results) > 1 {
+		m.Retries += len(attempts.Results) - 1
+	}
+	m.Throttles += countThrottles(out.ResultMetadata)
+}
+
+// requestCapacity makes sure input returns consumed capacity, for metadata.
+func (m *QueryMetadata) requestCapacity(input *dynamodb.QueryInput) {
+	if m == nil || input.ReturnConsumedCapacity != "" {
+		return
+	}
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+}
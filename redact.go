@@ -0,0 +1,164 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultErrorValueLimit is the default maximum size in bytes of string and binary values
+// included in error messages. It is zero, so attribute values are left out of error messages
+// unless enabled with [DB.ErrorValueLimit].
+const DefaultErrorValueLimit = 0
+
+// ErrorValueLimit returns a copy of db that includes attribute values in error messages,
+// such as when an attribute can't be unmarshaled into a struct field, limited to n bytes.
+// String values larger than n bytes are truncated, and binary values larger than n bytes are replaced by a summary of their size,
+// which keeps error strings and logs manageable when items contain large attributes.
+// A negative n includes values in full.
+// The default is [DefaultErrorValueLimit], which leaves values out, as they may contain sensitive data.
+// The returned DB shares db's table description cache.
+func (db *DB) ErrorValueLimit(n int) *DB {
+	cp := *db
+	cp.errLimit = n
+	return &cp
+}
+
+type errLimitKey struct{}
+
+// codecContext returns ctx with db's options for marshaling and unmarshaling:
+// its error value limit, name mapper, and non-finite float function.
+func (db *DB) codecContext(ctx context.Context) context.Context {
+	if db == nil {
+		return ctx
+	}
+	if db.errLimit != DefaultErrorValueLimit {
+		ctx = context.WithValue(ctx, errLimitKey{}, db.errLimit)
+	}
+	if db.names != nil {
+		ctx = context.WithValue(ctx, nameMapperKey{}, db.names)
+	}
+	if db.floats != nil {
+		ctx = context.WithValue(ctx, nonFiniteFloatKey{}, db.floats)
+	}
+	return ctx
+}
+
+// unmarshaler wraps fn to unmarshal with db's options.
+func (db *DB) unmarshaler(fn unmarshalFunc) unmarshalFunc {
+	if db == nil || (db.errLimit == DefaultErrorValueLimit && db.names == nil) {
+		return fn
+	}
+	return func(ctx context.Context, item Item, out interface{}) error {
+		return fn(db.codecContext(ctx), item, out)
+	}
+}
+
+func errorValueLimit(ctx context.Context) int {
+	if n, ok := ctx.Value(errLimitKey{}).(int); ok {
+		return n
+	}
+	return DefaultErrorValueLimit
+}
+
+// formatAV formats av for error messages, truncating strings and summarizing binary values larger than limit bytes.
+func formatAV(av types.AttributeValue, limit int) string {
+	var sb strings.Builder
+	writeAV(&sb, av, limit)
+	return sb.String()
+}
+
+func writeAV(sb *strings.Builder, av types.AttributeValue, limit int) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		sb.WriteString(formatString(v.Value, limit))
+	case *types.AttributeValueMemberN:
+		sb.WriteString(v.Value)
+	case *types.AttributeValueMemberB:
+		sb.WriteString(formatBinary(v.Value, limit))
+	case *types.AttributeValueMemberBOOL:
+		sb.WriteString(strconv.FormatBool(v.Value))
+	case *types.AttributeValueMemberNULL:
+		sb.WriteString("null")
+	case *types.AttributeValueMemberSS:
+		writeList(sb, len(v.Value), limit, func(i int) { sb.WriteString(formatString(v.Value[i], limit)) })
+	case *types.AttributeValueMemberNS:
+		writeList(sb, len(v.Value), limit, func(i int) { sb.WriteString(v.Value[i]) })
+	case *types.AttributeValueMemberBS:
+		writeList(sb, len(v.Value), limit, func(i int) { sb.WriteString(formatBinary(v.Value[i], limit)) })
+	case *types.AttributeValueMemberL:
+		writeList(sb, len(v.Value), limit, func(i int) { writeAV(sb, v.Value[i], limit) })
+	case *types.AttributeValueMemberM:
+		keys := make([]string, 0, len(v.Value))
+		for k := range v.Value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+				if limit >= 0 && sb.Len() > limit {
+					fmt.Fprintf(sb, "... (%d entries)", len(keys))
+					break
+				}
+			}
+			sb.WriteString(formatString(k, limit))
+			sb.WriteString(": ")
+			writeAV(sb, v.Value[k], limit)
+		}
+		sb.WriteByte('}')
+	default:
+		fmt.Fprintf(sb, "%T", av)
+	}
+}
+
+// writeList writes a list of n elements, stopping early once the output is larger than limit.
+func writeList(sb *strings.Builder, n, limit int, elem func(int)) {
+	sb.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+			if limit >= 0 && sb.Len() > limit {
+				fmt.Fprintf(sb, "... (%d elements)", n)
+				break
+			}
+		}
+		elem(i)
+	}
+	sb.WriteByte(']')
+}
+
+// formatString quotes s, truncating it to limit bytes.
+func formatString(s string, limit int) string {
+	if limit < 0 || len(s) <= limit {
+		return strconv.Quote(s)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", strconv.Quote(s[:cut]), len(s))
+}
+
+// formatName quotes a map key for error messages. Unlike values, keys are included when limit is zero.
+func formatName(name string, limit int) string {
+	if limit == 0 {
+		return strconv.Quote(name)
+	}
+	return formatString(name, limit)
+}
+
+// formatBinary encodes b as base64, or summarizes it if it's larger than limit bytes.
+func formatBinary(b []byte, limit int) string {
+	if limit < 0 || len(b) <= limit {
+		return "b64:" + base64.StdEncoding.EncodeToString(b)
+	}
+	return fmt.Sprintf("<binary: %d bytes>", len(b))
+}
@@ -0,0 +1,77 @@
+package dynamo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestFormatAV(t *testing.T) {
+	tests := []struct {
+		av    types.AttributeValue
+		limit int
+		want  string
+	}{
+		{&types.AttributeValueMemberS{Value: "hello"}, 10, `"hello"`},
+		{&types.AttributeValueMemberS{Value: "hello world"}, 5, `"hello"... (11 bytes)`},
+		{&types.AttributeValueMemberS{Value: "héllo"}, 2, `"h"... (6 bytes)`},
+		{&types.AttributeValueMemberS{Value: "hello world"}, -1, `"hello world"`},
+		{&types.AttributeValueMemberN{Value: "123"}, 1, `123`},
+		{&types.AttributeValueMemberB{Value: []byte("hi")}, 10, `b64:aGk=`},
+		{&types.AttributeValueMemberB{Value: make([]byte, 2048)}, 1024, `<binary: 2048 bytes>`},
+		{&types.AttributeValueMemberSS{Value: []string{"a", "bbbbbbbbbbbb"}}, 8, `["a", "bbbbbbbb"... (12 bytes)]`},
+		{&types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberN{Value: "1"},
+			&types.AttributeValueMemberN{Value: "2"},
+			&types.AttributeValueMemberN{Value: "3"},
+		}}, 5, `[1, 2, ... (3 elements)]`},
+		{&types.AttributeValueMemberM{Value: Item{
+			"b": &types.AttributeValueMemberBOOL{Value: true},
+			"a": &types.AttributeValueMemberNULL{Value: true},
+		}}, 100, `{"a": null, "b": true}`},
+	}
+	for _, test := range tests {
+		if got := formatAV(test.av, test.limit); got != test.want {
+			t.Errorf("formatAV(%v, %d): want %s, got %s", test.av, test.limit, test.want, got)
+		}
+	}
+}
+
+func TestErrorValueLimit(t *testing.T) {
+	type widget struct {
+		ID   int
+		Size int
+	}
+	long := strings.Repeat("x", 5000)
+	item := Item{
+		"ID":   &types.AttributeValueMemberN{Value: "1"},
+		"Size": &types.AttributeValueMemberS{Value: long},
+	}
+
+	var w widget
+	err := UnmarshalItem(item, &w)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	// values are left out by default
+	if msg := err.Error(); strings.Contains(msg, "xxx") || !strings.Contains(msg, "cannot unmarshal string attribute value into type int") {
+		t.Error("value included by default:", msg)
+	}
+
+	client := &queryRecorder{items: []Item{item}}
+	db := NewFromIface(client).ErrorValueLimit(8)
+	err = db.Table("Widgets").Get("ID", 1).All(context.Background(), &[]widget{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"xxxxxxxx"... (5000 bytes)`) {
+		t.Error("value not truncated to DB limit:", msg)
+	}
+
+	err = NewFromIface(client).ErrorValueLimit(-1).Table("Widgets").Get("ID", 1).All(context.Background(), &[]widget{})
+	if err == nil || !strings.Contains(err.Error(), long) {
+		t.Error("want full value, got:", err)
+	}
+}
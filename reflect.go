@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"context"
 	"encoding"
 	"fmt"
 	"reflect"
@@ -30,19 +31,30 @@ var (
 	// time.Time
 	rtypeTime = reflect.TypeOf(time.Time{})
 
+	// Number
+	rtypeNumber = reflect.TypeOf(Number(""))
+
 	// Unmarshaler
 	rtypeUnmarshaler = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	// UnmarshalerCtx
+	rtypeUnmarshalerCtx = reflect.TypeOf((*UnmarshalerCtx)(nil)).Elem()
 	// dynamodbattribute.Unmarshaler
 	rtypeAWSUnmarshaler = reflect.TypeOf((*attributevalue.Unmarshaler)(nil)).Elem()
 	// encoding.TextUnmarshaler
 	rtypeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	// encoding.BinaryUnmarshaler
+	rtypeBinaryUnmarshaler = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
 
 	// Marshaler
 	rtypeMarshaler = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	// MarshalerCtx
+	rtypeMarshalerCtx = reflect.TypeOf((*MarshalerCtx)(nil)).Elem()
 	// attributevalue.Marshaler
 	rtypeAWSMarshaler = reflect.TypeOf((*attributevalue.Marshaler)(nil)).Elem()
 	// encoding.TextMarshaler
 	rtypeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	// encoding.BinaryMarshaler
+	rtypeBinaryMarshaler = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
 
 	// interface{ IsZero() bool } (time.Time, etc.)
 	rtypeIsZeroer = reflect.TypeOf((*isZeroer)(nil)).Elem()
@@ -129,7 +141,7 @@ func dig(rv reflect.Value, index []int) reflect.Value {
 	return rv
 }
 
-func visitFields(item map[string]types.AttributeValue, rv reflect.Value, seen map[string]struct{}, fn func(av types.AttributeValue, flags encodeFlags, v reflect.Value) error) error {
+func visitFields(item map[string]types.AttributeValue, rv reflect.Value, names *NameMapper, seen map[string]struct{}, fn func(av types.AttributeValue, flags encodeFlags, v reflect.Value) error) error {
 	for rv.Kind() == reflect.Pointer {
 		if rv.IsNil() {
 			if !rv.CanSet() {
@@ -154,7 +166,7 @@ func visitFields(item map[string]types.AttributeValue, rv reflect.Value, seen ma
 		fv := rv.Field(i)
 		isPtr := fv.Type().Kind() == reflect.Ptr
 
-		name, flags := fieldInfo(field)
+		name, flags := fieldInfo(field, names)
 		if name == "-" {
 			// skip
 			continue
@@ -175,7 +187,7 @@ func visitFields(item map[string]types.AttributeValue, rv reflect.Value, seen ma
 				continue
 			}
 
-			if err := visitFields(item, fv, seen, fn); err != nil {
+			if err := visitFields(item, fv, names, seen, fn); err != nil {
 				return err
 			}
 			continue
@@ -214,9 +226,12 @@ type structInfo struct {
 	queue []encodeKey
 }
 
-func (info *structInfo) encode(rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+func (info *structInfo) encode(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
 	item := make(Item, len(info.fields))
 	for _, field := range info.fields {
+		if field.flags&flagReadOnly != 0 {
+			continue
+		}
 		fv := dig(rv, field.index)
 		if !fv.IsValid() {
 			// TODO: encode NULL?
@@ -229,7 +244,7 @@ func (info *structInfo) encode(rv reflect.Value, flags encodeFlags) (types.Attri
 			}
 		}
 
-		av, err := field.enc(fv, field.flags)
+		av, err := field.enc(ctx, fv, field.flags)
 		if err != nil {
 			return nil, err
 		}
@@ -312,7 +327,9 @@ func (def *typedef) structInfo(rt reflect.Type, parent *structInfo) (*structInfo
 		seen:   make(map[encodeKey]struct{}),
 	}
 
-	collectTypes(rt, info, nil)
+	if err := collectTypes(rt, info, def.names, nil); err != nil {
+		return nil, err
+	}
 
 	for _, key := range info.queue {
 		fn, err := def.encodeType(key.rt, key.flags, info)
@@ -338,7 +355,7 @@ func (def *typedef) structInfo(rt reflect.Type, parent *structInfo) (*structInfo
 	return info, nil
 }
 
-func collectTypes(rt reflect.Type, info *structInfo, trail []int) *structInfo {
+func collectTypes(rt reflect.Type, info *structInfo, names *NameMapper, trail []int) error {
 	for rt.Kind() == reflect.Pointer {
 		rt = rt.Elem()
 	}
@@ -352,11 +369,14 @@ func collectTypes(rt reflect.Type, info *structInfo, trail []int) *structInfo {
 		ft := field.Type
 		isPtr := ft.Kind() == reflect.Ptr
 
-		name, flags := fieldInfo(field)
+		name, flags := fieldInfo(field, names)
 		if name == "-" {
 			// skip
 			continue
 		}
+		if err := checkFieldTag(field); err != nil {
+			return err
+		}
 
 		key := encodeKey{
 			rt:    ft,
@@ -383,7 +403,9 @@ func collectTypes(rt reflect.Type, info *structInfo, trail []int) *structInfo {
 
 		// embed anonymous structs, they could be pointers so test that too
 		if (ft.Kind() == reflect.Struct || isPtr && ft.Elem().Kind() == reflect.Struct) && field.Anonymous {
-			collectTypes(ft, info, idx)
+			if err := collectTypes(ft, info, names, idx); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -395,10 +417,10 @@ func collectTypes(rt reflect.Type, info *structInfo, trail []int) *structInfo {
 		}
 		info.queue = append(info.queue, key)
 	}
-	return info
+	return nil
 }
 
-func visitTypeFields(rt reflect.Type, seen map[string]struct{}, trail []int, fn func(name string, index []int, flags encodeFlags, vt reflect.Type) error) error {
+func visitTypeFields(rt reflect.Type, names *NameMapper, seen map[string]struct{}, trail []int, fn func(name string, index []int, flags encodeFlags, vt reflect.Type) error) error {
 	for rt.Kind() == reflect.Pointer {
 		rt = rt.Elem()
 	}
@@ -416,7 +438,7 @@ func visitTypeFields(rt reflect.Type, seen map[string]struct{}, trail []int, fn
 		ft := field.Type
 		isPtr := ft.Kind() == reflect.Ptr
 
-		name, flags := fieldInfo(field)
+		name, flags := fieldInfo(field, names)
 		if name == "-" {
 			// skip
 			continue
@@ -432,7 +454,7 @@ func visitTypeFields(rt reflect.Type, seen map[string]struct{}, trail []int, fn
 			if len(trail) > 0 {
 				index = append(trail, field.Index...)
 			}
-			if err := visitTypeFields(ft, seen, index, fn); err != nil {
+			if err := visitTypeFields(ft, names, seen, index, fn); err != nil {
 				return err
 			}
 			continue
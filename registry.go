@@ -0,0 +1,146 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// registryTypeAttr is the attribute that holds the registered name of a concrete type.
+	registryTypeAttr = "_type"
+	// registryValueAttr is the attribute that holds the encoded concrete value.
+	registryValueAttr = "_value"
+)
+
+var typeRegistry = struct {
+	mu     sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// RegisterType registers the concrete type of v under the given name,
+// allowing it to be marshaled into and unmarshaled from non-empty interface fields.
+// Values in such fields are encoded as a map of the type's name (in the "_type" attribute)
+// and the encoded concrete value (in the "_value" attribute).
+//
+// Types are registered exactly as given, so registering T and *T are distinct.
+// RegisterType panics if name or the type of v is already registered.
+func RegisterType(name string, v any) {
+	rt := reflect.TypeOf(v)
+	if rt == nil {
+		panic("dynamo: RegisterType: nil value")
+	}
+
+	typeRegistry.mu.Lock()
+	defer typeRegistry.mu.Unlock()
+
+	if prev, ok := typeRegistry.byName[name]; ok {
+		panic(fmt.Sprintf("dynamo: RegisterType: name %q already registered for type %s", name, prev))
+	}
+	if prev, ok := typeRegistry.byType[rt]; ok {
+		panic(fmt.Sprintf("dynamo: RegisterType: type %s already registered as %q", rt, prev))
+	}
+	typeRegistry.byName[name] = rt
+	typeRegistry.byType[rt] = name
+}
+
+// registeredEncoders caches the encoders of registered concrete types.
+var registeredEncoders sync.Map // registeredKey → encodeFunc
+
+type registeredKey struct {
+	encodeKey
+	names *NameMapper
+}
+
+func registeredName(rt reflect.Type) (string, bool) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+	name, ok := typeRegistry.byType[rt]
+	return name, ok
+}
+
+func registeredType(name string) (reflect.Type, bool) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+	rt, ok := typeRegistry.byName[name]
+	return rt, ok
+}
+
+// encodeRegistered encodes a non-empty interface using the type registry.
+func (def *typedef) encodeRegistered(ctx context.Context, rv reflect.Value, flags encodeFlags) (types.AttributeValue, error) {
+	if !rv.CanInterface() || rv.IsNil() {
+		if flags&flagNull != 0 {
+			return nullAV, nil
+		}
+		return nil, nil
+	}
+	concrete := rv.Elem()
+	name, ok := registeredName(concrete.Type())
+	if !ok {
+		return nil, fmt.Errorf("dynamo: marshal: type %s (in interface %s) is not registered, see RegisterType", concrete.Type(), rv.Type())
+	}
+	enc, err := def.registeredEncoder(concrete.Type(), flags)
+	if err != nil {
+		return nil, err
+	}
+	av, err := enc(ctx, concrete, flags)
+	if err != nil {
+		return nil, err
+	}
+	item := Item{
+		registryTypeAttr: &types.AttributeValueMemberS{Value: name},
+	}
+	if av != nil {
+		item[registryValueAttr] = av
+	}
+	return &types.AttributeValueMemberM{Value: item}, nil
+}
+
+func (def *typedef) registeredEncoder(rt reflect.Type, flags encodeFlags) (encodeFunc, error) {
+	key := registeredKey{encodeKey: encodeKey{rt: rt, flags: flags}, names: def.names}
+	if enc, ok := registeredEncoders.Load(key); ok {
+		return enc.(encodeFunc), nil
+	}
+	enc, err := def.encodeType(rt, flags, nil)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := registeredEncoders.LoadOrStore(key, enc)
+	return cached.(encodeFunc), nil
+}
+
+// decodeRegistered decodes a non-empty interface using the type registry.
+func decodeRegistered(ctx context.Context, plan *typedef, flags encodeFlags, av types.AttributeValue, rv reflect.Value) error {
+	item := av.(*types.AttributeValueMemberM).Value
+	tag, ok := item[registryTypeAttr].(*types.AttributeValueMemberS)
+	if !ok {
+		return fmt.Errorf("dynamo: unmarshal into %s: missing %s attribute", rv.Type(), registryTypeAttr)
+	}
+	rt, ok := registeredType(tag.Value)
+	if !ok {
+		return fmt.Errorf("dynamo: unmarshal into %s: type %q is not registered, see RegisterType", rv.Type(), tag.Value)
+	}
+	if !rt.AssignableTo(rv.Type()) {
+		return fmt.Errorf("dynamo: unmarshal into %s: registered type %s (%q) does not implement it", rv.Type(), rt, tag.Value)
+	}
+
+	concrete := reflect.New(rt)
+	if value, ok := item[registryValueAttr]; ok {
+		cdef, err := typedefOf(rt, plan.names)
+		if err != nil {
+			return err
+		}
+		if err := cdef.decodeAttr(ctx, flags, value, concrete.Elem()); err != nil {
+			return err
+		}
+	}
+	rv.Set(concrete.Elem())
+	return nil
+}
@@ -0,0 +1,91 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type square struct {
+	Side float64
+}
+
+func (s square) Area() float64 { return s.Side * s.Side }
+
+type circle struct {
+	Radius float64
+}
+
+func (c *circle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+func init() {
+	RegisterType("square", square{})
+	RegisterType("circle", &circle{})
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	type drawing struct {
+		Main   shape
+		Shapes []shape
+		None   shape
+	}
+
+	in := drawing{
+		Main:   square{Side: 2},
+		Shapes: []shape{&circle{Radius: 1}, square{Side: 3}},
+	}
+	want := Item{
+		"Main": &types.AttributeValueMemberM{Value: Item{
+			"_type":  &types.AttributeValueMemberS{Value: "square"},
+			"_value": &types.AttributeValueMemberM{Value: Item{"Side": &types.AttributeValueMemberN{Value: "2"}}},
+		}},
+		"Shapes": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberM{Value: Item{
+				"_type":  &types.AttributeValueMemberS{Value: "circle"},
+				"_value": &types.AttributeValueMemberM{Value: Item{"Radius": &types.AttributeValueMemberN{Value: "1"}}},
+			}},
+			&types.AttributeValueMemberM{Value: Item{
+				"_type":  &types.AttributeValueMemberS{Value: "square"},
+				"_value": &types.AttributeValueMemberM{Value: Item{"Side": &types.AttributeValueMemberN{Value: "3"}}},
+			}},
+		}},
+	}
+
+	item, err := MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("bad marshal. want: %#v, got: %#v", want, item)
+	}
+
+	var out drawing
+	if err := UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("bad unmarshal. want: %#v, got: %#v", in, out)
+	}
+
+	if _, ok := registeredEncoders.Load(registeredKey{encodeKey: encodeKey{rt: reflect.TypeOf(square{})}}); !ok {
+		t.Error("encoder for registered type not cached")
+	}
+
+	t.Run("unregistered", func(t *testing.T) {
+		type triangle struct{ square }
+		if _, err := MarshalItem(drawing{Main: triangle{}}); err == nil {
+			t.Error("expected error for unregistered type")
+		}
+		bad := Item{"Main": &types.AttributeValueMemberM{Value: Item{
+			"_type": &types.AttributeValueMemberS{Value: "triangle"},
+		}}}
+		if err := UnmarshalItem(bad, &out); err == nil {
+			t.Error("expected error for unregistered type name")
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 )
 
 // TODO: delete this
@@ -41,3 +42,26 @@ func shouldRetryTx(err error) aws.Ternary {
 	}
 	return aws.UnknownTernary
 }
+
+// isThrottle reports whether err is the result of throttling.
+func isThrottle(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pte *types.ProvisionedThroughputExceededException
+	if errors.As(err, &pte) {
+		return true
+	}
+	var rle *types.RequestLimitExceeded
+	if errors.As(err, &rle) {
+		return true
+	}
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		switch ae.ErrorCode() {
+		case "ThrottlingException", "ProvisionedThroughputExceededException", "RequestLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
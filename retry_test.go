@@ -6,7 +6,10 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 func TestRetryCustom(t *testing.T) {
@@ -33,3 +36,102 @@ func TestRetryCustom(t *testing.T) {
 		t.Error("wrong number of runs. want:", want, "got:", runs)
 	}
 }
+
+// throttledClient throttles all strongly consistent reads,
+// or only the first n of them if recoverAfter is set.
+type throttledClient struct {
+	dynamodbiface.DynamoDBAPI
+	consistent, eventual int
+	recoverAfter         int
+}
+
+func (c *throttledClient) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if in.ConsistentRead != nil && *in.ConsistentRead {
+		c.consistent++
+		if c.recoverAfter == 0 || c.consistent <= c.recoverAfter {
+			return nil, &types.ProvisionedThroughputExceededException{}
+		}
+		return &dynamodb.GetItemOutput{Item: Item{"ID": &types.AttributeValueMemberN{Value: "1"}}}, nil
+	}
+	c.eventual++
+	return &dynamodb.GetItemOutput{Item: Item{"ID": &types.AttributeValueMemberN{Value: "1"}}}, nil
+}
+
+func TestConsistentFallback(t *testing.T) {
+	client := &throttledClient{}
+	table := NewFromIface(client).Table("Throttled")
+
+	var got struct{ ID int }
+	var served bool
+	if err := table.Get("ID", 1).ConsistentFallback(&served).One(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 1 {
+		t.Error("bad result:", got)
+	}
+	if served {
+		t.Error("expected eventually consistent read to be reported")
+	}
+	if client.consistent != 1 || client.eventual != 1 {
+		t.Error("bad request count. consistent:", client.consistent, "eventual:", client.eventual)
+	}
+
+	// without fallback, the error is returned as-is
+	err := table.Get("ID", 1).Consistent(true).One(context.Background(), &got)
+	if !isThrottle(err) {
+		t.Error("expected throttling error, got:", err)
+	}
+}
+
+func TestOneOrOlder(t *testing.T) {
+	ctx := context.Background()
+	var got struct{ ID int }
+
+	t.Run("fallback", func(t *testing.T) {
+		client := &throttledClient{}
+		stale, err := NewFromIface(client).Table("Throttled").Get("ID", 1).FallbackAfter(3).OneOrOlder(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stale {
+			t.Error("expected stale result")
+		}
+		if client.consistent != 3 || client.eventual != 1 {
+			t.Error("bad request count. consistent:", client.consistent, "eventual:", client.eventual)
+		}
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		client := &throttledClient{recoverAfter: 1}
+		stale, err := NewFromIface(client).Table("Throttled").Get("ID", 1).FallbackAfter(2).OneOrOlder(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stale {
+			t.Error("expected fresh result")
+		}
+		if client.consistent != 2 || client.eventual != 0 {
+			t.Error("bad request count. consistent:", client.consistent, "eventual:", client.eventual)
+		}
+	})
+
+	t.Run("query unchanged", func(t *testing.T) {
+		client := &throttledClient{}
+		var served bool
+		q := NewFromIface(client).Table("Throttled").Get("ID", 1).ConsistentFallback(&served).FallbackAfter(2)
+		served = true
+		if _, err := q.OneOrOlder(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !served {
+			t.Error("OneOrOlder overwrote the query's served pointer")
+		}
+		plain := NewFromIface(client).Table("Throttled").Get("ID", 1)
+		if _, err := plain.OneOrOlder(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		if plain.consistent || plain.served != nil {
+			t.Error("OneOrOlder changed the query's consistency")
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Save writes item to this table, sending only the changes made since old, its previously loaded state.
+// Attributes that differ from old are set and attributes that are no longer present are removed,
+// with a single UpdateItem request. If nothing changed, no request is made.
+// Fields with the readonly option are never written, and the primary key can't be changed.
+// If old is nil, item is written in full with PutItem instead, as in [Table.Put].
+//
+// The table's primary key is determined by its description, which is cached after the first DescribeTable call.
+// Save does not check that the item in the table still matches old; to guard against concurrent changes,
+// consider using a version attribute with [Table.Update] and [Update.If] instead.
+//
+//	var user User
+//	err := table.Get("ID", id).One(ctx, &user)
+//	// ...
+//	old := user
+//	user.Name = "Bob"
+//	err = table.Save(ctx, old, user)
+func (table Table) Save(ctx context.Context, old, item interface{}) error {
+	if old == nil || isNilValue(reflect.ValueOf(old)) {
+		return table.Put(item).Run(ctx)
+	}
+	u, err := table.saveUpdate(ctx, old, item)
+	if err != nil || u == nil {
+		return err
+	}
+	return u.Run(ctx)
+}
+
+// saveUpdate returns an update of the differences between old and item, or nil if there are none.
+func (table Table) saveUpdate(ctx context.Context, old, item interface{}) (*Update, error) {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codec := table.db.codecContext(ctx)
+	before, err := marshalItemContext(codec, old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := marshalItemContext(codec, item)
+	if err != nil {
+		return nil, err
+	}
+
+	u := table.Update(desc.HashKey, after[desc.HashKey])
+	keys := []string{desc.HashKey}
+	if desc.RangeKey != "" {
+		u.Range(desc.RangeKey, after[desc.RangeKey])
+		keys = append(keys, desc.RangeKey)
+	}
+	for _, key := range keys {
+		if after[key] == nil {
+			return nil, fmt.Errorf("dynamo: save: item is missing primary key attribute %q", key)
+		}
+		if !sameValue(before[key], after[key]) {
+			return nil, fmt.Errorf("dynamo: save: primary key attribute %q changed; use Put and Delete instead", key)
+		}
+		delete(before, key)
+		delete(after, key)
+	}
+
+	changed := false
+	for _, name := range sortedNames(after) {
+		if av, ok := before[name]; ok && sameValue(av, after[name]) {
+			continue
+		}
+		u.SetExpr("$ = ?", name, after[name])
+		changed = true
+	}
+	for _, name := range sortedNames(before) {
+		if _, ok := after[name]; !ok {
+			u.RemoveExpr("$", name)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, u.err
+	}
+	return u, u.err
+}
+
+// sameValue reports whether a and b hold the same data,
+// ignoring the order of set members and the formatting of numbers.
+func sameValue(a, b types.AttributeValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ha, hb := sha256.New(), sha256.New()
+	canonicalWriter{h: ha}.av(a)
+	canonicalWriter{h: hb}.av(b)
+	return string(ha.Sum(nil)) == string(hb.Sum(nil))
+}
+
+func sortedNames(item Item) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
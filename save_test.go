@@ -0,0 +1,94 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// saveClient is a table keyed by UserID and Time (see keysClient) that records writes.
+type saveClient struct {
+	recordingClient
+	puts []*dynamodb.PutItemInput
+}
+
+func (c *saveClient) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return new(keysClient).DescribeTable(ctx, in, optFns...)
+}
+
+func (c *saveClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.puts = append(c.puts, in)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestSave(t *testing.T) {
+	type post struct {
+		UserID  int
+		Time    time.Time
+		Title   string
+		Body    string
+		Tags    []string `dynamo:",set"`
+		Views   int      `dynamo:",readonly"`
+		Deleted bool     `dynamo:",omitempty"`
+	}
+
+	ctx := context.Background()
+	client := new(saveClient)
+	table := NewFromIface(client).Table("Posts")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := post{UserID: 1, Time: now, Title: "Hello", Body: "...", Tags: []string{"a", "b"}, Views: 10, Deleted: true}
+
+	t.Run("put", func(t *testing.T) {
+		if err := table.Save(ctx, nil, old); err != nil {
+			t.Fatal(err)
+		}
+		if len(client.puts) != 1 {
+			t.Fatal("want 1 put, got", len(client.puts))
+		}
+		if _, ok := client.puts[0].Item["Views"]; ok {
+			t.Error("readonly field was written")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		changed := old
+		changed.Title = "Hello, world"
+		changed.Tags = []string{"b", "a"} // same set
+		changed.Views = 99
+		changed.Deleted = false
+		if err := table.Save(ctx, old, &changed); err != nil {
+			t.Fatal(err)
+		}
+		if len(client.updates) != 1 {
+			t.Fatal("want 1 update, got", len(client.updates))
+		}
+		in := client.updates[0]
+		want := "SET #s" + encodeName("Title") + " = :v0 REMOVE #s" + encodeName("Deleted")
+		if got := *in.UpdateExpression; got != want {
+			t.Errorf("bad update expression. want: %s got: %s", want, got)
+		}
+		if len(in.Key) != 2 || in.Key["UserID"] == nil || in.Key["Time"] == nil {
+			t.Error("bad key:", in.Key)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		client.updates = nil
+		if err := table.Save(ctx, &old, old); err != nil {
+			t.Fatal(err)
+		}
+		if len(client.updates) != 0 {
+			t.Error("unexpected update:", client.updates)
+		}
+	})
+
+	t.Run("key changed", func(t *testing.T) {
+		changed := old
+		changed.UserID = 2
+		if err := table.Save(ctx, old, changed); err == nil {
+			t.Error("expected error for changed key")
+		}
+	})
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -21,26 +24,45 @@ type Scan struct {
 	index    string
 
 	projection  string
+	keysOnly    bool
 	filters     []string
 	consistent  bool
 	limit       int
 	searchLimit int32
 	reqLimit    int
+	exactly     bool
+	restart     bool
+	modify      func(*dynamodb.ScanInput)
+	optFns      []func(*dynamodb.Options)
+	prefer      []string
+	onFullScan  func(reason string)
+	distinct    string
+	pinSchema   bool
+	throttle    *pacer
+	hedger      *Hedger
+	onDrift     func(context.Context, *SchemaDrift)
 
 	segment       int32
 	totalSegments int32
+	// segment range [rangeStart, rangeEnd), when rangeEnd > 0
+	rangeStart int32
+	rangeEnd   int32
 
 	subber
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	metrics *ParallelScanMetrics
 }
 
 // Scan creates a new request to scan this table.
 func (table Table) Scan() *Scan {
-	return &Scan{
-		table: table,
+	s := &Scan{
+		table:  table,
+		subber: subber{db: table.db},
 	}
+	table.interceptScan(s)
+	return s
 }
 
 // StartFrom makes this scan continue from a previous one.
@@ -64,13 +86,58 @@ func (s *Scan) Index(name string) *Scan {
 func (s *Scan) Segment(segment int, totalSegments int) *Scan {
 	s.segment = int32(segment)
 	s.totalSegments = int32(totalSegments)
+	s.rangeStart, s.rangeEnd = 0, 0
 	if totalSegments > math.MaxInt32 {
 		s.setError(fmt.Errorf("dynamo: total segments in Scan must be less than or equal to %d (got %d)", math.MaxInt32, totalSegments))
 	}
 	return s
 }
 
+// SegmentRange limits this scan to the segments from start (inclusive) to end (exclusive) out of totalSegments.
+// This allows for distributing a scan across multiple workers, each running a contiguous range of segments.
+// For example, worker 3 (counting from zero) of 8 could handle segments 96 to 127 of 256 with SegmentRange(96, 128, 256).
+//
+// Parallel methods such as [Scan.IterParallel] and [Scan.AllParallel] will run every segment in the range in parallel,
+// ignoring their segments argument. Their LastEvaluatedKeys are ordered by segment, starting with start,
+// and can be passed to [Scan.IterParallelStartFrom] and [Scan.AllParallelStartFrom] to continue scanning the same range.
+// Other methods will return an error for ranges of more than one segment.
+// totalSegments must be less than MaxInt32 due to API limits.
+func (s *Scan) SegmentRange(start, end, totalSegments int) *Scan {
+	switch {
+	case totalSegments > math.MaxInt32:
+		s.setError(fmt.Errorf("dynamo: total segments in Scan must be less than or equal to %d (got %d)", math.MaxInt32, totalSegments))
+	case start < 0 || start >= end || end > totalSegments:
+		s.setError(fmt.Errorf("dynamo: invalid segment range [%d, %d) of %d", start, end, totalSegments))
+	default:
+		s.segment = int32(start)
+		s.totalSegments = int32(totalSegments)
+		s.rangeStart = int32(start)
+		s.rangeEnd = int32(end)
+	}
+	return s
+}
+
+// sequentialErr returns this scan's error for non-parallel methods.
+func (s *Scan) sequentialErr() error {
+	if s.err == nil && s.rangeEnd-s.rangeStart > 1 {
+		return fmt.Errorf("dynamo: scan of segment range [%d, %d) requires a parallel method such as AllParallel", s.rangeStart, s.rangeEnd)
+	}
+	return s.err
+}
+
 func (s *Scan) newSegments(segments int, leks []PagingKey) []*scanIter {
+	first, total := 0, segments
+	if s.rangeEnd > 0 {
+		first, total = int(s.rangeStart), int(s.totalSegments)
+		if leks == nil {
+			segments = int(s.rangeEnd - s.rangeStart)
+		} else if len(leks) != int(s.rangeEnd-s.rangeStart) {
+			s.setError(fmt.Errorf("dynamo: got %d keys for scan of segment range [%d, %d)", len(leks), s.rangeStart, s.rangeEnd))
+		}
+	}
+	s.metrics.reset(total)
+	seen := newDistinctSet(s.distinct)
+	pin := newSchemaPin(s)
 	iters := make([]*scanIter, segments)
 	lekLen := len(leks)
 	for i := int(0); i < segments; i++ {
@@ -79,17 +146,23 @@ func (s *Scan) newSegments(segments int, leks []PagingKey) []*scanIter {
 		if s.cc != nil {
 			cc = new(ConsumedCapacity)
 		}
-		seg.Segment(i, segments).ConsumedCapacity(cc)
+		seg.Segment(first+i, total).ConsumedCapacity(cc)
 		if i < lekLen {
 			lek := leks[i]
 			if lek == nil {
 				continue
 			}
-			seg.StartFrom(leks[i])
+			if len(lek) == 0 {
+				// segment was stopped before it returned anything, start from the beginning
+				lek = nil
+			}
+			seg.StartFrom(lek)
 		} else {
 			seg.StartFrom(nil)
 		}
 		iters[i] = seg.Iter().(*scanIter)
+		iters[i].seen = seen
+		iters[i].pin = pin
 	}
 	return iters
 }
@@ -102,6 +175,21 @@ func (s *Scan) Project(paths ...string) *Scan {
 	return s
 }
 
+// KeysOnly limits the result attributes to the primary keys of the table (and index, if using [Scan.Index]).
+// The key names are determined by the table's description, which is cached after the first DescribeTable call.
+// KeysOnly overrides Project.
+//
+// Results can be unmarshaled into user structs as usual, or into [Keys] (or a slice of them, for [Scan.All]),
+// in which case each Keys will hold the table's hash key and range key values.
+// This is useful for jobs that need to visit every item, such as deleting the contents of a table:
+//
+//	var keys []dynamo.Keys
+//	err := table.Scan().KeysOnly().All(ctx, &keys)
+func (s *Scan) KeysOnly() *Scan {
+	s.keysOnly = true
+	return s
+}
+
 // Filter takes an expression that all results will be evaluated against.
 // Use single quotes to specificy reserved names inline (like 'Count').
 // Use the placeholder ? within the expression to substitute values, and use $ for names.
@@ -114,6 +202,33 @@ func (s *Scan) Filter(expr string, args ...interface{}) *Scan {
 	return s
 }
 
+// OrFilter combines all filters added so far with the given filter expression using OR.
+// Filters added afterwards are combined with the result using AND, so that filters read from left to right:
+//
+//	// (A AND B) OR C
+//	s.Filter(A).Filter(B).OrFilter(C)
+//	// (A OR B) AND C
+//	s.Filter(A).OrFilter(B).Filter(C)
+//
+// If there are no other filters, OrFilter is the same as [Scan.Filter].
+// The expression is specified as in [Scan.Filter].
+func (s *Scan) OrFilter(expr string, args ...interface{}) *Scan {
+	expr, err := s.subExprN(expr, args...)
+	s.setError(err)
+	s.filters = orFilters(s.filters, wrapExpr(expr))
+	return s
+}
+
+// NotFilter takes a filter expression that items must not match.
+// It is combined with other filters using AND.
+// The expression is specified as in [Scan.Filter].
+func (s *Scan) NotFilter(expr string, args ...interface{}) *Scan {
+	expr, err := s.subExprN(expr, args...)
+	s.setError(err)
+	s.filters = append(s.filters, notExpr(expr))
+	return s
+}
+
 // Consistent will, if on is true, make this scan use a strongly consistent read.
 // Scans are eventually consistent by default.
 // Strongly consistent reads are more resource-heavy than eventually consistent reads.
@@ -123,15 +238,26 @@ func (s *Scan) Consistent(on bool) *Scan {
 }
 
 // Limit specifies the maximum amount of results to return.
+// Results are counted across pages, so exactly limit results are returned
+// unless fewer match or another limit (such as [Scan.RequestLimit]) is reached first.
 func (s *Scan) Limit(limit int) *Scan {
 	s.limit = limit
 	return s
 }
 
+// Exactly, when on, keeps issuing requests until [Scan.Limit] results have been collected
+// or every result has been examined, even if [Scan.SearchLimit] is set.
+// SearchLimit then determines the size of each page instead of implying RequestLimit(1).
+// Use [Scan.RequestLimit] to bound the number of requests made.
+func (s *Scan) Exactly(on bool) *Scan {
+	s.exactly = on
+	return s
+}
+
 // SearchLimit specifies the maximum amount of results to evaluate.
 // Use this along with StartFrom and Iter's LastEvaluatedKey to split up results.
 // Note that DynamoDB limits result sets to 1MB.
-// SearchLimit > 0 implies RequestLimit(1).
+// SearchLimit > 0 implies RequestLimit(1), unless [Scan.Exactly] is on.
 func (s *Scan) SearchLimit(limit int) *Scan {
 	s.searchLimit = int32(min(limit, math.MaxInt32))
 	return s
@@ -144,18 +270,51 @@ func (s *Scan) RequestLimit(limit int) *Scan {
 	return s
 }
 
+// RestartOnBadKey makes this scan start over from the beginning if DynamoDB rejects the paging key
+// given to [Scan.StartFrom] (or [Scan.IterParallelStartFrom] and friends), instead of returning [ErrBadPagingKey].
+// This is useful for long-lived resumable jobs whose saved keys can go stale
+// when the table or index's key schema changes.
+func (s *Scan) RestartOnBadKey(on bool) *Scan {
+	s.restart = on
+	return s
+}
+
+// ModifyInput sets a function that is called with the input of each Scan request just before it is first sent,
+// allowing for API parameters that this library does not support yet.
+// Changes that conflict with this scan's other settings may lead to unexpected results.
+// For parallel scans, fn is called for every segment's input and must be safe for concurrent use.
+func (s *Scan) ModifyInput(fn func(*dynamodb.ScanInput)) *Scan {
+	s.modify = fn
+	return s
+}
+
+// RequestOptions sets options that are applied to each request made by this scan, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// For parallel scans, it applies to every segment's requests.
+func (s *Scan) RequestOptions(optFns ...func(*dynamodb.Options)) *Scan {
+	s.optFns = optFns
+	return s
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (s *Scan) ConsumedCapacity(cc *ConsumedCapacity) *Scan {
 	s.cc = cc
 	return s
 }
 
+// Metrics will record per-segment statistics for this scan in m.
+// For parallel scans (such as [Scan.IterParallel]), m is reset when the scan begins.
+func (s *Scan) Metrics(m *ParallelScanMetrics) *Scan {
+	s.metrics = m
+	return s
+}
+
 // Iter returns a results iterator for this request.
 func (s *Scan) Iter() PagingIter {
 	return &scanIter{
 		scan:      s,
-		unmarshal: unmarshalItem,
-		err:       s.err,
+		unmarshal: s.unmarshalKeys(unmarshalItem),
+		err:       s.sequentialErr(),
 	}
 }
 
@@ -163,8 +322,9 @@ func (s *Scan) Iter() PagingIter {
 // Canceling the context given here will cancel the processing of all segments.
 func (s *Scan) IterParallel(ctx context.Context, segments int) ParallelIter {
 	iters := s.newSegments(segments, nil)
-	ps := newParallelScan(iters, s.cc, false, unmarshalItem)
-	go ps.run(ctx)
+	ps := newParallelScan(iters, s.cc, false, s.unmarshalKeys(unmarshalItem))
+	ps.setError(s.err)
+	ps.start(ctx)
 	return ps
 }
 
@@ -172,8 +332,9 @@ func (s *Scan) IterParallel(ctx context.Context, segments int) ParallelIter {
 // Canceling the context given here will cancel the processing of all segments.
 func (s *Scan) IterParallelStartFrom(ctx context.Context, keys []PagingKey) ParallelIter {
 	iters := s.newSegments(len(keys), keys)
-	ps := newParallelScan(iters, s.cc, false, unmarshalItem)
-	go ps.run(ctx)
+	ps := newParallelScan(iters, s.cc, false, s.unmarshalKeys(unmarshalItem))
+	ps.setError(s.err)
+	ps.start(ctx)
 	return ps
 }
 
@@ -181,8 +342,22 @@ func (s *Scan) IterParallelStartFrom(ctx context.Context, keys []PagingKey) Para
 func (s *Scan) All(ctx context.Context, out interface{}) error {
 	itr := &scanIter{
 		scan:      s,
-		unmarshal: unmarshalAppendTo(out),
-		err:       s.err,
+		unmarshal: s.unmarshalKeys(unmarshalAppendTo(out)),
+		err:       s.sequentialErr(),
+	}
+	for itr.Next(ctx, out) {
+	}
+	return itr.Err()
+}
+
+// AllWithRaw executes this request and unmarshals all results to out, which must be a pointer to a slice.
+// The raw items are also appended to raw, in the same order as out.
+// This is useful when you need the original attributes as well, such as for checksums or passing them through.
+func (s *Scan) AllWithRaw(ctx context.Context, out interface{}, raw *[]Item) error {
+	itr := &scanIter{
+		scan:      s,
+		unmarshal: s.unmarshalKeys(unmarshalAppendRaw(out, raw)),
+		err:       s.sequentialErr(),
 	}
 	for itr.Next(ctx, out) {
 	}
@@ -194,8 +369,8 @@ func (s *Scan) All(ctx context.Context, out interface{}) error {
 func (s *Scan) AllWithLastEvaluatedKey(ctx context.Context, out interface{}) (PagingKey, error) {
 	itr := &scanIter{
 		scan:      s,
-		unmarshal: unmarshalAppendTo(out),
-		err:       s.err,
+		unmarshal: s.unmarshalKeys(unmarshalAppendTo(out)),
+		err:       s.sequentialErr(),
 	}
 	for itr.Next(ctx, out) {
 	}
@@ -206,8 +381,9 @@ func (s *Scan) AllWithLastEvaluatedKey(ctx context.Context, out interface{}) (Pa
 // AllParallel executes this request by running the given number of segments in parallel, then unmarshaling all results to out, which must be a pointer to a slice.
 func (s *Scan) AllParallel(ctx context.Context, segments int, out interface{}) error {
 	iters := s.newSegments(segments, nil)
-	ps := newParallelScan(iters, s.cc, true, unmarshalAppendTo(out))
-	go ps.run(ctx)
+	ps := newParallelScan(iters, s.cc, true, s.unmarshalKeys(unmarshalAppendTo(out)))
+	ps.setError(s.err)
+	ps.start(ctx)
 	for ps.Next(ctx, out) {
 	}
 	return ps.Err()
@@ -217,8 +393,9 @@ func (s *Scan) AllParallel(ctx context.Context, segments int, out interface{}) e
 // Returns a slice of LastEvalutedKeys that can be used to continue the query later.
 func (s *Scan) AllParallelWithLastEvaluatedKeys(ctx context.Context, segments int, out interface{}) ([]PagingKey, error) {
 	iters := s.newSegments(segments, nil)
-	ps := newParallelScan(iters, s.cc, false, unmarshalAppendTo(out))
-	go ps.run(ctx)
+	ps := newParallelScan(iters, s.cc, false, s.unmarshalKeys(unmarshalAppendTo(out)))
+	ps.setError(s.err)
+	ps.start(ctx)
 	for ps.Next(ctx, out) {
 	}
 	leks, err := ps.LastEvaluatedKeys(ctx)
@@ -229,8 +406,9 @@ func (s *Scan) AllParallelWithLastEvaluatedKeys(ctx context.Context, segments in
 // Returns a new slice of LastEvaluatedKeys after the scan finishes.
 func (s *Scan) AllParallelStartFrom(ctx context.Context, keys []PagingKey, out interface{}) ([]PagingKey, error) {
 	iters := s.newSegments(len(keys), keys)
-	ps := newParallelScan(iters, s.cc, false, unmarshalAppendTo(out))
-	go ps.run(ctx)
+	ps := newParallelScan(iters, s.cc, false, s.unmarshalKeys(unmarshalAppendTo(out)))
+	ps.setError(s.err)
+	ps.start(ctx)
 	for ps.Next(ctx, out) {
 	}
 	leks, err := ps.LastEvaluatedKeys(ctx)
@@ -241,41 +419,100 @@ func (s *Scan) AllParallelStartFrom(ctx context.Context, keys []PagingKey, out i
 // It takes into account the filter, limit, search limit, and all other parameters given.
 // It may return a higher count than the limits.
 func (s *Scan) Count(ctx context.Context) (int, error) {
-	if s.err != nil {
-		return 0, s.err
+	count, _, err := s.count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountWithLastEvaluatedKey is like [Scan.Count], but also returns a PagingKey you can use with [Scan.StartFrom]
+// to resume counting where it left off, or nil if the end of the table was reached.
+// Combined with [Scan.SearchLimit] or [Scan.RequestLimit], this allows for very large counts to be split up
+// and checkpointed across multiple invocations.
+// If counting is interrupted by an error, such as ctx's deadline passing,
+// the count so far is returned along with the key to resume from and the error.
+// When a limit is combined with a filter, the count is capped at the limit
+// but the returned key may be past the last item counted.
+func (s *Scan) CountWithLastEvaluatedKey(ctx context.Context) (int, PagingKey, error) {
+	return s.count(ctx)
+}
+
+func (s *Scan) count(ctx context.Context) (int, PagingKey, error) {
+	if err := s.sequentialErr(); err != nil {
+		return 0, s.startKey, err
 	}
 	var count int
 	var scanned int32
 	input := s.scanInput()
 	input.Select = types.SelectCount
+	var err error
+	if input.ExpressionAttributeValues, err = s.values(ctx); err != nil {
+		return 0, s.startKey, err
+	}
+	if s.modify != nil {
+		s.modify(input)
+	}
+	if err := s.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return 0, s.startKey, err
+	}
+	plan, err := s.plan(ctx)
+	if err != nil {
+		return 0, s.startKey, err
+	}
 	var reqs int
+	var out *dynamodb.ScanOutput
 	for {
-		var out *dynamodb.ScanOutput
+		if err := s.throttle.delay(ctx); err != nil {
+			return count, PagingKey(input.ExclusiveStartKey), err
+		}
 		err := s.table.db.retry(ctx, func() error {
 			var err error
-			out, err = s.table.db.client.Scan(ctx, input)
+			out, err = s.send(ctx, plan, input)
 			s.cc.incRequests()
 			return err
 		})
+		if shouldRestart(s.restart, reqs, input.ExclusiveStartKey, err) {
+			// start over from the beginning
+			input.ExclusiveStartKey = nil
+			continue
+		}
 		if err != nil {
-			return 0, err
+			err = pagingKeyErr(err, input.ExclusiveStartKey)
+			s.metrics.record(int(s.segment), nil, err)
+			return count, PagingKey(input.ExclusiveStartKey), err
 		}
+		s.metrics.record(int(s.segment), out, nil)
 		reqs++
 
 		count += int(out.Count)
 		scanned += out.ScannedCount
 		s.cc.add(out.ConsumedCapacity)
+		s.throttle.charge(readUnits(out.ConsumedCapacity, nil, input.ConsistentRead))
 
 		if out.LastEvaluatedKey == nil ||
 			(s.limit > 0 && count >= s.limit) ||
-			(s.searchLimit > 0 && scanned >= s.searchLimit) ||
+			(s.searchLimit > 0 && scanned >= s.searchLimit && !s.exactly) ||
 			(s.reqLimit > 0 && reqs >= s.reqLimit) {
 			break
 		}
 
 		input.ExclusiveStartKey = out.LastEvaluatedKey
+		s.limitRemaining(input, count)
+	}
+	if s.limit > 0 && count > s.limit {
+		count = s.limit
+	}
+	return count, out.LastEvaluatedKey, nil
+}
+
+// limitRemaining lowers the page size of a follow-up request to the number of results still wanted,
+// given that n results have already been collected.
+func (s *Scan) limitRemaining(input *dynamodb.ScanInput, n int) {
+	if s.limit > 0 && n < s.limit && len(s.filters) == 0 && s.searchLimit == 0 {
+		limit := int32(min(math.MaxInt32, s.limit-n))
+		input.Limit = &limit
 	}
-	return count, nil
 }
 
 func (s *Scan) scanInput() *dynamodb.ScanInput {
@@ -311,10 +548,83 @@ func (s *Scan) scanInput() *dynamodb.ScanInput {
 	}
 	if s.cc != nil {
 		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	} else if s.throttle != nil {
+		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
 	}
 	return input
 }
 
+// projectKeys sets input's projection to the primary keys, for KeysOnly.
+func (s *Scan) projectKeys(ctx context.Context, input *dynamodb.ScanInput) error {
+	keys, err := s.table.primaryKeys(ctx, nil, nil, s.index)
+	if err != nil {
+		return err
+	}
+	projection, names := keysProjection(input.FilterExpression, input.ExpressionAttributeNames, keys)
+	input.ProjectionExpression = &projection
+	input.ExpressionAttributeNames = names
+	return nil
+}
+
+// keysProjection returns a projection expression for the given key attributes,
+// along with the expression attribute names it uses, keeping the names used by filter.
+// Names only used by a previous projection are dropped.
+func keysProjection(filter *string, names map[string]string, keys map[string]struct{}) (string, map[string]string) {
+	used := make(map[string]string, len(keys))
+	if filter != nil {
+		used, _ = usedPlaceholders(*filter, names, nil)
+		if used == nil {
+			used = make(map[string]string, len(keys))
+		}
+	}
+	paths := make([]string, 0, len(keys))
+	for key := range keys {
+		sub := "#s" + encodeName(key)
+		used[sub] = key
+		paths = append(paths, sub)
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, ", "), used
+}
+
+// unmarshalKeys wraps unmarshal to support decoding into Keys for KeysOnly.
+func (s *Scan) unmarshalKeys(unmarshal unmarshalFunc) unmarshalFunc {
+	unmarshal = s.table.unmarshaler(unmarshal)
+	if !s.keysOnly {
+		return unmarshal
+	}
+	table := s.table
+	return func(ctx context.Context, item Item, out any) error {
+		switch out := out.(type) {
+		case *Keys:
+			return table.decodeKeys(ctx, item, out)
+		case *[]Keys:
+			var keys Keys
+			if err := table.decodeKeys(ctx, item, &keys); err != nil {
+				return err
+			}
+			*out = append(*out, keys)
+			return nil
+		}
+		return unmarshal(ctx, item, out)
+	}
+}
+
+func (table Table) decodeKeys(ctx context.Context, item Item, out *Keys) error {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return err
+	}
+	*out = Keys{}
+	if err := UnmarshalContext(ctx, item[desc.HashKey], &out[0]); err != nil {
+		return err
+	}
+	if desc.RangeKey != "" {
+		return UnmarshalContext(ctx, item[desc.RangeKey], &out[1])
+	}
+	return nil
+}
+
 func (s *Scan) setError(err error) {
 	if s.err == nil {
 		s.err = err
@@ -326,6 +636,7 @@ type scanIter struct {
 	scan   *Scan
 	input  *dynamodb.ScanInput
 	output *dynamodb.ScanOutput
+	plan   *scanPlan
 	err    error
 	idx    int
 	n      int
@@ -339,6 +650,12 @@ type scanIter struct {
 	exLEK  Item
 	exESK  Item
 	keyErr error
+	// see AllUntil
+	deadline pageDeadline
+	// see DistinctOn
+	seen *distinctSet
+	// see PinSchema
+	pin *schemaPin
 
 	unmarshal unmarshalFunc
 }
@@ -366,7 +683,7 @@ redo:
 	if itr.output != nil && itr.idx < len(itr.output.Items) {
 		item := itr.output.Items[itr.idx]
 		itr.last = item
-		itr.err = itr.unmarshal(item, out)
+		itr.err = itr.unmarshal(ctx, item, out)
 		itr.idx++
 		itr.n++
 		return itr.err == nil
@@ -375,40 +692,94 @@ redo:
 	// new scan
 	if itr.input == nil {
 		itr.input = itr.scan.scanInput()
+		if itr.input.ExpressionAttributeValues, itr.err = itr.scan.values(ctx); itr.err != nil {
+			return false
+		}
+		if itr.scan.keysOnly {
+			if itr.err = itr.scan.projectKeys(ctx, itr.input); itr.err != nil {
+				return false
+			}
+		}
+		if itr.scan.modify != nil {
+			itr.scan.modify(itr.input)
+		}
+		if itr.err = itr.scan.table.db.resolveName(ctx, &itr.input.TableName); itr.err != nil {
+			return false
+		}
+		if itr.plan, itr.err = itr.scan.plan(ctx); itr.err != nil {
+			return false
+		}
+		if itr.seen == nil {
+			itr.seen = newDistinctSet(itr.scan.distinct)
+		}
+		if itr.pin == nil {
+			itr.pin = newSchemaPin(itr.scan)
+		}
+		if itr.err = itr.pin.start(ctx); itr.err != nil {
+			return false
+		}
 	}
 	if len(itr.input.ExclusiveStartKey) > len(itr.exESK) {
 		itr.exESK = itr.input.ExclusiveStartKey
 	}
 	if itr.output != nil && itr.idx >= len(itr.output.Items) {
 		// have we exhausted all results?
-		if itr.output.LastEvaluatedKey == nil || itr.scan.searchLimit > 0 {
+		if itr.output.LastEvaluatedKey == nil || (itr.scan.searchLimit > 0 && !itr.scan.exactly) {
 			return false
 		}
 		// have we hit the request limit?
 		if itr.scan.reqLimit > 0 && itr.reqs == itr.scan.reqLimit {
 			return false
 		}
+		// is there time for another request?
+		if itr.deadline.near() {
+			return false
+		}
 
 		// no, prepare next request and reset index
 		itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
+		itr.scan.limitRemaining(itr.input, itr.n)
 		itr.idx = 0
 	}
 
-	itr.err = itr.scan.table.db.retry(ctx, func() error {
+	send := func() error {
 		var err error
-		itr.output, err = itr.scan.table.db.client.Scan(ctx, itr.input)
+		itr.output, err = itr.scan.send(ctx, itr.plan, itr.input)
 		itr.scan.cc.incRequests()
 		return err
-	})
+	}
+	if itr.err = itr.scan.throttle.delay(ctx); itr.err != nil {
+		return false
+	}
+	start := time.Now()
+	itr.err = itr.scan.table.db.retry(ctx, send)
+	if shouldRestart(itr.scan.restart, itr.reqs, itr.input.ExclusiveStartKey, itr.err) {
+		// start over from the beginning
+		itr.input.ExclusiveStartKey = nil
+		itr.exESK = nil
+		itr.err = itr.scan.table.db.retry(ctx, send)
+	}
 
 	if itr.err != nil {
+		itr.err = pagingKeyErr(itr.err, itr.input.ExclusiveStartKey)
+		itr.scan.metrics.record(int(itr.scan.segment), nil, itr.err)
 		return false
 	}
+	itr.deadline.observe(start)
+	itr.scan.metrics.record(int(itr.scan.segment), itr.output, nil)
 	itr.scan.cc.add(itr.output.ConsumedCapacity)
+	itr.scan.throttle.charge(readUnits(itr.output.ConsumedCapacity, itr.output.Items, itr.input.ConsistentRead))
 	if len(itr.output.LastEvaluatedKey) > len(itr.exLEK) {
 		itr.exLEK = itr.output.LastEvaluatedKey
 	}
 	itr.reqs++
+	if itr.err = itr.pin.filter(ctx, itr.output.Items); itr.err != nil {
+		return false
+	}
+	itr.output.Items, itr.err = itr.seen.filter(itr.output.Items)
+	if itr.err != nil {
+		return false
+	}
 
 	if len(itr.output.Items) == 0 {
 		if itr.scan.reqLimit > 0 && itr.reqs == itr.scan.reqLimit {
@@ -422,7 +793,7 @@ redo:
 
 	item := itr.output.Items[itr.idx]
 	itr.last = item
-	itr.err = itr.unmarshal(item, out)
+	itr.err = itr.unmarshal(ctx, item, out)
 	itr.idx++
 	itr.n++
 	return itr.err == nil
@@ -464,6 +835,21 @@ func (itr *scanIter) LastEvaluatedKey(ctx context.Context) (PagingKey, error) {
 	return nil, nil
 }
 
+// SegmentError is an error that occurred while scanning a particular segment of a parallel scan.
+// Errors returned by parallel scans wrap a SegmentError for each failed segment.
+type SegmentError struct {
+	Segment int
+	Err     error
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("dynamo: scan segment %d: %v", e.Segment, e.Err)
+}
+
+func (e *SegmentError) Unwrap() error {
+	return e.Err
+}
+
 type parallelScan struct {
 	iters []*scanIter
 	items chan Item
@@ -471,9 +857,14 @@ type parallelScan struct {
 	leks   []PagingKey
 	lekErr error
 
-	cc  *ConsumedCapacity
-	err error
-	mu  *sync.Mutex
+	cc      *ConsumedCapacity
+	err     error
+	segErrs []error
+	mu      *sync.Mutex
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	stopped atomic.Bool
 
 	unmarshal unmarshalFunc
 }
@@ -483,7 +874,9 @@ func newParallelScan(iters []*scanIter, cc *ConsumedCapacity, skipLEK bool, unma
 		iters:     iters,
 		items:     make(chan Item),
 		cc:        cc,
+		segErrs:   make([]error, len(iters)),
 		mu:        new(sync.Mutex),
+		done:      make(chan struct{}),
 		unmarshal: unmarshal,
 	}
 	if !skipLEK {
@@ -492,33 +885,48 @@ func newParallelScan(iters []*scanIter, cc *ConsumedCapacity, skipLEK bool, unma
 	return ps
 }
 
+func (ps *parallelScan) start(ctx context.Context) {
+	ctx, ps.cancel = context.WithCancel(ctx)
+	go ps.run(ctx)
+}
+
 func (ps *parallelScan) run(ctx context.Context) {
-	grp, ctx := errgroup.WithContext(ctx)
+	defer close(ps.done)
+	defer ps.cancel()
+	var grp errgroup.Group
 	for i, iter := range ps.iters {
 		i, iter := i, iter
 		if iter == nil {
 			continue
 		}
 		grp.Go(func() error {
+			if ps.leks != nil {
+				// if stopped before delivering anything, resume from where we started
+				start := iter.scan.startKey
+				if start == nil {
+					start = PagingKey{}
+				}
+				ps.mu.Lock()
+				ps.leks[i] = start
+				ps.mu.Unlock()
+			}
+
 			var item Item
 			for iter.Next(ctx, &item) {
 				select {
 				case <-ctx.Done():
-					return ctx.Err()
+					ps.setSegmentError(i, ctx.Err())
+					return nil
 				case ps.items <- item:
 					// reset the map, so we don't overwrite the one we've already sent
 					item = nil
 				}
 
-				if ps.leks != nil {
-					lek, err := iter.LastEvaluatedKey(ctx)
-					ps.mu.Lock()
-					ps.leks[i] = lek
-					if err != nil && ps.lekErr == nil {
-						ps.lekErr = err
-					}
-					ps.mu.Unlock()
-				}
+				// the item has been delivered, so record its key even if we are stopping
+				ps.recordLEK(ctx, i, iter)
+			}
+			if iter.Err() == nil {
+				ps.recordLEK(ctx, i, iter)
 			}
 
 			if ps.cc != nil && iter.scan.cc != nil {
@@ -527,16 +935,42 @@ func (ps *parallelScan) run(ctx context.Context) {
 				ps.mu.Unlock()
 			}
 
-			return iter.Err()
+			ps.setSegmentError(i, iter.Err())
+			return nil
 		})
 	}
-	err := grp.Wait()
-	if err != nil {
-		ps.setError(err)
+	grp.Wait()
+
+	var errs []error
+	for _, err := range ps.SegmentErrors() {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		ps.setError(errors.Join(errs...))
 	}
 	close(ps.items)
 }
 
+func (ps *parallelScan) setSegmentError(i int, err error) {
+	if err == nil || (ps.stopped.Load() && errors.Is(err, context.Canceled)) {
+		return
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.segErrs[i] = &SegmentError{Segment: int(ps.iters[i].scan.segment), Err: err}
+}
+
+// SegmentErrors returns the error for each segment, or nil for segments that succeeded (so far).
+func (ps *parallelScan) SegmentErrors() []error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	errs := make([]error, len(ps.segErrs))
+	copy(errs, ps.segErrs)
+	return errs
+}
+
 func (ps *parallelScan) Next(ctx context.Context, out interface{}) bool {
 	select {
 	case <-ctx.Done():
@@ -546,7 +980,7 @@ func (ps *parallelScan) Next(ctx context.Context, out interface{}) bool {
 		if item == nil {
 			return false
 		}
-		if err := ps.unmarshal(item, out); err != nil {
+		if err := ps.unmarshal(ctx, item, out); err != nil {
 			ps.setError(err)
 			return false
 		}
@@ -554,6 +988,29 @@ func (ps *parallelScan) Next(ctx context.Context, out interface{}) bool {
 	}
 }
 
+func (ps *parallelScan) recordLEK(ctx context.Context, i int, iter *scanIter) {
+	if ps.leks == nil {
+		return
+	}
+	lek, err := iter.LastEvaluatedKey(context.WithoutCancel(ctx))
+	ps.mu.Lock()
+	ps.leks[i] = lek
+	if err != nil && ps.lekErr == nil {
+		ps.lekErr = err
+	}
+	ps.mu.Unlock()
+}
+
+// Stop cancels all segments and waits for them to finish.
+// Items already fetched but not yet returned by Next are discarded,
+// and the last evaluated keys will cover only the items returned by Next.
+func (ps *parallelScan) Stop() ([]PagingKey, error) {
+	ps.stopped.Store(true)
+	ps.cancel()
+	<-ps.done
+	return ps.LastEvaluatedKeys(context.Background())
+}
+
 func (ps *parallelScan) setError(err error) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
@@ -2,10 +2,19 @@ package dynamo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 func TestScan(t *testing.T) {
@@ -179,7 +188,7 @@ func TestScanMagicLEK(t *testing.T) {
 	}
 
 	testDB0 := *testDB
-	testDB0.descs = new(sync.Map)
+	testDB0.configs = new(sync.Map)
 	freshTestDB := &testDB0
 
 	table := freshTestDB.Table(testTableWidgets)
@@ -253,3 +262,337 @@ func TestScanMagicLEK(t *testing.T) {
 		}
 	})
 }
+
+// keysClient is a fake table with a hash key of UserID and range key of Time.
+type keysClient struct {
+	dynamodbiface.DynamoDBAPI
+	scans []*dynamodb.ScanInput
+}
+
+func (c *keysClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName: in.TableName,
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("UserID"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("Time"), KeyType: types.KeyTypeRange},
+			},
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("UserID"), AttributeType: types.ScalarAttributeTypeN},
+				{AttributeName: aws.String("Time"), AttributeType: types.ScalarAttributeTypeS},
+			},
+		},
+	}, nil
+}
+
+func (c *keysClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.scans = append(c.scans, in)
+	return &dynamodb.ScanOutput{
+		Items: []Item{
+			{"UserID": &types.AttributeValueMemberN{Value: "1"}, "Time": &types.AttributeValueMemberS{Value: "a"}},
+			{"UserID": &types.AttributeValueMemberN{Value: "2"}, "Time": &types.AttributeValueMemberS{Value: "b"}},
+		},
+	}, nil
+}
+
+func TestScanKeysOnly(t *testing.T) {
+	ctx := context.Background()
+	client := &keysClient{}
+	table := NewFromIface(client).Table("Keys")
+
+	var keys []Keys
+	if err := table.Scan().Project("'Msg'").Filter("$ > ?", "Count", 0).KeysOnly().All(ctx, &keys); err != nil {
+		t.Fatal(err)
+	}
+	want := []Keys{{1.0, "a"}, {2.0, "b"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Error("bad keys. want:", want, "got:", keys)
+	}
+
+	input := client.scans[0]
+	if got, want := *input.ProjectionExpression, "#s"+encodeName("Time")+", #s"+encodeName("UserID"); got != want {
+		t.Error("bad projection. want:", want, "got:", got)
+	}
+	if len(input.ExpressionAttributeNames) != 3 {
+		t.Error("unexpected names:", input.ExpressionAttributeNames)
+	}
+
+	type key struct {
+		UserID int
+		Time   string
+	}
+	var structs []key
+	if err := table.Scan().KeysOnly().All(ctx, &structs); err != nil {
+		t.Fatal(err)
+	}
+	if want := []key{{1, "a"}, {2, "b"}}; !reflect.DeepEqual(structs, want) {
+		t.Error("bad keys. want:", want, "got:", structs)
+	}
+
+	var one Keys
+	iter := table.Scan().KeysOnly().Iter()
+	if !iter.Next(ctx, &one) {
+		t.Fatal(iter.Err())
+	}
+	if want := (Keys{1.0, "a"}); one != want {
+		t.Error("bad key. want:", want, "got:", one)
+	}
+}
+
+// segmentClient returns (segment+1) items for each segment of a parallel scan, with half of the scanned items filtered out.
+type segmentClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (segmentClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	seg := int(*in.Segment)
+	out := &dynamodb.ScanOutput{
+		ScannedCount: int32(2 * (seg + 1)),
+	}
+	for i := 0; i <= seg; i++ {
+		out.Items = append(out.Items, Item{
+			"UserID": &types.AttributeValueMemberN{Value: strconv.Itoa(seg)},
+			"Time":   &types.AttributeValueMemberS{Value: strconv.Itoa(i)},
+		})
+	}
+	out.Count = int32(len(out.Items))
+	return out, nil
+}
+
+func (segmentClient) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, opts ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return new(keysClient).DescribeTable(ctx, in, opts...)
+}
+
+func TestScanMetrics(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(segmentClient{}).Table("Segments")
+
+	var metrics ParallelScanMetrics
+	var items []Item
+	if err := table.Scan().Metrics(&metrics).AllParallel(ctx, 3, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 6 {
+		t.Error("wrong number of items. want:", 6, "got:", len(items))
+	}
+
+	want := []SegmentMetrics{
+		{Segment: 0, Requests: 1, Scanned: 2, Returned: 1},
+		{Segment: 1, Requests: 1, Scanned: 4, Returned: 2},
+		{Segment: 2, Requests: 1, Scanned: 6, Returned: 3},
+	}
+	if !reflect.DeepEqual(metrics.Segments, want) {
+		t.Error("bad metrics. want:", want, "got:", metrics.Segments)
+	}
+	if total, want := metrics.Total(), (SegmentMetrics{Segment: -1, Requests: 3, Scanned: 12, Returned: 6}); total != want {
+		t.Error("bad total. want:", want, "got:", total)
+	}
+}
+
+// endlessClient returns one item per page forever, numbering each segment's items.
+type endlessClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (endlessClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	seg := strconv.Itoa(int(*in.Segment))
+	n := 0
+	if in.ExclusiveStartKey != nil {
+		n, _ = strconv.Atoi(in.ExclusiveStartKey["N"].(*types.AttributeValueMemberN).Value)
+	}
+	key := Item{
+		"Seg": &types.AttributeValueMemberN{Value: seg},
+		"N":   &types.AttributeValueMemberN{Value: strconv.Itoa(n + 1)},
+	}
+	return &dynamodb.ScanOutput{
+		Items:            []Item{key},
+		LastEvaluatedKey: key,
+		Count:            1,
+		ScannedCount:     1,
+	}, nil
+}
+
+func TestParallelIterStop(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(endlessClient{}).Table("Endless")
+
+	const segments = 4
+	iter := table.Scan().IterParallel(ctx, segments)
+	seen := make(map[int]int)
+	var item struct {
+		Seg int
+		N   int
+	}
+	for i := 0; i < 10 && iter.Next(ctx, &item); i++ {
+		seen[item.Seg] = item.N
+	}
+	leks, err := iter.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iter.Next(ctx, &item) {
+		t.Error("Next returned true after Stop")
+	}
+	if err := iter.Err(); err != nil {
+		t.Error("unexpected error after Stop:", err)
+	}
+	if len(leks) != segments {
+		t.Fatal("wrong number of keys. want:", segments, "got:", len(leks))
+	}
+	for seg, lek := range leks {
+		n, ok := seen[seg]
+		if !ok {
+			if lek == nil || len(lek) != 0 {
+				t.Errorf("segment %d: expected empty key, got: %v", seg, lek)
+			}
+			continue
+		}
+		if got := lek["N"].(*types.AttributeValueMemberN).Value; got != strconv.Itoa(n) {
+			t.Errorf("segment %d: bad key. want: %d got: %s", seg, n, got)
+		}
+	}
+
+	// resume from where we stopped
+	iter = table.Scan().IterParallelStartFrom(ctx, leks)
+	resumed := make(map[int]int)
+	for i := 0; i < 20 && iter.Next(ctx, &item); i++ {
+		if _, ok := resumed[item.Seg]; !ok {
+			resumed[item.Seg] = item.N
+		}
+	}
+	if _, err := iter.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for seg, n := range resumed {
+		if want := seen[seg] + 1; n != want {
+			t.Errorf("segment %d: resumed at wrong item. want: %d got: %d", seg, want, n)
+		}
+	}
+}
+
+// flakySegmentClient fails to scan odd segments.
+type flakySegmentClient struct {
+	segmentClient
+}
+
+func (c flakySegmentClient) Scan(ctx context.Context, in *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if *in.Segment%2 == 1 {
+		return nil, fmt.Errorf("segment %d is broken", *in.Segment)
+	}
+	return c.segmentClient.Scan(ctx, in, opts...)
+}
+
+func TestParallelScanSegmentErrors(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(flakySegmentClient{}).Table("Flaky")
+
+	iter := table.Scan().IterParallel(ctx, 4)
+	var items []Item
+	var item Item
+	for iter.Next(ctx, &item) {
+		items = append(items, item)
+		item = nil
+	}
+	// segment 0 has 1 item, segment 2 has 3
+	if len(items) != 4 {
+		t.Error("wrong number of items. want:", 4, "got:", len(items))
+	}
+
+	errs := iter.SegmentErrors()
+	if len(errs) != 4 {
+		t.Fatal("wrong number of segment errors. want:", 4, "got:", len(errs))
+	}
+	for i, err := range errs {
+		if i%2 == 0 {
+			if err != nil {
+				t.Errorf("segment %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		var segErr *SegmentError
+		if !errors.As(err, &segErr) || segErr.Segment != i {
+			t.Errorf("segment %d: bad error: %v", i, err)
+		}
+	}
+
+	err := iter.Err()
+	for _, want := range []error{errs[1], errs[3]} {
+		if !errors.Is(err, want) {
+			t.Error("joined error missing segment error:", want, "got:", err)
+		}
+	}
+
+	// completed segments can be skipped when retrying
+	leks, err := iter.LastEvaluatedKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leks[0] != nil || leks[2] != nil {
+		t.Error("expected completed segments to have nil keys:", leks)
+	}
+	if leks[1] == nil || leks[3] == nil {
+		t.Error("expected failed segments to have non-nil keys:", leks)
+	}
+}
+
+func TestScanSegmentRange(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(segmentClient{}).Table("Segments")
+
+	var metrics ParallelScanMetrics
+	var items []struct{ UserID int }
+	err := table.Scan().SegmentRange(2, 4, 8).Metrics(&metrics).AllParallel(ctx, 100, &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// segment 2 has 3 items, segment 3 has 4
+	if len(items) != 7 {
+		t.Error("wrong number of items. want:", 7, "got:", len(items))
+	}
+	for _, item := range items {
+		if item.UserID != 2 && item.UserID != 3 {
+			t.Error("item from unexpected segment:", item.UserID)
+		}
+	}
+	if len(metrics.Segments) != 8 || metrics.Segments[2].Requests != 1 || metrics.Segments[3].Requests != 1 || metrics.Segments[0].Requests != 0 {
+		t.Error("bad metrics:", metrics.Segments)
+	}
+
+	leks, err := table.Scan().SegmentRange(2, 4, 8).AllParallelWithLastEvaluatedKeys(ctx, 100, &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leks) != 2 {
+		t.Error("wrong number of keys. want:", 2, "got:", len(leks))
+	}
+
+	if err := table.Scan().SegmentRange(2, 4, 8).All(ctx, &items); err == nil {
+		t.Error("expected error for sequential scan of segment range")
+	}
+	if err := table.Scan().SegmentRange(4, 2, 8).AllParallel(ctx, 1, &items); err == nil {
+		t.Error("expected error for invalid segment range")
+	}
+	if _, err := table.Scan().SegmentRange(2, 4, 8).AllParallelStartFrom(ctx, make([]PagingKey, 3), &items); err == nil {
+		t.Error("expected error for mismatched keys")
+	}
+}
+
+func TestScanAllWithRaw(t *testing.T) {
+	table := NewFromIface(&keysClient{}).Table("Raw")
+
+	type row struct {
+		UserID int
+		Time   string
+	}
+	var rows []row
+	var raw []Item
+	if err := table.Scan().AllWithRaw(context.Background(), &rows, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if want := []row{{1, "a"}, {2, "b"}}; !reflect.DeepEqual(rows, want) {
+		t.Error("bad typed results. want:", want, "got:", rows)
+	}
+	if len(raw) != 2 || raw[1]["Time"].(*types.AttributeValueMemberS).Value != "b" {
+		t.Error("bad raw results:", raw)
+	}
+}
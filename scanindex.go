@@ -0,0 +1,237 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// PreferIndex makes this scan run as a query when possible, avoiding a full table scan.
+// If one of the given attributes is the hash key of the table or one of its indexes
+// and a filter checks it for equality, such as Filter("$ = ?", "Email", email),
+// the scan is sent as a Query against that table or index instead.
+// A filter on the chosen index's range key, using one of the operators supported by [Query.Range], becomes part of the key condition;
+// other filters on its keys prevent the rewrite.
+// Attributes are tried in the given order, preferring the table's own keys to its indexes.
+// Indexes must project all attributes, and global secondary indexes are not used for consistent scans.
+// The table's description is used to find its indexes, see [Table.Describe].
+//
+// If the scan can't be rewritten, it runs as a normal scan and the function given to [Scan.OnFullScan], if any, is called.
+// Parallel scans are never rewritten.
+func (s *Scan) PreferIndex(attributes ...string) *Scan {
+	s.prefer = attributes
+	return s
+}
+
+// OnFullScan sets a function that is called with the reason a scan using [Scan.PreferIndex]
+// could not be rewritten as a query, before it runs as a full scan.
+// This is useful for logging accidental full table scans.
+func (s *Scan) OnFullScan(fn func(reason string)) *Scan {
+	s.onFullScan = fn
+	return s
+}
+
+// scanPlan is a scan rewritten as a query.
+type scanPlan struct {
+	index   string
+	keyCond string
+	filter  *string
+}
+
+// plan returns the query to run instead of this scan, or nil if it should run as a scan.
+func (s *Scan) plan(ctx context.Context) (*scanPlan, error) {
+	if len(s.prefer) == 0 {
+		return nil, nil
+	}
+	if s.totalSegments > 0 {
+		s.fullScan("parallel scans can't be rewritten as queries")
+		return nil, nil
+	}
+	desc, err := s.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []Index{{HashKey: desc.HashKey, RangeKey: desc.RangeKey, ProjectionType: AllProjection}}
+	candidates = append(candidates, desc.GSI...)
+	candidates = append(candidates, desc.LSI...)
+	var reasons []string
+	for _, attr := range s.prefer {
+		for _, idx := range candidates {
+			if idx.HashKey != attr {
+				continue
+			}
+			if s.index != "" && idx.Name != s.index {
+				continue
+			}
+			if idx.Name != s.index && idx.ProjectionType != AllProjection {
+				reasons = append(reasons, fmt.Sprintf("index %s does not project all attributes", idx.Name))
+				continue
+			}
+			if s.consistent && idx.Name != "" && !idx.Local {
+				reasons = append(reasons, fmt.Sprintf("index %s does not support consistent reads", idx.Name))
+				continue
+			}
+			plan, reason := s.splitFilters(idx)
+			if plan != nil {
+				return plan, nil
+			}
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, fmt.Sprintf("no index of table %s has a hash key in %v", s.table.Name(), s.prefer))
+	}
+	s.fullScan(strings.Join(reasons, "; "))
+	return nil, nil
+}
+
+// splitFilters moves this scan's filters on idx's keys into a key condition.
+func (s *Scan) splitFilters(idx Index) (*scanPlan, string) {
+	name := "table " + s.table.Name()
+	if idx.Name != "" {
+		name = "index " + idx.Name
+	}
+	var hashCond, rangeCond string
+	var filters []string
+	for _, filter := range s.filters {
+		if attr, ok := s.keyCondAttr(filter, true); ok && attr == idx.HashKey && hashCond == "" {
+			hashCond = unwrapExpr(filter)
+			continue
+		}
+		if attr, ok := s.keyCondAttr(filter, false); ok && attr == idx.RangeKey && rangeCond == "" {
+			rangeCond = unwrapExpr(filter)
+			continue
+		}
+		if s.references(filter, idx.HashKey) || (idx.RangeKey != "" && s.references(filter, idx.RangeKey)) {
+			return nil, fmt.Sprintf("%s: filter %s can't be used as a key condition", name, filter)
+		}
+		filters = append(filters, filter)
+	}
+	if hashCond == "" {
+		return nil, fmt.Sprintf("%s: no equality filter on hash key %s", name, idx.HashKey)
+	}
+
+	plan := &scanPlan{index: idx.Name, keyCond: hashCond}
+	if rangeCond != "" {
+		plan.keyCond += " AND " + rangeCond
+	}
+	if len(filters) > 0 {
+		filter := strings.Join(filters, " AND ")
+		plan.filter = &filter
+	}
+	return plan, ""
+}
+
+var (
+	keyCondEqual   = regexp.MustCompile(`^\(?\s*([#A-Za-z0-9_]+)\s*=\s*(:[A-Za-z0-9_]+)\s*\)?$`)
+	keyCondCompare = regexp.MustCompile(`^\(?\s*([#A-Za-z0-9_]+)\s*(?:<=|>=|<|>)\s*(:[A-Za-z0-9_]+)\s*\)?$`)
+	keyCondBetween = regexp.MustCompile(`^\(?\s*([#A-Za-z0-9_]+)\s+(?i:BETWEEN)\s+(:[A-Za-z0-9_]+)\s+(?i:AND)\s+(:[A-Za-z0-9_]+)\s*\)?$`)
+	keyCondBegins  = regexp.MustCompile(`^\(?\s*(?i:begins_with)\s*\(\s*([#A-Za-z0-9_]+)\s*,\s*(:[A-Za-z0-9_]+)\s*\)\s*\)?$`)
+	identRegexp    = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// keyCondAttr returns the attribute name of filter if it can be used as a key condition,
+// which is only equality for hash keys.
+func (s *Scan) keyCondAttr(filter string, hash bool) (string, bool) {
+	patterns := []*regexp.Regexp{keyCondEqual}
+	if !hash {
+		patterns = append(patterns, keyCondCompare, keyCondBetween, keyCondBegins)
+	}
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(filter); m != nil {
+			return s.resolveName(m[1]), true
+		}
+	}
+	return "", false
+}
+
+func (s *Scan) resolveName(name string) string {
+	if strings.HasPrefix(name, "#") {
+		return s.nameExpr[name]
+	}
+	return name
+}
+
+// references returns true if filter refers to the given top-level attribute.
+func (s *Scan) references(filter, attr string) bool {
+	for _, ph := range placeholderRegexp.FindAllString(filter, -1) {
+		if s.resolveName(ph) == attr {
+			return true
+		}
+	}
+	plain := placeholderRegexp.ReplaceAllString(filter, " ")
+	return slices.Contains(identRegexp.FindAllString(plain, -1), attr)
+}
+
+// unwrapExpr removes the parentheses added by wrapExpr.
+func unwrapExpr(expr string) string {
+	if len(expr) < 2 || expr[0] != '(' || expr[len(expr)-1] != ')' {
+		return expr
+	}
+	if inner := expr[1 : len(expr)-1]; wrapExpr(inner) == expr {
+		return inner
+	}
+	return expr
+}
+
+func (s *Scan) fullScan(reason string) {
+	if s.onFullScan != nil {
+		s.onFullScan(reason)
+	}
+}
+
+// queryInput converts a scan request into the equivalent query.
+func (plan *scanPlan) queryInput(in *dynamodb.ScanInput) *dynamodb.QueryInput {
+	query := &dynamodb.QueryInput{
+		TableName:                 in.TableName,
+		KeyConditionExpression:    &plan.keyCond,
+		FilterExpression:          plan.filter,
+		ProjectionExpression:      in.ProjectionExpression,
+		ExpressionAttributeNames:  in.ExpressionAttributeNames,
+		ExpressionAttributeValues: in.ExpressionAttributeValues,
+		ExclusiveStartKey:         in.ExclusiveStartKey,
+		ConsistentRead:            in.ConsistentRead,
+		Limit:                     in.Limit,
+		Select:                    in.Select,
+		ReturnConsumedCapacity:    in.ReturnConsumedCapacity,
+	}
+	if plan.index != "" {
+		query.IndexName = &plan.index
+	}
+	return query
+}
+
+// scanOutput converts the output of a query made by queryInput.
+func (plan *scanPlan) scanOutput(out *dynamodb.QueryOutput) *dynamodb.ScanOutput {
+	return &dynamodb.ScanOutput{
+		Items:            out.Items,
+		Count:            out.Count,
+		ScannedCount:     out.ScannedCount,
+		LastEvaluatedKey: out.LastEvaluatedKey,
+		ConsumedCapacity: out.ConsumedCapacity,
+		ResultMetadata:   out.ResultMetadata,
+	}
+}
+
+// send makes a Scan request, or a Query request if the scan was rewritten.
+// The request is hedged if [Scan.Hedge] was used.
+func (s *Scan) send(ctx context.Context, plan *scanPlan, in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	if plan == nil {
+		return hedge(ctx, s.hedger, func(ctx context.Context) (*dynamodb.ScanOutput, error) {
+			return s.table.db.client.Scan(ctx, in, s.table.db.requestOptions(s.optFns)...)
+		}, s.cc.incRequests)
+	}
+	qin := plan.queryInput(in)
+	out, err := hedge(ctx, s.hedger, func(ctx context.Context) (*dynamodb.QueryOutput, error) {
+		return s.table.db.client.Query(ctx, qin, s.table.db.requestOptions(s.optFns)...)
+	}, s.cc.incRequests)
+	if err != nil {
+		return nil, err
+	}
+	return plan.scanOutput(out), nil
+}
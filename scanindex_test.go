@@ -0,0 +1,141 @@
+package dynamo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// indexedClient is a table with hash key ID, a GSI on Email and Time, and a keys-only GSI on Name.
+type indexedClient struct {
+	dynamodbiface.DynamoDBAPI
+	queries []*dynamodb.QueryInput
+	scans   []*dynamodb.ScanInput
+}
+
+func (c *indexedClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName: aws.String("Users"),
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{
+			{
+				IndexName: aws.String("Email-Time-index"),
+				IndexArn:  aws.String("arn"),
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("Email"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("Time"), KeyType: types.KeyTypeRange},
+				},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			},
+			{
+				IndexName:  aws.String("Name-index"),
+				IndexArn:   aws.String("arn"),
+				KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("Name"), KeyType: types.KeyTypeHash}},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+			},
+		},
+	}}, nil
+}
+
+func (c *indexedClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, in)
+	return &dynamodb.QueryOutput{
+		Items: []Item{{"ID": &types.AttributeValueMemberN{Value: "1"}}},
+		Count: 1,
+	}, nil
+}
+
+func (c *indexedClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.scans = append(c.scans, in)
+	return &dynamodb.ScanOutput{Count: 0}, nil
+}
+
+func TestScanPreferIndex(t *testing.T) {
+	ctx := context.Background()
+	client := new(indexedClient)
+	table := NewFromIface(client).Table("Users")
+
+	var reason string
+	var got []Item
+	err := table.Scan().
+		Filter("$ = ?", "Email", "a@example.com").
+		Filter("'Time' > ?", 5).
+		Filter("Msg = ?", "hi").
+		PreferIndex("Name", "Email").
+		OnFullScan(func(r string) { reason = r }).
+		All(ctx, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.scans) != 0 || len(client.queries) != 1 || len(got) != 1 {
+		t.Fatal("scan not rewritten as query:", len(client.scans), "scans,", len(client.queries), "queries:", reason)
+	}
+	in := client.queries[0]
+	if *in.IndexName != "Email-Time-index" {
+		t.Error("wrong index:", *in.IndexName)
+	}
+	if got := *in.KeyConditionExpression; got != "#sIVWWC2LM = :v0 AND #sKRUW2ZI > :v1" {
+		t.Error("bad key condition:", got)
+	}
+	if got := *in.FilterExpression; got != "(Msg = :v2)" {
+		t.Error("bad filter:", got)
+	}
+	if reason != "" {
+		t.Error("unexpected full scan:", reason)
+	}
+
+	t.Run("count", func(t *testing.T) {
+		client.queries = nil
+		n, err := table.Scan().Filter("$ = ?", "ID", 1).PreferIndex("ID").Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 || len(client.queries) != 1 || client.queries[0].IndexName != nil {
+			t.Error("count not rewritten as table query:", n, client.queries)
+		}
+		if client.queries[0].Select != types.SelectCount {
+			t.Error("bad select:", client.queries[0].Select)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		client.queries = nil
+		var reason string
+		err := table.Scan().
+			Filter("$ = ?", "Email", "a@example.com").
+			OrFilter("$ = ?", "Email", "b@example.com").
+			PreferIndex("Email").
+			OnFullScan(func(r string) { reason = r }).
+			All(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(client.queries) != 0 || len(client.scans) != 1 {
+			t.Error("scan should not be rewritten")
+		}
+		if !strings.Contains(reason, "can't be used as a key condition") {
+			t.Error("bad reason:", reason)
+		}
+
+		err = table.Scan().
+			Filter("$ = ?", "Name", "Alice").
+			PreferIndex("Name").
+			OnFullScan(func(r string) { reason = r }).
+			All(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(client.queries) != 0 || len(client.scans) != 2 {
+			t.Error("scan should not use keys-only index")
+		}
+		if !strings.Contains(reason, "Name-index does not project all attributes") {
+			t.Error("bad reason:", reason)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package dynamo
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ParallelScanMetrics records statistics for each segment of a scan.
+// It is useful for detecting hot segments and tuning the number of segments for parallel scans.
+// Use [Scan.Metrics] to enable it.
+// Metrics are safe to read once the scan has finished.
+type ParallelScanMetrics struct {
+	// Segments holds the metrics for each segment, indexed by segment number.
+	Segments []SegmentMetrics
+	mu       sync.Mutex
+}
+
+// SegmentMetrics are statistics for a single segment of a scan.
+type SegmentMetrics struct {
+	// Segment is this segment's number.
+	Segment int
+	// Requests is the number of Scan requests made.
+	Requests int
+	// Scanned is the number of items evaluated, before any filter is applied.
+	Scanned int
+	// Returned is the number of items returned, after any filter is applied.
+	Returned int
+	// Throttles is the number of throttled attempts, including those that succeeded after being retried.
+	Throttles int
+}
+
+// Total returns the sum of all segments' metrics.
+// The Segment field of the result is always -1.
+func (m *ParallelScanMetrics) Total() SegmentMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := SegmentMetrics{Segment: -1}
+	for _, seg := range m.Segments {
+		total.Requests += seg.Requests
+		total.Scanned += seg.Scanned
+		total.Returned += seg.Returned
+		total.Throttles += seg.Throttles
+	}
+	return total
+}
+
+// reset prepares metrics for the given number of segments.
+func (m *ParallelScanMetrics) reset(segments int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Segments = make([]SegmentMetrics, segments)
+	for i := range m.Segments {
+		m.Segments[i].Segment = i
+	}
+}
+
+// record adds the results of a Scan request to the given segment.
+func (m *ParallelScanMetrics) record(segment int, out *dynamodb.ScanOutput, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.Segments) <= segment {
+		m.Segments = append(m.Segments, SegmentMetrics{Segment: len(m.Segments)})
+	}
+	seg := &m.Segments[segment]
+	seg.Requests++
+	if err != nil {
+		if isThrottle(err) {
+			seg.Throttles++
+		}
+		return
+	}
+	seg.Scanned += int(out.ScannedCount)
+	seg.Returned += int(out.Count)
+	seg.Throttles += countThrottles(out.ResultMetadata)
+}
+
+// countThrottles returns the number of throttled attempts for a successful request.
+func countThrottles(meta middleware.Metadata) int {
+	var n int
+	if attempts, ok := retry.GetAttemptResults(meta); ok {
+		for _, attempt := range attempts.Results {
+			if isThrottle(attempt.Err) {
+				n++
+			}
+		}
+	}
+	return n
+}
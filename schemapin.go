@@ -0,0 +1,222 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PinSchema makes this scan describe the table when it starts and pin the projection of the scanned index,
+// so that long-running scans return consistent results even if the index is recreated with a different projection mid-scan.
+// Attributes outside of the pinned projection are removed from results.
+// The first time a result has such attributes, the table is described again and onDrift is called with
+// a [SchemaDrift] comparing the pinned and live descriptions. If onDrift is nil, the scan stops
+// and returns the *SchemaDrift as its error instead.
+// Results can't show that an index was recreated with fewer projected attributes, so the table is also
+// described again after each page of results if a minute has passed since it was last described,
+// and drift is reported if the live index no longer projects every pinned attribute.
+// Parallel scans share the pinned description across segments.
+// Drift is reported at most once per scan.
+//
+// Scans of the table itself have nothing to pin, so drift is never reported for them.
+func (s *Scan) PinSchema(onDrift func(ctx context.Context, drift *SchemaDrift)) *Scan {
+	s.pinSchema = true
+	s.onDrift = onDrift
+	return s
+}
+
+// SchemaDrift is reported by [Scan.PinSchema] when a scan's results no longer match
+// the projection of the index described when the scan started.
+type SchemaDrift struct {
+	// Table is the name of the scanned table.
+	Table string
+	// Index is the name of the scanned index.
+	Index string
+	// Pinned is the description of the table when the scan started.
+	Pinned Description
+	// Live is the description of the table when drift was detected.
+	// It is the zero value if the table could not be described.
+	Live Description
+	// Unexpected are the attributes found outside of the pinned projection, which were removed from results.
+	Unexpected []string
+	// Missing are the attributes of the pinned projection that the live index no longer projects.
+	// If the pinned index projected all attributes, compare the projections of Pinned and Live instead.
+	Missing []string
+}
+
+func (drift *SchemaDrift) Error() string {
+	msg := fmt.Sprintf("dynamo: schema of index %s on table %s changed during scan", drift.Index, drift.Table)
+	if len(drift.Unexpected) > 0 {
+		msg += ": unexpected attributes " + strings.Join(drift.Unexpected, ", ")
+	}
+	if len(drift.Missing) > 0 {
+		msg += ": attributes no longer projected " + strings.Join(drift.Missing, ", ")
+	}
+	if len(drift.Unexpected) == 0 && len(drift.Missing) == 0 {
+		msg += ": fewer attributes projected"
+	}
+	return msg
+}
+
+// schemaCheckInterval is how often PinSchema describes the table during a scan
+// to check for indexes recreated with fewer projected attributes.
+var schemaCheckInterval = time.Minute
+
+// schemaPin tracks the projection pinned by PinSchema.
+type schemaPin struct {
+	table   Table
+	index   string
+	onDrift func(context.Context, *SchemaDrift)
+
+	desc lazy[Description]
+	// attributes projected by the pinned index, or nil if all attributes are projected
+	attrs map[string]struct{}
+
+	mu      sync.Mutex
+	drifted bool
+	// when the table was last described
+	checked time.Time
+}
+
+func newSchemaPin(s *Scan) *schemaPin {
+	if !s.pinSchema {
+		return nil
+	}
+	return &schemaPin{
+		table:   s.table,
+		index:   s.index,
+		onDrift: s.onDrift,
+	}
+}
+
+// start describes the table, if it hasn't been already.
+func (pin *schemaPin) start(ctx context.Context) error {
+	if pin == nil {
+		return nil
+	}
+	_, err := pin.desc.resolve(ctx, func(ctx context.Context) (Description, error) {
+		desc, err := pin.table.Describe().Run(ctx)
+		if err != nil {
+			return desc, err
+		}
+		if pin.index == "" {
+			return desc, nil
+		}
+		pin.mu.Lock()
+		pin.checked = time.Now()
+		pin.mu.Unlock()
+		idx, ok := desc.index(pin.index)
+		if !ok {
+			return desc, fmt.Errorf("dynamo: PinSchema: index %s not found in table %s", pin.index, desc.Name)
+		}
+		pin.attrs = desc.projected(idx)
+		return desc, nil
+	})
+	return err
+}
+
+// filter removes attributes outside of the pinned projection from items, reporting drift the first time it happens.
+// It also checks the live projection, see check.
+// It returns an error if drift is found and there is no onDrift callback to report it to.
+func (pin *schemaPin) filter(ctx context.Context, items []Item) error {
+	if pin == nil || pin.index == "" {
+		return nil
+	}
+	if pin.attrs != nil {
+		var unexpected map[string]struct{}
+		for _, item := range items {
+			for name := range item {
+				if _, ok := pin.attrs[name]; ok {
+					continue
+				}
+				delete(item, name)
+				if unexpected == nil {
+					unexpected = make(map[string]struct{})
+				}
+				unexpected[name] = struct{}{}
+			}
+		}
+		if unexpected != nil && pin.claim() {
+			drift := &SchemaDrift{}
+			for name := range unexpected {
+				drift.Unexpected = append(drift.Unexpected, name)
+			}
+			sort.Strings(drift.Unexpected)
+			if live, err := pin.table.Describe().Run(ctx); err == nil {
+				drift.Live = live
+			}
+			return pin.report(ctx, drift)
+		}
+	}
+	return pin.check(ctx)
+}
+
+// check describes the table if it hasn't been described for schemaCheckInterval,
+// reporting drift if the live index projects fewer attributes than the pinned index.
+func (pin *schemaPin) check(ctx context.Context) error {
+	pin.mu.Lock()
+	if pin.drifted || time.Since(pin.checked) < schemaCheckInterval {
+		pin.mu.Unlock()
+		return nil
+	}
+	pin.checked = time.Now()
+	pin.mu.Unlock()
+
+	live, err := pin.table.Describe().Run(ctx)
+	if err != nil {
+		return nil
+	}
+	idx, ok := live.index(pin.index)
+	if !ok {
+		// the scan itself will fail
+		return nil
+	}
+	attrs := live.projected(idx)
+	if attrs == nil {
+		return nil
+	}
+	drift := &SchemaDrift{Live: live}
+	if pin.attrs != nil {
+		for name := range pin.attrs {
+			if _, ok := attrs[name]; !ok {
+				drift.Missing = append(drift.Missing, name)
+			}
+		}
+		if len(drift.Missing) == 0 {
+			return nil
+		}
+		sort.Strings(drift.Missing)
+	}
+	if pin.claim() {
+		return pin.report(ctx, drift)
+	}
+	return nil
+}
+
+// claim returns true the first time drift is found.
+func (pin *schemaPin) claim() bool {
+	pin.mu.Lock()
+	defer pin.mu.Unlock()
+	if pin.drifted {
+		return false
+	}
+	pin.drifted = true
+	return true
+}
+
+// report calls onDrift with drift, or returns drift as an error if there is no callback.
+func (pin *schemaPin) report(ctx context.Context, drift *SchemaDrift) error {
+	pinned, _ := pin.desc.load()
+	drift.Table = pinned.Name
+	drift.Index = pin.index
+	drift.Pinned = pinned
+
+	if pin.onDrift == nil {
+		return drift
+	}
+	pin.onDrift(ctx, drift)
+	return nil
+}
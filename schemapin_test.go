@@ -0,0 +1,168 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// driftClient has an index on Name whose projection gains Email after the first DescribeTable,
+// with the second page of scan results reflecting the new projection.
+type driftClient struct {
+	dynamodbiface.DynamoDBAPI
+	describes int
+}
+
+func (c *driftClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.describes++
+	projected := []string{"Nickname"}
+	if c.describes > 1 {
+		projected = append(projected, "Email")
+	}
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName: aws.String("Users"),
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName: aws.String("Name-index"),
+			IndexArn:  aws.String("arn"),
+			KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("Name"), KeyType: types.KeyTypeHash}},
+			Projection: &types.Projection{
+				ProjectionType:   types.ProjectionTypeInclude,
+				NonKeyAttributes: projected,
+			},
+		}},
+		LocalSecondaryIndexes: []types.LocalSecondaryIndexDescription{{
+			IndexName: aws.String("Age-index"),
+			IndexArn:  aws.String("arn"),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("age"), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+		}},
+	}}, nil
+}
+
+func (c *driftClient) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	user := func(id string) Item {
+		return Item{
+			"ID":       &types.AttributeValueMemberS{Value: id},
+			"Name":     &types.AttributeValueMemberS{Value: "name-" + id},
+			"Nickname": &types.AttributeValueMemberS{Value: "nick-" + id},
+		}
+	}
+	if in.ExclusiveStartKey == nil {
+		return &dynamodb.ScanOutput{
+			Items:            []Item{user("1")},
+			LastEvaluatedKey: Item{"ID": &types.AttributeValueMemberS{Value: "1"}},
+		}, nil
+	}
+	item := user("2")
+	item["Email"] = &types.AttributeValueMemberS{Value: "2@example.com"}
+	return &dynamodb.ScanOutput{Items: []Item{item}}, nil
+}
+
+// narrowClient is a driftClient whose index loses Email after the first DescribeTable.
+type narrowClient struct {
+	driftClient
+}
+
+func (c *narrowClient) DescribeTable(ctx context.Context, in *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	out, err := c.driftClient.DescribeTable(ctx, in, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	proj := out.Table.GlobalSecondaryIndexes[0].Projection
+	if c.describes == 1 {
+		proj.NonKeyAttributes = []string{"Nickname", "Email"}
+	} else {
+		proj.NonKeyAttributes = []string{"Nickname"}
+	}
+	return out, nil
+}
+
+func TestScanPinSchema(t *testing.T) {
+	ctx := context.Background()
+	client := new(driftClient)
+	table := NewFromIface(client).Table("Users")
+
+	var drifts []*SchemaDrift
+	var got []Item
+	err := table.Scan().Index("Name-index").PinSchema(func(_ context.Context, drift *SchemaDrift) {
+		drifts = append(drifts, drift)
+	}).All(ctx, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range got {
+		if _, ok := item["Email"]; ok {
+			t.Error("unpinned attribute returned:", item)
+		}
+		if _, ok := item["Nickname"]; !ok {
+			t.Error("pinned attribute missing:", item)
+		}
+	}
+	if len(drifts) != 1 {
+		t.Fatal("want 1 drift, got:", len(drifts))
+	}
+	drift := drifts[0]
+	if drift.Table != "Users" || drift.Index != "Name-index" || !reflect.DeepEqual(drift.Unexpected, []string{"Email"}) {
+		t.Error("bad drift:", drift)
+	}
+	if got := drift.Pinned.GSI[0].ProjectionAttribs; !reflect.DeepEqual(got, []string{"Nickname"}) {
+		t.Error("bad pinned projection:", got)
+	}
+	if got := drift.Live.GSI[0].ProjectionAttribs; !reflect.DeepEqual(got, []string{"Nickname", "Email"}) {
+		t.Error("bad live projection:", got)
+	}
+
+	t.Run("missing index", func(t *testing.T) {
+		err := table.Scan().Index("Nope-index").PinSchema(nil).All(ctx, &got)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("no callback", func(t *testing.T) {
+		table := NewFromIface(new(driftClient)).Table("Users")
+		var got []Item
+		err := table.Scan().Index("Name-index").PinSchema(nil).All(ctx, &got)
+		var drift *SchemaDrift
+		if !errors.As(err, &drift) {
+			t.Fatal("want *SchemaDrift error, got:", err)
+		}
+		if !reflect.DeepEqual(drift.Unexpected, []string{"Email"}) {
+			t.Error("bad drift:", drift)
+		}
+	})
+
+	t.Run("fewer attributes", func(t *testing.T) {
+		defer func(interval time.Duration) { schemaCheckInterval = interval }(schemaCheckInterval)
+		schemaCheckInterval = 0
+
+		client := new(narrowClient)
+		table := NewFromIface(client).Table("Users")
+		var drifts []*SchemaDrift
+		err := table.Scan().Index("Name-index").PinSchema(func(_ context.Context, drift *SchemaDrift) {
+			drifts = append(drifts, drift)
+		}).All(ctx, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 1 {
+			t.Fatal("want 1 drift, got:", len(drifts))
+		}
+		if drift := drifts[0]; !reflect.DeepEqual(drift.Missing, []string{"Email"}) || len(drift.Unexpected) != 0 {
+			t.Error("bad drift:", drift)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// DefaultSlowThreshold is the duration after which [WithSlog] considers a request slow.
+const DefaultSlowThreshold = time.Second
+
+// WithSlog returns an option for [New] (or [dynamodb.NewFromConfig]) that logs notable operations
+// to logger at the given level as structured records. Records are emitted for:
+//   - retried requests ("dynamo: request retried")
+//   - throttled requests ("dynamo: request throttled")
+//   - slow requests, taking longer than [DefaultSlowThreshold] ("dynamo: slow request")
+//   - condition check failures ("dynamo: condition check failed")
+//
+// Each record includes the operation name, tables, attempts, duration, and request ID.
+// Operations that are none of the above are not logged.
+// Use [WithSlogThreshold] to customize the slow request threshold.
+func WithSlog(logger *slog.Logger, level slog.Level) func(*dynamodb.Options) {
+	return WithSlogThreshold(logger, level, DefaultSlowThreshold)
+}
+
+// WithSlogThreshold is like [WithSlog], but considers requests taking longer than slow to be slow.
+// A slow threshold of zero or less disables slow request logging.
+func WithSlogThreshold(logger *slog.Logger, level slog.Level, slow time.Duration) func(*dynamodb.Options) {
+	return WithTracer(&slogTracer{logger: logger, level: level, slow: slow})
+}
+
+type slogTracer struct {
+	logger *slog.Logger
+	level  slog.Level
+	slow   time.Duration
+}
+
+func (t *slogTracer) StartOperation(ctx context.Context, _ *Operation) context.Context {
+	return ctx
+}
+
+func (t *slogTracer) EndOperation(ctx context.Context, op *Operation, err error) {
+	if !t.logger.Enabled(ctx, t.level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("operation", op.Name),
+		slog.Any("tables", op.Tables),
+		slog.Int("attempts", op.Attempts),
+		slog.Duration("duration", op.Duration),
+		slog.String("request_id", op.RequestID),
+	}
+	log := func(msg string, extra ...slog.Attr) {
+		t.logger.LogAttrs(ctx, t.level, msg, append(extra, attrs...)...)
+	}
+
+	switch {
+	case op.Throttles > 0:
+		log("dynamo: request throttled", slog.Int("throttles", op.Throttles))
+	case op.Attempts > 1:
+		log("dynamo: request retried")
+	}
+	if t.slow > 0 && op.Duration > t.slow {
+		log("dynamo: slow request", slog.Duration("threshold", t.slow))
+	}
+	if err != nil && IsCondCheckFailed(err) {
+		log("dynamo: condition check failed", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,125 @@
+package dynamo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// scriptedHTTP returns the given responses in order.
+type scriptedHTTP struct {
+	responses []scriptedResponse
+}
+
+type scriptedResponse struct {
+	status int
+	body   string
+}
+
+func (c *scriptedHTTP) Do(req *http.Request) (*http.Response, error) {
+	next := c.responses[0]
+	c.responses = c.responses[1:]
+	return &http.Response{
+		StatusCode: next.status,
+		Header:     http.Header{"X-Amzn-Requestid": []string{"req"}},
+		Body:       io.NopCloser(strings.NewReader(next.body)),
+		Request:    req,
+	}, nil
+}
+
+type recordingHandler struct {
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) messages() []string {
+	msgs := make([]string, 0, len(h.records))
+	for _, r := range h.records {
+		msgs = append(msgs, r.Message)
+	}
+	return msgs
+}
+
+func TestWithSlog(t *testing.T) {
+	const (
+		throttled  = `{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`
+		condFailed = `{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"nope"}`
+	)
+	httpClient := &scriptedHTTP{responses: []scriptedResponse{
+		{400, throttled},
+		{200, `{"Item":{"ID":{"N":"1"}}}`},
+		{400, condFailed},
+		{200, `{}`},
+	}}
+	handler := new(recordingHandler)
+	newDB := func(slow time.Duration) *DB {
+		cfg := aws.Config{
+			Region:      "us-east-1",
+			Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+			HTTPClient:  httpClient,
+			Retryer: func() aws.Retryer {
+				return retry.NewStandard(func(opts *retry.StandardOptions) {
+					opts.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+				})
+			},
+		}
+		return New(cfg, func(opts *dynamodb.Options) {
+			opts.BaseEndpoint = aws.String("http://localhost:8000")
+		}, WithSlogThreshold(slog.New(handler), slog.LevelWarn, slow))
+	}
+	db := newDB(time.Hour)
+	ctx := context.Background()
+
+	var item struct{ ID int }
+	if err := db.Table("Logged").Get("ID", 1).One(ctx, &item); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Table("Logged").Put(item).If("attribute_not_exists(ID)").Run(ctx)
+	if !IsCondCheckFailed(err) {
+		t.Fatal("expected condition check failure, got:", err)
+	}
+
+	want := []string{"dynamo: request throttled", "dynamo: condition check failed"}
+	if got := handler.messages(); !reflect.DeepEqual(got, want) {
+		t.Fatal("bad records. want:", want, "got:", got)
+	}
+	rec := handler.records[0]
+	if rec.Level != slog.LevelWarn {
+		t.Error("bad level:", rec.Level)
+	}
+	attrs := make(map[string]slog.Value)
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	if attrs["operation"].String() != "GetItem" || attrs["throttles"].Int64() != 1 || attrs["attempts"].Int64() != 2 {
+		t.Error("bad attributes:", attrs)
+	}
+
+	// every request is slow now
+	handler.records = nil
+	db = newDB(time.Nanosecond)
+	if err := db.Table("Logged").Put(item).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"dynamo: slow request"}, handler.messages(); !reflect.DeepEqual(got, want) {
+		t.Error("bad records. want:", want, "got:", got)
+	}
+}
@@ -0,0 +1,106 @@
+package dynamo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestSortedSets(t *testing.T) {
+	SetSortedSets(true)
+	defer SetSortedSets(false)
+
+	in := struct {
+		Strings map[string]struct{} `dynamo:",set"`
+		Ints    map[int]bool        `dynamo:",set"`
+		Binary  map[[2]byte]bool    `dynamo:",set"`
+	}{
+		Strings: map[string]struct{}{"c": {}, "a": {}, "b": {}, "aa": {}},
+		Ints:    map[int]bool{10: true, -1: true, 2: true, 100: true, 3: false},
+		Binary:  map[[2]byte]bool{{2, 0}: true, {0, 1}: true, {1, 9}: true},
+	}
+	for i := 0; i < 10; i++ {
+		item, err := MarshalItem(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := item["Strings"].(*types.AttributeValueMemberSS).Value, []string{"a", "aa", "b", "c"}; !reflect.DeepEqual(got, want) {
+			t.Fatal("bad SS order:", got)
+		}
+		if got, want := item["Ints"].(*types.AttributeValueMemberNS).Value, []string{"-1", "2", "10", "100"}; !reflect.DeepEqual(got, want) {
+			t.Fatal("bad NS order:", got)
+		}
+		if got, want := item["Binary"].(*types.AttributeValueMemberBS).Value, [][]byte{{0, 1}, {1, 9}, {2, 0}}; !reflect.DeepEqual(got, want) {
+			t.Fatal("bad BS order:", got)
+		}
+	}
+}
+
+type bodyRecorder struct {
+	bodies []string
+}
+
+func (r *bodyRecorder) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.bodies = append(r.bodies, string(body))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func TestWithSortedRequests(t *testing.T) {
+	ctx := context.Background()
+	rec := new(bodyRecorder)
+	client := dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://localhost:8000"),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   rec,
+	}, WithSortedRequests())
+	table := NewFromIface(client).Table("Sorted")
+
+	item := map[string]any{"ID": 1, "Z": "<z>", "M": map[string]any{"b": 2, "a": 1.50, "c": 3}}
+	for i := 0; i < 5; i++ {
+		if err := table.Put(item).Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const want = `{"Item":{"ID":{"N":"1"},"M":{"M":{"a":{"N":"1.5"},"b":{"N":"2"},"c":{"N":"3"}}},"Z":{"S":"<z>"}},"ReturnValues":"NONE","TableName":"Sorted"}`
+	for _, body := range rec.bodies {
+		if body != want {
+			t.Errorf("unexpected body.\nwant: %s\ngot:  %s", want, body)
+		}
+	}
+
+	// update expressions must be identical too
+	rec.bodies = nil
+	for i := 0; i < 5; i++ {
+		err := table.Update("ID", 1).
+			Add("A", 1).Add("B", 2).Add("C", 3).
+			Remove("X", "Y", "Z").
+			Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rec.bodies) != 5 {
+		t.Fatal("want 5 requests, got", len(rec.bodies))
+	}
+	for _, body := range rec.bodies[1:] {
+		if body != rec.bodies[0] {
+			t.Errorf("identical updates have different bodies.\nfirst: %s\ngot:   %s", rec.bodies[0], body)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxSortWindow is the largest [Query.Limit] allowed by [Query.PageSortedBy].
+const MaxSortWindow = 1000
+
+// PageSortedBy executes this request and unmarshals one page of results to out, which must be a pointer to a slice,
+// sorted client-side by the attribute at the given document path.
+// This is meant for paginated UI tables that sort by a column that isn't the sort key.
+//
+// The page is fetched in key order, bounded by [Query.Limit], which is required and can be at most [MaxSortWindow].
+// Only the results within the page are re-sorted: pages are not globally sorted by path,
+// and the last result of one page can sort after the first result of the next.
+// The returned PagingKey continues in key order, so passing it to [Query.StartFrom] never skips or repeats results,
+// and each page consumes no more read capacity than a regular query with the same limit.
+// For a global ordering by an attribute, query a secondary index that uses it as the sort key instead.
+//
+// Numbers are compared numerically, strings lexically, and binary data with [bytes.Compare].
+// Results without a value at path, or with a NULL value, come last in either order.
+// Values of different types are grouped by type. Results that compare equal keep their key order.
+func (q *Query) PageSortedBy(ctx context.Context, path string, order Order, out interface{}) (PagingKey, error) {
+	if _, err := parsePath(path); err != nil {
+		return nil, err
+	}
+	if q.limit <= 0 || q.limit > MaxSortWindow {
+		return nil, fmt.Errorf("dynamo: PageSortedBy: limit must be between 1 and %d, got %d", MaxSortWindow, q.limit)
+	}
+
+	var page []Item
+	iter := q.newIter(func(_ context.Context, item Item, _ any) error {
+		page = append(page, item)
+		return nil
+	})
+	for iter.Next(ctx, out) {
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	lek, err := iter.LastEvaluatedKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sortPage(page, path, order)
+	push := unmarshalAppendTo(out)
+	for _, item := range page {
+		if err := push(ctx, item, out); err != nil {
+			return nil, err
+		}
+	}
+	return lek, nil
+}
+
+// sortPage stably sorts items by their value at path.
+func sortPage(items []Item, path string, order Order) {
+	values := make([]types.AttributeValue, len(items))
+	for i, item := range items {
+		av, err := lookupPath(item, path)
+		if err != nil {
+			continue
+		}
+		if _, ok := av.(*types.AttributeValueMemberNULL); !ok {
+			values[i] = av
+		}
+	}
+	sort.Stable(sortedPage{items: items, values: values, order: order})
+}
+
+type sortedPage struct {
+	items  []Item
+	values []types.AttributeValue
+	order  Order
+}
+
+func (p sortedPage) Len() int {
+	return len(p.items)
+}
+
+func (p sortedPage) Less(i, j int) bool {
+	a, b := p.values[i], p.values[j]
+	switch {
+	case a == nil:
+		return false
+	case b == nil:
+		return true
+	}
+	c := compareAV(a, b)
+	if p.order == Descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (p sortedPage) Swap(i, j int) {
+	p.items[i], p.items[j] = p.items[j], p.items[i]
+	p.values[i], p.values[j] = p.values[j], p.values[i]
+}
+
+// compareAV returns -1, 0, or 1 depending on whether a sorts before, equal to, or after b.
+// Scalars of the same type are compared by value; anything else is ordered by type name.
+func compareAV(a, b types.AttributeValue) int {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			x, okx := new(big.Rat).SetString(a.Value)
+			y, oky := new(big.Rat).SetString(b.Value)
+			if okx && oky {
+				return x.Cmp(y)
+			}
+			return strings.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberBOOL:
+		if b, ok := b.(*types.AttributeValueMemberBOOL); ok {
+			switch {
+			case a.Value == b.Value:
+				return 0
+			case b.Value:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(avTypeName(a), avTypeName(b))
+}
@@ -0,0 +1,80 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// pricePager returns two pages of products in ID order.
+type pricePager struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (pricePager) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	product := func(id, price string) Item {
+		item := Item{"ID": &types.AttributeValueMemberN{Value: id}}
+		if price != "" {
+			item["Price"] = &types.AttributeValueMemberN{Value: price}
+		}
+		return item
+	}
+	if in.ExclusiveStartKey == nil {
+		return &dynamodb.QueryOutput{
+			Items:            []Item{product("1", "9.5"), product("2", ""), product("3", "10"), product("4", "1e1")},
+			LastEvaluatedKey: Item{"ID": &types.AttributeValueMemberN{Value: "4"}},
+		}, nil
+	}
+	return &dynamodb.QueryOutput{Items: []Item{product("5", "3")}}, nil
+}
+
+func TestPageSortedBy(t *testing.T) {
+	ctx := context.Background()
+	table := NewFromIface(pricePager{}).Table("Products")
+
+	type product struct {
+		ID    int
+		Price float64
+	}
+	ids := func(ps []product) []int {
+		var ids []int
+		for _, p := range ps {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	var page []product
+	lek, err := table.Get("Shop", 1).Limit(4).PageSortedBy(ctx, "Price", Descending, &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// equal prices keep key order, missing prices come last
+	if want := []int{3, 4, 1, 2}; !reflect.DeepEqual(ids(page), want) {
+		t.Error("bad order. want:", want, "got:", ids(page))
+	}
+
+	page = nil
+	lek, err = table.Get("Shop", 1).Limit(4).StartFrom(lek).PageSortedBy(ctx, "Price", Ascending, &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5}; !reflect.DeepEqual(ids(page), want) {
+		t.Error("bad order. want:", want, "got:", ids(page))
+	}
+	if lek != nil {
+		t.Error("unexpected LastEvaluatedKey:", lek)
+	}
+
+	if _, err := table.Get("Shop", 1).PageSortedBy(ctx, "Price", Ascending, &page); err == nil {
+		t.Error("want error without limit")
+	}
+	if _, err := table.Get("Shop", 1).Limit(MaxSortWindow+1).PageSortedBy(ctx, "Price", Ascending, &page); err == nil {
+		t.Error("want error for limit over MaxSortWindow")
+	}
+}
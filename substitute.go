@@ -2,9 +2,12 @@ package dynamo
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"encoding/base32"
 	"fmt"
+	"maps"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -13,8 +16,18 @@ import (
 
 // subber is a "mixin" for operators for keep track of subtituted keys and values
 type subber struct {
+	// db's marshaling options are used for values, or the defaults if nil
+	db        *DB
 	nameExpr  map[string]string
 	valueExpr Item
+	// deferred are values implementing MarshalerCtx, marshaled with the request's context (see values)
+	deferred []deferredValue
+}
+
+type deferredValue struct {
+	sub   string
+	value interface{}
+	flags encodeFlags
 }
 
 func (s *subber) subName(name string) string {
@@ -37,17 +50,43 @@ func (s *subber) subValue(value interface{}, flags encodeFlags) (string, error)
 	}
 
 	sub := fmt.Sprintf(":v%d", len(s.valueExpr))
-	av, err := marshal(value, flags)
+	av, deferred, err := marshalDeferred(s.db.codecContext(context.Background()), value, flags)
 	if err != nil {
 		return "", err
 	}
+	if !deferred {
+		av = stringerValue(value, av)
+	}
 	if av == nil {
 		return "", fmt.Errorf("invalid substitute value for '%s': %v", sub, av)
 	}
 	s.valueExpr[sub] = av
+	if deferred {
+		s.deferred = append(s.deferred, deferredValue{sub: sub, value: value, flags: flags})
+	}
 	return sub, nil
 }
 
+// values returns the substituted values, marshaling values implementing MarshalerCtx with ctx.
+func (s *subber) values(ctx context.Context) (Item, error) {
+	if len(s.deferred) == 0 {
+		return s.valueExpr, nil
+	}
+	values := maps.Clone(s.valueExpr)
+	ctx = s.db.codecContext(ctx)
+	for _, d := range s.deferred {
+		av, err := marshalContext(ctx, d.value, d.flags)
+		if err != nil {
+			return nil, err
+		}
+		if av == nil {
+			return nil, fmt.Errorf("invalid substitute value for '%s': %v", d.sub, av)
+		}
+		values[d.sub] = av
+	}
+	return values, nil
+}
+
 // subExpr takes a dynamo-flavored expression and fills in its placeholders
 // with the given args.
 func (s *subber) subExpr(expr string, args ...interface{}) (string, error) {
@@ -144,14 +183,44 @@ type ExpressionLiteral struct {
 	AttributeValues Item
 }
 
+// Path returns a document path made of the given attribute names, such as a nested map key.
+// Each name is used literally, so names containing dots, brackets, quotes, or reserved words are handled safely.
+// It can be passed to any function that takes an expression as $.
+// For example, this filters on the "animal.cow" key inside of the Meta map:
+//
+//	scan.Filter("$ = ?", dynamo.Path("Meta", "animal.cow"), "moo")
+//
+// To refer to an element of a list, add an index after the placeholder, as in "$[0]".
+// Paths can also be used for projections, as in ProjectExpr("$, $", path1, path2).
+func Path(names ...string) ExpressionLiteral {
+	lit := ExpressionLiteral{
+		AttributeNames: make(map[string]*string, len(names)),
+	}
+	subs := make([]string, 0, len(names))
+	for _, name := range names {
+		name := name
+		sub := "#s" + encodeName(name)
+		lit.AttributeNames[sub] = &name
+		subs = append(subs, sub)
+	}
+	lit.Expression = strings.Join(subs, ".")
+	return lit
+}
+
 // we don't want people to accidentally refer to our placeholders, so just slap an x_ in front of theirs
 var foreignPlaceholder = strings.NewReplacer("#", "#x_", ":", ":x_")
 
 // merge in a foreign expression literal
 // returns a rewritten expression with prefixed placeholders
 func (s *subber) merge(lit ExpressionLiteral) string {
+	p, replacer := "x_", foreignPlaceholder
 	prefix := func(key string) string {
-		return string(key[0]) + "x_" + key[1:]
+		return string(key[0]) + p + key[1:]
+	}
+	// if a previously merged literal used the same placeholders for something else, try x1_, x2_, etc.
+	for n := 1; s.mergeCollides(lit, prefix); n++ {
+		p = "x" + strconv.Itoa(n) + "_"
+		replacer = strings.NewReplacer("#", "#"+p, ":", ":"+p)
 	}
 
 	if len(lit.AttributeNames) > 0 && s.nameExpr == nil {
@@ -170,10 +239,26 @@ func (s *subber) merge(lit ExpressionLiteral) string {
 		s.valueExpr[safe] = v
 	}
 
-	expr := foreignPlaceholder.Replace(lit.Expression)
+	expr := replacer.Replace(lit.Expression)
 	return expr
 }
 
+// mergeCollides reports whether merging lit with the given placeholder prefix
+// would overwrite different names or any values already substituted.
+func (s *subber) mergeCollides(lit ExpressionLiteral, prefix func(string) string) bool {
+	for k, v := range lit.AttributeNames {
+		if name, ok := s.nameExpr[prefix(k)]; ok && name != *v {
+			return true
+		}
+	}
+	for k := range lit.AttributeValues {
+		if _, ok := s.valueExpr[prefix(k)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 var nameEncoder = base32.StdEncoding.WithPadding(base32.NoPadding)
 
 // encodeName consistently encodes a name.
@@ -196,6 +281,22 @@ func (s *subber) escape(name string) (string, error) {
 	return name, nil
 }
 
+// orFilters combines filters (which are ANDed together) with expr using OR, resulting in a single filter.
+func orFilters(filters []string, expr string) []string {
+	switch len(filters) {
+	case 0:
+		return []string{expr}
+	case 1:
+		return []string{"(" + filters[0] + " OR " + expr + ")"}
+	}
+	return []string{"((" + strings.Join(filters, " AND ") + ") OR " + expr + ")"}
+}
+
+// notExpr negates expr.
+func notExpr(expr string) string {
+	return "(NOT " + wrapExpr(expr) + ")"
+}
+
 // wrapExpr wraps expr in parens if needed
 func wrapExpr(expr string) string {
 	if len(expr) == 0 {
@@ -238,3 +339,49 @@ func wrapExpr(expr string) string {
 	}
 	return wrap
 }
+
+// MaxExpressionSize is the maximum length of an expression string in bytes,
+// such as a condition, filter, or update expression.
+const MaxExpressionSize = 4 * 1024
+
+// ExpressionTooLargeError is returned when an expression exceeds [MaxExpressionSize].
+type ExpressionTooLargeError struct {
+	// Name of the expression, such as "UpdateExpression".
+	Name string
+	// Size of the expression in bytes.
+	Size int
+}
+
+func (e *ExpressionTooLargeError) Error() string {
+	return fmt.Sprintf("dynamo: %s is too large (%d bytes, maximum is %d bytes)", e.Name, e.Size, MaxExpressionSize)
+}
+
+func checkExprSize(name string, expr *string) error {
+	if expr != nil && len(*expr) > MaxExpressionSize {
+		return &ExpressionTooLargeError{Name: name, Size: len(*expr)}
+	}
+	return nil
+}
+
+var placeholderRegexp = regexp.MustCompile(`[#:][A-Za-z0-9_]+`)
+
+// usedPlaceholders returns the subset of names and values referenced by expr.
+func usedPlaceholders(expr string, names map[string]string, values Item) (map[string]string, Item) {
+	var usedNames map[string]string
+	var usedValues Item
+	for _, ph := range placeholderRegexp.FindAllString(expr, -1) {
+		if name, ok := names[ph]; ok {
+			if usedNames == nil {
+				usedNames = make(map[string]string)
+			}
+			usedNames[ph] = name
+		}
+		if value, ok := values[ph]; ok {
+			if usedValues == nil {
+				usedValues = make(Item)
+			}
+			usedValues[ph] = value
+		}
+	}
+	return usedNames, usedValues
+}
@@ -108,3 +108,24 @@ func BenchmarkSubExpr(b *testing.B) {
 		s.subExpr(expr, 613, "Time", "2015-12-04")
 	}
 }
+
+func TestPath(t *testing.T) {
+	s := subber{}
+	expr, err := s.subExpr("$ = ? AND size($[0]) > ?", Path("Meta", "animal.cow"), "moo", Path("Count"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, cow, count := "#x_s"+encodeName("Meta"), "#x_s"+encodeName("animal.cow"), "#x_s"+encodeName("Count")
+	want := meta + "." + cow + " = :v0 AND size(" + count + "[0]) > :v1"
+	if expr != want {
+		t.Error("bad expression. want:", want, "got:", expr)
+	}
+	wantNames := map[string]string{
+		meta:  "Meta",
+		cow:   "animal.cow",
+		count: "Count",
+	}
+	if !reflect.DeepEqual(s.nameExpr, wantNames) {
+		t.Error("bad names. want:", wantNames, "got:", s.nameExpr)
+	}
+}
@@ -32,6 +32,12 @@ const (
 type Table struct {
 	name string
 	db   *DB
+	// time to live attribute, see TTLAttribute
+	ttl string
+	// see Intercept
+	interceptors []Interceptor
+	// see CoalesceGets
+	coalesce *getCoalescer
 }
 
 // Table returns a Table handle specified by name.
@@ -143,6 +149,11 @@ func (table Table) primaryKeys(ctx context.Context, lek, esk Item, index string)
 	return keys, nil
 }
 
+// description returns this table's cached description, calling DescribeTable if necessary.
+func (table Table) description(ctx context.Context) (Description, error) {
+	return table.db.tableState(table.name).desc.resolve(ctx, table.Describe().Run)
+}
+
 func lekify(item Item, keys map[string]struct{}) (Item, error) {
 	if item == nil {
 		// this shouldn't happen because in queries without results, a LastEvaluatedKey should be given to us by AWS
@@ -162,10 +173,31 @@ func lekify(item Item, keys map[string]struct{}) (Item, error) {
 	return lek, nil
 }
 
+// LEKFromItem returns a PagingKey for use with StartFrom that continues a query or scan after v,
+// such as when listing records after the last one a client has seen.
+// v is marshaled like an item to put, and must include the primary key of desc's table,
+// as well as the key of the given index if index is not blank.
+// desc can be the result of [Table.Describe].
+func LEKFromItem(v any, desc Description, index string) (PagingKey, error) {
+	if desc.HashKey == "" {
+		return nil, fmt.Errorf("dynamo: LEKFromItem: description of table %q has no hash key", desc.Name)
+	}
+	keys := desc.keys(index)
+	if keys == nil {
+		return nil, fmt.Errorf("dynamo: unknown index %s on table %s", index, desc.Name)
+	}
+	item, err := marshalItem(v)
+	if err != nil {
+		return nil, err
+	}
+	return lekify(item, keys)
+}
+
 // DeleteTable is a request to delete a table.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DeleteTable.html
 type DeleteTable struct {
-	table Table
+	table  Table
+	optFns []func(*dynamodb.Options)
 }
 
 // DeleteTable begins a new request to delete this table.
@@ -173,11 +205,22 @@ func (table Table) DeleteTable() *DeleteTable {
 	return &DeleteTable{table: table}
 }
 
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// They are not applied to the requests [DeleteTable.Wait] makes while waiting for the table to be deleted.
+func (dt *DeleteTable) RequestOptions(optFns ...func(*dynamodb.Options)) *DeleteTable {
+	dt.optFns = optFns
+	return dt
+}
+
 // Run executes this request and deletes the table.
 func (dt *DeleteTable) Run(ctx context.Context) error {
 	input := dt.input()
+	if err := dt.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return err
+	}
 	return dt.table.db.retry(ctx, func() error {
-		_, err := dt.table.db.client.DeleteTable(ctx, input)
+		_, err := dt.table.db.client.DeleteTable(ctx, input, dt.table.db.requestOptions(dt.optFns)...)
 		return err
 	})
 }
@@ -236,6 +279,10 @@ type ConsumedCapacity struct {
 	TableWrite float64
 	// TableName is the name of the table affected by this operation.
 	TableName string
+	// Tables is a map of table names to the capacity consumed by each table.
+	// This is only set for transactions, which can span multiple tables.
+	// Requests are not counted per table.
+	Tables map[string]*ConsumedCapacity
 
 	// Requests is the number of SDK requests made against DynamoDB's API.
 	Requests int
@@ -308,6 +355,26 @@ func (cc *ConsumedCapacity) add(raw *types.ConsumedCapacity) {
 	}
 }
 
+// addPerTable is like add, but also adds raw to the breakdown for its table in Tables.
+func (cc *ConsumedCapacity) addPerTable(raw *types.ConsumedCapacity) {
+	if cc == nil || raw == nil {
+		return
+	}
+	cc.add(raw)
+	if raw.TableName == nil {
+		return
+	}
+	if cc.Tables == nil {
+		cc.Tables = make(map[string]*ConsumedCapacity)
+	}
+	table := cc.Tables[*raw.TableName]
+	if table == nil {
+		table = new(ConsumedCapacity)
+		cc.Tables[*raw.TableName] = table
+	}
+	table.add(raw)
+}
+
 func (cc *ConsumedCapacity) incRequests() {
 	if cc == nil {
 		return
@@ -376,5 +443,16 @@ func mergeConsumedCapacity(dst, src *ConsumedCapacity) {
 	if dst.TableName == "" && src.TableName != "" {
 		dst.TableName = src.TableName
 	}
+	if len(src.Tables) > 0 {
+		if dst.Tables == nil {
+			dst.Tables = make(map[string]*ConsumedCapacity, len(src.Tables))
+		}
+		for name, consumed := range src.Tables {
+			if dst.Tables[name] == nil {
+				dst.Tables[name] = new(ConsumedCapacity)
+			}
+			mergeConsumedCapacity(dst.Tables[name], consumed)
+		}
+	}
 	dst.Requests += src.Requests
 }
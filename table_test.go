@@ -210,3 +210,54 @@ func normalizeDesc(desc *Description) {
 		return desc.LSI[i].Name < desc.LSI[j].Name
 	})
 }
+
+func TestLEKFromItem(t *testing.T) {
+	desc := Description{
+		Name:     "Orders",
+		HashKey:  "UserID",
+		RangeKey: "OrderID",
+		GSI: []Index{{
+			Name:     "Status-index",
+			HashKey:  "Status",
+			RangeKey: "Created",
+		}},
+	}
+	type order struct {
+		UserID  int
+		OrderID string
+		Status  string
+		Created time.Time
+		Total   int
+	}
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	o := order{UserID: 42, OrderID: "o-1", Status: "shipped", Created: created, Total: 100}
+
+	lek, err := LEKFromItem(o, desc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PagingKey{
+		"UserID":  &types.AttributeValueMemberN{Value: "42"},
+		"OrderID": &types.AttributeValueMemberS{Value: "o-1"},
+	}
+	if !reflect.DeepEqual(lek, want) {
+		t.Error("bad LEK. want:", want, "got:", lek)
+	}
+
+	lek, err = LEKFromItem(&o, desc, "Status-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want["Status"] = &types.AttributeValueMemberS{Value: "shipped"}
+	want["Created"] = &types.AttributeValueMemberS{Value: created.Format(time.RFC3339Nano)}
+	if !reflect.DeepEqual(lek, want) {
+		t.Error("bad index LEK. want:", want, "got:", lek)
+	}
+
+	if _, err := LEKFromItem(o, desc, "Nope-index"); err == nil {
+		t.Error("expected error for unknown index")
+	}
+	if _, err := LEKFromItem(struct{ UserID int }{1}, desc, ""); err == nil {
+		t.Error("expected error for missing range key")
+	}
+}
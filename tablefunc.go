@@ -0,0 +1,119 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var tableFuncSeq atomic.Int64
+
+// TableFunc returns a table whose name is chosen by fn when each request is sent,
+// using the request's context. This is useful when the table depends on the request,
+// such as architectures with a table per customer:
+//
+//	widgets := db.TableFunc(func(ctx context.Context) string {
+//		return "Widgets-" + tenantFrom(ctx)
+//	})
+//	err := widgets.Get("ID", id).One(ctx, &w)
+//
+// The returned table works with all operations, including batches and transactions,
+// so it can be created once and shared, instead of creating a table in every handler.
+// Its Name is a placeholder that identifies it, not a real table name.
+// If fn returns an empty name, the request fails.
+//
+// Cached table metadata, such as the description used to determine primary keys,
+// is shared by all of the tables fn chooses, so they should have the same schema.
+func (db *DB) TableFunc(fn func(ctx context.Context) string) Table {
+	name := fmt.Sprintf("<TableFunc %d>", tableFuncSeq.Add(1))
+	db.tableFuncs.Store(name, fn)
+	return db.Table(name)
+}
+
+// tableName returns the name to use for a request to the table with the given name,
+// which differs for tables made by TableFunc.
+func (db *DB) tableName(ctx context.Context, name string) (string, error) {
+	fn, ok := db.tableFuncs.Load(name)
+	if !ok {
+		return name, nil
+	}
+	resolved := fn.(func(context.Context) string)(ctx)
+	if resolved == "" {
+		return "", fmt.Errorf("dynamo: table %s: TableFunc returned an empty table name", name)
+	}
+	return resolved, nil
+}
+
+// resolveName replaces *name with the name to use for this request.
+func (db *DB) resolveName(ctx context.Context, name **string) error {
+	if *name == nil {
+		return nil
+	}
+	resolved, err := db.tableName(ctx, **name)
+	if err != nil {
+		return err
+	}
+	if resolved != **name {
+		*name = &resolved
+	}
+	return nil
+}
+
+// resolveNames returns a copy of items, a map of table names to requests, with names to use for this request,
+// and a map of the new names to the original ones, or nil if none changed.
+func resolveNames[V any](ctx context.Context, db *DB, items map[string]V) (map[string]V, map[string]string, error) {
+	var renamed map[string]string
+	resolved := make(map[string]V, len(items))
+	for name, v := range items {
+		to, err := db.tableName(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if to != name {
+			if renamed == nil {
+				renamed = make(map[string]string)
+			}
+			renamed[to] = name
+		}
+		resolved[to] = v
+	}
+	return resolved, renamed, nil
+}
+
+// resolveTxGet resolves the table names of a TransactGetItems request.
+func (db *DB) resolveTxGet(ctx context.Context, input *dynamodb.TransactGetItemsInput) error {
+	for _, item := range input.TransactItems {
+		if item.Get == nil {
+			continue
+		}
+		if err := db.resolveName(ctx, &item.Get.TableName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveTxWrite resolves the table names of a TransactWriteItems request.
+func (db *DB) resolveTxWrite(ctx context.Context, input *dynamodb.TransactWriteItemsInput) error {
+	for _, item := range input.TransactItems {
+		var name **string
+		switch {
+		case item.ConditionCheck != nil:
+			name = &item.ConditionCheck.TableName
+		case item.Delete != nil:
+			name = &item.Delete.TableName
+		case item.Put != nil:
+			name = &item.Put.TableName
+		case item.Update != nil:
+			name = &item.Update.TableName
+		default:
+			continue
+		}
+		if err := db.resolveName(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
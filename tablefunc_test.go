@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// nameRecorder records the table names of requests, and returns one item for reads.
+type nameRecorder struct {
+	dynamodbiface.DynamoDBAPI
+	names []string
+}
+
+var nameRecorderItem = Item{"ID": &types.AttributeValueMemberS{Value: "x"}}
+
+func (c *nameRecorder) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.names = append(c.names, *in.TableName)
+	return &dynamodb.GetItemOutput{Item: nameRecorderItem}, nil
+}
+
+func (c *nameRecorder) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.names = append(c.names, *in.TableName)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *nameRecorder) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.names = append(c.names, *in.TableName)
+	return &dynamodb.ScanOutput{Items: []Item{nameRecorderItem}}, nil
+}
+
+func (c *nameRecorder) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	resp := make(map[string][]Item)
+	for name := range in.RequestItems {
+		c.names = append(c.names, name)
+		resp[name] = []Item{nameRecorderItem}
+	}
+	return &dynamodb.BatchGetItemOutput{Responses: resp}, nil
+}
+
+func (c *nameRecorder) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, item := range in.TransactItems {
+		c.names = append(c.names, *item.Put.TableName)
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+type tenantKey struct{}
+
+func TestTableFunc(t *testing.T) {
+	client := new(nameRecorder)
+	db := NewFromIface(client)
+	table := db.TableFunc(func(ctx context.Context) string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		if tenant == "" {
+			return ""
+		}
+		return "Widgets-" + tenant
+	})
+	db.storeDesc(Description{Name: table.Name(), HashKey: "ID"})
+
+	type widget struct {
+		ID string
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, "a")
+	var w widget
+	if err := table.Get("ID", "x").One(ctx, &w); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put(widget{ID: "x"}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx = context.WithValue(context.Background(), tenantKey{}, "b")
+	var ws []widget
+	if err := table.Scan().All(ctx, &ws); err != nil {
+		t.Fatal(err)
+	}
+	var track string
+	iter := table.Batch("ID").Get(Keys{"x"}).IterWithTable(&track)
+	for iter.Next(ctx, &w) {
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if track != table.Name() {
+		t.Errorf("bad tracked table. want: %q, got: %q", table.Name(), track)
+	}
+	if err := db.WriteTx().Put(table.Put(widget{ID: "y"})).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Widgets-a", "Widgets-a", "Widgets-b", "Widgets-b", "Widgets-b"}
+	if !reflect.DeepEqual(client.names, want) {
+		t.Errorf("bad table names. want: %v, got: %v", want, client.names)
+	}
+
+	t.Run("empty name", func(t *testing.T) {
+		err := table.Get("ID", "x").One(context.Background(), &w)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if len(client.names) != len(want) {
+			t.Error("unexpected requests:", client.names[len(want):])
+		}
+	})
+}
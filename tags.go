@@ -0,0 +1,120 @@
+package dynamo
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// Tags returns this table's metadata tags, such as cost allocation tags.
+// The table's ARN is determined by its description, which is cached after the first DescribeTable call.
+// Tags, [Table.UpdateTags], and [Table.RemoveTags] need a client that implements [dynamodbiface.TagsAPI].
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ListTagsOfResource.html
+func (table Table) Tags(ctx context.Context) (map[string]string, error) {
+	client, err := clientAs[dynamodbiface.TagsAPI](table.db.client, "ListTagsOfResource")
+	if err != nil {
+		return nil, err
+	}
+	arn, err := table.arn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	input := &dynamodb.ListTagsOfResourceInput{
+		ResourceArn: &arn,
+	}
+	for {
+		var out *dynamodb.ListTagsOfResourceOutput
+		err := table.db.retry(ctx, func() error {
+			var err error
+			out, err = client.ListTagsOfResource(ctx, input, table.db.optFns...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range out.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		if out.NextToken == nil {
+			return tags, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// UpdateTags adds the given metadata tags to this table, overwriting the values of existing tags with the same keys.
+// Tags not given are left as-is; use [Table.RemoveTags] to delete them.
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TagResource.html
+func (table Table) UpdateTags(ctx context.Context, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	client, err := clientAs[dynamodbiface.TagsAPI](table.db.client, "TagResource")
+	if err != nil {
+		return err
+	}
+	arn, err := table.arn(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	input := &dynamodb.TagResourceInput{
+		ResourceArn: &arn,
+		Tags:        make([]types.Tag, 0, len(tags)),
+	}
+	for _, k := range keys {
+		input.Tags = append(input.Tags, types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
+	return table.db.retry(ctx, func() error {
+		_, err := client.TagResource(ctx, input, table.db.optFns...)
+		return err
+	})
+}
+
+// RemoveTags deletes the metadata tags with the given keys from this table.
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_UntagResource.html
+func (table Table) RemoveTags(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	client, err := clientAs[dynamodbiface.TagsAPI](table.db.client, "UntagResource")
+	if err != nil {
+		return err
+	}
+	arn, err := table.arn(ctx)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UntagResourceInput{
+		ResourceArn: &arn,
+		TagKeys:     keys,
+	}
+	return table.db.retry(ctx, func() error {
+		_, err := client.UntagResource(ctx, input, table.db.optFns...)
+		return err
+	})
+}
+
+func (table Table) arn(ctx context.Context) (string, error) {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return "", err
+	}
+	return desc.ARN, nil
+}
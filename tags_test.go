@@ -0,0 +1,104 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// tagsClient stores tags in memory, listing them one at a time.
+type tagsClient struct {
+	dynamodbiface.DynamoDBAPI
+	tags map[string]string
+}
+
+func (c *tagsClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName: in.TableName,
+			TableArn:  aws.String("arn:aws:dynamodb:us-east-1:123456789012:table/" + *in.TableName),
+		},
+	}, nil
+}
+
+func (c *tagsClient) ListTagsOfResource(_ context.Context, in *dynamodb.ListTagsOfResourceInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListTagsOfResourceOutput, error) {
+	if *in.ResourceArn != "arn:aws:dynamodb:us-east-1:123456789012:table/Tagged" {
+		return nil, fmt.Errorf("bad arn: %s", *in.ResourceArn)
+	}
+	var keys []string
+	for k := range c.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	start := 0
+	if in.NextToken != nil {
+		start = sort.SearchStrings(keys, *in.NextToken)
+	}
+	out := &dynamodb.ListTagsOfResourceOutput{}
+	if start < len(keys) {
+		out.Tags = []types.Tag{{Key: aws.String(keys[start]), Value: aws.String(c.tags[keys[start]])}}
+	}
+	if start+1 < len(keys) {
+		out.NextToken = aws.String(keys[start+1])
+	}
+	return out, nil
+}
+
+func (c *tagsClient) TagResource(_ context.Context, in *dynamodb.TagResourceInput, _ ...func(*dynamodb.Options)) (*dynamodb.TagResourceOutput, error) {
+	for _, tag := range in.Tags {
+		c.tags[*tag.Key] = *tag.Value
+	}
+	return &dynamodb.TagResourceOutput{}, nil
+}
+
+func (c *tagsClient) UntagResource(_ context.Context, in *dynamodb.UntagResourceInput, _ ...func(*dynamodb.Options)) (*dynamodb.UntagResourceOutput, error) {
+	for _, k := range in.TagKeys {
+		delete(c.tags, k)
+	}
+	return &dynamodb.UntagResourceOutput{}, nil
+}
+
+func TestTableTags(t *testing.T) {
+	ctx := context.Background()
+	client := &tagsClient{tags: map[string]string{"team": "storage"}}
+	table := NewFromIface(client).Table("Tagged")
+
+	err := table.UpdateTags(ctx, map[string]string{"cost-center": "1234", "env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.RemoveTags(ctx, "team"); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := table.Tags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"cost-center": "1234", "env": "prod"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Error("bad tags. want:", want, "got:", tags)
+	}
+
+	t.Run("unsupported client", func(t *testing.T) {
+		// keysClient can describe tables, but not tag them
+		table := NewFromIface(&keysClient{}).Table("Tagged")
+		if _, err := table.Tags(ctx); !errors.Is(err, errors.ErrUnsupported) {
+			t.Error("want ErrUnsupported, got:", err)
+		}
+		if err := table.UpdateTags(ctx, want); !errors.Is(err, errors.ErrUnsupported) {
+			t.Error("want ErrUnsupported, got:", err)
+		}
+	})
+}
+
+var _ dynamodbiface.TagsAPI = (*dynamodb.Client)(nil)
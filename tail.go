@@ -0,0 +1,353 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// StreamsAPI is the subset of the DynamoDB Streams client used by [Tail].
+// It is satisfied by [*dynamodbstreams.Client].
+type StreamsAPI interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+// DefaultTailPollInterval is the default time to wait between polls of a stream with no new records.
+const DefaultTailPollInterval = time.Second
+
+// DefaultTailClockSkew is the default tolerance for differences between the local clock
+// and the creation times of stream records. See [Tail.ClockSkew].
+const DefaultTailClockSkew = time.Minute
+
+// TailEvent is the kind of a [TailRecord].
+type TailEvent string
+
+// Tail events. Except for TailScanned, these are the event names of stream records.
+const (
+	// TailScanned is an item read by the initial scan.
+	TailScanned TailEvent = "SCAN"
+	TailInsert  TailEvent = "INSERT"
+	TailModify  TailEvent = "MODIFY"
+	TailRemove  TailEvent = "REMOVE"
+)
+
+// TailRecord is an item delivered by [Tail].
+type TailRecord struct {
+	Event TailEvent
+	// Keys is the item's primary key.
+	Keys Item
+	// NewImage is the item after the change, or the item read by the scan.
+	// It is nil for removals and streams that don't include new images.
+	NewImage Item
+	// OldImage is the item before the change.
+	// It is nil for scanned items, insertions, and streams that don't include old images.
+	OldImage Item
+	// Time is the approximate time of the change, or the zero time for scanned items.
+	Time time.Time
+	// SequenceNumber is the stream record's sequence number, or empty for scanned items.
+	SequenceNumber string
+}
+
+// Unmarshal unmarshals the record's new image, or its old image if there is no new image, into out.
+func (r TailRecord) Unmarshal(out interface{}) error {
+	if r.NewImage != nil {
+		return UnmarshalItem(r.NewImage, out)
+	}
+	return UnmarshalItem(r.OldImage, out)
+}
+
+// Tail is a feed of a table's items that starts with every item in the table, then follows changes.
+// See [Table.ScanThenTail].
+type Tail struct {
+	table    Table
+	streams  StreamsAPI
+	segments int
+	poll     time.Duration
+	skew     time.Duration
+}
+
+// ScanThenTail returns a feed of this table's items that first scans the whole table
+// and then reads the table's stream, using the given DynamoDB Streams client.
+// This is useful for keeping a copy of a table, such as a cache or search index, up to date from scratch.
+// The table must have a stream enabled.
+//
+// Stream records are read starting from just before the scan began, so no changes are missed,
+// but changes made during the scan can be delivered twice: once by the scan and again as stream records.
+// Records are skipped if their approximate creation time is before the scan began by more than
+// the clock skew tolerance (see [Tail.ClockSkew]), so changes made shortly before the scan can also be delivered twice.
+// Consumers should apply records idempotently.
+// Records of the same item are delivered in order, but records of different items are not.
+func (table Table) ScanThenTail(streams StreamsAPI) *Tail {
+	return &Tail{
+		table:    table,
+		streams:  streams,
+		segments: 1,
+		poll:     DefaultTailPollInterval,
+		skew:     DefaultTailClockSkew,
+	}
+}
+
+// Segments sets the number of segments to scan in parallel. The default is 1.
+func (t *Tail) Segments(n int) *Tail {
+	t.segments = max(n, 1)
+	return t
+}
+
+// PollInterval sets how long to wait before polling again when the stream has no new records.
+// The default is [DefaultTailPollInterval].
+func (t *Tail) PollInterval(d time.Duration) *Tail {
+	t.poll = d
+	return t
+}
+
+// ClockSkew sets how far the local clock, which is used to note when the scan began,
+// can be ahead of the clock that sets the creation time of stream records
+// without records of changes made during the scan being skipped.
+// The default is [DefaultTailClockSkew].
+func (t *Tail) ClockSkew(d time.Duration) *Tail {
+	t.skew = max(d, 0)
+	return t
+}
+
+// Run scans the table, calling fn for each item, then calls fn for each change read from the stream.
+// It runs until ctx is canceled, fn returns an error, or the stream is disabled and fully read, in which case it returns nil.
+func (t *Tail) Run(ctx context.Context, fn func(context.Context, TailRecord) error) error {
+	desc, err := t.table.Describe().Run(ctx)
+	if err != nil {
+		return err
+	}
+	if !desc.StreamEnabled || desc.LatestStreamARN == "" {
+		return fmt.Errorf("dynamo: tail: table %q does not have a stream enabled", t.table.Name())
+	}
+	// stream record times are set by DynamoDB and rounded down to the second,
+	// so allow for the local clock being ahead of it
+	since := time.Now().Add(-t.skew).Truncate(time.Second)
+
+	if err := t.scan(ctx, desc, fn); err != nil {
+		return err
+	}
+	return t.tail(ctx, desc.LatestStreamARN, since, fn)
+}
+
+func (t *Tail) scan(ctx context.Context, desc Description, fn func(context.Context, TailRecord) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	iter := t.table.Scan().IterParallel(ctx, t.segments)
+	for {
+		var item Item
+		if !iter.Next(ctx, &item) {
+			break
+		}
+		keys := Item{desc.HashKey: item[desc.HashKey]}
+		if desc.RangeKey != "" {
+			keys[desc.RangeKey] = item[desc.RangeKey]
+		}
+		if err := fn(ctx, TailRecord{Event: TailScanned, Keys: keys, NewImage: item}); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// tailShard is the progress of reading a shard.
+type tailShard struct {
+	parent  string
+	iter    *string
+	lastSeq string
+	done    bool
+}
+
+func (t *Tail) tail(ctx context.Context, arn string, since time.Time, fn func(context.Context, TailRecord) error) error {
+	shards := make(map[string]*tailShard)
+	refresh := true
+	for {
+		if refresh {
+			disabled, err := t.describeShards(ctx, arn, shards)
+			if err != nil {
+				return err
+			}
+			if disabled && allShardsDone(shards) {
+				return nil
+			}
+			refresh = false
+		}
+
+		ids := make([]string, 0, len(shards))
+		for id, shard := range shards {
+			if shard.done {
+				continue
+			}
+			// read parents before children, so changes to each item are in order
+			if parent, ok := shards[shard.parent]; ok && !parent.done {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if len(ids) == 0 {
+			refresh = true
+		}
+
+		var got int
+		for _, id := range ids {
+			shard := shards[id]
+			n, err := t.readShard(ctx, arn, id, shard, since, fn)
+			if err != nil {
+				return err
+			}
+			got += n
+			if shard.done {
+				// a closed shard is replaced by new ones
+				refresh = true
+			}
+		}
+		if got > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.poll):
+		}
+	}
+}
+
+// describeShards adds the stream's new shards to shards, and returns true if the stream is disabled.
+func (t *Tail) describeShards(ctx context.Context, arn string, shards map[string]*tailShard) (disabled bool, err error) {
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)}
+	for {
+		var out *dynamodbstreams.DescribeStreamOutput
+		err := t.table.db.retry(ctx, func() error {
+			var err error
+			out, err = t.streams.DescribeStream(ctx, input)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+		sd := out.StreamDescription
+		for _, shard := range sd.Shards {
+			id := aws.ToString(shard.ShardId)
+			if _, ok := shards[id]; !ok {
+				shards[id] = &tailShard{parent: aws.ToString(shard.ParentShardId)}
+			}
+		}
+		disabled = sd.StreamStatus == streamstypes.StreamStatusDisabled || sd.StreamStatus == streamstypes.StreamStatusDisabling
+		if sd.LastEvaluatedShardId == nil {
+			return disabled, nil
+		}
+		input.ExclusiveStartShardId = sd.LastEvaluatedShardId
+	}
+}
+
+func allShardsDone(shards map[string]*tailShard) bool {
+	for _, shard := range shards {
+		if !shard.done {
+			return false
+		}
+	}
+	return true
+}
+
+// readShard gets one batch of records from shard, returning the number of records read.
+func (t *Tail) readShard(ctx context.Context, arn, id string, shard *tailShard, since time.Time, fn func(context.Context, TailRecord) error) (int, error) {
+	if shard.iter == nil {
+		if err := t.shardIterator(ctx, arn, id, shard); err != nil {
+			return 0, err
+		}
+	}
+
+	var out *dynamodbstreams.GetRecordsOutput
+	err := t.table.db.retry(ctx, func() error {
+		var err error
+		out, err = t.streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{ShardIterator: shard.iter})
+		return err
+	})
+	var expired *streamstypes.ExpiredIteratorException
+	if errors.As(err, &expired) {
+		// pick up where we left off next time
+		shard.iter = nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rec := range out.Records {
+		record, err := tailRecordFrom(rec)
+		if err != nil {
+			return 0, err
+		}
+		shard.lastSeq = record.SequenceNumber
+		// records without a time can't be skipped safely
+		if !record.Time.IsZero() && record.Time.Before(since) {
+			continue
+		}
+		if err := fn(ctx, record); err != nil {
+			return 0, err
+		}
+	}
+	shard.iter = out.NextShardIterator
+	shard.done = out.NextShardIterator == nil
+	return len(out.Records), nil
+}
+
+func (t *Tail) shardIterator(ctx context.Context, arn, id string, shard *tailShard) error {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(arn),
+		ShardId:           aws.String(id),
+		ShardIteratorType: streamstypes.ShardIteratorTypeTrimHorizon,
+	}
+	if shard.lastSeq != "" {
+		input.ShardIteratorType = streamstypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(shard.lastSeq)
+	}
+	return t.table.db.retry(ctx, func() error {
+		out, err := t.streams.GetShardIterator(ctx, input)
+		if err != nil {
+			return err
+		}
+		shard.iter = out.ShardIterator
+		return nil
+	})
+}
+
+func tailRecordFrom(rec streamstypes.Record) (TailRecord, error) {
+	record := TailRecord{Event: TailEvent(rec.EventName)}
+	sr := rec.Dynamodb
+	if sr == nil {
+		return record, nil
+	}
+	record.SequenceNumber = aws.ToString(sr.SequenceNumber)
+	if sr.ApproximateCreationDateTime != nil {
+		record.Time = *sr.ApproximateCreationDateTime
+	}
+	var err error
+	if record.Keys, err = fromStreamsItem(sr.Keys); err != nil {
+		return record, err
+	}
+	if record.NewImage, err = fromStreamsItem(sr.NewImage); err != nil {
+		return record, err
+	}
+	if record.OldImage, err = fromStreamsItem(sr.OldImage); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+func fromStreamsItem(item map[string]streamstypes.AttributeValue) (Item, error) {
+	if item == nil {
+		return nil, nil
+	}
+	return attributevalue.FromDynamoDBStreamsMap(item)
+}
@@ -0,0 +1,131 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// tailClient is a table with a stream, containing one item.
+type tailClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (tailClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName:           aws.String("Tail"),
+		KeySchema:           []types.KeySchemaElement{{AttributeName: aws.String("ID"), KeyType: types.KeyTypeHash}},
+		StreamSpecification: &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages},
+		LatestStreamArn:     aws.String("arn:stream"),
+	}}, nil
+}
+
+func (tailClient) Scan(_ context.Context, _ *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return &dynamodb.ScanOutput{Items: []Item{
+		{"ID": &types.AttributeValueMemberS{Value: "a"}, "N": &types.AttributeValueMemberN{Value: "1"}},
+	}}, nil
+}
+
+// tailStreams is a disabled stream with a closed shard and its closed child.
+type tailStreams struct {
+	records map[string][]streamstypes.Record
+}
+
+func (s *tailStreams) DescribeStream(_ context.Context, _ *dynamodbstreams.DescribeStreamInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: &streamstypes.StreamDescription{
+		StreamStatus: streamstypes.StreamStatusDisabled,
+		Shards: []streamstypes.Shard{
+			{ShardId: aws.String("shard-2"), ParentShardId: aws.String("shard-1")},
+			{ShardId: aws.String("shard-1")},
+		},
+	}}, nil
+}
+
+func (s *tailStreams) GetShardIterator(_ context.Context, in *dynamodbstreams.GetShardIteratorInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: in.ShardId}, nil
+}
+
+func (s *tailStreams) GetRecords(_ context.Context, in *dynamodbstreams.GetRecordsInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	return &dynamodbstreams.GetRecordsOutput{Records: s.records[*in.ShardIterator]}, nil
+}
+
+func streamRecord(event streamstypes.OperationType, id, n string, at time.Time) streamstypes.Record {
+	return streamstypes.Record{
+		EventName: event,
+		Dynamodb: &streamstypes.StreamRecord{
+			ApproximateCreationDateTime: aws.Time(at),
+			Keys:                        map[string]streamstypes.AttributeValue{"ID": &streamstypes.AttributeValueMemberS{Value: id}},
+			NewImage: map[string]streamstypes.AttributeValue{
+				"ID": &streamstypes.AttributeValueMemberS{Value: id},
+				"N":  &streamstypes.AttributeValueMemberN{Value: n},
+			},
+			SequenceNumber: aws.String(id + n),
+		},
+	}
+}
+
+func TestScanThenTail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	untimed := streamRecord(streamstypes.OperationTypeModify, "d", "5", now)
+	untimed.Dynamodb.ApproximateCreationDateTime = nil
+	streams := &tailStreams{records: map[string][]streamstypes.Record{
+		"shard-1": {
+			streamRecord(streamstypes.OperationTypeInsert, "old", "0", now.Add(-time.Hour)),
+			streamRecord(streamstypes.OperationTypeModify, "a", "2", now.Add(time.Second)),
+		},
+		"shard-2": {
+			// within the clock skew tolerance
+			streamRecord(streamstypes.OperationTypeInsert, "c", "4", now.Add(-30*time.Second)),
+			streamRecord(streamstypes.OperationTypeInsert, "b", "3", now.Add(2*time.Second)),
+			untimed,
+		},
+	}}
+	table := NewFromIface(tailClient{}).Table("Tail")
+
+	type widget struct {
+		ID string
+		N  int
+	}
+	type event struct {
+		Event TailEvent
+		Item  widget
+	}
+	var got []event
+	err := table.ScanThenTail(streams).Segments(2).PollInterval(time.Millisecond).Run(ctx, func(_ context.Context, rec TailRecord) error {
+		var w widget
+		if err := rec.Unmarshal(&w); err != nil {
+			return err
+		}
+		if rec.Keys["ID"].(*types.AttributeValueMemberS).Value != w.ID {
+			t.Error("bad keys:", rec.Keys)
+		}
+		got = append(got, event{rec.Event, w})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []event{
+		// the fake returns the same item for each segment
+		{TailScanned, widget{"a", 1}},
+		{TailScanned, widget{"a", 1}},
+		{TailModify, widget{"a", 2}},
+		{TailInsert, widget{"c", 4}},
+		{TailInsert, widget{"b", 3}},
+		{TailModify, widget{"d", 5}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad records. want: %v, got: %v", want, got)
+	}
+}
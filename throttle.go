@@ -0,0 +1,49 @@
+package dynamo
+
+import (
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Throttle paces this query's requests to consume at most rcu read capacity units per second on average,
+// protecting tables shared with other workloads from background jobs.
+// After each page, the next request is delayed according to the capacity consumed by the page,
+// as reported by DynamoDB, or estimated from the size of the results if not reported.
+// The pace is shared by every request made with this query, including later calls to All, Iter, or Count.
+// Zero disables throttling.
+func (q *Query) Throttle(rcu float64) *Query {
+	q.throttle = newPacer(rcu)
+	return q
+}
+
+// Throttle paces this scan's requests to consume at most rcu read capacity units per second on average,
+// protecting tables shared with other workloads from background jobs.
+// After each page, the next request is delayed according to the capacity consumed by the page,
+// as reported by DynamoDB, or estimated from the size of the results if not reported.
+// The pace is shared by every request made with this scan, including every segment of a parallel scan.
+// Zero disables throttling.
+func (s *Scan) Throttle(rcu float64) *Scan {
+	s.throttle = newPacer(rcu)
+	return s
+}
+
+// readUnits returns the read capacity consumed by a page of results.
+// If DynamoDB didn't report it, it is estimated from the size of items:
+// 1 unit per 4 KB for strongly consistent reads, half that for eventually consistent reads.
+func readUnits(cc *types.ConsumedCapacity, items []Item, consistent *bool) float64 {
+	if cc != nil && cc.CapacityUnits != nil {
+		return *cc.CapacityUnits
+	}
+	size := 0
+	for _, item := range items {
+		// items from DynamoDB are valid, so this can't fail
+		n, _ := ItemSize(item)
+		size += n
+	}
+	units := math.Ceil(float64(size) / 4096)
+	if consistent == nil || !*consistent {
+		units /= 2
+	}
+	return units
+}
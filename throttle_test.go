@@ -0,0 +1,93 @@
+package dynamo
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// capacityPager returns 5 pages of 1 item each, each consuming 10 capacity units.
+type capacityPager struct {
+	dynamodbiface.DynamoDBAPI
+	returnCC []types.ReturnConsumedCapacity
+}
+
+func (c *capacityPager) page(esk Item, rcc types.ReturnConsumedCapacity) ([]Item, Item, *types.ConsumedCapacity) {
+	c.returnCC = append(c.returnCC, rcc)
+	n := 0
+	if esk != nil {
+		n, _ = strconv.Atoi(esk["ID"].(*types.AttributeValueMemberN).Value)
+	}
+	n++
+	item := Item{"ID": &types.AttributeValueMemberN{Value: strconv.Itoa(n)}}
+	var lek Item
+	if n < 5 {
+		lek = item
+	}
+	return []Item{item}, lek, &types.ConsumedCapacity{CapacityUnits: aws.Float64(10)}
+}
+
+func (c *capacityPager) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	items, lek, cc := c.page(in.ExclusiveStartKey, in.ReturnConsumedCapacity)
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: lek, ConsumedCapacity: cc}, nil
+}
+
+func (c *capacityPager) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	items, lek, cc := c.page(in.ExclusiveStartKey, in.ReturnConsumedCapacity)
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: lek, ConsumedCapacity: cc}, nil
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	runs := map[string]func(table Table, out *[]Item) error{
+		"query": func(table Table, out *[]Item) error {
+			return table.Get("Group", 1).Throttle(1000).All(ctx, out)
+		},
+		"scan": func(table Table, out *[]Item) error {
+			return table.Scan().Throttle(1000).All(ctx, out)
+		},
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			client := new(capacityPager)
+			table := NewFromIface(client).Table("Throttled")
+			var got []Item
+			start := time.Now()
+			if err := run(table, &got); err != nil {
+				t.Fatal(err)
+			}
+			// 4 waits of 10 units at 1000 units/s
+			if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+				t.Error("not throttled:", elapsed)
+			}
+			if len(got) != 5 {
+				t.Error("bad results:", len(got))
+			}
+			for _, rcc := range client.returnCC {
+				if rcc != types.ReturnConsumedCapacityTotal {
+					t.Error("consumed capacity not requested:", rcc)
+				}
+			}
+		})
+	}
+}
+
+func TestReadUnits(t *testing.T) {
+	big := Item{"Data": &types.AttributeValueMemberS{Value: string(make([]byte, 5000))}}
+	if got := readUnits(nil, []Item{big}, nil); got != 1 {
+		t.Error("bad eventually consistent estimate:", got)
+	}
+	if got := readUnits(nil, []Item{big}, aws.Bool(true)); got != 2 {
+		t.Error("bad strongly consistent estimate:", got)
+	}
+	if got := readUnits(&types.ConsumedCapacity{CapacityUnits: aws.Float64(3.5)}, []Item{big}, nil); got != 3.5 {
+		t.Error("reported capacity not used:", got)
+	}
+}
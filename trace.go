@@ -0,0 +1,201 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Tracer is notified of every DynamoDB API operation,
+// allowing for integration with tracing systems such as AWS X-Ray.
+// See [WithTracer].
+type Tracer interface {
+	// StartOperation is called before an operation begins.
+	// The returned context is used for the operation (including retries) and passed to EndOperation.
+	StartOperation(ctx context.Context, op *Operation) context.Context
+	// EndOperation is called after an operation finishes, with the error returned by the operation.
+	EndOperation(ctx context.Context, op *Operation, err error)
+}
+
+// Operation describes a DynamoDB API call for a [Tracer].
+type Operation struct {
+	// Name is the name of the API operation, such as "GetItem".
+	Name string
+	// Tables are the names of the tables involved, if any.
+	Tables []string
+	// Start is the time the operation started.
+	Start time.Time
+
+	// The following fields are set once the operation finishes.
+
+	// Duration is the total time taken, including retries.
+	Duration time.Duration
+	// Attempts is the number of attempts made, including the first.
+	Attempts int
+	// Throttles is the number of attempts that failed due to throttling.
+	Throttles int
+	// RequestID is the AWS request ID of the last attempt.
+	RequestID string
+	// ConsumedCapacity is the capacity consumed, if requested (see the ConsumedCapacity methods).
+	ConsumedCapacity []types.ConsumedCapacity
+}
+
+// WithTracer returns an option for [New] (or [dynamodb.NewFromConfig]) that reports every operation to t.
+//
+// For example, annotating AWS X-Ray subsegments:
+//
+//	type xrayTracer struct{}
+//
+//	func (xrayTracer) StartOperation(ctx context.Context, op *dynamo.Operation) context.Context {
+//		ctx, _ = xray.BeginSubsegment(ctx, "dynamodb."+op.Name)
+//		return ctx
+//	}
+//
+//	func (xrayTracer) EndOperation(ctx context.Context, op *dynamo.Operation, err error) {
+//		seg := xray.GetSegment(ctx)
+//		seg.AddAnnotation("operation", op.Name)
+//		seg.AddAnnotation("tables", strings.Join(op.Tables, ","))
+//		seg.AddMetadata("attempts", op.Attempts)
+//		seg.AddMetadata("consumed_capacity", op.ConsumedCapacity)
+//		seg.Close(err)
+//	}
+//
+//	db := dynamo.New(cfg, dynamo.WithTracer(xrayTracer{}))
+func WithTracer(t Tracer) func(*dynamodb.Options) {
+	id := middlewareID("dynamo.Tracer")
+	return func(opts *dynamodb.Options) {
+		opts.APIOptions = append(opts.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(&traceMiddleware{id: id, tracer: t, op: stack.ID()}, middleware.Before)
+		})
+	}
+}
+
+type traceMiddleware struct {
+	id     string
+	tracer Tracer
+	op     string
+}
+
+func (m *traceMiddleware) ID() string {
+	return m.id
+}
+
+var middlewareSeq atomic.Int64
+
+// middlewareID returns a unique middleware ID starting with name,
+// so that options adding the same kind of middleware can be used together.
+func middlewareID(name string) string {
+	return name + "." + strconv.FormatInt(middlewareSeq.Add(1), 10)
+}
+
+func (m *traceMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	op := &Operation{
+		Name:   m.op,
+		Tables: inputTables(in.Parameters),
+		Start:  time.Now(),
+	}
+	ctx = m.tracer.StartOperation(ctx, op)
+
+	out, meta, err := next.HandleInitialize(ctx, in)
+
+	op.Duration = time.Since(op.Start)
+	if attempts, ok := retry.GetAttemptResults(meta); ok {
+		op.Attempts = len(attempts.Results)
+	}
+	if op.Attempts == 0 {
+		op.Attempts = 1
+	}
+	op.Throttles = countThrottles(meta)
+	op.RequestID, _ = awsmiddleware.GetRequestIDMetadata(meta)
+	op.ConsumedCapacity = outputCapacity(out.Result)
+	m.tracer.EndOperation(ctx, op, err)
+
+	return out, meta, err
+}
+
+// inputTables returns the names of tables referenced by an operation's input.
+func inputTables(input any) []string {
+	seen := make(map[string]struct{})
+	add := func(name *string) {
+		if name != nil {
+			seen[*name] = struct{}{}
+		}
+	}
+	switch input := input.(type) {
+	case *dynamodb.TransactGetItemsInput:
+		for _, item := range input.TransactItems {
+			if item.Get != nil {
+				add(item.Get.TableName)
+			}
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		for _, item := range input.TransactItems {
+			switch {
+			case item.ConditionCheck != nil:
+				add(item.ConditionCheck.TableName)
+			case item.Delete != nil:
+				add(item.Delete.TableName)
+			case item.Put != nil:
+				add(item.Put.TableName)
+			case item.Update != nil:
+				add(item.Update.TableName)
+			}
+		}
+	default:
+		rv := reflect.ValueOf(input)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		rv = rv.Elem()
+		if field := rv.FieldByName("TableName"); field.IsValid() {
+			if name, ok := field.Interface().(*string); ok {
+				add(name)
+			}
+		}
+		if items := rv.FieldByName("RequestItems"); items.Kind() == reflect.Map {
+			for _, k := range items.MapKeys() {
+				name := k.String()
+				add(&name)
+			}
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	tables := make([]string, 0, len(seen))
+	for name := range seen {
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// outputCapacity returns the consumed capacity reported by an operation's output.
+func outputCapacity(output any) []types.ConsumedCapacity {
+	rv := reflect.ValueOf(output)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field := rv.Elem().FieldByName("ConsumedCapacity")
+	if !field.IsValid() {
+		return nil
+	}
+	switch cc := field.Interface().(type) {
+	case *types.ConsumedCapacity:
+		if cc != nil {
+			return []types.ConsumedCapacity{*cc}
+		}
+	case []types.ConsumedCapacity:
+		return cc
+	}
+	return nil
+}
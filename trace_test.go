@@ -0,0 +1,130 @@
+package dynamo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// flakyHTTP fails the first request with a server error, then returns body.
+type flakyHTTP struct {
+	body  string
+	calls int
+}
+
+func (c *flakyHTTP) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"X-Amzn-Requestid": []string{"req-" + strings.Repeat("x", c.calls)}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Request:    req,
+	}
+	if c.calls == 1 {
+		resp.StatusCode = 500
+		resp.Body = io.NopCloser(strings.NewReader(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"oops"}`))
+	}
+	return resp, nil
+}
+
+type recordingTracer struct {
+	started []string
+	ended   []*Operation
+	errs    []error
+}
+
+type tracerCtxKey struct{}
+
+func (t *recordingTracer) StartOperation(ctx context.Context, op *Operation) context.Context {
+	t.started = append(t.started, op.Name)
+	return context.WithValue(ctx, tracerCtxKey{}, op.Name)
+}
+
+func (t *recordingTracer) EndOperation(ctx context.Context, op *Operation, err error) {
+	if ctx.Value(tracerCtxKey{}) != op.Name {
+		panic("context not passed through")
+	}
+	t.ended = append(t.ended, op)
+	t.errs = append(t.errs, err)
+}
+
+func TestWithTracer(t *testing.T) {
+	httpClient := &flakyHTTP{body: `{"Item":{"ID":{"N":"1"}},"ConsumedCapacity":{"TableName":"Traced","CapacityUnits":0.5}}`}
+	tracer := new(recordingTracer)
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:  httpClient,
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(opts *retry.StandardOptions) {
+				opts.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})
+		},
+	}
+	db := New(cfg, func(opts *dynamodb.Options) {
+		opts.BaseEndpoint = aws.String("http://localhost:8000")
+	}, WithTracer(tracer))
+
+	var item struct{ ID int }
+	var cc ConsumedCapacity
+	err := db.Table("Traced").Get("ID", 1).ConsumedCapacity(&cc).One(context.Background(), &item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != 1 {
+		t.Error("bad item:", item)
+	}
+
+	if want := []string{"GetItem"}; !reflect.DeepEqual(tracer.started, want) {
+		t.Fatal("bad started operations. want:", want, "got:", tracer.started)
+	}
+	op := tracer.ended[0]
+	if op.Name != "GetItem" || !reflect.DeepEqual(op.Tables, []string{"Traced"}) {
+		t.Error("bad operation:", op)
+	}
+	if op.Attempts != 2 {
+		t.Error("bad attempts. want:", 2, "got:", op.Attempts)
+	}
+	if op.RequestID != "req-xx" {
+		t.Error("bad request ID:", op.RequestID)
+	}
+	if len(op.ConsumedCapacity) != 1 || *op.ConsumedCapacity[0].CapacityUnits != 0.5 {
+		t.Error("bad consumed capacity:", op.ConsumedCapacity)
+	}
+	if tracer.errs[0] != nil {
+		t.Error("unexpected error:", tracer.errs[0])
+	}
+}
+
+func TestWithTracerCombined(t *testing.T) {
+	httpClient := &flakyHTTP{body: `{"Item":{"ID":{"N":"1"}}}`, calls: 1}
+	first, second := new(recordingTracer), new(recordingTracer)
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:  httpClient,
+	}
+	db := New(cfg, func(opts *dynamodb.Options) {
+		opts.BaseEndpoint = aws.String("http://localhost:8000")
+	}, WithTracer(first), WithTracer(second), WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil)), slog.LevelInfo))
+
+	var item struct{ ID int }
+	if err := db.Table("Traced").Get("ID", 1).One(context.Background(), &item); err != nil {
+		t.Fatal(err)
+	}
+	for _, tracer := range []*recordingTracer{first, second} {
+		if want := []string{"GetItem"}; !reflect.DeepEqual(tracer.started, want) {
+			t.Error("bad started operations. want:", want, "got:", tracer.started)
+		}
+	}
+}
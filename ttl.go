@@ -2,6 +2,9 @@ package dynamo
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -16,6 +19,7 @@ type UpdateTTL struct {
 	table   Table
 	attrib  string
 	enabled bool
+	optFns  []func(*dynamodb.Options)
 }
 
 // UpdateTTL begins a new request to enable or disable this table's time to live.
@@ -31,12 +35,22 @@ func (table Table) UpdateTTL(attribute string, enabled bool) *UpdateTTL {
 	}
 }
 
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (ttl *UpdateTTL) RequestOptions(optFns ...func(*dynamodb.Options)) *UpdateTTL {
+	ttl.optFns = optFns
+	return ttl
+}
+
 // Run executes this request.
 func (ttl *UpdateTTL) Run(ctx context.Context) error {
 	input := ttl.input()
+	if err := ttl.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return err
+	}
 
 	err := ttl.table.db.retry(ctx, func() error {
-		_, err := ttl.table.db.client.UpdateTimeToLive(ctx, input)
+		_, err := ttl.table.db.client.UpdateTimeToLive(ctx, input, ttl.table.db.requestOptions(ttl.optFns)...)
 		return err
 	})
 	return err
@@ -54,22 +68,33 @@ func (ttl *UpdateTTL) input() *dynamodb.UpdateTimeToLiveInput {
 
 // DescribeTTL is a request to obtain details about a table's time to live configuration.
 type DescribeTTL struct {
-	table Table
+	table  Table
+	optFns []func(*dynamodb.Options)
 }
 
 // DescribeTTL begins a new request to obtain details about this table's time to live configuration.
 func (table Table) DescribeTTL() *DescribeTTL {
-	return &DescribeTTL{table}
+	return &DescribeTTL{table: table}
+}
+
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (d *DescribeTTL) RequestOptions(optFns ...func(*dynamodb.Options)) *DescribeTTL {
+	d.optFns = optFns
+	return d
 }
 
 // Run executes this request and returns details about time to live, or an error.
 func (d *DescribeTTL) Run(ctx context.Context) (TTLDescription, error) {
 	input := d.input()
+	if err := d.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return TTLDescription{}, err
+	}
 
 	var result *dynamodb.DescribeTimeToLiveOutput
 	err := d.table.db.retry(ctx, func() error {
 		var err error
-		result, err = d.table.db.client.DescribeTimeToLive(ctx, input)
+		result, err = d.table.db.client.DescribeTimeToLive(ctx, input, d.table.db.requestOptions(d.optFns)...)
 		return err
 	})
 	if err != nil {
@@ -85,6 +110,7 @@ func (d *DescribeTTL) Run(ctx context.Context) (TTLDescription, error) {
 	if result.TimeToLiveDescription.AttributeName != nil {
 		desc.Attribute = *result.TimeToLiveDescription.AttributeName
 	}
+	d.table.db.tableState(d.table.name).ttl.store(desc)
 	return desc, nil
 }
 
@@ -117,3 +143,73 @@ const (
 	TTLDisabled  TTLStatus = "DISABLED"
 	TTLDisabling TTLStatus = "DISABLING"
 )
+
+// TTLAttribute returns a copy of table that uses name as its time to live attribute for [BatchWrite.PutWithTTL],
+// instead of looking it up with [Table.DescribeTTL].
+func (table Table) TTLAttribute(name string) Table {
+	table.ttl = name
+	return table
+}
+
+// ttlAttribute returns the name of this table's time to live attribute.
+func (table Table) ttlAttribute(ctx context.Context) (string, error) {
+	if table.ttl != "" {
+		return table.ttl, nil
+	}
+	desc, err := table.ttlDescription(ctx)
+	if err != nil {
+		return "", err
+	}
+	if desc.Attribute == "" || desc.Status == TTLDisabled || desc.Status == TTLDisabling {
+		return "", fmt.Errorf("dynamo: time to live is not enabled for table %q (status: %s)", table.Name(), desc.Status)
+	}
+	return desc.Attribute, nil
+}
+
+// PutWithTTL adds put operations for items to this batch using the default table,
+// setting each item's time to live attribute so that it expires ttl from now.
+// Any existing value of the attribute is overwritten.
+// The attribute is the one given to [Table.TTLAttribute], or if unset, the table's current time to live attribute,
+// which is looked up with [Table.DescribeTTL] when the batch is run and cached (see [TableConfig]).
+// Run returns an error if time to live is not enabled for the table.
+func (bw *BatchWrite) PutWithTTL(ttl time.Duration, items ...interface{}) *BatchWrite {
+	start := len(bw.ops)
+	bw.PutIn(bw.batch.table, items...)
+	expires := time.Now().Add(ttl).Unix()
+	for i := start; i < len(bw.ops); i++ {
+		bw.ops[i].expires = expires
+	}
+	bw.addTTLTable(bw.batch.table.Name(), bw.batch.table)
+	return bw
+}
+
+func (bw *BatchWrite) addTTLTable(name string, table Table) {
+	if bw.ttlTables == nil {
+		bw.ttlTables = make(map[string]Table)
+	}
+	if _, ok := bw.ttlTables[name]; !ok || table.ttl != "" {
+		bw.ttlTables[name] = table
+	}
+}
+
+// stampTTL sets the time to live attribute of puts added with PutWithTTL.
+func (bw *BatchWrite) stampTTL(ctx context.Context) error {
+	if len(bw.ttlTables) == 0 {
+		return nil
+	}
+	attrs := make(map[string]string, len(bw.ttlTables))
+	for name, table := range bw.ttlTables {
+		attr, err := table.ttlAttribute(ctx)
+		if err != nil {
+			return err
+		}
+		attrs[name] = attr
+	}
+	for _, op := range bw.ops {
+		if op.expires == 0 || op.op.PutRequest == nil {
+			continue
+		}
+		op.op.PutRequest.Item[attrs[op.table]] = &types.AttributeValueMemberN{Value: strconv.FormatInt(op.expires, 10)}
+	}
+	return nil
+}
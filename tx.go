@@ -3,8 +3,10 @@ package dynamo
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -27,6 +29,8 @@ type GetTx struct {
 	db           *DB
 	items        []getTxOp
 	unmarshalers map[getTxOp]interface{}
+	modify       func(*dynamodb.TransactGetItemsInput)
+	optFns       []func(*dynamodb.Options)
 	cc           *ConsumedCapacity
 }
 
@@ -54,7 +58,22 @@ func (tx *GetTx) GetOne(q *Query, out interface{}) *GetTx {
 	return tx
 }
 
+// ModifyInput sets a function that is called with the input of the TransactGetItems request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+func (tx *GetTx) ModifyInput(fn func(*dynamodb.TransactGetItemsInput)) *GetTx {
+	tx.modify = fn
+	return tx
+}
+
+// RequestOptions sets options that are applied to each request made by this transaction, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (tx *GetTx) RequestOptions(optFns ...func(*dynamodb.Options)) *GetTx {
+	tx.optFns = optFns
+	return tx
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this transaction and add it to cc.
+// The capacity consumed by each table is also added to cc.Tables.
 func (tx *GetTx) ConsumedCapacity(cc *ConsumedCapacity) *GetTx {
 	tx.cc = cc
 	return tx
@@ -62,18 +81,24 @@ func (tx *GetTx) ConsumedCapacity(cc *ConsumedCapacity) *GetTx {
 
 // Run executes this transaction and unmarshals everything specified by GetOne.
 func (tx *GetTx) Run(ctx context.Context) error {
+	if err := tx.db.checkTransactions(); err != nil {
+		return err
+	}
 	input, err := tx.input()
 	if err != nil {
 		return err
 	}
+	if err := tx.db.resolveTxGet(ctx, input); err != nil {
+		return err
+	}
 	var resp *dynamodb.TransactGetItemsOutput
 	err = tx.db.retry(ctx, func() error {
 		var err error
-		resp, err = tx.db.client.TransactGetItems(ctx, input)
+		resp, err = tx.db.client.TransactGetItems(ctx, input, tx.db.requestOptions(tx.optFns)...)
 		tx.cc.incRequests()
 		if tx.cc != nil && resp != nil {
 			for i := range resp.ConsumedCapacity {
-				tx.cc.add(&resp.ConsumedCapacity[i])
+				tx.cc.addPerTable(&resp.ConsumedCapacity[i])
 			}
 		}
 		return err
@@ -84,16 +109,31 @@ func (tx *GetTx) Run(ctx context.Context) error {
 	if isResponsesEmpty(resp.Responses) {
 		return ErrNotFound
 	}
-	return tx.unmarshal(resp)
+	return tx.unmarshal(ctx, resp, nil)
 }
 
-func (tx *GetTx) unmarshal(resp *dynamodb.TransactGetItemsOutput) error {
+// unmarshal unmarshals each item to its bound output, and appends it to out if it isn't nil.
+// Read interceptors are run once per item.
+func (tx *GetTx) unmarshal(ctx context.Context, resp *dynamodb.TransactGetItemsOutput, out interface{}) error {
+	var push unmarshalFunc
+	if out != nil {
+		push = unmarshalAppendTo(out)
+	}
+	ctx = tx.db.codecContext(ctx)
 	for i, item := range resp.Responses {
 		if item.Item == nil {
 			continue
 		}
+		if err := interceptTxRead(tx.items[i], item.Item); err != nil {
+			return err
+		}
 		if target := tx.unmarshalers[tx.items[i]]; target != nil {
-			if err := UnmarshalItem(item.Item, target); err != nil {
+			if err := unmarshalItem(ctx, item.Item, target); err != nil {
+				return err
+			}
+		}
+		if push != nil {
+			if err := push(ctx, item.Item, out); err != nil {
 				return err
 			}
 		}
@@ -103,18 +143,24 @@ func (tx *GetTx) unmarshal(resp *dynamodb.TransactGetItemsOutput) error {
 
 // All executes this transaction and unmarshals every value to out, which must be a pointer to a slice.
 func (tx *GetTx) All(ctx context.Context, out interface{}) error {
+	if err := tx.db.checkTransactions(); err != nil {
+		return err
+	}
 	input, err := tx.input()
 	if err != nil {
 		return err
 	}
+	if err := tx.db.resolveTxGet(ctx, input); err != nil {
+		return err
+	}
 	var resp *dynamodb.TransactGetItemsOutput
 	err = tx.db.retry(ctx, func() error {
 		var err error
-		resp, err = tx.db.client.TransactGetItems(ctx, input)
+		resp, err = tx.db.client.TransactGetItems(ctx, input, tx.db.requestOptions(tx.optFns)...)
 		tx.cc.incRequests()
 		if tx.cc != nil && resp != nil {
 			for i := range resp.ConsumedCapacity {
-				tx.cc.add(&resp.ConsumedCapacity[i])
+				tx.cc.addPerTable(&resp.ConsumedCapacity[i])
 			}
 		}
 		return err
@@ -125,17 +171,16 @@ func (tx *GetTx) All(ctx context.Context, out interface{}) error {
 	if isResponsesEmpty(resp.Responses) {
 		return ErrNotFound
 	}
-	if err := tx.unmarshal(resp); err != nil {
-		return err
-	}
-	push := unmarshalAppendTo(out)
-	for _, item := range resp.Responses {
-		if item.Item == nil {
-			continue
-		}
-		if err := push(item.Item, out); err != nil {
-			return err
-		}
+	return tx.unmarshal(ctx, resp, out)
+}
+
+// interceptTxRead runs the read interceptors of op's table.
+func interceptTxRead(op getTxOp, item Item) error {
+	switch op := op.(type) {
+	case *Query:
+		return op.table.interceptRead(item)
+	case *keyGet:
+		return op.table.interceptRead(item)
 	}
 	return nil
 }
@@ -155,11 +200,14 @@ func (tx *GetTx) input() (*dynamodb.TransactGetItemsInput, error) {
 	if tx.cc != nil {
 		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
 	}
+	if tx.modify != nil {
+		tx.modify(input)
+	}
 	return input, nil
 }
 
 type writeTxOp interface {
-	writeTxItem() (*types.TransactWriteItem, error)
+	writeTxItem(ctx context.Context) (*types.TransactWriteItem, error)
 }
 
 // WriteTx is a transaction to delete, put, update, and check items.
@@ -171,7 +219,11 @@ type WriteTx struct {
 	db         *DB
 	items      []writeTxOp
 	token      string
+	payloadTok bool
 	onCondFail types.ReturnValuesOnConditionCheckFailure
+	outs       map[writeTxOp]interface{}
+	modify     func(*dynamodb.TransactWriteItemsInput)
+	optFns     []func(*dynamodb.Options)
 	cc         *ConsumedCapacity
 	err        error
 }
@@ -201,6 +253,33 @@ func (tx *WriteTx) Update(u *Update) *WriteTx {
 	return tx
 }
 
+// UpdateReturning adds an update operation to this transaction, and specifies out to which the item is unmarshaled.
+// If the transaction succeeds, the updated item is read back with a follow-up get transaction (TransactGetItems).
+// If the transaction is canceled because a condition failed, out receives the item's current value as included
+// in the cancellation reasons (see [WriteTx.IncludeAllItemsInCondCheckFail]), if it exists.
+// Out must be a pointer. You can use this multiple times in one transaction.
+func (tx *WriteTx) UpdateReturning(u *Update, out interface{}) *WriteTx {
+	tx.Update(u)
+	tx.bind(u, out)
+	return tx
+}
+
+// PutReturning adds a put operation to this transaction, and specifies out to which the item is unmarshaled.
+// Results are handled the same as [WriteTx.UpdateReturning].
+// Out must be a pointer. You can use this multiple times in one transaction.
+func (tx *WriteTx) PutReturning(p *Put, out interface{}) *WriteTx {
+	tx.Put(p)
+	tx.bind(p, out)
+	return tx
+}
+
+func (tx *WriteTx) bind(op writeTxOp, out interface{}) {
+	if tx.outs == nil {
+		tx.outs = make(map[writeTxOp]interface{})
+	}
+	tx.outs[op] = out
+}
+
 // Check adds a conditional check to this transaction.
 func (tx *WriteTx) Check(check *ConditionCheck) *WriteTx {
 	tx.items = append(tx.items, check)
@@ -226,6 +305,7 @@ func (tx *WriteTx) IncludeAllItemsInCondCheckFail(enabled bool) *WriteTx {
 // An idempotent transaction ran multiple times will have the same effect as being run once.
 // An idempotent request is only good for 10 minutes, after that it will be considered a new request.
 func (tx *WriteTx) Idempotent(enabled bool) *WriteTx {
+	tx.payloadTok = false
 	if tx.token != "" && enabled {
 		return tx
 	}
@@ -246,6 +326,18 @@ func newIdempotencyToken() (string, error) {
 	return hex.EncodeToString(b[:]), err
 }
 
+// payloadToken returns an idempotency token derived from the canonical serialization of items.
+func payloadToken(items []types.TransactWriteItem) string {
+	h := sha256.New()
+	w := canonicalWriter{h: h}
+	w.len(len(items))
+	for _, item := range items {
+		w.writeTxItem(item)
+	}
+	// tokens can be at most 36 characters
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
 // IdempotentWithToken marks this transaction as idempotent and explicitly specifies the token value.
 // If token is empty, idempotency will be disabled instead.
 // Unless you have special circumstances that require a custom token, consider using Idempotent to generate a token for you.
@@ -253,56 +345,219 @@ func newIdempotencyToken() (string, error) {
 // An idempotent request (token) is only good for 10 minutes, after that it will be considered a new request.
 func (tx *WriteTx) IdempotentWithToken(token string) *WriteTx {
 	tx.token = token
+	tx.payloadTok = false
+	return tx
+}
+
+// IdempotentFromPayload marks this transaction as idempotent, deriving its token from a hash of the transaction's operations.
+// Unlike Idempotent, logically identical transactions built separately (such as retries from different processes)
+// share the same token, so DynamoDB will only apply them once.
+// Operations must be added in the same order for their tokens to match.
+// An idempotent request (token) is only good for 10 minutes, after that it will be considered a new request.
+func (tx *WriteTx) IdempotentFromPayload() *WriteTx {
+	tx.token = ""
+	tx.payloadTok = true
+	return tx
+}
+
+// ModifyInput sets a function that is called with the input of the TransactWriteItems request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+func (tx *WriteTx) ModifyInput(fn func(*dynamodb.TransactWriteItemsInput)) *WriteTx {
+	tx.modify = fn
+	return tx
+}
+
+// RequestOptions sets options that are applied to each request made by this transaction, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (tx *WriteTx) RequestOptions(optFns ...func(*dynamodb.Options)) *WriteTx {
+	tx.optFns = optFns
 	return tx
 }
 
 // ConsumedCapacity will measure the throughput capacity consumed by this transaction and add it to cc.
+// The capacity consumed by each table is also added to cc.Tables.
 func (tx *WriteTx) ConsumedCapacity(cc *ConsumedCapacity) *WriteTx {
 	tx.cc = cc
 	return tx
 }
 
 // Run executes this transaction.
+// Items bound with UpdateReturning or PutReturning are unmarshaled afterwards.
+// If the transaction was committed but those items couldn't be read,
+// Run returns a [*ReadBackError]; the transaction should not be retried in that case.
 func (tx *WriteTx) Run(ctx context.Context) error {
 	if tx.err != nil {
 		return tx.err
 	}
-	input, err := tx.input()
+	if err := tx.db.checkTransactions(); err != nil {
+		return err
+	}
+	input, err := tx.input(ctx)
 	if err != nil {
 		return err
 	}
+	if err := tx.db.resolveTxWrite(ctx, input); err != nil {
+		return err
+	}
 	err = tx.db.retry(ctx, func() error {
-		out, err := tx.db.client.TransactWriteItems(ctx, input)
+		out, err := tx.db.client.TransactWriteItems(ctx, input, tx.db.requestOptions(tx.optFns)...)
 		tx.cc.incRequests()
 		if out != nil {
 			for i := range out.ConsumedCapacity {
-				tx.cc.add(&out.ConsumedCapacity[i])
+				tx.cc.addPerTable(&out.ConsumedCapacity[i])
 			}
 		}
 		return err
 	})
+	if len(tx.outs) == 0 {
+		return err
+	}
+	if err != nil {
+		if uerr := tx.unmarshalCanceled(ctx, err); uerr != nil {
+			return errors.Join(err, uerr)
+		}
+		return err
+	}
+	if err := tx.readBack(ctx); err != nil {
+		return &ReadBackError{Err: err}
+	}
+	return nil
+}
+
+// ReadBackError is returned by [WriteTx.Run] when the transaction was committed successfully,
+// but the items bound with UpdateReturning or PutReturning could not be read afterwards.
+// The transaction's writes were applied, so retrying it would apply them again.
+type ReadBackError struct {
+	// Err is the error returned while reading the items.
+	Err error
+}
+
+func (e *ReadBackError) Error() string {
+	return "dynamo: write transaction committed, but reading back items failed: " + e.Err.Error()
+}
+
+func (e *ReadBackError) Unwrap() error {
+	return e.Err
+}
+
+// unmarshalCanceled unmarshals the items included in a TransactionCanceledException to their bound outputs.
+func (tx *WriteTx) unmarshalCanceled(ctx context.Context, err error) error {
+	var txe *types.TransactionCanceledException
+	if !errors.As(err, &txe) {
+		return nil
+	}
+	for i, reason := range txe.CancellationReasons {
+		if i >= len(tx.items) || reason.Item == nil {
+			continue
+		}
+		if out, ok := tx.outs[tx.items[i]]; ok {
+			if err := writeTxTable(tx.items[i]).unmarshalItem(ctx, reason.Item, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readBack fetches the items bound to outputs after a successful transaction.
+func (tx *WriteTx) readBack(ctx context.Context) error {
+	get := tx.db.GetTx().ConsumedCapacity(tx.cc)
+	get.unmarshalers = make(map[getTxOp]interface{}, len(tx.outs))
+	for _, op := range tx.items {
+		out, ok := tx.outs[op]
+		if !ok {
+			continue
+		}
+		kg, err := writtenKey(ctx, op)
+		if err != nil {
+			return err
+		}
+		get.items = append(get.items, kg)
+		get.unmarshalers[kg] = out
+	}
+	err := get.Run(ctx)
+	if err == ErrNotFound {
+		return nil
+	}
 	return err
 }
 
-func (tx *WriteTx) input() (*dynamodb.TransactWriteItemsInput, error) {
+// keyGet is a transactional get of a single item by key.
+type keyGet struct {
+	table Table
+	key   Item
+}
+
+func (kg *keyGet) getTxItem() (types.TransactGetItem, error) {
+	return types.TransactGetItem{
+		Get: &types.Get{
+			TableName: aws.String(kg.table.name),
+			Key:       kg.key,
+		},
+	}, nil
+}
+
+// writtenKey returns the primary key of the item written by op.
+func writtenKey(ctx context.Context, op writeTxOp) (*keyGet, error) {
+	switch op := op.(type) {
+	case *Update:
+		return &keyGet{table: op.table, key: op.key()}, nil
+	case *Put:
+		desc, err := op.table.description(ctx)
+		if err != nil {
+			return nil, err
+		}
+		key := Item{desc.HashKey: op.item[desc.HashKey]}
+		if desc.RangeKey != "" {
+			key[desc.RangeKey] = op.item[desc.RangeKey]
+		}
+		return &keyGet{table: op.table, key: key}, nil
+	}
+	return nil, fmt.Errorf("dynamo: cannot determine key of %T", op)
+}
+
+// writeTxTable returns the table that op writes to.
+func writeTxTable(op writeTxOp) Table {
+	switch op := op.(type) {
+	case *Put:
+		return op.table
+	case *Update:
+		return op.table
+	case *Delete:
+		return op.table
+	case *ConditionCheck:
+		return op.table
+	}
+	return Table{}
+}
+
+func (tx *WriteTx) input(ctx context.Context) (*dynamodb.TransactWriteItemsInput, error) {
 	if len(tx.items) == 0 {
 		return nil, ErrNoInput
 	}
 	input := &dynamodb.TransactWriteItemsInput{}
 	for _, item := range tx.items {
-		wti, err := item.writeTxItem()
+		wti, err := item.writeTxItem(ctx)
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := tx.outs[item]; ok && tx.onCondFail == "" {
+			setTWIReturnType(wti, types.ReturnValuesOnConditionCheckFailureAllOld)
+		}
 		setTWIReturnType(wti, tx.onCondFail)
 		input.TransactItems = append(input.TransactItems, *wti)
 	}
-	if tx.token != "" {
+	if tx.payloadTok {
+		input.ClientRequestToken = aws.String(payloadToken(input.TransactItems))
+	} else if tx.token != "" {
 		input.ClientRequestToken = aws.String(tx.token)
 	}
 	if tx.cc != nil {
 		input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
 	}
+	if tx.modify != nil {
+		tx.modify(input)
+	}
 	return input, nil
 }
 
@@ -9,7 +9,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/smithy-go"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 func TestTx(t *testing.T) {
@@ -277,3 +282,373 @@ func TestTxRetry(t *testing.T) {
 		t.Error("unexpected count. want:", count, "got:", got.Count)
 	}
 }
+
+func TestWriteTxIdempotentFromPayload(t *testing.T) {
+	db := NewFromIface(nil)
+	table := db.Table("Payload")
+	build := func(count int, tags ...string) *WriteTx {
+		set := make(map[string]struct{}, len(tags))
+		for _, tag := range tags {
+			set[tag] = struct{}{}
+		}
+		return db.WriteTx().
+			Put(table.Put(map[string]any{"ID": 1, "Tags": set, "Meta": map[string]any{"A": 1, "B": "2"}})).
+			Update(table.Update("ID", 2).Add("Count", count).If("attribute_exists(ID)")).
+			IdempotentFromPayload()
+	}
+	token := func(tx *WriteTx) string {
+		t.Helper()
+		input, err := tx.input(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if input.ClientRequestToken == nil {
+			t.Fatal("missing token")
+		}
+		return *input.ClientRequestToken
+	}
+
+	a := token(build(1, "x", "y", "z"))
+	if len(a) > 36 {
+		t.Error("token too long:", a)
+	}
+	if b := token(build(1, "z", "y", "x")); a != b {
+		t.Error("identical transactions have different tokens:", a, b)
+	}
+	if c := token(build(2, "x", "y", "z")); a == c {
+		t.Error("different transactions have the same token:", c)
+	}
+	if d := token(build(1, "x", "y")); a == d {
+		t.Error("different transactions have the same token:", d)
+	}
+
+	// multiple ADD, DELETE, and REMOVE clauses must render in the same order every time
+	multi := func() *WriteTx {
+		return db.WriteTx().
+			Update(table.Update("ID", 3).
+				Add("A", 1).Add("B", 2).Add("C", 3).
+				DeleteFromSet("D", "x").DeleteFromSet("E", "y").
+				Remove("F", "G", "H")).
+			IdempotentFromPayload()
+	}
+	want := token(multi())
+	for i := 0; i < 50; i++ {
+		if got := token(multi()); got != want {
+			t.Fatal("identical multi-clause updates have different tokens:", want, got)
+		}
+	}
+
+	tx := build(1).IdempotentWithToken("explicit")
+	if got := token(tx); got != "explicit" {
+		t.Error("explicit token not used:", got)
+	}
+}
+
+type txOutClient struct {
+	keysClient
+	writes []*dynamodb.TransactWriteItemsInput
+	gets   []*dynamodb.TransactGetItemsInput
+	cancel bool
+}
+
+func (c *txOutClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.writes = append(c.writes, in)
+	if c.cancel {
+		reasons := make([]types.CancellationReason, len(in.TransactItems))
+		for i := range reasons {
+			reasons[i].Code = aws.String("None")
+		}
+		reasons[0] = types.CancellationReason{
+			Code: aws.String("ConditionalCheckFailed"),
+			Item: Item{"UserID": &types.AttributeValueMemberN{Value: "1"}, "Count": &types.AttributeValueMemberN{Value: "42"}},
+		}
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *txOutClient) TransactGetItems(_ context.Context, in *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	c.gets = append(c.gets, in)
+	out := &dynamodb.TransactGetItemsOutput{}
+	for _, get := range in.TransactItems {
+		item := Item{"Count": &types.AttributeValueMemberN{Value: "7"}}
+		for k, v := range get.Get.Key {
+			item[k] = v
+		}
+		out.Responses = append(out.Responses, types.ItemResponse{Item: item})
+	}
+	return out, nil
+}
+
+func TestWriteTxReturning(t *testing.T) {
+	ctx := context.Background()
+	client := new(txOutClient)
+	table := NewFromIface(client).Table("Returning")
+
+	type counter struct {
+		UserID int
+		Time   string
+		Count  int
+	}
+	var updated, put counter
+	err := table.db.WriteTx().
+		UpdateReturning(table.Update("UserID", 1).Range("Time", "a").Add("Count", 1), &updated).
+		Delete(table.Delete("UserID", 3).Range("Time", "c")).
+		PutReturning(table.Put(counter{UserID: 2, Time: "b"}), &put).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (counter{UserID: 1, Time: "a", Count: 7}); updated != want {
+		t.Error("bad updated item. want:", want, "got:", updated)
+	}
+	if want := (counter{UserID: 2, Time: "b", Count: 7}); put != want {
+		t.Error("bad put item. want:", want, "got:", put)
+	}
+	if len(client.gets) != 1 || len(client.gets[0].TransactItems) != 2 {
+		t.Fatal("expected one read back of two items. got:", client.gets)
+	}
+	if ret := client.writes[0].TransactItems[0].Update.ReturnValuesOnConditionCheckFailure; ret != types.ReturnValuesOnConditionCheckFailureAllOld {
+		t.Error("bound item should return values on condition failure. got:", ret)
+	}
+	if ret := client.writes[0].TransactItems[1].Delete.ReturnValuesOnConditionCheckFailure; ret != "" {
+		t.Error("unbound item shouldn't return values on condition failure. got:", ret)
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		client.cancel = true
+		var current counter
+		err := table.db.WriteTx().
+			UpdateReturning(table.Update("UserID", 1).Range("Time", "a").Add("Count", 1).If("Count < ?", 10), &current).
+			Run(ctx)
+		if !IsCondCheckFailed(err) {
+			t.Fatal("expected condition check failure. got:", err)
+		}
+		if current.Count != 42 {
+			t.Error("bad current item:", current)
+		}
+		if len(client.gets) != 1 {
+			t.Error("unexpected read back after failure")
+		}
+	})
+}
+
+// txGroupClient fails transactions by the value of their first put's Msg attribute,
+// and remembers confirmation items of committed transactions.
+type txGroupClient struct {
+	dynamodbiface.DynamoDBAPI
+	writes    []*dynamodb.TransactWriteItemsInput
+	confirmed map[string]bool
+	attempts  map[string]int
+}
+
+func (c *txGroupClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.writes = append(c.writes, in)
+	var last string
+	if put := in.TransactItems[len(in.TransactItems)-1].Put; put != nil {
+		if id, ok := put.Item["ID"].(*types.AttributeValueMemberS); ok {
+			last = id.Value
+		}
+	}
+	switch in.TransactItems[0].Put.Item["Msg"].(*types.AttributeValueMemberS).Value {
+	case "cancel":
+		return nil, &types.TransactionCanceledException{Message: aws.String("canceled")}
+	case "lost":
+		// committed, but the response never arrived
+		c.confirmed[last] = true
+		return nil, errors.New("connection reset")
+	case "dropped":
+		return nil, errors.New("connection reset")
+	case "late":
+		// the first attempt is lost before it is applied
+		c.attempts[*in.ClientRequestToken]++
+		if c.attempts[*in.ClientRequestToken] == 1 {
+			return nil, errors.New("connection reset")
+		}
+	}
+	c.confirmed[last] = true
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *txGroupClient) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	id := in.KeyConditions["ID"].AttributeValueList[0].(*types.AttributeValueMemberS).Value
+	if !*in.ConsistentRead {
+		return nil, errors.New("confirmation should use consistent read")
+	}
+	if c.confirmed[id] {
+		return &dynamodb.QueryOutput{Count: 1}, nil
+	}
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (c *txGroupClient) TransactGetItems(_ context.Context, _ *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	return nil, &types.TransactionCanceledException{Message: aws.String("read back canceled")}
+}
+
+func TestWriteTxGroup(t *testing.T) {
+	ctx := context.Background()
+	client := &txGroupClient{confirmed: make(map[string]bool), attempts: make(map[string]int)}
+	db := NewFromIface(client)
+	table := db.Table("Widgets")
+
+	type widget struct {
+		UserID int `dynamo:",hash"`
+		Msg    string
+	}
+	group := func(msg string, n int) *WriteTx {
+		tx := db.WriteTx()
+		for i := 0; i < n; i++ {
+			tx.Put(table.Put(widget{UserID: i, Msg: msg}))
+		}
+		return tx
+	}
+
+	results, err := db.WriteTxGroup().
+		Add(group("ok", 99), group("cancel", 2), group("lost", 1), group("dropped", 1), group("late", 1), group("big", 100)).
+		Confirm(db.Table("TxConfirm"), "ID").
+		Run(ctx)
+	if err == nil {
+		t.Error("expected error")
+	}
+	want := []TxGroupStatus{TxGroupCommitted, TxGroupFailed, TxGroupCommitted, TxGroupUnknown, TxGroupCommitted, TxGroupFailed}
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("group %d: want status %v, got %v (err: %v)", i, want[i], result.Status, result.Err)
+		}
+		if (result.Err == nil) != (result.Status == TxGroupCommitted) {
+			t.Errorf("group %d: unexpected error: %v", i, result.Err)
+		}
+	}
+	// dropped and late are sent again with the same token
+	if len(client.writes) != 7 {
+		t.Fatal("want 7 transactions, got", len(client.writes))
+	}
+	if *client.writes[5].ClientRequestToken != results[4].ID || *client.writes[6].ClientRequestToken != results[4].ID {
+		t.Error("late transaction not sent again with the same token")
+	}
+	first := client.writes[0]
+	if len(first.TransactItems) != 100 || *first.ClientRequestToken != results[0].ID {
+		t.Error("bad first transaction:", len(first.TransactItems), first.ClientRequestToken)
+	}
+	if put := first.TransactItems[99].Put; *put.TableName != "TxConfirm" || put.ConditionExpression == nil {
+		t.Error("bad confirmation item:", put)
+	}
+
+	t.Run("without confirmation", func(t *testing.T) {
+		results, _ := db.WriteTxGroup().Add(group("dropped", 1)).Run(ctx)
+		if results[0].Status != TxGroupUnknown {
+			t.Error("want unknown status, got", results[0].Status)
+		}
+	})
+
+	t.Run("read back failure", func(t *testing.T) {
+		var out widget
+		tx := group("ok", 1).UpdateReturning(table.Update("UserID", 1).Set("Msg", "updated"), &out)
+		err := tx.Run(ctx)
+		var rberr *ReadBackError
+		if !errors.As(err, &rberr) {
+			t.Fatal("want ReadBackError, got:", err)
+		}
+
+		results, err := db.WriteTxGroup().Add(tx).Run(ctx)
+		if results[0].Status != TxGroupCommitted {
+			t.Error("want committed status, got", results[0].Status)
+		}
+		if !errors.As(results[0].Err, &rberr) || !errors.As(err, &rberr) {
+			t.Error("want ReadBackError, got:", results[0].Err, err)
+		}
+	})
+}
+
+// txCapacityClient reports 1 read or write unit per item, by table.
+type txCapacityClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func txCapacity(tables []string, read bool) []types.ConsumedCapacity {
+	units := make(map[string]float64)
+	var order []string
+	for _, table := range tables {
+		if _, ok := units[table]; !ok {
+			order = append(order, table)
+		}
+		units[table]++
+	}
+	var ccs []types.ConsumedCapacity
+	for _, table := range order {
+		cc := types.ConsumedCapacity{
+			TableName:     aws.String(table),
+			CapacityUnits: aws.Float64(units[table]),
+			Table:         &types.Capacity{CapacityUnits: aws.Float64(units[table])},
+		}
+		if read {
+			cc.ReadCapacityUnits = cc.CapacityUnits
+		} else {
+			cc.WriteCapacityUnits = cc.CapacityUnits
+		}
+		ccs = append(ccs, cc)
+	}
+	return ccs
+}
+
+func (txCapacityClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var tables []string
+	for _, item := range in.TransactItems {
+		switch {
+		case item.Put != nil:
+			tables = append(tables, *item.Put.TableName)
+		case item.Delete != nil:
+			tables = append(tables, *item.Delete.TableName)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{ConsumedCapacity: txCapacity(tables, false)}, nil
+}
+
+func (txCapacityClient) TransactGetItems(_ context.Context, in *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	var tables []string
+	out := &dynamodb.TransactGetItemsOutput{}
+	for _, item := range in.TransactItems {
+		tables = append(tables, *item.Get.TableName)
+		out.Responses = append(out.Responses, types.ItemResponse{Item: item.Get.Key})
+	}
+	out.ConsumedCapacity = txCapacity(tables, true)
+	return out, nil
+}
+
+func TestTxConsumedCapacityTables(t *testing.T) {
+	ctx := context.Background()
+	db := NewFromIface(txCapacityClient{})
+	users, orders := db.Table("Users"), db.Table("Orders")
+
+	var cc ConsumedCapacity
+	err := db.WriteTx().
+		Put(users.Put(Item{"ID": &types.AttributeValueMemberN{Value: "1"}})).
+		Put(orders.Put(Item{"ID": &types.AttributeValueMemberN{Value: "1"}})).
+		Delete(orders.Delete("ID", 2)).
+		ConsumedCapacity(&cc).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.GetTx().
+		GetOne(orders.Get("ID", 1), new(Item)).
+		ConsumedCapacity(&cc).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cc.Total != 4 || cc.Write != 3 || cc.Read != 1 || cc.Requests != 2 {
+		t.Error("bad total consumed capacity:", cc)
+	}
+	want := map[string]*ConsumedCapacity{
+		"Users":  {Total: 1, Write: 1, Table: 1, TableName: "Users"},
+		"Orders": {Total: 3, Write: 2, Read: 1, Table: 3, TableName: "Orders"},
+	}
+	if !reflect.DeepEqual(cc.Tables, want) {
+		for name, got := range cc.Tables {
+			t.Errorf("%s: %+v", name, *got)
+		}
+		t.Error("bad per-table consumed capacity")
+	}
+}
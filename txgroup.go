@@ -0,0 +1,192 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// WriteTxGroup runs logically independent groups of operations as separate write transactions,
+// for bulk workflows that exceed the limit of 100 operations in a single transaction.
+// Each group is atomic on its own, but groups are not atomic with each other,
+// so Run reports the outcome of each group separately.
+//
+// When a transaction's outcome can't be known from its response, such as after a network error,
+// its group's status is [TxGroupUnknown]. Use [WriteTxGroup.Confirm] to have each transaction also write
+// a confirmation item, which is checked afterwards to resolve unknown outcomes.
+type WriteTxGroup struct {
+	db         *DB
+	groups     []*WriteTx
+	confirm    *Table
+	confirmKey string
+}
+
+// WriteTxGroup begins a new group of independent write transactions.
+func (db *DB) WriteTxGroup() *WriteTxGroup {
+	return &WriteTxGroup{db: db}
+}
+
+// Add adds transactions to this group, each of which runs separately.
+// Each transaction can contain up to 100 operations, or 99 when using [WriteTxGroup.Confirm].
+func (g *WriteTxGroup) Add(txs ...*WriteTx) *WriteTxGroup {
+	g.groups = append(g.groups, txs...)
+	return g
+}
+
+// Confirm makes each transaction also put a confirmation item into table, whose hash key attribute is hashKey (a string).
+// The item's key is the group's ID (see [TxGroupResult]), and it is only put if the rest of the transaction succeeds.
+// If a transaction's outcome is unknown, Run reads its confirmation item with a strongly consistent read
+// to find out whether it was committed. If the item is missing, the transaction might still be in flight,
+// so Run sends it again with the same idempotency token, which DynamoDB treats as a retry of the original transaction
+// (within its 10 minute idempotency window). The status is only [TxGroupFailed] if DynamoDB rejects the retry;
+// if the retry's outcome isn't known either, it is [TxGroupUnknown].
+// Confirmation items are never deleted by this library; consider enabling TTL on the table.
+func (g *WriteTxGroup) Confirm(table Table, hashKey string) *WriteTxGroup {
+	g.confirm = &table
+	g.confirmKey = hashKey
+	return g
+}
+
+// TxGroupStatus is the outcome of a transaction in a [WriteTxGroup].
+type TxGroupStatus int
+
+const (
+	// TxGroupNotRun means the transaction was not attempted, because the context was canceled.
+	TxGroupNotRun TxGroupStatus = iota
+	// TxGroupCommitted means the transaction succeeded.
+	TxGroupCommitted
+	// TxGroupFailed means the transaction was rejected or canceled, and none of its operations were applied.
+	TxGroupFailed
+	// TxGroupUnknown means the transaction may or may not have been committed.
+	TxGroupUnknown
+)
+
+func (s TxGroupStatus) String() string {
+	switch s {
+	case TxGroupNotRun:
+		return "not run"
+	case TxGroupCommitted:
+		return "committed"
+	case TxGroupFailed:
+		return "failed"
+	case TxGroupUnknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("TxGroupStatus(%d)", int(s))
+}
+
+// TxGroupResult is the outcome of one transaction in a [WriteTxGroup].
+type TxGroupResult struct {
+	// ID is a unique identifier of this transaction's run, used as its idempotency token
+	// (unless one was already set) and as the key of its confirmation item.
+	ID     string
+	Status TxGroupStatus
+	// Err is the error returned by the transaction, or nil if it was committed.
+	// Committed transactions can have a [*ReadBackError] if their bound outputs couldn't be read.
+	Err error
+}
+
+// Run executes each transaction in order, returning the results in the same order as they were added.
+// The returned error is nil if every transaction was committed, otherwise it contains the errors of the rest,
+// along with any [*ReadBackError] of committed transactions.
+func (g *WriteTxGroup) Run(ctx context.Context) ([]TxGroupResult, error) {
+	results := make([]TxGroupResult, len(g.groups))
+	for i, tx := range g.groups {
+		results[i] = g.run(ctx, tx)
+	}
+
+	var errs []error
+	for i, result := range results {
+		if result.Status != TxGroupCommitted || result.Err != nil {
+			errs = append(errs, fmt.Errorf("dynamo: write tx group %d (%s): %w", i, result.Status, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+func (g *WriteTxGroup) run(ctx context.Context, tx *WriteTx) TxGroupResult {
+	if err := ctx.Err(); err != nil {
+		return TxGroupResult{Status: TxGroupNotRun, Err: err}
+	}
+	id, err := newIdempotencyToken()
+	if err != nil {
+		return TxGroupResult{Status: TxGroupNotRun, Err: err}
+	}
+	result := TxGroupResult{ID: id}
+
+	run := *tx
+	limit := maxWriteTxOps
+	if g.confirm != nil {
+		limit--
+		marker := g.confirm.Put(Item{g.confirmKey: &types.AttributeValueMemberS{Value: id}}).
+			If("attribute_not_exists($)", g.confirmKey)
+		run.items = append(slices.Clip(tx.items), marker)
+	}
+	if run.token == "" && !run.payloadTok {
+		run.token = id
+	}
+	if len(tx.items) > limit {
+		result.Status = TxGroupFailed
+		result.Err = fmt.Errorf("dynamo: write tx group has %d operations, maximum is %d", len(tx.items), limit)
+		return result
+	}
+	if run.err == nil {
+		_, run.err = run.input(ctx)
+	}
+	if run.err != nil {
+		result.Status = TxGroupFailed
+		result.Err = run.err
+		return result
+	}
+
+	result.Err = run.Run(ctx)
+	var rberr *ReadBackError
+	switch {
+	case result.Err == nil, errors.As(result.Err, &rberr):
+		result.Status = TxGroupCommitted
+	case isTxRejected(result.Err):
+		result.Status = TxGroupFailed
+	case g.confirm != nil:
+		result.Status, result.Err = g.resolve(ctx, &run, id, result.Err)
+	default:
+		result.Status = TxGroupUnknown
+	}
+	return result
+}
+
+// resolve finds out the outcome of run, which ended with err without a known outcome, by checking for its confirmation item.
+// A missing confirmation item doesn't prove that run failed, because it might still be in flight,
+// so run is sent again with the same idempotency token: DynamoDB either returns the outcome of the original transaction
+// or, if it was never applied, runs it now. If that is inconclusive too, the status is TxGroupUnknown.
+func (g *WriteTxGroup) resolve(ctx context.Context, run *WriteTx, id string, err error) (TxGroupStatus, error) {
+	n, cerr := g.confirm.Get(g.confirmKey, id).Consistent(true).Count(ctx)
+	switch {
+	case cerr != nil:
+		return TxGroupUnknown, errors.Join(err, cerr)
+	case n > 0:
+		return TxGroupCommitted, nil
+	}
+
+	err = run.Run(ctx)
+	var rberr *ReadBackError
+	switch {
+	case err == nil, errors.As(err, &rberr):
+		return TxGroupCommitted, err
+	case isTxRejected(err):
+		return TxGroupFailed, err
+	}
+	return TxGroupUnknown, err
+}
+
+// isTxRejected returns true if err is a response from DynamoDB meaning the transaction was not committed.
+func isTxRejected(err error) bool {
+	var ae smithy.APIError
+	if !errors.As(err, &ae) {
+		return false
+	}
+	return ae.ErrorFault() != smithy.FaultServer && ae.ErrorCode() != "TransactionInProgressException"
+}
@@ -0,0 +1,119 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+)
+
+// TypedQuery is a [Query] whose results are unmarshaled into values of type T.
+// It is the runtime support for query builders generated by the dynamogen tool
+// (see github.com/guregu/dynamo/v2/cmd/dynamogen), but can also be used directly.
+//
+//	widgets, err := dynamo.NewTypedQuery[Widget](table.Get("UserID", 613)).All(ctx)
+type TypedQuery[T any] struct {
+	q *Query
+}
+
+// NewTypedQuery wraps q, unmarshaling its results into values of type T.
+func NewTypedQuery[T any](q *Query) TypedQuery[T] {
+	return TypedQuery[T]{q: q}
+}
+
+// TypedGet creates a new request to get items of type T, like [Table.Get].
+// Unlike Get, value is encoded the same way as T's field for the hash key,
+// so struct tag options such as unixtime, string, and keyfmt are taken into account.
+func TypedGet[T any](table Table, name string, value interface{}) TypedQuery[T] {
+	return TypedQuery[T]{q: table.get(name, value, fieldFlags[T](table.db.nameMapper(), name))}
+}
+
+// TypedGetField is like [TypedGet], but takes the Go name of T's hash key field instead of its attribute name.
+// The attribute name is found at run time, so it follows the table's name mapper (see [DB.NameMapper]).
+// Code generated by dynamogen uses it, so that generated queries work with any name mapper.
+func TypedGetField[T any](table Table, field string, value interface{}) TypedQuery[T] {
+	return TypedGet[T](table, fieldAttr[T](table.db.nameMapper(), field), value)
+}
+
+// Range specifies the range key (a.k.a. sort key) or keys to get, like [Query.Range].
+// Values are encoded the same way as T's field for the range key,
+// so struct tag options such as unixtime, string, and keyfmt are taken into account.
+func (tq TypedQuery[T]) Range(name string, op Operator, values ...interface{}) TypedQuery[T] {
+	tq.q.rangeFlags(name, op, fieldFlags[T](tq.q.table.db.nameMapper(), name), values...)
+	return tq
+}
+
+// RangeField is like [TypedQuery.Range], but takes the Go name of T's range key field instead of its attribute name.
+// See [TypedGetField].
+func (tq TypedQuery[T]) RangeField(field string, op Operator, values ...interface{}) TypedQuery[T] {
+	return tq.Range(fieldAttr[T](tq.q.table.db.nameMapper(), field), op, values...)
+}
+
+// Query returns the underlying query, for setting options that have no typed equivalent.
+// Changes made to it affect this TypedQuery.
+func (tq TypedQuery[T]) Query() *Query {
+	return tq.q
+}
+
+// One executes this query and returns its single result.
+// See [Query.One].
+func (tq TypedQuery[T]) One(ctx context.Context) (T, error) {
+	var out T
+	err := tq.q.One(ctx, &out)
+	return out, err
+}
+
+// All executes this query and returns all of its results.
+// See [Query.All].
+func (tq TypedQuery[T]) All(ctx context.Context) ([]T, error) {
+	var out []T
+	err := tq.q.All(ctx, &out)
+	return out, err
+}
+
+// AllWithLastEvaluatedKey executes this query and returns its results,
+// along with a PagingKey you can use with [Query.StartFrom] to continue where it left off.
+// See [Query.AllWithLastEvaluatedKey].
+func (tq TypedQuery[T]) AllWithLastEvaluatedKey(ctx context.Context) ([]T, PagingKey, error) {
+	var out []T
+	lek, err := tq.q.AllWithLastEvaluatedKey(ctx, &out)
+	return out, lek, err
+}
+
+// Count executes this query and returns the number of results.
+// See [Query.Count].
+func (tq TypedQuery[T]) Count(ctx context.Context) (int, error) {
+	return tq.q.Count(ctx)
+}
+
+// fieldFlags returns the encoding flags of T's field for the given attribute,
+// or flagNone if T is not a struct or has no such field.
+func fieldFlags[T any](names *NameMapper, name string) encodeFlags {
+	rt := structTypeOf((*T)(nil))
+	if rt == nil {
+		return flagNone
+	}
+	flags := flagNone
+	visitTypeFields(rt, names, nil, nil, func(field string, _ []int, ff encodeFlags, _ reflect.Type) error {
+		if field == name {
+			flags = ff
+		}
+		return nil
+	})
+	return flags
+}
+
+// fieldAttr returns the attribute name of T's field with the given Go name,
+// or the Go name itself if T is not a struct or has no such field.
+func fieldAttr[T any](names *NameMapper, field string) string {
+	rt := structTypeOf((*T)(nil))
+	if rt == nil {
+		return field
+	}
+	attr := field
+	visitTypeFields(rt, names, nil, nil, func(name string, index []int, _ encodeFlags, _ reflect.Type) error {
+		if rt.FieldByIndex(index).Name == field {
+			attr = name
+		}
+		return nil
+	})
+	return attr
+}
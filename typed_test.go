@@ -0,0 +1,88 @@
+package dynamo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestTypedQuery(t *testing.T) {
+	ctx := context.Background()
+	client := &queryRecorder{items: []Item{
+		{"ID": &types.AttributeValueMemberN{Value: "1"}, "Seq": &types.AttributeValueMemberN{Value: "1"}},
+		{"ID": &types.AttributeValueMemberN{Value: "1"}, "Seq": &types.AttributeValueMemberN{Value: "2"}},
+	}}
+	table := NewFromIface(client).Table("Typed")
+
+	type event struct {
+		ID  int
+		Seq int
+	}
+	q := NewTypedQuery[event](table.Get("ID", 1).Range("Seq", Greater, 0))
+	q.Query().Filter("attribute_exists(Seq)")
+	got, err := q.All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != (event{ID: 1, Seq: 2}) {
+		t.Error("bad results:", got)
+	}
+	if in := client.queries[0]; in.FilterExpression == nil {
+		t.Error("options set via Query not applied:", in)
+	}
+}
+
+func TestTypedQueryFlags(t *testing.T) {
+	ctx := context.Background()
+	client := &queryRecorder{}
+	table := NewFromIface(client).Table("Typed")
+
+	type event struct {
+		ID   int       `dynamo:",hash,keyfmt=%06d"`
+		Time time.Time `dynamo:",range,unixtime,omitempty"`
+	}
+	_, err := TypedGet[event](table, "ID", 42).Range("Time", Between, time.Unix(0, 0), time.Unix(100, 0)).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conds := client.queries[0].KeyConditions
+	wantHash := []types.AttributeValue{&types.AttributeValueMemberS{Value: "000042"}}
+	if got := conds["ID"].AttributeValueList; !reflect.DeepEqual(got, wantHash) {
+		t.Error("bad hash key. want:", wantHash, "got:", got)
+	}
+	wantRange := []types.AttributeValue{
+		&types.AttributeValueMemberN{Value: "0"},
+		&types.AttributeValueMemberN{Value: "100"},
+	}
+	if got := conds["Time"].AttributeValueList; !reflect.DeepEqual(got, wantRange) {
+		t.Error("bad range key. want:", wantRange, "got:", got)
+	}
+}
+
+func TestTypedQueryFields(t *testing.T) {
+	ctx := context.Background()
+	client := &queryRecorder{}
+	table := NewFromIface(client).NameMapper(SnakeCase).Table("Typed")
+
+	type meta struct {
+		Seq int `dynamo:"seqno"`
+	}
+	type event struct {
+		UserID int `dynamo:",hash"`
+		meta
+	}
+	_, err := TypedGetField[event](table, "UserID", 42).RangeField("Seq", Greater, 1).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conds := client.queries[0].KeyConditions
+	if _, ok := conds["user_id"]; !ok {
+		t.Error("hash key not named by mapper:", conds)
+	}
+	if _, ok := conds["seqno"]; !ok {
+		t.Error("range key not named by its tag:", conds)
+	}
+}
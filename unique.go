@@ -0,0 +1,196 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UniqueError is returned by [Table.PutUnique] when an item can't be written
+// because it would violate a uniqueness constraint.
+// It wraps the underlying TransactionCanceledException, so [IsCondCheckFailed] is true for it as well.
+type UniqueError struct {
+	// Table is the name of the table.
+	Table string
+	// Attribute is the unique attribute whose value is already taken by another item.
+	// It is empty if an item with the same primary key already exists.
+	Attribute string
+	// Value is the value of Attribute. It is not included in the error message.
+	Value types.AttributeValue
+
+	err error
+}
+
+func (e *UniqueError) Error() string {
+	if e.Attribute == "" {
+		return fmt.Sprintf("dynamo: put unique: item already exists in table %s", e.Table)
+	}
+	return fmt.Sprintf("dynamo: put unique: value of %s is already taken in table %s", e.Attribute, e.Table)
+}
+
+func (e *UniqueError) Unwrap() error {
+	return e.err
+}
+
+// PutUnique creates a new item, ensuring that no other item in this table has the same value for any of uniqueAttrs,
+// such as usernames or email addresses. DynamoDB has no unique constraints, so this uses the standard workaround:
+// for each unique attribute, a lock item whose primary key is derived from the attribute name and value (like "Email#bob@example.com")
+// is written together with the item in a single transaction, and each write only succeeds if its key doesn't exist yet.
+//
+// If an item with the same primary key already exists, or one of the unique values is taken,
+// nothing is written and a [*UniqueError] is returned.
+// Unique attributes that are missing or null aren't locked, and values are compared exactly,
+// so normalize them (for example, lowercasing email addresses) beforehand as needed.
+//
+// The table's hash key (and range key, if any) must be strings, as lock items share the table with regular items.
+// Lock items only contain their primary key. Use [Table.DeleteUnique] to delete items written by PutUnique,
+// so that their lock items are deleted as well.
+func (table Table) PutUnique(ctx context.Context, item interface{}, uniqueAttrs ...string) error {
+	if len(uniqueAttrs) == 0 {
+		return errors.New("dynamo: put unique: no unique attributes given")
+	}
+	desc, err := table.uniqueDescription(ctx)
+	if err != nil {
+		return err
+	}
+
+	put := table.Put(item)
+	if put.err != nil {
+		return put.err
+	}
+	encoded, err := put.marshal(ctx)
+	if err != nil {
+		return err
+	}
+	put.If("attribute_not_exists($)", desc.HashKey)
+	tx := table.db.WriteTx().Put(put)
+	var locked []string
+	for _, attr := range uniqueAttrs {
+		av := encoded[attr]
+		if isNullAV(av) {
+			continue
+		}
+		lock, err := table.uniqueLock(desc, attr, av)
+		if err != nil {
+			return err
+		}
+		tx.Put(lock.If("attribute_not_exists($)", desc.HashKey))
+		locked = append(locked, attr)
+	}
+
+	err = tx.Run(ctx)
+	var txe *types.TransactionCanceledException
+	if !errors.As(err, &txe) {
+		return err
+	}
+	for i, reason := range txe.CancellationReasons {
+		if reason.Code == nil || *reason.Code != "ConditionalCheckFailed" {
+			continue
+		}
+		uerr := &UniqueError{Table: table.Name(), err: err}
+		if i > 0 && i <= len(locked) {
+			uerr.Attribute = locked[i-1]
+			uerr.Value = encoded[uerr.Attribute]
+		}
+		return uerr
+	}
+	return err
+}
+
+// DeleteUnique deletes an item written by [Table.PutUnique], along with its lock items for uniqueAttrs,
+// in a single transaction. item must contain the item's primary key and its current values for uniqueAttrs;
+// if the item in the table doesn't have those values, nothing is deleted and a ConditionalCheckFailed error
+// is returned (see [IsCondCheckFailed]), so that values now held by other items are never unlocked.
+// Deleting an item that doesn't exist is not an error, as long as it has no unique values.
+func (table Table) DeleteUnique(ctx context.Context, item interface{}, uniqueAttrs ...string) error {
+	desc, err := table.uniqueDescription(ctx)
+	if err != nil {
+		return err
+	}
+	encoded, err := marshalItemContext(table.db.codecContext(ctx), item)
+	if err != nil {
+		return err
+	}
+	if isNullAV(encoded[desc.HashKey]) {
+		return fmt.Errorf("dynamo: delete unique: item is missing hash key attribute %q", desc.HashKey)
+	}
+
+	del := table.Delete(desc.HashKey, encoded[desc.HashKey])
+	if desc.RangeKey != "" {
+		if isNullAV(encoded[desc.RangeKey]) {
+			return fmt.Errorf("dynamo: delete unique: item is missing range key attribute %q", desc.RangeKey)
+		}
+		del.Range(desc.RangeKey, encoded[desc.RangeKey])
+	}
+	tx := table.db.WriteTx().Delete(del)
+	for _, attr := range uniqueAttrs {
+		av := encoded[attr]
+		if isNullAV(av) {
+			del.If("attribute_not_exists($)", attr)
+			continue
+		}
+		del.If("$ = ?", attr, av)
+		lock, err := table.uniqueLock(desc, attr, av)
+		if err != nil {
+			return err
+		}
+		lockDel := table.Delete(desc.HashKey, lock.item[desc.HashKey])
+		if desc.RangeKey != "" {
+			lockDel.Range(desc.RangeKey, lock.item[desc.RangeKey])
+		}
+		tx.Delete(lockDel)
+	}
+	return tx.Run(ctx)
+}
+
+// uniqueDescription returns this table's description, checking that its keys can hold lock items.
+func (table Table) uniqueDescription(ctx context.Context) (Description, error) {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return desc, err
+	}
+	if desc.HashKeyType != StringType || (desc.RangeKey != "" && desc.RangeKeyType != StringType) {
+		return desc, fmt.Errorf("dynamo: unique items require string keys, but table %s has a different key type", table.Name())
+	}
+	return desc, nil
+}
+
+// uniqueLock returns a put of the lock item for the given unique attribute value.
+// Its hash key (and range key, if any) are "<attr>#<value>".
+func (table Table) uniqueLock(desc Description, attr string, av types.AttributeValue) (*Put, error) {
+	value, ok := uniqueValueString(av)
+	if !ok {
+		return nil, fmt.Errorf("dynamo: unique attribute %q must be a string, number, or binary value", attr)
+	}
+	key := &types.AttributeValueMemberS{Value: attr + "#" + value}
+	lock := Item{desc.HashKey: key}
+	if desc.RangeKey != "" {
+		lock[desc.RangeKey] = key
+	}
+	return &Put{table: table, subber: subber{db: table.db}, item: lock}, nil
+}
+
+// uniqueValueString returns av as a string for use in lock item keys, or false if it isn't a scalar.
+func uniqueValueString(av types.AttributeValue) (string, bool) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value, true
+	case *types.AttributeValueMemberN:
+		return normalizeNumber(v.Value), true
+	case *types.AttributeValueMemberB:
+		return base64.StdEncoding.EncodeToString(v.Value), true
+	}
+	return "", false
+}
+
+// isNullAV returns true if av is missing or null.
+func isNullAV(av types.AttributeValue) bool {
+	if av == nil {
+		return true
+	}
+	_, null := av.(*types.AttributeValueMemberNULL)
+	return null
+}
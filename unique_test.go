@@ -0,0 +1,168 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
+)
+
+// uniqueClient is a fake table with string keys PK and SK that stores the items of write transactions.
+// Puts with a condition fail if the item exists, and deletes with a condition fail if the item doesn't exist.
+type uniqueClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]Item
+	txs   []*dynamodb.TransactWriteItemsInput
+}
+
+func (c *uniqueClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{
+		Table: &types.TableDescription{
+			TableName: in.TableName,
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("SK"), KeyType: types.KeyTypeRange},
+			},
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("SK"), AttributeType: types.ScalarAttributeTypeS},
+			},
+		},
+	}, nil
+}
+
+func (c *uniqueClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.txs = append(c.txs, in)
+	if c.items == nil {
+		c.items = make(map[string]Item)
+	}
+	id := func(item Item) string {
+		return keyID(item["PK"], item["SK"])
+	}
+	reasons := make([]types.CancellationReason, len(in.TransactItems))
+	failed := false
+	for i, twi := range in.TransactItems {
+		reasons[i].Code = aws.String("None")
+		var exists, ok bool
+		switch {
+		case twi.Put != nil && twi.Put.ConditionExpression != nil:
+			_, exists = c.items[id(twi.Put.Item)]
+			ok = !exists
+		case twi.Delete != nil && twi.Delete.ConditionExpression != nil:
+			_, exists = c.items[id(twi.Delete.Key)]
+			ok = exists
+		default:
+			ok = true
+		}
+		if !ok {
+			reasons[i].Code = aws.String("ConditionalCheckFailed")
+			failed = true
+		}
+	}
+	if failed {
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+	for _, twi := range in.TransactItems {
+		switch {
+		case twi.Put != nil:
+			c.items[id(twi.Put.Item)] = twi.Put.Item
+		case twi.Delete != nil:
+			delete(c.items, id(twi.Delete.Key))
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func TestPutUnique(t *testing.T) {
+	type user struct {
+		PK       string
+		SK       string
+		Email    string
+		Username string `dynamo:",omitempty"`
+	}
+
+	ctx := context.Background()
+	client := new(uniqueClient)
+	table := NewFromIface(client).Table("Users")
+
+	alice := user{PK: "user#1", SK: "profile", Email: "alice@example.com", Username: "alice"}
+	if err := table.PutUnique(ctx, alice, "Email", "Username"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(client.txs[0].TransactItems); got != 3 {
+		t.Fatal("want 3 transaction items, got", got)
+	}
+	if len(client.items) != 3 {
+		t.Fatal("want the item and 2 lock items, got", len(client.items))
+	}
+	lock := keyID(&types.AttributeValueMemberS{Value: "Email#alice@example.com"}, &types.AttributeValueMemberS{Value: "Email#alice@example.com"})
+	if _, ok := client.items[lock]; !ok {
+		t.Error("missing email lock item")
+	}
+
+	t.Run("taken", func(t *testing.T) {
+		bob := user{PK: "user#2", SK: "profile", Email: "bob@example.com", Username: "alice"}
+		err := table.PutUnique(ctx, bob, "Email", "Username")
+		var uerr *UniqueError
+		if !errors.As(err, &uerr) {
+			t.Fatal("want UniqueError, got", err)
+		}
+		if uerr.Attribute != "Username" {
+			t.Error("want Username, got", uerr.Attribute)
+		}
+		if !IsCondCheckFailed(err) {
+			t.Error("UniqueError should be a condition check failure")
+		}
+		if len(client.items) != 3 {
+			t.Error("items were written:", len(client.items))
+		}
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		again := alice
+		again.Email = "alice2@example.com"
+		again.Username = ""
+		err := table.PutUnique(ctx, again, "Email", "Username")
+		var uerr *UniqueError
+		if !errors.As(err, &uerr) {
+			t.Fatal("want UniqueError, got", err)
+		}
+		if uerr.Attribute != "" {
+			t.Error("want empty attribute, got", uerr.Attribute)
+		}
+		if got := len(client.txs[len(client.txs)-1].TransactItems); got != 2 {
+			t.Error("missing attribute should not be locked; transaction items:", got)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := table.DeleteUnique(ctx, alice, "Email", "Username"); err != nil {
+			t.Fatal(err)
+		}
+		if len(client.items) != 0 {
+			t.Error("want all items deleted, got", len(client.items))
+		}
+		del := client.txs[len(client.txs)-1].TransactItems[0].Delete
+		if del.ConditionExpression == nil || len(del.ExpressionAttributeValues) != 2 {
+			t.Error("delete should check the unique values; condition:", aws.ToString(del.ConditionExpression))
+		}
+
+		// the email is free again
+		bob := user{PK: "user#2", SK: "profile", Email: "alice@example.com"}
+		if err := table.PutUnique(ctx, bob, "Email"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("number keys", func(t *testing.T) {
+		table := NewFromIface(new(keysClient)).Table("Numbers")
+		if err := table.PutUnique(ctx, alice, "Email"); err == nil {
+			t.Error("want error for non-string keys")
+		}
+	})
+}
@@ -3,6 +3,8 @@ package dynamo
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -30,6 +32,9 @@ type Update struct {
 	remove map[string]struct{}
 
 	condition string
+	split     bool
+	modify    func(*dynamodb.UpdateItemInput)
+	optFns    []func(*dynamodb.Options)
 
 	subber
 
@@ -42,16 +47,15 @@ func (table Table) Update(hashKey string, value interface{}) *Update {
 	u := &Update{
 		table:   table,
 		hashKey: hashKey,
+		subber:  subber{db: table.db},
 
 		set:    make([]string, 0),
 		add:    make(map[string]string),
 		del:    make(map[string]string),
 		remove: make(map[string]struct{}),
 	}
-	u.hashValue, u.err = marshal(value, flagNone)
-	if u.hashValue == nil {
-		u.setError(fmt.Errorf("dynamo: update hash key value is nil or omitted for attribute %q", u.hashKey))
-	}
+	u.hashValue, u.err = table.marshalKey("update hash", hashKey, value)
+	table.interceptUpdate(u)
 	return u
 }
 
@@ -59,11 +63,8 @@ func (table Table) Update(hashKey string, value interface{}) *Update {
 func (u *Update) Range(name string, value interface{}) *Update {
 	var err error
 	u.rangeKey = name
-	u.rangeValue, err = marshal(value, flagNone)
+	u.rangeValue, err = u.table.marshalKey("update range", name, value)
 	u.setError(err)
-	if u.rangeValue == nil {
-		u.setError(fmt.Errorf("dynamo: update range key value is nil or omitted for attribute %q", u.rangeKey))
-	}
 	return u
 }
 
@@ -72,16 +73,21 @@ func (u *Update) Range(name string, value interface{}) *Update {
 // Paths that are reserved words are automatically escaped.
 // Use single quotes to escape complex values like 'User'.'Count'.
 func (u *Update) Set(path string, value interface{}) *Update {
-	v, err := marshal(value, flagNone)
+	v, deferred, err := marshalDeferred(u.table.db.codecContext(context.Background()), value, flagNone)
 	if v == nil && err == nil {
 		// auto-omitted value
 		return u.Remove(path)
 	}
 	u.setError(err)
+	var arg interface{} = v
+	if deferred {
+		// marshal again with the request's context
+		arg = value
+	}
 
 	path, err = u.escape(path)
 	u.setError(err)
-	expr, err := u.subExpr("🝕 = ?", path, v)
+	expr, err := u.subExpr("🝕 = ?", path, arg)
 	u.setError(err)
 	u.set = append(u.set, expr)
 	return u
@@ -107,16 +113,21 @@ func (u *Update) SetNullable(path string, value interface{}) *Update {
 // Paths that are reserved words are automatically escaped.
 // Use single quotes to escape complex values like 'User'.'Count'.
 func (u *Update) SetSet(path string, value interface{}) *Update {
-	v, err := marshal(value, flagSet)
+	v, deferred, err := marshalDeferred(u.table.db.codecContext(context.Background()), value, flagSet)
 	if v == nil && err == nil {
 		// empty set
 		return u.Remove(path)
 	}
 	u.setError(err)
+	var arg interface{} = v
+	if deferred {
+		// marshal again with the request's context
+		arg = value
+	}
 
 	path, err = u.escape(path)
 	u.setError(err)
-	expr, err := u.subExpr("🝕 = ?", path, v)
+	expr, err := u.subExprFlags(flagSet, "🝕 = ?", path, arg)
 	u.setError(err)
 	u.set = append(u.set, expr)
 	return u
@@ -207,29 +218,52 @@ func (u *Update) delete(path string, value interface{}) *Update {
 // If value marshals to a number, string, or binary, that value will be deleted.
 // Delete is only for deleting values from sets. See Remove for removing entire attributes.
 func (u *Update) DeleteFromSet(path string, value interface{}) *Update {
-	v, err := marshal(value, flagSet)
+	v, deferred, err := marshalDeferred(u.table.db.codecContext(context.Background()), value, flagSet)
 	if err != nil {
 		u.setError(err)
 		return u
 	}
+	if deferred {
+		// marshal and box again with the request's context
+		return u.delete(path, setBox{value: value})
+	}
+	v, err = boxSet(value, v)
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	return u.delete(path, v)
+}
+
+// boxSet returns v, the marshaled value, as a set.
+func boxSet(value interface{}, v types.AttributeValue) (types.AttributeValue, error) {
 	switch t := v.(type) {
 	// ok:
 	case *types.AttributeValueMemberNS, *types.AttributeValueMemberSS, *types.AttributeValueMemberBS:
+		return v, nil
 
 	// need to box:
 	case *types.AttributeValueMemberN:
-		v = &types.AttributeValueMemberNS{Value: []string{t.Value}}
+		return &types.AttributeValueMemberNS{Value: []string{t.Value}}, nil
 	case *types.AttributeValueMemberS:
-		v = &types.AttributeValueMemberSS{Value: []string{t.Value}}
+		return &types.AttributeValueMemberSS{Value: []string{t.Value}}, nil
 	case *types.AttributeValueMemberB:
-		v = &types.AttributeValueMemberBS{Value: [][]byte{t.Value}}
-
-	default:
-		u.setError(fmt.Errorf("dynamo: Update.DeleteFromSet given unsupported value: %v (%T: %s)", value, value, avTypeName(v)))
-		return u
+		return &types.AttributeValueMemberBS{Value: [][]byte{t.Value}}, nil
 	}
+	return nil, fmt.Errorf("dynamo: Update.DeleteFromSet given unsupported value: %v (%T: %s)", value, value, avTypeName(v))
+}
 
-	return u.delete(path, v)
+// setBox marshals value as a set with the request's context, for DeleteFromSet.
+type setBox struct {
+	value interface{}
+}
+
+func (b setBox) MarshalDynamoContext(ctx context.Context) (types.AttributeValue, error) {
+	v, err := marshalContext(ctx, b.value, flagSet)
+	if err != nil {
+		return nil, err
+	}
+	return boxSet(b.value, v)
 }
 
 // DeleteStringsFromSet deletes the given values from the string set specified by path.
@@ -282,6 +316,70 @@ func (u *Update) If(expr string, args ...interface{}) *Update {
 	return u
 }
 
+// SplitLarge specifies whether an update whose expression exceeds [MaxExpressionSize]
+// should be split into multiple sequential updates instead of returning an *ExpressionTooLargeError.
+// Split updates are not atomic: if one of them fails, the previous ones will have already been applied.
+// Splitting is only done when it is safe: updates with conditions, or that return values other than
+// the new item (see [Update.Value]) will still return an error.
+// Because each part sees the changes made by the previous parts, updates where a part's expression reads
+// an attribute changed by another part (such as SET A = B, B = A, or if_not_exists and list_append
+// of an attribute set elsewhere in the update) also return an error instead of being split.
+// Split updates cannot be used in transactions.
+func (u *Update) SplitLarge(enabled bool) *Update {
+	u.split = enabled
+	return u
+}
+
+// Expression returns the update expression generated by this update,
+// along with its attribute name and value placeholders.
+// Names and values also include any placeholders used by the condition expression (see [Update.If]).
+// Value placeholders are assigned in the order they were added (":v0", ":v1", and so on),
+// so code updating many items with the same shape can cache the expression and swap out only the values.
+// The expression is the same every time for the same update: SET clauses keep the order they were added,
+// and ADD, DELETE, and REMOVE clauses are sorted by their attribute name placeholders.
+// The returned maps are copies and are safe to modify.
+func (u *Update) Expression() (expr string, names map[string]string, values Item, err error) {
+	if u.err != nil {
+		return "", nil, nil, u.err
+	}
+	expr = *u.updateExpr()
+	if len(u.nameExpr) > 0 {
+		names = make(map[string]string, len(u.nameExpr))
+		for k, v := range u.nameExpr {
+			names[k] = v
+		}
+	}
+	// values implementing MarshalerCtx are marshaled without a request, as with [MarshalItem]
+	subs, err := u.values(context.Background())
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(subs) > 0 {
+		values = make(Item, len(subs))
+		for k, v := range subs {
+			values[k] = v
+		}
+	}
+	return expr, names, values, nil
+}
+
+// ModifyInput sets a function that is called with the input of each UpdateItem request just before it is sent,
+// allowing for API parameters that this library does not support yet.
+// Split updates (see [Update.SplitLarge]) call fn once per request.
+// It is not called when this update is part of a transaction.
+func (u *Update) ModifyInput(fn func(*dynamodb.UpdateItemInput)) *Update {
+	u.modify = fn
+	return u
+}
+
+// RequestOptions sets options that are applied to each request made by this update, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+// It has no effect when this update is part of a transaction.
+func (u *Update) RequestOptions(optFns ...func(*dynamodb.Options)) *Update {
+	u.optFns = optFns
+	return u
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (u *Update) ConsumedCapacity(cc *ConsumedCapacity) *Update {
 	u.cc = cc
@@ -303,7 +401,7 @@ func (u *Update) Value(ctx context.Context, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // OldValue executes this update, encoding out with the old value before the update.
@@ -314,7 +412,7 @@ func (u *Update) OldValue(ctx context.Context, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // OnlyUpdatedValue executes this update, encoding out with only with new values of the attributes that were changed.
@@ -325,7 +423,7 @@ func (u *Update) OnlyUpdatedValue(ctx context.Context, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // OnlyUpdatedOldValue executes this update, encoding out with only with old values of the attributes that were changed.
@@ -336,7 +434,7 @@ func (u *Update) OnlyUpdatedOldValue(ctx context.Context, out interface{}) error
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // CurrentValue executes this update.
@@ -352,12 +450,12 @@ func (u *Update) CurrentValue(ctx context.Context, out interface{}) (wrote bool,
 	u.onCondFail = types.ReturnValuesOnConditionCheckFailureAllOld
 	output, err := u.run(ctx)
 	if err != nil {
-		if ok, err := UnmarshalItemFromCondCheckFailed(err, out); ok {
+		if ok, err := unmarshalItemFromCondCheckFailed(ctx, err, out, u.table.unmarshalItem); ok {
 			return false, err
 		}
 		return false, err
 	}
-	return true, unmarshalItem(output.Attributes, out)
+	return true, u.table.unmarshalItem(ctx, output.Attributes, out)
 }
 
 // IncludeAllItemsInCondCheckFail specifies whether an item update that fails its condition check should include the item itself in the error.
@@ -377,10 +475,30 @@ func (u *Update) run(ctx context.Context) (*dynamodb.UpdateItemOutput, error) {
 	}
 
 	input := u.updateInput()
+	if err := checkExprSize("ConditionExpression", input.ConditionExpression); err != nil {
+		return nil, err
+	}
+	if err := checkExprSize("UpdateExpression", input.UpdateExpression); err != nil {
+		if !u.split {
+			return nil, err
+		}
+		return u.runSplit(ctx, err)
+	}
+	var err error
+	if input.ExpressionAttributeValues, err = u.values(ctx); err != nil {
+		return nil, err
+	}
+
+	if u.modify != nil {
+		u.modify(input)
+	}
+	if err := u.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return nil, err
+	}
 	var output *dynamodb.UpdateItemOutput
-	err := u.table.db.retry(ctx, func() error {
+	err = u.table.db.retry(ctx, func() error {
 		var err error
-		output, err = u.table.db.client.UpdateItem(ctx, input)
+		output, err = u.table.db.client.UpdateItem(ctx, input, u.table.db.requestOptions(u.optFns)...)
 		u.cc.incRequests()
 		return err
 	})
@@ -390,6 +508,57 @@ func (u *Update) run(ctx context.Context) (*dynamodb.UpdateItemOutput, error) {
 	return output, err
 }
 
+// runSplit executes this update as multiple updates, each with an expression that fits within the size limit.
+func (u *Update) runSplit(ctx context.Context, tooLarge error) (*dynamodb.UpdateItemOutput, error) {
+	if u.condition != "" {
+		return nil, fmt.Errorf("dynamo: cannot split conditional update: %w", tooLarge)
+	}
+	if u.returnType != types.ReturnValueNone && u.returnType != types.ReturnValueAllNew {
+		return nil, fmt.Errorf("dynamo: cannot split update returning %s: %w", u.returnType, tooLarge)
+	}
+	chunks, err := splitUpdateExpr(u.clauses(), MaxExpressionSize)
+	if err != nil {
+		return nil, err
+	}
+	if attr, ok := u.splitDependency(chunks); ok {
+		return nil, fmt.Errorf("dynamo: cannot split update that reads attribute %q changed by another part of the update: %w", attr, tooLarge)
+	}
+	values, err := u.values(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var output *dynamodb.UpdateItemOutput
+	for i, chunk := range chunks {
+		expr := renderUpdateExpr(chunk)
+		input := u.updateInput()
+		input.UpdateExpression = &expr
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues = usedPlaceholders(expr, u.nameExpr, values)
+		if i != len(chunks)-1 {
+			input.ReturnValues = types.ReturnValueNone
+		}
+		if u.modify != nil {
+			u.modify(input)
+		}
+		if err = u.table.db.resolveName(ctx, &input.TableName); err != nil {
+			return output, err
+		}
+		err = u.table.db.retry(ctx, func() error {
+			var err error
+			output, err = u.table.db.client.UpdateItem(ctx, input, u.table.db.requestOptions(u.optFns)...)
+			u.cc.incRequests()
+			return err
+		})
+		if output != nil {
+			u.cc.add(output.ConsumedCapacity)
+		}
+		if err != nil {
+			return output, err
+		}
+	}
+	return output, nil
+}
+
 func (u *Update) updateInput() *dynamodb.UpdateItemInput {
 	input := &dynamodb.UpdateItemInput{
 		TableName:                 &u.table.name,
@@ -409,11 +578,21 @@ func (u *Update) updateInput() *dynamodb.UpdateItemInput {
 	return input
 }
 
-func (u *Update) writeTxItem() (*types.TransactWriteItem, error) {
+func (u *Update) writeTxItem(ctx context.Context) (*types.TransactWriteItem, error) {
 	if u.err != nil {
 		return nil, u.err
 	}
 	input := u.updateInput()
+	if err := checkExprSize("ConditionExpression", input.ConditionExpression); err != nil {
+		return nil, err
+	}
+	if err := checkExprSize("UpdateExpression", input.UpdateExpression); err != nil {
+		return nil, err
+	}
+	var err error
+	if input.ExpressionAttributeValues, err = u.values(ctx); err != nil {
+		return nil, err
+	}
 	item := &types.TransactWriteItem{
 		Update: &types.Update{
 			TableName:                           input.TableName,
@@ -438,39 +617,156 @@ func (u *Update) key() Item {
 	return key
 }
 
+// updateClause is a single action of an update expression, such as "SET" and "Foo = :v0".
+type updateClause struct {
+	action string
+	expr   string
+}
+
+// clauses returns every action of this update in the order they are rendered.
+func (u *Update) clauses() []updateClause {
+	clauses := make([]updateClause, 0, len(u.set)+len(u.add)+len(u.del)+len(u.remove))
+	for _, expr := range u.set {
+		clauses = append(clauses, updateClause{"SET", expr})
+	}
+	// sort map keys so the same update always renders the same expression
+	for _, k := range sortedKeys(u.add) {
+		clauses = append(clauses, updateClause{"ADD", fmt.Sprintf("%s %s", k, u.add[k])})
+	}
+	for _, k := range sortedKeys(u.del) {
+		clauses = append(clauses, updateClause{"DELETE", fmt.Sprintf("%s %s", k, u.del[k])})
+	}
+	for _, k := range sortedKeys(u.remove) {
+		clauses = append(clauses, updateClause{"REMOVE", k})
+	}
+	return clauses
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 func (u *Update) updateExpr() *string {
-	var expr []string
+	joined := renderUpdateExpr(u.clauses())
+	return &joined
+}
 
-	if len(u.set) > 0 {
-		expr = append(expr, "SET", strings.Join(u.set, ", "))
+// renderUpdateExpr renders clauses into an update expression.
+// Clauses must be grouped by action.
+func renderUpdateExpr(clauses []updateClause) string {
+	var expr []string
+	var exprs []string
+	for i, clause := range clauses {
+		exprs = append(exprs, clause.expr)
+		if i == len(clauses)-1 || clauses[i+1].action != clause.action {
+			expr = append(expr, clause.action, strings.Join(exprs, ", "))
+			exprs = exprs[:0]
+		}
 	}
+	return strings.Join(expr, " ")
+}
 
-	adds := make([]string, 0, len(u.add))
-	for k, v := range u.add {
-		adds = append(adds, fmt.Sprintf("%s %s", k, v))
+// splitUpdateExpr packs clauses into as few chunks as possible, each rendering an update expression no larger than limit.
+func splitUpdateExpr(clauses []updateClause, limit int) ([][]updateClause, error) {
+	var chunks [][]updateClause
+	var chunk []updateClause
+	for _, clause := range clauses {
+		next := renderUpdateExpr(append(chunk, clause))
+		if len(next) <= limit {
+			chunk = append(chunk, clause)
+			continue
+		}
+		if len(chunk) == 0 {
+			return nil, &ExpressionTooLargeError{Name: "UpdateExpression", Size: len(next)}
+		}
+		chunks = append(chunks, chunk)
+		chunk = []updateClause{clause}
+		if rendered := renderUpdateExpr(chunk); len(rendered) > limit {
+			return nil, &ExpressionTooLargeError{Name: "UpdateExpression", Size: len(rendered)}
+		}
 	}
-	if len(adds) > 0 {
-		expr = append(expr, "ADD", strings.Join(adds, ", "))
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
 	}
+	return chunks, nil
+}
 
-	dels := make([]string, 0, len(u.del))
-	for k, v := range u.del {
-		dels = append(dels, fmt.Sprintf("%s %s", k, v))
+// splitDependency returns the name of a top-level attribute that is changed by one chunk
+// and read by another, as splitting such an update would change its result.
+func (u *Update) splitDependency(chunks [][]updateClause) (string, bool) {
+	changedBy := make(map[string]int)
+	for i, chunk := range chunks {
+		for _, clause := range chunk {
+			changedBy[u.topLevelName(clause.target())] = i
+		}
 	}
-	if len(dels) > 0 {
-		expr = append(expr, "DELETE", strings.Join(dels, ", "))
+	for i, chunk := range chunks {
+		for _, clause := range chunk {
+			for _, ref := range clause.reads() {
+				name := u.topLevelName(ref)
+				if j, ok := changedBy[name]; ok && j != i {
+					return name, true
+				}
+			}
+		}
 	}
+	return "", false
+}
 
-	rems := make([]string, 0, len(u.remove))
-	for k := range u.remove {
-		rems = append(rems, k)
+// topLevelName returns the attribute name at the root of path, resolving name placeholders.
+func (u *Update) topLevelName(path string) string {
+	if i := strings.IndexAny(path, ".["); i != -1 {
+		path = path[:i]
 	}
-	if len(rems) > 0 {
-		expr = append(expr, "REMOVE", strings.Join(rems, ", "))
+	path = strings.TrimSpace(path)
+	if name, ok := u.nameExpr[path]; ok {
+		return name
 	}
+	return path
+}
 
-	joined := strings.Join(expr, " ")
-	return &joined
+// target returns the document path changed by this clause.
+func (c updateClause) target() string {
+	if c.action == "SET" {
+		target, _, _ := strings.Cut(c.expr, "=")
+		return strings.TrimSpace(target)
+	}
+	target, _, _ := strings.Cut(strings.TrimSpace(c.expr), " ")
+	return target
+}
+
+// matches attribute names, name placeholders, value placeholders, and function names
+var exprOperandRegexp = regexp.MustCompile(`[#:]?[A-Za-z_][A-Za-z0-9_]*`)
+
+// reads returns the document paths read by this clause's value, such as "B" in "A = B + :v0".
+// Only the root of each path is returned.
+func (c updateClause) reads() []string {
+	if c.action != "SET" {
+		return nil
+	}
+	_, value, _ := strings.Cut(c.expr, "=")
+	var paths []string
+	for _, loc := range exprOperandRegexp.FindAllStringIndex(value, -1) {
+		operand := value[loc[0]:loc[1]]
+		switch {
+		case operand[0] == ':':
+			// value placeholder
+			continue
+		case loc[0] > 0 && value[loc[0]-1] == '.':
+			// nested path element
+			continue
+		case strings.HasPrefix(strings.TrimSpace(value[loc[1]:]), "("):
+			// function
+			continue
+		}
+		paths = append(paths, operand)
+	}
+	return paths
 }
 
 func (u *Update) setError(err error) {
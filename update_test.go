@@ -2,12 +2,17 @@ package dynamo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/guregu/dynamo/v2/dynamodbiface"
 )
 
 func TestUpdate(t *testing.T) {
@@ -273,3 +278,219 @@ func TestUpdateSetAutoOmit(t *testing.T) {
 		t.Errorf("bad result. %+v ≠ %+v", result, expected)
 	}
 }
+
+// recordingClient records write requests.
+type recordingClient struct {
+	dynamodbiface.DynamoDBAPI
+	updates []*dynamodb.UpdateItemInput
+	batches []*dynamodb.BatchWriteItemInput
+	txs     []*dynamodb.TransactWriteItemsInput
+}
+
+func (c *recordingClient) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, in)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *recordingClient) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.batches = append(c.batches, in)
+	out := &dynamodb.BatchWriteItemOutput{}
+	for table, reqs := range in.RequestItems {
+		out.ConsumedCapacity = append(out.ConsumedCapacity, types.ConsumedCapacity{
+			TableName:     aws.String(table),
+			CapacityUnits: aws.Float64(float64(len(reqs))),
+		})
+	}
+	return out, nil
+}
+
+func (c *recordingClient) TransactWriteItems(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.txs = append(c.txs, in)
+	return &dynamodb.TransactWriteItemsOutput{
+		ConsumedCapacity: []types.ConsumedCapacity{{
+			TableName:     in.TransactItems[0].Put.TableName,
+			CapacityUnits: aws.Float64(float64(2 * len(in.TransactItems))),
+		}},
+	}, nil
+}
+
+func TestUpdateSplitLarge(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingClient{}
+	table := NewFromIface(client).Table("Wide")
+
+	wide := func() *Update {
+		u := table.Update("ID", 1)
+		for i := 0; i < 300; i++ {
+			u.Set(fmt.Sprintf("Attribute%03d", i), i)
+		}
+		return u
+	}
+
+	err := wide().Run(ctx)
+	var tooLarge *ExpressionTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatal("expected ExpressionTooLargeError, got:", err)
+	}
+	if len(client.updates) != 0 {
+		t.Fatal("unexpected request")
+	}
+
+	if err := wide().SplitLarge(true).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.updates) < 2 {
+		t.Fatal("expected multiple requests, got:", len(client.updates))
+	}
+	var total int
+	for _, input := range client.updates {
+		if len(*input.UpdateExpression) > MaxExpressionSize {
+			t.Error("expression too large:", len(*input.UpdateExpression))
+		}
+		_, usedValues := usedPlaceholders(*input.UpdateExpression, nil, input.ExpressionAttributeValues)
+		if len(usedValues) != len(input.ExpressionAttributeValues) {
+			t.Error("unused values sent:", len(input.ExpressionAttributeValues)-len(usedValues))
+		}
+		total += len(input.ExpressionAttributeValues)
+	}
+	if total != 300 {
+		t.Error("bad total number of values. want: 300, got:", total)
+	}
+
+	// conditional updates can't be split
+	err = wide().SplitLarge(true).If("attribute_exists(ID)").Run(ctx)
+	if !errors.As(err, &tooLarge) {
+		t.Error("expected ExpressionTooLargeError, got:", err)
+	}
+
+	// nor can updates whose parts depend on each other
+	client.updates = nil
+	err = wide().SetExpr("$ = $", "Copy", "Attribute000").SplitLarge(true).Run(ctx)
+	if !errors.As(err, &tooLarge) {
+		t.Error("expected ExpressionTooLargeError, got:", err)
+	}
+	if len(client.updates) != 0 {
+		t.Error("unexpected request")
+	}
+	// functions and self-references are fine
+	err = wide().SetExpr("$ = if_not_exists($, ?)", "Copy", "Copy", 0).SplitLarge(true).Run(ctx)
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestUpdateExpression(t *testing.T) {
+	table := NewFromIface(&recordingClient{}).Table("Expr")
+	expr, names, values, err := table.Update("ID", 1).
+		Set("Name", "Bob").
+		Set("Count", 2).
+		Expression()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, count := "#s"+encodeName("Name"), "#s"+encodeName("Count")
+	if want := "SET " + name + " = :v0, " + count + " = :v1"; expr != want {
+		t.Error("bad expression. want:", want, "got:", expr)
+	}
+	wantNames := map[string]string{name: "Name", count: "Count"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Error("bad names. want:", wantNames, "got:", names)
+	}
+	wantValues := Item{
+		":v0": &types.AttributeValueMemberS{Value: "Bob"},
+		":v1": &types.AttributeValueMemberN{Value: "2"},
+	}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Error("bad values. want:", wantValues, "got:", values)
+	}
+
+	// ADD, DELETE, and REMOVE clauses are ordered by attribute name
+	multi := table.Update("ID", 1).Add("B", 1).Add("A", 2).DeleteFromSet("C", "x").Remove("E", "D")
+	first, _, _, err := multi.Expression()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if got, _, _, _ := multi.Expression(); got != first {
+			t.Fatal("expression changed between calls:", first, got)
+		}
+	}
+}
+
+func TestModifyInput(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("writes", func(t *testing.T) {
+		client := new(recordingClient)
+		table := NewFromIface(client).Table("Modify")
+		metrics := types.ReturnItemCollectionMetricsSize
+
+		err := table.Update("ID", 1).Set("Name", "x").
+			ModifyInput(func(in *dynamodb.UpdateItemInput) { in.ReturnItemCollectionMetrics = metrics }).
+			Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.updates[0].ReturnItemCollectionMetrics; got != metrics {
+			t.Error("update not modified:", got)
+		}
+
+		_, err = table.Batch().Write().Put(widget{UserID: 1}).
+			ModifyInput(func(in *dynamodb.BatchWriteItemInput) { in.ReturnItemCollectionMetrics = metrics }).
+			Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.batches[0].ReturnItemCollectionMetrics; got != metrics {
+			t.Error("batch write not modified:", got)
+		}
+
+		err = NewFromIface(client).WriteTx().Put(table.Put(widget{UserID: 1})).
+			ModifyInput(func(in *dynamodb.TransactWriteItemsInput) { in.ReturnItemCollectionMetrics = metrics }).
+			Run(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.txs[0].ReturnItemCollectionMetrics; got != metrics {
+			t.Error("write tx not modified:", got)
+		}
+	})
+
+	t.Run("reads", func(t *testing.T) {
+		client := new(legacyRecorder)
+		table := NewFromIface(client).Table("Modify")
+		total := types.ReturnConsumedCapacityTotal
+
+		var item Item
+		err := table.Get("ID", 1).
+			ModifyGetItemInput(func(in *dynamodb.GetItemInput) { in.ReturnConsumedCapacity = total }).
+			One(ctx, &item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.get.ReturnConsumedCapacity; got != total {
+			t.Error("get not modified:", got)
+		}
+
+		var items []Item
+		err = table.Get("ID", 1).Range("Seq", Greater, 1).
+			ModifyInput(func(in *dynamodb.QueryInput) { in.ReturnConsumedCapacity = total }).
+			All(ctx, &items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.query.ReturnConsumedCapacity; got != total {
+			t.Error("query not modified:", got)
+		}
+
+		err = table.Scan().
+			ModifyInput(func(in *dynamodb.ScanInput) { in.ReturnConsumedCapacity = total }).
+			All(ctx, &items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.scan.ReturnConsumedCapacity; got != total {
+			t.Error("scan not modified:", got)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package dynamo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// BulkUpdate is a request to update every item matching a query.
+// See [Query.UpdateAll].
+type BulkUpdate struct {
+	query       *Query
+	fn          func(u *Update)
+	concurrency int
+	rate        float64
+	checked     bool
+	dryRun      bool
+}
+
+// UpdateAll creates a request to apply the same update to every item matching this query.
+// For each matching item, fn is called with an [Update] of that item's primary key,
+// to which it should add the changes to make, such as with [Update.Set] or [Update.Add].
+// Only the primary keys of matching items are read.
+//
+//	updated, err := table.Get("UserID", 42).
+//		Filter("'Status' = ?", "pending").
+//		UpdateAll(func(u *dynamo.Update) {
+//			u.Set("Status", "canceled")
+//		}).
+//		Concurrency(4).
+//		RateLimit(100).
+//		Run(ctx)
+func (q *Query) UpdateAll(fn func(u *Update)) *BulkUpdate {
+	return &BulkUpdate{
+		query:       q,
+		fn:          fn,
+		concurrency: 1,
+	}
+}
+
+// Concurrency sets the number of updates to run at the same time. The default is 1.
+// The update function given to [Query.UpdateAll] is still called by one goroutine at a time, so it doesn't need to be safe for concurrent use.
+func (bu *BulkUpdate) Concurrency(n int) *BulkUpdate {
+	bu.concurrency = max(n, 1)
+	return bu
+}
+
+// RateLimit limits updates to at most perSecond each second, across all concurrent updates,
+// to avoid exhausting the table's write capacity. Zero (the default) means no limit.
+func (bu *BulkUpdate) RateLimit(perSecond float64) *BulkUpdate {
+	bu.rate = perSecond
+	return bu
+}
+
+// Checked makes each update use the query's filters as its condition when enabled,
+// so that items that were changed by someone else to no longer match after they were read are skipped.
+func (bu *BulkUpdate) Checked(enabled bool) *BulkUpdate {
+	bu.checked = enabled
+	return bu
+}
+
+// DryRun makes Run count the items that would be updated without updating them when enabled.
+// The update function is still called for each item, so that invalid updates are reported as errors.
+func (bu *BulkUpdate) DryRun(enabled bool) *BulkUpdate {
+	bu.dryRun = enabled
+	return bu
+}
+
+// Run executes this request, returning the number of items updated (or that would be updated, for dry runs).
+// Updates that fail a condition check, whether added by the update function or by [BulkUpdate.Checked],
+// are skipped and not counted.
+// If an error occurs, Run stops and returns it; updates in progress are finished first.
+func (bu *BulkUpdate) Run(ctx context.Context) (updated int, err error) {
+	q := bu.query
+	if q.err != nil {
+		return 0, q.err
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var cond *ExpressionLiteral
+	if bu.checked {
+		cond = q.filterCond()
+	}
+
+	var (
+		count atomic.Int64
+		ccMu  sync.Mutex
+		pace  = newPacer(bu.rate)
+	)
+	// prepare is called from a single goroutine, so fn is never called concurrently
+	prepare := func(item Item) *Update {
+		u := q.table.Update(desc.HashKey, item[desc.HashKey])
+		if desc.RangeKey != "" {
+			u.Range(desc.RangeKey, item[desc.RangeKey])
+		}
+		bu.fn(u)
+		if cond != nil {
+			u.If("?", *cond)
+		}
+		return u
+	}
+	update := func(ctx context.Context, u *Update) error {
+		if bu.dryRun {
+			if u.err == nil {
+				count.Add(1)
+			}
+			return u.err
+		}
+		if err := pace.wait(ctx); err != nil {
+			return err
+		}
+		var cc *ConsumedCapacity
+		if q.cc != nil {
+			cc = new(ConsumedCapacity)
+			u.ConsumedCapacity(cc)
+		}
+		err := u.Run(ctx)
+		if cc != nil {
+			ccMu.Lock()
+			mergeConsumedCapacity(q.cc, cc)
+			ccMu.Unlock()
+		}
+		if IsCondCheckFailed(err) {
+			return nil
+		}
+		if err == nil {
+			count.Add(1)
+		}
+		return err
+	}
+
+	// the keys are read while updates run, so they get their own capacity, merged at the end
+	read := q.keysOnly(desc)
+	var readCC *ConsumedCapacity
+	if q.cc != nil {
+		readCC = new(ConsumedCapacity)
+		read.cc = readCC
+	}
+	defer func() {
+		if readCC != nil {
+			ccMu.Lock()
+			mergeConsumedCapacity(q.cc, readCC)
+			ccMu.Unlock()
+		}
+	}()
+
+	grp, gctx := errgroup.WithContext(ctx)
+	grp.SetLimit(bu.concurrency)
+	var item Item
+	iter := read.newIter(func(_ context.Context, got Item, _ any) error {
+		item = got
+		return nil
+	})
+	for iter.Next(gctx, nil) {
+		u := prepare(item)
+		grp.Go(func() error {
+			return update(gctx, u)
+		})
+	}
+	err = grp.Wait()
+	if err == nil {
+		err = iter.Err()
+	}
+	return int(count.Load()), err
+}
@@ -0,0 +1,103 @@
+package dynamo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// updateAllClient is like deleteAllClient, but records updates.
+// Updates of Seq 3 fail their condition check.
+// Each query page and update consumes 1 capacity unit.
+type updateAllClient struct {
+	*deleteAllClient
+	mu      sync.Mutex
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *updateAllClient) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updates = append(c.updates, in)
+	if in.Key["Seq"].(*types.AttributeValueMemberN).Value == "3" {
+		return nil, &smithy.GenericAPIError{Code: "ConditionalCheckFailedException"}
+	}
+	return &dynamodb.UpdateItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: in.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (c *updateAllClient) Query(ctx context.Context, in *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	out, err := c.deleteAllClient.Query(ctx, in, opts...)
+	if out != nil {
+		out.ConsumedCapacity = &types.ConsumedCapacity{TableName: in.TableName, CapacityUnits: aws.Float64(1)}
+	}
+	return out, err
+}
+
+func TestQueryUpdateAll(t *testing.T) {
+	ctx := context.Background()
+	client := &updateAllClient{deleteAllClient: &deleteAllClient{}}
+	table := NewFromIface(client).Table("Events")
+	set := func(u *Update) {
+		u.Set("Status", "archived")
+	}
+
+	n, err := table.Get("User", 1).Filter("Kind = ?", "temp").UpdateAll(set).DryRun(true).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 30 || len(client.updates) != 0 {
+		t.Error("bad dry run:", n, len(client.updates))
+	}
+
+	start := time.Now()
+	var cc ConsumedCapacity
+	// not safe for concurrent use, see Concurrency
+	calls := 0
+	counted := func(u *Update) {
+		calls++
+		set(u)
+	}
+	n, err = table.Get("User", 1).Filter("Kind = ?", "temp").
+		ConsumedCapacity(&cc).
+		UpdateAll(counted).
+		Checked(true).
+		Concurrency(4).
+		RateLimit(1000).
+		Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 29 || len(client.updates) != 30 || calls != 30 {
+		t.Error("bad update count:", n, len(client.updates), calls)
+	}
+	// 3 query pages and 29 successful updates
+	if cc.Total != 32 {
+		t.Error("bad consumed capacity:", cc.Total)
+	}
+	// 30 updates at 1000/s
+	if elapsed := time.Since(start); elapsed < 29*time.Millisecond {
+		t.Error("not rate limited:", elapsed)
+	}
+	for _, in := range client.updates {
+		if in.ConditionExpression == nil || *in.UpdateExpression == "" {
+			t.Fatal("bad update:", in.ConditionExpression, in.UpdateExpression)
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := table.Get("User", 1).UpdateAll(func(u *Update) {
+			u.Set("Bad", func() {})
+		}).DryRun(true).Run(ctx)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -16,16 +16,21 @@ type UpdateTable struct {
 	r, w  int64 // throughput
 
 	billingMode types.BillingMode
+	ondemandMax *OnDemandThroughput
+	warm        *WarmThroughput
 
 	disableStream bool
 	streamView    StreamView
 
 	updateIdx map[string]Throughput
+	maxIdx    map[string]OnDemandThroughput
+	warmIdx   map[string]WarmThroughput
 	createIdx []Index
 	deleteIdx []string
 	ads       []types.AttributeDefinition
 
-	err error
+	optFns []func(*dynamodb.Options)
+	err    error
 }
 
 // UpdateTable makes changes to this table's settings.
@@ -33,6 +38,8 @@ func (table Table) UpdateTable() *UpdateTable {
 	return &UpdateTable{
 		table:     table,
 		updateIdx: make(map[string]Throughput),
+		maxIdx:    make(map[string]OnDemandThroughput),
+		warmIdx:   make(map[string]WarmThroughput),
 	}
 }
 
@@ -59,6 +66,34 @@ func (ut *UpdateTable) ProvisionIndex(name string, read, write int64) *UpdateTab
 	return ut
 }
 
+// MaxOnDemand sets this table's maximum read and write request units per second in on-demand mode.
+// Zero means no maximum.
+func (ut *UpdateTable) MaxOnDemand(maxRead, maxWrite int64) *UpdateTable {
+	ut.ondemandMax = &OnDemandThroughput{MaxRead: maxRead, MaxWrite: maxWrite}
+	return ut
+}
+
+// MaxOnDemandIndex updates a global secondary index's maximum read and write request units per second in on-demand mode.
+// Zero means no maximum.
+func (ut *UpdateTable) MaxOnDemandIndex(name string, maxRead, maxWrite int64) *UpdateTable {
+	ut.maxIdx[name] = OnDemandThroughput{MaxRead: maxRead, MaxWrite: maxWrite}
+	return ut
+}
+
+// WarmThroughput raises the reads and writes per second that this table can instantly support,
+// such as ahead of a high-traffic launch or a large import. Warm throughput can only be increased.
+// The table's [Description.WarmThroughput] has UpdatingStatus until the change is complete.
+func (ut *UpdateTable) WarmThroughput(read, write int64) *UpdateTable {
+	ut.warm = &WarmThroughput{Read: read, Write: write}
+	return ut
+}
+
+// WarmThroughputIndex raises the reads and writes per second that a global secondary index can instantly support.
+func (ut *UpdateTable) WarmThroughputIndex(name string, read, write int64) *UpdateTable {
+	ut.warmIdx[name] = WarmThroughput{Read: read, Write: write}
+	return ut
+}
+
 // CreateIndex adds a new secondary global index.
 // You must specify the index name, keys, key types, projection.
 // If this table is not on-demand you must also specify throughput.
@@ -106,6 +141,13 @@ func (ut *UpdateTable) DisableStream() *UpdateTable {
 	return ut
 }
 
+// RequestOptions sets options that are applied to this request, such as extra middleware (see [WithAPIOptions]).
+// They are applied after the options of the client and of [DB.RequestOptions].
+func (ut *UpdateTable) RequestOptions(optFns ...func(*dynamodb.Options)) *UpdateTable {
+	ut.optFns = optFns
+	return ut
+}
+
 // Run executes this request and describes the table.
 func (ut *UpdateTable) Run(ctx context.Context) (Description, error) {
 	if ut.err != nil {
@@ -113,11 +155,14 @@ func (ut *UpdateTable) Run(ctx context.Context) (Description, error) {
 	}
 
 	input := ut.input()
+	if err := ut.table.db.resolveName(ctx, &input.TableName); err != nil {
+		return Description{}, err
+	}
 
 	var result *dynamodb.UpdateTableOutput
 	err := ut.table.db.retry(ctx, func() error {
 		var err error
-		result, err = ut.table.db.client.UpdateTable(ctx, input)
+		result, err = ut.table.db.client.UpdateTable(ctx, input, ut.table.db.requestOptions(ut.optFns)...)
 		return err
 	})
 	if err != nil {
@@ -141,6 +186,13 @@ func (ut *UpdateTable) input() *dynamodb.UpdateTableInput {
 		}
 	}
 
+	if ut.ondemandMax != nil {
+		input.OnDemandThroughput = ut.ondemandMax.input()
+	}
+	if ut.warm != nil {
+		input.WarmThroughput = ut.warm.input()
+	}
+
 	if ut.disableStream {
 		input.StreamSpecification = &types.StreamSpecification{
 			StreamEnabled: aws.Bool(false),
@@ -152,15 +204,27 @@ func (ut *UpdateTable) input() *dynamodb.UpdateTableInput {
 		}
 	}
 
+	updates := make(map[string]*types.UpdateGlobalSecondaryIndexAction)
+	update := func(index string) *types.UpdateGlobalSecondaryIndexAction {
+		if up, ok := updates[index]; ok {
+			return up
+		}
+		up := &types.UpdateGlobalSecondaryIndexAction{IndexName: aws.String(index)}
+		updates[index] = up
+		input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, types.GlobalSecondaryIndexUpdate{Update: up})
+		return up
+	}
 	for index, thru := range ut.updateIdx {
-		up := types.GlobalSecondaryIndexUpdate{Update: &types.UpdateGlobalSecondaryIndexAction{
-			IndexName: aws.String(index),
-			ProvisionedThroughput: &types.ProvisionedThroughput{
-				ReadCapacityUnits:  aws.Int64(thru.Read),
-				WriteCapacityUnits: aws.Int64(thru.Write),
-			},
-		}}
-		input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, up)
+		update(index).ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(thru.Read),
+			WriteCapacityUnits: aws.Int64(thru.Write),
+		}
+	}
+	for index, odt := range ut.maxIdx {
+		update(index).OnDemandThroughput = odt.input()
+	}
+	for index, wt := range ut.warmIdx {
+		update(index).WarmThroughput = wt.input()
 	}
 	for _, index := range ut.createIdx {
 		up := types.GlobalSecondaryIndexUpdate{Create: createIndexAction(index)}
@@ -214,6 +278,12 @@ func createIndexAction(index Index) *types.CreateGlobalSecondaryIndexAction {
 			WriteCapacityUnits: aws.Int64(index.Throughput.Write),
 		}
 	}
+	if index.OnDemandThroughput != (OnDemandThroughput{}) {
+		add.OnDemandThroughput = index.OnDemandThroughput.input()
+	}
+	if index.WarmThroughput.Read != 0 || index.WarmThroughput.Write != 0 {
+		add.WarmThroughput = index.WarmThroughput.input()
+	}
 	if index.ProjectionType == IncludeProjection {
 		add.Projection.NonKeyAttributes = index.ProjectionAttribs
 	}
@@ -0,0 +1,186 @@
+package dynamo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB item limits.
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/ServiceQuotas.html
+const (
+	// MaxItemSize is the maximum size of an item in bytes, including attribute names.
+	MaxItemSize = 400 * 1024
+	// MaxNestingDepth is the maximum depth of nested lists and maps.
+	MaxNestingDepth = 32
+	// MaxAttributeNameLength is the maximum length of an attribute name in bytes.
+	MaxAttributeNameLength = 64 * 1024
+)
+
+// ItemValidationError is returned by ValidateItem when an item violates DynamoDB's limits.
+type ItemValidationError struct {
+	// Path to the offending attribute, such as "Foo.Bar[2]". Empty for item-wide problems.
+	Path   string
+	Reason string
+}
+
+func (e *ItemValidationError) Error() string {
+	if e.Path == "" {
+		return "dynamo: invalid item: " + e.Reason
+	}
+	return fmt.Sprintf("dynamo: invalid item: %s: %s", e.Path, e.Reason)
+}
+
+// ValidateItem checks the given item against DynamoDB's limits:
+// item size, attribute name length, nesting depth, and set constraints.
+// It returns an *ItemValidationError describing the first problem found.
+// This lets you catch bad items before making a request,
+// instead of receiving an opaque ValidationException from DynamoDB.
+func ValidateItem(item Item) error {
+	size, err := validateItem(item, "", 0)
+	if err != nil {
+		return err
+	}
+	if size > MaxItemSize {
+		return &ItemValidationError{Reason: fmt.Sprintf("item size of %d bytes exceeds maximum of %d bytes", size, MaxItemSize)}
+	}
+	return nil
+}
+
+// ItemSize returns the approximate size of the given item in bytes, as calculated by DynamoDB.
+// It returns an *ItemValidationError if the item can't be sized because it is invalid,
+// such as one with nil attribute values. Unlike [ValidateItem], items over [MaxItemSize] are not an error.
+func ItemSize(item Item) (int, error) {
+	return validateItem(item, "", 0)
+}
+
+func validateItem(item Item, path string, depth int) (int, error) {
+	// iterate in a stable order so errors are deterministic
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var size int
+	for _, name := range names {
+		sub := joinPath(path, name)
+		switch {
+		case len(name) == 0:
+			return 0, &ItemValidationError{Path: path, Reason: "empty attribute name"}
+		case len(name) > MaxAttributeNameLength:
+			return 0, &ItemValidationError{Path: sub, Reason: fmt.Sprintf("attribute name length of %d bytes exceeds maximum of %d bytes", len(name), MaxAttributeNameLength)}
+		}
+		n, err := validateAV(item[name], sub, depth)
+		if err != nil {
+			return 0, err
+		}
+		size += len(name) + n
+	}
+	return size, nil
+}
+
+func validateAV(av types.AttributeValue, path string, depth int) (int, error) {
+	switch v := av.(type) {
+	case nil:
+		return 0, &ItemValidationError{Path: path, Reason: "nil attribute value"}
+	case *types.AttributeValueMemberS:
+		return len(v.Value), nil
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value), nil
+	case *types.AttributeValueMemberB:
+		return len(v.Value), nil
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1, nil
+	case *types.AttributeValueMemberSS:
+		if len(v.Value) == 0 {
+			return 0, &ItemValidationError{Path: path, Reason: "empty string set"}
+		}
+		var size int
+		seen := make(map[string]struct{}, len(v.Value))
+		for _, s := range v.Value {
+			if _, dupe := seen[s]; dupe {
+				return 0, &ItemValidationError{Path: path, Reason: fmt.Sprintf("duplicate value %q in string set", s)}
+			}
+			seen[s] = struct{}{}
+			size += len(s)
+		}
+		return size, nil
+	case *types.AttributeValueMemberNS:
+		if len(v.Value) == 0 {
+			return 0, &ItemValidationError{Path: path, Reason: "empty number set"}
+		}
+		var size int
+		seen := make(map[string]struct{}, len(v.Value))
+		for _, n := range v.Value {
+			// numbers like 1 and 1.0 are the same member
+			norm := normalizeNumber(n)
+			if _, dupe := seen[norm]; dupe {
+				return 0, &ItemValidationError{Path: path, Reason: fmt.Sprintf("duplicate value %s in number set", n)}
+			}
+			seen[norm] = struct{}{}
+			size += numberSize(n)
+		}
+		return size, nil
+	case *types.AttributeValueMemberBS:
+		if len(v.Value) == 0 {
+			return 0, &ItemValidationError{Path: path, Reason: "empty binary set"}
+		}
+		var size int
+		seen := make(map[string]struct{}, len(v.Value))
+		for _, b := range v.Value {
+			if _, dupe := seen[string(b)]; dupe {
+				return 0, &ItemValidationError{Path: path, Reason: "duplicate value in binary set"}
+			}
+			seen[string(b)] = struct{}{}
+			size += len(b)
+		}
+		return size, nil
+	case *types.AttributeValueMemberL:
+		if depth+1 > MaxNestingDepth {
+			return 0, &ItemValidationError{Path: path, Reason: fmt.Sprintf("nesting depth exceeds maximum of %d", MaxNestingDepth)}
+		}
+		// 3 bytes of overhead for the list, plus 1 byte per element
+		size := 3
+		for i, elem := range v.Value {
+			n, err := validateAV(elem, path+"["+strconv.Itoa(i)+"]", depth+1)
+			if err != nil {
+				return 0, err
+			}
+			size += n + 1
+		}
+		return size, nil
+	case *types.AttributeValueMemberM:
+		if depth+1 > MaxNestingDepth {
+			return 0, &ItemValidationError{Path: path, Reason: fmt.Sprintf("nesting depth exceeds maximum of %d", MaxNestingDepth)}
+		}
+		n, err := validateItem(v.Value, path, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		// 3 bytes of overhead for the map, plus 1 byte per element
+		return 3 + n + len(v.Value), nil
+	}
+	return 0, &ItemValidationError{Path: path, Reason: fmt.Sprintf("unknown attribute value type %T", av)}
+}
+
+// numberSize approximates the size of a number: 1 byte per 2 significant digits, plus 1 byte.
+func numberSize(n string) int {
+	n = strings.TrimLeft(n, "+-")
+	if i := strings.IndexAny(n, "eE"); i != -1 {
+		n = n[:i]
+	}
+	n = strings.Replace(n, ".", "", 1)
+	n = strings.Trim(n, "0")
+	return (len(n)+1)/2 + 1
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
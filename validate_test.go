@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestValidateItem(t *testing.T) {
+	nested := func(depth int) types.AttributeValue {
+		var av types.AttributeValue = &types.AttributeValueMemberS{Value: "deep"}
+		for i := 0; i < depth; i++ {
+			av = &types.AttributeValueMemberL{Value: []types.AttributeValue{av}}
+		}
+		return av
+	}
+
+	tests := []struct {
+		name string
+		item Item
+		path string
+	}{
+		{
+			name: "ok",
+			item: Item{
+				"ID":   &types.AttributeValueMemberN{Value: "123"},
+				"Deep": nested(MaxNestingDepth),
+			},
+		},
+		{
+			name: "too big",
+			item: Item{"Data": &types.AttributeValueMemberS{Value: strings.Repeat("x", MaxItemSize)}},
+		},
+		{
+			name: "too deep",
+			item: Item{"Deep": nested(MaxNestingDepth + 1)},
+			path: "Deep" + strings.Repeat("[0]", MaxNestingDepth),
+		},
+		{
+			name: "empty set",
+			item: Item{"M": &types.AttributeValueMemberM{Value: Item{
+				"Set": &types.AttributeValueMemberSS{Value: []string{}},
+			}}},
+			path: "M.Set",
+		},
+		{
+			name: "duplicate number",
+			item: Item{"Nums": &types.AttributeValueMemberNS{Value: []string{"1", "2", "1.0"}}},
+			path: "Nums",
+		},
+		{
+			name: "long name",
+			item: Item{strings.Repeat("a", MaxAttributeNameLength+1): &types.AttributeValueMemberBOOL{Value: true}},
+			path: strings.Repeat("a", MaxAttributeNameLength+1),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateItem(tc.item)
+			if tc.name == "ok" {
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+				return
+			}
+			var verr *ItemValidationError
+			if !errors.As(err, &verr) {
+				t.Fatal("want ItemValidationError, got:", err)
+			}
+			if verr.Path != tc.path {
+				t.Error("bad path. want:", tc.path, "got:", verr.Path)
+			}
+		})
+	}
+}
+
+func TestItemSize(t *testing.T) {
+	item := Item{
+		"Name":   &types.AttributeValueMemberS{Value: "hello"}, // 4 + 5
+		"Number": &types.AttributeValueMemberN{Value: "12345"}, // 6 + 4
+		"Bool":   &types.AttributeValueMemberBOOL{Value: true}, // 4 + 1
+		"List": &types.AttributeValueMemberL{Value: []types.AttributeValue{ // 4 + 3 + (1 + 1)
+			&types.AttributeValueMemberNULL{Value: true},
+		}},
+	}
+	got, err := ItemSize(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 33; got != want {
+		t.Error("bad size. want:", want, "got:", got)
+	}
+
+	item["Bad"] = nil
+	if _, err := ItemSize(item); err == nil {
+		t.Error("expected error for nil attribute value")
+	}
+}
+
+func TestSplitTxInvalidItem(t *testing.T) {
+	puts := []*Put{
+		{item: Item{"ID": &types.AttributeValueMemberN{Value: "1"}}},
+		{item: Item{"ID": &types.AttributeValueMemberN{Value: "2"}, "Bad": nil}},
+	}
+	var verr *ItemValidationError
+	if _, err := splitTx(puts, []string{"1", "2"}); !errors.As(err, &verr) {
+		t.Error("want ItemValidationError, got:", err)
+	}
+}